@@ -0,0 +1,156 @@
+// SPDX-FileCopyrightText: 2021 Eric Neidhardt
+// SPDX-License-Identifier: MIT
+package client
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Benchmarker performs the operations of a workload, e.g. the requests of a Client, and counts their
+// results in its statistic. Implement it to run other protocols with RunForAmount, RunForDuration or a Runner.
+type Benchmarker interface {
+	// Perform performs a single operation and returns its result, which has been counted. Nothing is performed
+	// and false is returned, if there are no more operations or the context is done before starting one.
+	// Operations failing, as the context of a run is done, are counted as interrupted, see RunDone.
+	Perform(ctx context.Context) (Result, bool)
+	// Snapshot returns a copy of the statistic, it is called while Perform is running.
+	Snapshot() Statistic
+}
+
+// RunForAmount instructs the benchmarkers to perform their operations in turn until each performed
+// a certain number of operations, has no more operations or the context is done.
+func RunForAmount(ctx context.Context, count int, benchmarkers ...Benchmarker) {
+	ctx = context.WithValue(ctx, runKey{}, true)
+	exhausted := make([]bool, len(benchmarkers))
+	remaining := len(benchmarkers)
+	for i := 0; i < count && ctx.Err() == nil && remaining > 0; i++ {
+		remaining = performInTurn(ctx, benchmarkers, exhausted)
+	}
+}
+
+// RunForDuration instructs the benchmarkers to perform their operations in turn as often as possible
+// for a given duration, until each has no more operations or the context is done.
+func RunForDuration(ctx context.Context, timeout time.Duration, benchmarkers ...Benchmarker) {
+	startTime := time.Now()
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	// the last operations are interrupted by the timeout
+	ctx = context.WithValue(ctx, runKey{}, true)
+	exhausted := make([]bool, len(benchmarkers))
+	remaining := len(benchmarkers)
+	for time.Since(startTime) < timeout && ctx.Err() == nil && remaining > 0 {
+		remaining = performInTurn(ctx, benchmarkers, exhausted)
+	}
+}
+
+// performInTurn performs an operation of each benchmarker, which is not exhausted,
+// and returns the number of benchmarkers with operations left.
+func performInTurn(ctx context.Context, benchmarkers []Benchmarker, exhausted []bool) int {
+	remaining := 0
+	for i, b := range benchmarkers {
+		if exhausted[i] {
+			continue
+		}
+		if _, ok := b.Perform(ctx); !ok && ctx.Err() == nil {
+			exhausted[i] = true
+			continue
+		}
+		remaining++
+	}
+	return remaining
+}
+
+// runKey marks the context of a run, whose operations in flight are counted as
+// Statistic.InterruptedCount instead of failures, when it is done.
+type runKey struct{}
+
+// RunDone returns true, if the context belongs to a run, which is done. An operation failing then
+// has been interrupted by the end of the run, see ClassInterrupted.
+func RunDone(ctx context.Context) bool {
+	return ctx.Value(runKey{}) != nil && ctx.Err() != nil
+}
+
+// Recorder counts the results of a Benchmarker in its statistic. It is safe for concurrent use.
+type Recorder struct {
+	mu        sync.Mutex
+	statistic Statistic
+}
+
+// Record counts the result by its class: successes, unexpected statuses, length mismatches, failed validations,
+// interrupted operations and otherwise network failures by their cause. All but network failures are counted
+// as responses with their latency, size, status code if it is set, and the durations of their phases.
+func (r *Recorder) Record(result Result) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	s := &r.statistic
+	if result.Class == ClassInterrupted {
+		s.InterruptedCount++
+		return
+	}
+	s.RequestCount++
+	s.WriteThroughput += result.BytesWritten
+	switch result.Class {
+	case ClassSuccess:
+		s.SuccessCount++
+	case ClassStatus:
+		s.FailureCount++
+	case ClassLengthMismatch:
+		s.LengthMismatchCount++
+	case ClassValidation:
+		s.ValidationFailedCount++
+	default:
+		s.NetworkFailedCount++
+		if s.NetworkFailures == nil {
+			s.NetworkFailures = make(map[string]int)
+		}
+		s.NetworkFailures[result.Class]++
+		return
+	}
+	s.ReadThroughput += result.BytesRead
+	s.WireReadThroughput += result.BytesRead
+	s.ResponseSizes.Add(result.BytesRead)
+	s.Latency += result.Latency
+	if result.StatusCode != 0 {
+		if s.StatusCodes == nil {
+			s.StatusCodes = make(map[int]int)
+		}
+		s.StatusCodes[result.StatusCode]++
+		if s.ClassLatencies == nil {
+			s.ClassLatencies = make(map[int]Latencies)
+		}
+		classLatencies := s.ClassLatencies[result.StatusCode/100]
+		classLatencies.Add(result.Latency)
+		s.ClassLatencies[result.StatusCode/100] = classLatencies
+	}
+	for phase, d := range result.Phases {
+		if s.Phases == nil {
+			s.Phases = make(map[string]DurationHistogram)
+		}
+		h := s.Phases[phase]
+		h.Add(d)
+		s.Phases[phase] = h
+	}
+}
+
+// Update calls f with the statistic, e.g. to count measurements specific to a protocol.
+func (r *Recorder) Update(f func(s *Statistic)) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	f(&r.statistic)
+}
+
+// Merge adds the statistic, e.g. a snapshot of a client, see Statistic.Merge.
+func (r *Recorder) Merge(s Statistic) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.statistic.Merge(s)
+}
+
+// Snapshot returns a copy of the statistic.
+func (r *Recorder) Snapshot() Statistic {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.statistic.clone()
+}
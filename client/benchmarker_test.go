@@ -0,0 +1,131 @@
+// SPDX-FileCopyrightText: 2021 Eric Neidhardt
+// SPDX-License-Identifier: MIT
+package client
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/EricNeid/go-bench/internal/verify"
+)
+
+// countingBenchmarker performs a limited number of operations, which fail after the run is done.
+type countingBenchmarker struct {
+	Recorder
+	limit     int
+	performed int
+	delay     time.Duration
+}
+
+func (b *countingBenchmarker) Perform(ctx context.Context) (Result, bool) {
+	if b.limit > 0 && b.performed == b.limit {
+		return Result{}, false
+	}
+	b.performed++
+	result := Result{Start: time.Now(), StatusCode: 200, BytesRead: 3, Class: ClassSuccess}
+	select {
+	case <-time.After(b.delay):
+	case <-ctx.Done():
+		result.Class, result.Err = ClassifyError(ctx.Err()), ctx.Err()
+		if RunDone(ctx) {
+			result.Class = ClassInterrupted
+		}
+	}
+	result.Latency = time.Since(result.Start)
+	b.Record(result)
+	return result, true
+}
+
+func TestRunForAmount_benchmarkers(t *testing.T) {
+	// arrange
+	limited := &countingBenchmarker{limit: 2}
+	unlimited := &countingBenchmarker{}
+	// action
+	RunForAmount(context.Background(), 5, limited, unlimited)
+	// verify
+	verify.Equals(t, 2, limited.Snapshot().SuccessCount)
+	verify.Equals(t, 5, unlimited.Snapshot().SuccessCount)
+	verify.Equals(t, int64(15), unlimited.Snapshot().ReadThroughput)
+	verify.Equals(t, map[int]int{200: 5}, unlimited.Snapshot().StatusCodes)
+}
+
+func TestRunForDuration_benchmarkers(t *testing.T) {
+	// arrange
+	unit := &countingBenchmarker{delay: 30 * time.Millisecond}
+	// action
+	RunForDuration(context.Background(), 100*time.Millisecond, unit)
+	// verify
+	s := unit.Snapshot()
+	verify.Assert(t, s.SuccessCount > 0, "Nothing performed")
+	verify.Equals(t, s.SuccessCount, s.RequestCount)
+	verify.Equals(t, 1, s.InterruptedCount)
+}
+
+func TestRunner_benchmarkers(t *testing.T) {
+	// arrange
+	unit := &Runner{Requests: 3}
+	for i := 0; i < 4; i++ {
+		unit.Slots = append(unit.Slots, []Benchmarker{&countingBenchmarker{}, &Client{NextRequest: func(context.Context) (Request, bool) { return Request{}, false }}})
+	}
+	// action
+	unit.Run(context.Background())
+	statistics := unit.Statistics()
+	// verify
+	verify.Equals(t, 12, statistics[0].SuccessCount)
+	verify.Equals(t, 0, statistics[1].RequestCount)
+}
+
+func TestRecorder_concurrent(t *testing.T) {
+	// arrange
+	var unit Recorder
+	var done sync.WaitGroup
+	// action
+	for i := 0; i < 8; i++ {
+		done.Add(1)
+		go func() {
+			defer done.Done()
+			for j := 0; j < 100; j++ {
+				unit.Record(Result{Class: ClassSuccess, StatusCode: 200})
+				unit.Merge(Statistic{RequestCount: 1, NetworkFailedCount: 1, NetworkFailures: map[string]int{FailureReset: 1}})
+				_ = unit.Snapshot()
+			}
+		}()
+	}
+	done.Wait()
+	// verify
+	s := unit.Snapshot()
+	verify.Equals(t, 1600, s.RequestCount)
+	verify.Equals(t, 800, s.SuccessCount)
+	verify.Equals(t, map[string]int{FailureReset: 800}, s.NetworkFailures)
+	verify.Equals(t, map[int]int{200: 800}, s.StatusCodes)
+}
+
+func TestRecorder_Record(t *testing.T) {
+	// arrange
+	var unit Recorder
+	// action
+	unit.Record(Result{Class: ClassSuccess, StatusCode: 200, BytesRead: 10, BytesWritten: 2, Latency: time.Millisecond})
+	unit.Record(Result{Class: ClassStatus, StatusCode: 503, Latency: time.Millisecond})
+	unit.Record(Result{Class: ClassLengthMismatch, StatusCode: 200})
+	unit.Record(Result{Class: ClassValidation, StatusCode: 200})
+	unit.Record(Result{Class: FailureRefused, Err: errors.New("refused")})
+	unit.Record(Result{Class: ClassInterrupted})
+	unit.Update(func(s *Statistic) { s.ThrottledCount++ })
+	// verify
+	s := unit.Snapshot()
+	verify.Equals(t, 5, s.RequestCount)
+	verify.Equals(t, 1, s.SuccessCount)
+	verify.Equals(t, 1, s.FailureCount)
+	verify.Equals(t, 1, s.LengthMismatchCount)
+	verify.Equals(t, 1, s.ValidationFailedCount)
+	verify.Equals(t, map[string]int{FailureRefused: 1}, s.NetworkFailures)
+	verify.Equals(t, 1, s.InterruptedCount)
+	verify.Equals(t, 1, s.ThrottledCount)
+	verify.Equals(t, map[int]int{200: 3, 503: 1}, s.StatusCodes)
+	verify.Equals(t, int64(10), s.ReadThroughput)
+	verify.Equals(t, int64(2), s.WriteThroughput)
+	verify.Equals(t, 2*time.Millisecond, s.Latency)
+}
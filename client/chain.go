@@ -0,0 +1,276 @@
+// SPDX-FileCopyrightText: 2021 Eric Neidhardt
+// SPDX-License-Identifier: MIT
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Extraction extracts a variable from a response body, either with a JSONPath or with a regular expression.
+type Extraction struct {
+	// Name of the variable.
+	Name string
+	// JSONPath of the value, like $.items[0].id. Only child and index selectors are supported.
+	JSONPath string
+	// Regexp, whose first group or, if it has none, whose match is the value. Used if JSONPath is empty.
+	Regexp *regexp.Regexp
+}
+
+// Extract returns the value of the extraction in the body.
+func (e Extraction) Extract(body []byte) (string, error) {
+	if e.JSONPath != "" {
+		return extractJSON(body, e.JSONPath)
+	}
+	match := e.Regexp.FindSubmatch(body)
+	switch {
+	case match == nil:
+		return "", fmt.Errorf("%s does not match", e.Regexp)
+	case len(match) > 1:
+		return string(match[1]), nil
+	default:
+		return string(match[0]), nil
+	}
+}
+
+// Step is a request of a chain, whose response provides variables for the following steps.
+type Step struct {
+	// Name of the step in Statistic.Steps, its number starting with 1 if empty.
+	Name    string
+	Request Request
+	Extract []Extraction
+}
+
+// StepStatistic contains the measurement results of the requests of a step, see Statistic.Steps.
+type StepStatistic struct {
+	// Number of performed requests and the ones counted as success, see Statistic.
+	RequestCount int
+	SuccessCount int
+	// Latencies of the requests, which received a response.
+	Latencies DurationHistogram
+}
+
+// Merge adds the results of other.
+func (s *StepStatistic) Merge(other StepStatistic) {
+	s.RequestCount += other.RequestCount
+	s.SuccessCount += other.SuccessCount
+	s.Latencies.Merge(other.Latencies)
+}
+
+// countStep counts the result in the statistic of its step.
+func (c *Client) countStep(result Result) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.Statistic.Steps == nil {
+		c.Statistic.Steps = make(map[string]StepStatistic)
+	}
+	step := c.Statistic.Steps[result.Request.Step]
+	step.RequestCount++
+	if result.Success() {
+		step.SuccessCount++
+	}
+	if result.StatusCode != 0 {
+		step.Latencies.Add(result.Latency)
+	}
+	c.Statistic.Steps[result.Request.Step] = step
+}
+
+// Chain performs its steps in order and starts over after the last one.
+// The templates of each step are rendered with the variables extracted by the previous steps,
+// see Template. If a step fails, because of a network error, a status other than 2xx or
+// a value which cannot be extracted, the chain starts over with the first step.
+// Steps, which cannot be rendered, fail when they are performed and are counted as network failures.
+// Values, which cannot be extracted, are counted as validation failures of kind extract.
+// A chain is not safe for concurrent use, each client needs a chain of its own.
+type Chain struct {
+	Steps    []Step
+	Template *Template
+	// Vars, if set, provides the initial variables for each pass.
+	Vars func() map[string]string
+
+	step      int
+	pending   bool
+	extracted bool
+	vars      map[string]string
+}
+
+// Attach sets up the client to perform the steps of the chain. It adds the extraction to the validations of
+// the client, validations set afterwards have to be appended.
+func (c *Chain) Attach(client *Client) {
+	client.NextRequest = c.Next
+	client.OnResponse = c.OnResponse
+	client.Validations = append(slices.Clip(client.Validations), Validation{Kind: "extract", Check: c.extract})
+}
+
+// Next returns the request of the next step, for use as Client.NextRequest.
+func (c *Chain) Next(_ context.Context) (Request, bool) {
+	if c.pending {
+		// no response received for the last step
+		c.step = 0
+	}
+	if c.step == 0 {
+		c.vars = make(map[string]string)
+		if c.Vars != nil {
+			for k, v := range c.Vars() {
+				c.vars[k] = v
+			}
+		}
+	}
+	step := c.Steps[c.step]
+	request, err := c.Template.Render(step.Request, c.vars)
+	if err != nil {
+		request = step.Request
+		request.err = fmt.Errorf("could not render step %d: %w", c.step+1, err)
+	}
+	c.pending, c.extracted = true, false
+	request.Step = step.name(c.step)
+	return request, true
+}
+
+// extract extracts the variables of the current step, for use as Validation.
+func (c *Chain) extract(_ *http.Response, body []byte) error {
+	if err := c.Steps[c.step].extract(body, c.vars); err != nil {
+		return err
+	}
+	c.extracted = true
+	return nil
+}
+
+// OnResponse continues with the next step, if the variables of the current one were extracted,
+// for use as Client.OnResponse.
+func (c *Chain) OnResponse(_ Request, resp *http.Response, _ []byte) {
+	c.pending = false
+	c.step = (c.step + 1) % len(c.Steps)
+	if resp.StatusCode < 200 || resp.StatusCode > 299 || !c.extracted {
+		c.step = 0
+	}
+}
+
+// name returns the name of the step with the given index.
+func (s Step) name(index int) string {
+	if s.Name != "" {
+		return s.Name
+	}
+	return strconv.Itoa(index + 1)
+}
+
+// extract stores the values extracted from the body in vars.
+func (s Step) extract(body []byte, vars map[string]string) error {
+	for _, e := range s.Extract {
+		value, err := e.Extract(body)
+		if err != nil {
+			return fmt.Errorf("could not extract %s: %w", e.Name, err)
+		}
+		vars[e.Name] = value
+	}
+	return nil
+}
+
+// PerformSteps performs the steps once in order, without recording statistics, and returns the given variables
+// together with the values extracted from the responses. It fails on network errors, a status other than 2xx
+// or values, which cannot be extracted. Use it to set up a client before running, e.g. to log in.
+func (c *Client) PerformSteps(ctx context.Context, template *Template, steps []Step, vars map[string]string) (map[string]string, error) {
+	result := make(map[string]string, len(vars))
+	for k, v := range vars {
+		result[k] = v
+	}
+	for i, step := range steps {
+		request, err := template.Render(step.Request, result)
+		if err != nil {
+			return nil, fmt.Errorf("step %d: %w", i+1, err)
+		}
+		body, resp, err := c.performStep(ctx, request)
+		if err != nil {
+			return nil, fmt.Errorf("step %d: %w", i+1, err)
+		}
+		if resp.StatusCode < 200 || resp.StatusCode > 299 {
+			return nil, fmt.Errorf("step %d: %s %s returned %s", i+1, resp.Request.Method, request.URL, resp.Status)
+		}
+		if err := step.extract(body, result); err != nil {
+			return nil, fmt.Errorf("step %d: %w", i+1, err)
+		}
+	}
+	return result, nil
+}
+
+// performStep sends the request of a step within the timeout of the client and returns the read body.
+func (c *Client) performStep(ctx context.Context, request Request) ([]byte, *http.Response, error) {
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
+	req, err := request.NewHTTPRequest(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	return body, resp, err
+}
+
+// extractJSON returns the value at the given path, strings are returned as is, other values as JSON.
+func extractJSON(body []byte, path string) (string, error) {
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		return "", err
+	}
+
+	rest := strings.TrimPrefix(path, "$")
+	for rest != "" {
+		switch {
+		case strings.HasPrefix(rest, "."):
+			rest = rest[1:]
+			end := strings.IndexAny(rest, ".[")
+			if end < 0 {
+				end = len(rest)
+			}
+			object, ok := value.(map[string]interface{})
+			if !ok {
+				return "", fmt.Errorf("%s: no object at .%s", path, rest[:end])
+			}
+			if value, ok = object[rest[:end]]; !ok {
+				return "", fmt.Errorf("%s: no field %s", path, rest[:end])
+			}
+			rest = rest[end:]
+		case strings.HasPrefix(rest, "["):
+			end := strings.Index(rest, "]")
+			if end < 0 {
+				return "", fmt.Errorf("%s: unterminated index", path)
+			}
+			index, err := strconv.Atoi(rest[1:end])
+			if err != nil {
+				return "", fmt.Errorf("%s: invalid index %s", path, rest[1:end])
+			}
+			array, ok := value.([]interface{})
+			if !ok || index < 0 || index >= len(array) {
+				return "", fmt.Errorf("%s: no element at [%d]", path, index)
+			}
+			value = array[index]
+			rest = rest[end+1:]
+		default:
+			return "", fmt.Errorf("%s: unsupported selector %s", path, rest)
+		}
+	}
+
+	switch v := value.(type) {
+	case string:
+		return v, nil
+	case nil:
+		return "", fmt.Errorf("%s: value is null", path)
+	default:
+		data, err := json.Marshal(v)
+		return string(data), err
+	}
+}
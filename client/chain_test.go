@@ -0,0 +1,196 @@
+// SPDX-FileCopyrightText: 2021 Eric Neidhardt
+// SPDX-License-Identifier: MIT
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"testing"
+
+	"github.com/EricNeid/go-bench/internal/verify"
+)
+
+func TestChain(t *testing.T) {
+	// arrange
+	var received []string
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = append(received, r.Method+" "+r.URL.RequestURI())
+		if r.Method == http.MethodPost {
+			_, _ = w.Write([]byte(`{"data":{"items":[{"id":42}]},"token":"abc"}`))
+		}
+	}))
+	defer mockServer.Close()
+	chain := &Chain{
+		Steps: []Step{
+			{
+				Request: Request{URL: mockServer.URL + "/users", PostBody: []byte("{}")},
+				Extract: []Extraction{
+					{Name: "id", JSONPath: "$.data.items[0].id"},
+					{Name: "token", Regexp: regexp.MustCompile(`"token":"(\w+)"`)},
+				},
+			},
+			{Request: Request{URL: mockServer.URL + "/users/{{.id}}?token={{.token}}"}},
+			{Request: Request{URL: mockServer.URL + "/users/{{.id}}", Method: http.MethodDelete}},
+		},
+		Template: NewTemplate(),
+	}
+	unit := NewClient(Request{})
+	chain.Attach(unit)
+	// action
+	unit.RunForAmount(4)
+	// verify
+	verify.Equals(t, []string{"POST /users", "GET /users/42?token=abc", "DELETE /users/42", "POST /users"}, received)
+	verify.Equals(t, 4, unit.Statistic.SuccessCount)
+}
+
+func TestChain_restartOnFailure(t *testing.T) {
+	// arrange
+	var received []string
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = append(received, r.Method+" "+r.URL.Path)
+		_, _ = w.Write([]byte(`{"name":"max"}`))
+	}))
+	defer mockServer.Close()
+	chain := &Chain{
+		Steps: []Step{
+			{Request: Request{URL: mockServer.URL + "/a"}, Extract: []Extraction{{Name: "id", JSONPath: "$.id"}}},
+			{Request: Request{URL: mockServer.URL + "/b/{{.id}}"}},
+		},
+		Template: NewTemplate(),
+	}
+	unit := NewClient(Request{})
+	chain.Attach(unit)
+	// action
+	unit.RunForAmount(2)
+	// verify
+	verify.Equals(t, []string{"GET /a", "GET /a"}, received)
+	verify.Equals(t, 2, unit.Statistic.ValidationFailedCount)
+	verify.Equals(t, map[string]int{"extract": 2}, unit.Statistic.ValidationFailures)
+}
+
+func TestChain_renderFailure(t *testing.T) {
+	// arrange
+	var received []string
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = append(received, r.Method+" "+r.URL.Path)
+	}))
+	defer mockServer.Close()
+	chain := &Chain{
+		Steps: []Step{
+			{Request: Request{URL: mockServer.URL + "/a"}},
+			{Request: Request{URL: mockServer.URL + "/b/{{.missing}}"}},
+		},
+		Template: NewTemplate(),
+	}
+	unit := NewClient(Request{})
+	chain.Attach(unit)
+	// action
+	unit.RunForAmount(4)
+	// verify
+	verify.Equals(t, []string{"GET /a", "GET /a"}, received)
+	verify.Equals(t, 2, unit.Statistic.SuccessCount)
+	verify.Equals(t, 2, unit.Statistic.NetworkFailedCount)
+	verify.Equals(t, 2, unit.Statistic.Steps["2"].RequestCount)
+}
+
+func TestPerformSteps(t *testing.T) {
+	// arrange
+	var received []string
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = append(received, r.Method+" "+r.URL.RequestURI())
+		_, _ = w.Write([]byte(`{"token":"abc"}`))
+	}))
+	defer mockServer.Close()
+	steps := []Step{
+		{Request: Request{URL: mockServer.URL + "/login?user={{.user}}", PostBody: []byte("{}")}, Extract: []Extraction{{Name: "token", JSONPath: "$.token"}}},
+		{Request: Request{URL: mockServer.URL + "/check?token={{.token}}"}},
+	}
+	unit := NewClient(Request{})
+	// action
+	vars, err := unit.PerformSteps(context.Background(), NewTemplate(), steps, map[string]string{"user": "max"})
+	// verify
+	verify.Ok(t, err)
+	verify.Equals(t, map[string]string{"user": "max", "token": "abc"}, vars)
+	verify.Equals(t, []string{"POST /login?user=max", "GET /check?token=abc"}, received)
+	verify.Equals(t, 0, unit.Statistic.RequestCount)
+}
+
+func TestPerformSteps_failed(t *testing.T) {
+	// arrange
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer mockServer.Close()
+	steps := []Step{{Request: Request{URL: mockServer.URL + "/login"}}}
+	unit := NewClient(Request{})
+	// action
+	_, err := unit.PerformSteps(context.Background(), NewTemplate(), steps, nil)
+	// verify
+	verify.Assert(t, err != nil, "Expected error for status 401")
+}
+
+func TestExtractJSON(t *testing.T) {
+	body := []byte(`{"id":12345678901234567890,"name":"max","tags":["a","b"],"nested":{"ok":true},"none":null}`)
+
+	value, err := extractJSON(body, "$.id")
+	verify.Ok(t, err)
+	verify.Equals(t, "12345678901234567890", value)
+
+	value, err = extractJSON(body, "$.name")
+	verify.Ok(t, err)
+	verify.Equals(t, "max", value)
+
+	value, err = extractJSON(body, "$.tags[1]")
+	verify.Ok(t, err)
+	verify.Equals(t, "b", value)
+
+	value, err = extractJSON(body, "$.nested")
+	verify.Ok(t, err)
+	verify.Equals(t, `{"ok":true}`, value)
+
+	_, err = extractJSON(body, "$.tags[2]")
+	verify.Assert(t, err != nil, "Expected error for missing element")
+	_, err = extractJSON(body, "$.missing")
+	verify.Assert(t, err != nil, "Expected error for missing field")
+	_, err = extractJSON(body, "$.none")
+	verify.Assert(t, err != nil, "Expected error for null")
+	_, err = extractJSON([]byte("no json"), "$.id")
+	verify.Assert(t, err != nil, "Expected error for invalid json")
+}
+
+func TestChain_stepStatistics(t *testing.T) {
+	// arrange
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/checkout" {
+			w.WriteHeader(http.StatusConflict)
+		}
+	}))
+	defer mockServer.Close()
+	chain := &Chain{
+		Steps: []Step{
+			{Name: "browse", Request: Request{URL: mockServer.URL + "/items"}},
+			{Request: Request{URL: mockServer.URL + "/cart"}},
+			{Name: "checkout", Request: Request{URL: mockServer.URL + "/checkout"}},
+		},
+		Template: NewTemplate(),
+	}
+	unit := NewClient(Request{})
+	chain.Attach(unit)
+	var other Statistic
+	// action
+	unit.RunForAmount(7)
+	other.Merge(unit.Snapshot())
+	other.Merge(unit.Snapshot())
+	// verify
+	verify.Equals(t, 3, len(unit.Statistic.Steps))
+	verify.Equals(t, 3, unit.Statistic.Steps["browse"].RequestCount)
+	verify.Equals(t, 3, unit.Statistic.Steps["browse"].SuccessCount)
+	verify.Equals(t, 2, unit.Statistic.Steps["2"].SuccessCount)
+	verify.Equals(t, 2, unit.Statistic.Steps["checkout"].RequestCount)
+	verify.Equals(t, 0, unit.Statistic.Steps["checkout"].SuccessCount)
+	verify.Equals(t, 2, unit.Statistic.Steps["checkout"].Latencies.Count)
+	verify.Equals(t, 6, other.Steps["browse"].RequestCount)
+	verify.Equals(t, 3, unit.Statistic.Steps["browse"].Latencies.Count)
+}
@@ -7,181 +7,718 @@ package client
 import (
 	"bytes"
 	"context"
+	"fmt"
 	"io"
-	"log"
+	"maps"
+	"math/rand"
 	"net/http"
+	"net/http/httptrace"
+	"net/http/httputil"
 	"os"
 	"strings"
+	"sync"
 	"time"
 )
 
 // Request configures http request.
 type Request struct {
 	URL string
+	// Host header sent instead of the host of the URL, e.g. to benchmark a virtual host by the address of
+	// its server. A Host header of AdditionalHeaders is used as well. The connection and the TLS server name
+	// still use the host of the URL.
+	Host string
+	// HTTP method, defaults to POST if a body is given and GET otherwise.
+	Method string
 
-	PostBody    []byte
+	PostBody []byte
+	// BodyFile, if set, is streamed as body of every request instead of PostBody.
+	// Use it for bodies, which are too large to be kept in memory.
+	BodyFile    string
 	ContentType string
+	// AcceptEncoding, if set, requests compressed responses, e.g. gzip or br. The responses are decompressed
+	// by the client, so that their compressed and decompressed sizes are measured. Responses of other
+	// encodings than gzip, deflate and br cannot be decompressed and are measured as received.
+	AcceptEncoding string
 
 	KeepAlive         bool
 	AdditionalHeaders map[string]string
+	// Step, if set, is the name of the step of a chain, to which the request belongs, see Statistic.Steps.
+	Step string
+
+	// err is returned by NewHTTPRequest instead of creating the request, e.g. if it could not be rendered,
+	// see Template.Next.
+	err error
 }
 
-// Statistic contains measurement results.
+// Statistic contains measurement results. It is a plain value, which is copied as snapshot, merged and
+// encoded, so it holds no lock: a mutex would be copied along with each snapshot and atomic counters cannot
+// cover its maps and histograms. Goroutines sharing a statistic use a Recorder instead, which synchronizes
+// its updates, merges and reads, e.g. to aggregate the statistics of several clients while they are running.
 type Statistic struct {
-	// Overall number of bytes read.
+	// Overall number of bytes read, after decompression.
 	ReadThroughput int64
+	// Overall number of bytes read as received, before decompression. Equal to ReadThroughput,
+	// unless compressed responses are requested with Request.AcceptEncoding, as the transparent
+	// decompression of net/http hides the compressed size.
+	WireReadThroughput int64
 	// Overall number of bytes written.
 	WriteThroughput int64
+	// Distribution of the body sizes of all received responses.
+	ResponseSizes ResponseSizes
+	// Number of messages received by streaming calls and events of event streams, see GRPCStream and SSE.
+	MessageCount int
+	// Number of event streams, which ended before the run was done, see SSE.
+	DroppedCount int
+	// Number of datagrams, whose reply was not received in time, see UDP.
+	LostCount int
 
-	// Overall number of performed requests, always equal to the sum of failed and successful requests.
+	// Overall number of performed requests, always equal to the sum of failed, length mismatched,
+	// validation failed and successful requests.
 	RequestCount int
 	SuccessCount int
-	// Number of requests that failed with status code != 2xx.
+	// Number of requests that failed with an unexpected status code, by default != 2xx.
 	FailureCount int
+	// Number of requests with an expected status code, whose body is shorter or longer than
+	// its Content-Length, e.g. as the server truncates responses under load.
+	LengthMismatchCount int
+	// Number of requests with an expected status code, whose response failed a validation.
+	ValidationFailedCount int
+	// Number of failed validations per kind, see Validation.
+	ValidationFailures map[string]int
+	// Number of responses per status code.
+	StatusCodes map[int]int
+	// Number of DNS responses per response code, e.g. NXDOMAIN, see DNSQuery.
+	ResponseCodes map[string]int
 	// Number of request that failed with error != nil while performing the request.
 	NetworkFailedCount int
+	// Number of network failures per cause, see ClassifyError.
+	NetworkFailures map[string]int
 	// Number of request that failed with error != nil while reading response.
 	IOFailedCount int
+	// Number of requests in flight, when the context of the run was done, e.g. at the end of RunForDuration.
+	// They are not counted as RequestCount.
+	InterruptedCount int
+	// Number of 429 and 503 responses with a Retry-After header, by which the server throttles the client.
+	ThrottledCount int
+	// Overall time waited for the delays of Retry-After headers, see Client.RetryAfter.
+	Backoff time.Duration
+
+	// Overall time spent on requests that received a response, including reading the body.
+	Latency time.Duration
+	// Latencies per status class, keyed by the first digit of the status code, e.g. 5 for 5xx.
+	// They differ a lot, e.g. if errors are returned fast.
+	ClassLatencies map[int]Latencies
+	// Number of requests, which received a response, sent over a new or a reused connection.
+	// Most requests should reuse connections, if keep-alive is working.
+	NewConnections    int
+	ReusedConnections int
+	// Number of new connections per negotiated protocol of their first response, e.g. HTTP/1.1 or HTTP/2.0.
+	Protocols map[string]int
+	// Number of new connections per IP family of their remote address, IPv4 or IPv6.
+	IPFamilies map[string]int
+	// Number of TLS handshakes per negotiated version, e.g. TLS 1.3, and cipher suite.
+	TLSVersions  map[string]int
+	CipherSuites map[string]int
+	// Durations of the phases of requests, which received a response, see Phases.
+	Phases map[string]DurationHistogram
+	// Statistics per step of the requests, which belong to a step, see Request.Step.
+	Steps map[string]StepStatistic
+}
+
+// AverageLatency returns the mean latency of all requests that received a response.
+func (s Statistic) AverageLatency() time.Duration {
+	responses := s.SuccessCount + s.FailureCount + s.LengthMismatchCount + s.ValidationFailedCount
+	if responses == 0 {
+		return 0
+	}
+	return s.Latency / time.Duration(responses)
 }
 
 // Client is a custom http client that performs a request and collects measurements.
 type Client struct {
-	Statistic  Statistic
-	Request    Request
-	HTTPClient http.Client
-}
-
-// NewRequest creates a new request.
-func NewRequest(
-	url string,
-	postDataFilePath string,
-	postBody string,
-	contentType string,
-	keepAlive bool,
-	authHeader string,
-	additionalHeaders string,
-) *Request {
-	// preparing request
-	var request = Request{
-		URL:       url,
-		KeepAlive: keepAlive,
-	}
-
-	// read optional post body
-	if postDataFilePath != "" {
-		data, err := os.ReadFile(postDataFilePath)
-		if err != nil {
-			log.Fatalf("Error while reading post body from file: %s Error: %s", postDataFilePath, err)
-		}
-		request.PostBody = data
-	} else if postBody != "" {
-		request.PostBody = []byte(postBody)
-	}
+	// Statistic of the performed requests. It must not be accessed while the client performs requests,
+	// the statistic of a running client is read with Snapshot.
+	Statistic Statistic
+	Request   Request
+	// NextRequest, if set, returns the request to perform next instead of Request.
+	// If it returns false, there are no more requests and the client stops.
+	NextRequest func(ctx context.Context) (Request, bool)
+	// OnResponse, if set, is called with every received response and its body.
+	OnResponse func(request Request, resp *http.Response, body []byte)
+	// OnFailure, if set, is called after OnResponse with every response, which is not counted as success.
+	OnFailure func(request Request, resp *http.Response, body []byte, reason error)
+	// OnResult, if set, is called with the result of every performed request after OnResponse and OnFailure,
+	// including the ones failed without response.
+	OnResult func(result Result)
+	// ExpectStatus are the status codes counted as success, 2xx if empty.
+	ExpectStatus ExpectedStatus
+	// Validations of the responses with an expected status, which are counted as success only if all pass.
+	// Validations, which check the body, fail if DiscardBody is set.
+	Validations []Validation
+	// DiscardBody drains the response bodies without keeping them in memory, so large bodies
+	// do not slow down the client. OnResponse and debug output receive no body then.
+	DiscardBody bool
+	HTTPClient  http.Client
+	// Middlewares wrap HTTPClient in the given order, the first one receives the requests first. Their time
+	// is part of the latency of the requests. They must not be changed after the first request.
+	Middlewares []Middleware
+	// Timeout of each request including reading the response body, unlimited if <= 0. It is applied with
+	// the context of the request, independent of the timeouts of the connections, e.g. of the dialer.
+	Timeout time.Duration
+	// Maximum number of requests per second, unlimited if <= 0.
+	RateLimit float64
+	// Maximum delay of the Retry-After header of 429 and 503 responses, for which the client waits before its
+	// next request. Longer delays are shortened to it, the header is ignored if <= 0.
+	RetryAfter time.Duration
+	// Number of exchanges, starting with the first one, for which request and response are written to DebugWriter.
+	DebugCount  int
+	DebugWriter io.Writer
+
+	limiter   rateLimiter
+	retryAt   time.Time
+	debugged  int
+	exhausted bool
+	// HTTPClient wrapped by Middlewares, see doer
+	chain Doer
+	// guards Statistic while requests are performed, see Snapshot
+	mu sync.Mutex
+}
 
-	// create headers
-	request.AdditionalHeaders = make(map[string]string)
-	if contentType != "" {
-		request.ContentType = contentType
+// NewRequest creates a new request of the given URL, see RequestOption. An error is returned,
+// if an option could not be applied, e.g. a body file could not be read.
+func NewRequest(url string, options ...RequestOption) (*Request, error) {
+	request := Request{
+		URL:               url,
+		AdditionalHeaders: make(map[string]string),
 	}
-	if authHeader != "" {
-		request.AdditionalHeaders["Authorization"] = authHeader
+	for _, option := range options {
+		if err := option(&request); err != nil {
+			return nil, err
+		}
 	}
-	headers := strings.Split(additionalHeaders, ",")
-	for _, header := range headers {
+	return &request, nil
+}
+
+// ParseHeaders parses header fields given as comma separated list of key=value pairs.
+// Malformed pairs are ignored.
+func ParseHeaders(headers string) map[string]string {
+	result := make(map[string]string)
+	for _, header := range strings.Split(headers, ",") {
 		keyValue := strings.Split(header, "=")
 		if len(keyValue) == 2 {
-			request.AdditionalHeaders[strings.TrimSpace(keyValue[0])] = strings.TrimSpace(keyValue[1])
+			result[strings.TrimSpace(keyValue[0])] = strings.TrimSpace(keyValue[1])
 		}
 	}
+	return result
+}
 
-	return &request
+// NewHTTPRequest creates the http request described by this configuration.
+func (r *Request) NewHTTPRequest(ctx context.Context) (*http.Request, error) {
+	if r.err != nil {
+		return nil, r.err
+	}
+	method := r.Method
+	if method == "" && (r.PostBody != nil || r.BodyFile != "") {
+		method = http.MethodPost
+	} else if method == "" {
+		method = http.MethodGet
+	}
+	var req *http.Request
+	var err error
+	if r.BodyFile != "" {
+		req, err = r.newStreamedRequest(ctx, method)
+	} else if r.PostBody != nil {
+		req, err = http.NewRequestWithContext(ctx, method, r.URL, bytes.NewReader(r.PostBody))
+		if err == nil {
+			req.Header.Set("Content-Type", r.ContentType)
+		}
+	} else {
+		req, err = http.NewRequestWithContext(ctx, method, r.URL, http.NoBody)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if r.KeepAlive {
+		req.Header.Set("Connection", "keep-alive")
+	} else {
+		req.Header.Set("Connection", "close")
+	}
+
+	if r.AcceptEncoding != "" {
+		// disables the transparent decompression of net/http
+		req.Header.Set("Accept-Encoding", r.AcceptEncoding)
+	}
+	for k, v := range r.AdditionalHeaders {
+		if strings.EqualFold(k, "Host") {
+			// ignored by net/http as header
+			req.Host = v
+			continue
+		}
+		req.Header.Set(k, v)
+	}
+	if r.Host != "" {
+		req.Host = r.Host
+	}
+	return req, nil
 }
 
-// NewClient creates a new client instance.
-func NewClient(timeout time.Duration, request Request) *Client {
-	return &Client{
-		HTTPClient: http.Client{
-			Timeout: timeout,
-		},
-		Request: request,
+// streamedBodyKey marks the context of a request, whose body is streamed from a body file, see streamedBody.
+type streamedBodyKey struct{}
+
+// streamedBody returns true, if the body of the request is streamed from a body file, e.g. so that round
+// trippers do not read it into memory. The mark is kept, when the request is cloned or its body is wrapped.
+func streamedBody(req *http.Request) bool {
+	return req.Context().Value(streamedBodyKey{}) != nil
+}
+
+// newStreamedRequest creates a request, whose body is read from the body file while it is sent.
+func (r *Request) newStreamedRequest(ctx context.Context, method string) (*http.Request, error) {
+	ctx = context.WithValue(ctx, streamedBodyKey{}, true)
+	file, err := os.Open(r.BodyFile)
+	if err != nil {
+		return nil, err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, err
 	}
+	req, err := http.NewRequestWithContext(ctx, method, r.URL, file)
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	req.ContentLength = info.Size()
+	req.GetBody = func() (io.ReadCloser, error) {
+		return os.Open(r.BodyFile)
+	}
+	req.Header.Set("Content-Type", r.ContentType)
+	return req, nil
 }
 
-// RunForDuration instructs the client to perform its request as often as possible for a given duration.
-func (c *Client) RunForDuration(timeout time.Duration) {
-	startTime := time.Now()
-	ctx, cancel := context.WithTimeout(context.Background(), timeout)
-	defer cancel()
-	for time.Since(startTime) < timeout {
-		c.PerformRequestWithContent(ctx)
+// Sequential returns a function for Client.NextRequest, which returns the given requests in turn,
+// starting again with the first one after the last. The returned function is not safe for concurrent use.
+func Sequential(requests []Request, offset int) func(ctx context.Context) (Request, bool) {
+	i := offset
+	return func(_ context.Context) (Request, bool) {
+		request := requests[i%len(requests)]
+		i = (i + 1) % len(requests)
+		return request, true
 	}
-	// the last request can be interrupted by context timeout
-	// we remove this from statistic
-	if c.Statistic.NetworkFailedCount == 1 {
-		c.Statistic.RequestCount--
-		c.Statistic.NetworkFailedCount--
+}
+
+// Shuffled returns a function for Client.NextRequest, which returns all given requests once per pass
+// in an order shuffled for every pass. The returned function is not safe for concurrent use.
+func Shuffled(requests []Request, rng *rand.Rand) func(ctx context.Context) (Request, bool) {
+	order := make([]Request, len(requests))
+	i := len(order)
+	return func(_ context.Context) (Request, bool) {
+		if i == len(order) {
+			copy(order, requests)
+			rng.Shuffle(len(order), func(a, b int) {
+				order[a], order[b] = order[b], order[a]
+			})
+			i = 0
+		}
+		request := order[i]
+		i++
+		return request, true
+	}
+}
+
+// Random returns a function for Client.NextRequest, which returns requests sampled uniformly at random.
+// The returned function is not safe for concurrent use.
+func Random(requests []Request, rng *rand.Rand) func(ctx context.Context) (Request, bool) {
+	return func(_ context.Context) (Request, bool) {
+		return requests[rng.Intn(len(requests))], true
+	}
+}
+
+// FromChannel returns a function for Client.NextRequest, which returns the requests received from the channel
+// until it is closed. It is safe to share the channel between clients.
+func FromChannel(requests <-chan Request) func(ctx context.Context) (Request, bool) {
+	return func(ctx context.Context) (Request, bool) {
+		select {
+		case request, ok := <-requests:
+			return request, ok
+		case <-ctx.Done():
+			return Request{}, false
+		}
+	}
+}
+
+// NewClient creates a new client instance performing the given request, see ClientOption.
+func NewClient(request Request, options ...ClientOption) *Client {
+	c := &Client{Request: request}
+	for _, option := range options {
+		option(c)
 	}
+	return c
+}
+
+// RunForDuration instructs the client to perform its request as often as possible for a given duration.
+func (c *Client) RunForDuration(timeout time.Duration) {
+	RunInterleavedForDurationContext(context.Background(), timeout, c)
+}
+
+// RunForDurationContext is like RunForDuration, but stops early if the context is done.
+// The statistic contains the requests performed until then.
+func (c *Client) RunForDurationContext(ctx context.Context, timeout time.Duration) {
+	RunInterleavedForDurationContext(ctx, timeout, c)
 }
 
 // RunForAmount instructs the client to perform its request until a certain request count is reached.
 func (c *Client) RunForAmount(requestCount int) {
-	for i := 0; i < requestCount; i++ {
-		c.PerformRequest()
+	RunInterleavedForAmountContext(context.Background(), requestCount, c)
+}
+
+// RunForAmountContext is like RunForAmount, but stops early if the context is done.
+// The statistic contains the requests performed until then.
+func (c *Client) RunForAmountContext(ctx context.Context, requestCount int) {
+	RunInterleavedForAmountContext(ctx, requestCount, c)
+}
+
+// RunInterleavedForDuration instructs the given clients to perform their requests in turn
+// as often as possible for a given duration.
+func RunInterleavedForDuration(timeout time.Duration, clients ...*Client) {
+	RunInterleavedForDurationContext(context.Background(), timeout, clients...)
+}
+
+// RunInterleavedForDurationContext is like RunInterleavedForDuration, but stops early if the context is done.
+func RunInterleavedForDurationContext(ctx context.Context, timeout time.Duration, clients ...*Client) {
+	RunForDuration(ctx, timeout, benchmarkers(clients)...)
+}
+
+// RunInterleavedForAmount instructs the given clients to perform their requests in turn
+// until each client reached a certain request count.
+func RunInterleavedForAmount(requestCount int, clients ...*Client) {
+	RunInterleavedForAmountContext(context.Background(), requestCount, clients...)
+}
+
+// RunInterleavedForAmountContext is like RunInterleavedForAmount, but stops early if the context is done.
+func RunInterleavedForAmountContext(ctx context.Context, requestCount int, clients ...*Client) {
+	RunForAmount(ctx, requestCount, benchmarkers(clients)...)
+}
+
+// benchmarkers returns the clients as benchmarkers.
+func benchmarkers(clients []*Client) []Benchmarker {
+	b := make([]Benchmarker, len(clients))
+	for i, c := range clients {
+		b[i] = c
 	}
+	return b
 }
 
-// PerformRequest instructs the client to perform its request once.
-func (c *Client) PerformRequest() {
-	c.PerformRequestWithContent(context.Background())
+// PerformRequest instructs the client to perform its request once, see PerformRequestWithContent.
+func (c *Client) PerformRequest() (Result, bool) {
+	return c.PerformRequestWithContent(context.Background())
 }
 
-// PerformRequestWithContent instructs the client to perform its request once with a given context.
-func (c *Client) PerformRequestWithContent(ctx context.Context) {
-	// prepare request from configuration
-	var req *http.Request
-	var err error
-	if c.Request.PostBody != nil {
-		req, err = http.NewRequestWithContext(ctx, "POST", c.Request.URL, bytes.NewReader(c.Request.PostBody))
-		req.Header.Set("Content-Type", c.Request.ContentType)
-	} else {
-		req, err = http.NewRequestWithContext(ctx, "GET", c.Request.URL, http.NoBody)
+// Perform performs the next request, see PerformRequestWithContent. It implements Benchmarker.
+func (c *Client) Perform(ctx context.Context) (Result, bool) {
+	return c.PerformRequestWithContent(ctx)
+}
+
+// PerformRequestWithContent instructs the client to perform its request once with a given context
+// and returns its result, which has been added to the statistic. Nothing is performed and false is returned,
+// if NextRequest has no more requests or the context is done while waiting for a Retry-After delay.
+func (c *Client) PerformRequestWithContent(ctx context.Context) (Result, bool) {
+	result, ok := c.performRequest(ctx)
+	if ok && result.Request.Step != "" && result.Class != ClassInterrupted {
+		c.countStep(result)
 	}
-	if err != nil {
-		panic("Could not create http request")
+	if ok && c.OnResult != nil {
+		c.OnResult(result)
 	}
+	return result, ok
+}
 
-	if c.Request.KeepAlive {
-		req.Header.Set("Connection", "keep-alive")
-	} else {
-		req.Header.Set("Connection", "close")
+func (c *Client) performRequest(ctx context.Context) (Result, bool) {
+	if c.exhausted || !c.waitForRetryAfter(ctx) {
+		return Result{}, false
 	}
+	c.limiter.wait(ctx, c.RateLimit)
 
-	if c.Request.AdditionalHeaders != nil {
-		for k, v := range c.Request.AdditionalHeaders {
-			req.Header.Set(k, v)
+	request := c.Request
+	if c.NextRequest != nil {
+		var ok bool
+		if request, ok = c.NextRequest(ctx); !ok {
+			c.exhausted = true
+			return Result{}, false
 		}
 	}
+	// covers reading the body as well
+	runCtx := ctx
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
+	req, err := request.NewHTTPRequest(ctx)
+	if err != nil {
+		// the body file may have been removed while running or a rendered URL may be invalid
+		c.countRequest()
+		return Result{Request: request, Start: time.Now(), Class: c.countNetworkFailure(err), Err: err}, true
+	}
+
+	debug := c.DebugWriter != nil && c.debugged < c.DebugCount
+	var requestDump string
+	if debug {
+		c.debugged++
+		requestDump = dumpRequest(req, request.BodyFile == "")
+	}
 
 	// perform request
-	c.Statistic.RequestCount++
-	resp, err := c.HTTPClient.Do(req)
+	c.countRequest()
+	startTime := time.Now()
+	phases, trace := newPhaseTrace(startTime)
+	req = req.WithContext(context.WithValue(httptrace.WithClientTrace(req.Context(), trace), phaseTraceKey{}, phases))
+	resp, err := c.doer().Do(req)
+	if err != nil && RunDone(runCtx) {
+		return c.interrupted(request, startTime, err), true
+	}
 	if err != nil {
-		c.Statistic.NetworkFailedCount++
-		return
+		result := Result{Request: request, Start: startTime, Latency: time.Since(startTime), Class: c.countNetworkFailure(err), Err: err}
+		if debug {
+			fmt.Fprintf(c.DebugWriter, ">>> request %d\n%s\n\n<<< failed: %s\n\n", c.debugged, requestDump, err)
+		}
+		return result, true
 	}
 	defer resp.Body.Close()
 
+	var body []byte
+	var received, read int64
+	var ioFailures int
+	if c.DiscardBody {
+		received, read, err = drainBody(resp.Body, resp.Header.Get("Content-Encoding"), request.AcceptEncoding != "")
+		if err != nil {
+			ioFailures++
+		}
+	} else {
+		body, err = io.ReadAll(resp.Body)
+		if err != nil {
+			ioFailures++
+		}
+		received = int64(len(body))
+		if err == nil && request.AcceptEncoding != "" {
+			if body, err = decodeBody(resp.Header.Get("Content-Encoding"), body); err != nil {
+				ioFailures++
+			}
+		}
+		read = int64(len(body))
+	}
+	if ioFailures > 0 && RunDone(runCtx) {
+		return c.interrupted(request, startTime, err), true
+	}
+	endTime := time.Now()
+	latency := endTime.Sub(startTime)
+
 	// write statistic
-	switch {
-	case resp.StatusCode >= 200 && resp.StatusCode <= 299:
-		c.Statistic.SuccessCount++
-	default:
+	c.mu.Lock()
+	c.Statistic.IOFailedCount += ioFailures
+	c.Statistic.WireReadThroughput += received
+	c.Statistic.ReadThroughput += read
+	c.Statistic.ResponseSizes.Add(read)
+	c.Statistic.Latency += latency
+	phases.record(&c.Statistic, resp.Proto, endTime)
+	if c.Statistic.StatusCodes == nil {
+		c.Statistic.StatusCodes = make(map[int]int)
+	}
+	c.Statistic.StatusCodes[resp.StatusCode]++
+	if c.Statistic.ClassLatencies == nil {
+		c.Statistic.ClassLatencies = make(map[int]Latencies)
+	}
+	classLatencies := c.Statistic.ClassLatencies[resp.StatusCode/100]
+	classLatencies.Add(latency)
+	c.Statistic.ClassLatencies[resp.StatusCode/100] = classLatencies
+	if delay, ok := retryAfter(resp, endTime); ok {
+		c.Statistic.ThrottledCount++
+		if c.RetryAfter > 0 {
+			c.retryAt = endTime.Add(min(delay, c.RetryAfter))
+		}
+	}
+	result := Result{Request: request, Start: startTime, Latency: latency, StatusCode: resp.StatusCode, BytesRead: read}
+	var failure error
+	if !c.ExpectStatus.Contains(resp.StatusCode) {
 		c.Statistic.FailureCount++
+		result.Class = ClassStatus
+		failure = fmt.Errorf("unexpected status %s", resp.Status)
+	} else if lengthMismatch(req, resp, received) {
+		c.Statistic.LengthMismatchCount++
+		result.Class = ClassLengthMismatch
+		failure = fmt.Errorf("received %d bytes instead of Content-Length %d", received, resp.ContentLength)
+	} else if failure = c.validate(resp, body); failure != nil {
+		c.Statistic.ValidationFailedCount++
+		result.Class = ClassValidation
+	} else {
+		c.Statistic.SuccessCount++
+		result.Class = ClassSuccess
+	}
+	result.Err = failure
+	if request.BodyFile != "" {
+		result.BytesWritten = req.ContentLength
+	} else {
+		result.BytesWritten = int64(len(request.PostBody))
+	}
+	c.Statistic.WriteThroughput += result.BytesWritten
+	c.mu.Unlock()
+
+	if c.OnResponse != nil {
+		c.OnResponse(request, resp, body)
+	}
+	if failure != nil && c.OnFailure != nil {
+		c.OnFailure(request, resp, body, failure)
+	}
+	if debug {
+		fmt.Fprintf(c.DebugWriter, ">>> request %d\n%s\n\n<<< response\n%s\n\n", c.debugged, requestDump, dumpResponse(resp, body))
+	}
+	return result, true
+}
+
+// Merge adds the counts and distributions of other to the statistic, e.g. to sum up the statistics
+// of several clients. Durations like Latency are summed up as well, AverageLatency of the merged statistic
+// is the mean of all requests.
+func (s *Statistic) Merge(other Statistic) {
+	s.ReadThroughput += other.ReadThroughput
+	s.WireReadThroughput += other.WireReadThroughput
+	s.WriteThroughput += other.WriteThroughput
+	s.ResponseSizes.Merge(other.ResponseSizes)
+	s.MessageCount += other.MessageCount
+	s.DroppedCount += other.DroppedCount
+	s.LostCount += other.LostCount
+	s.RequestCount += other.RequestCount
+	s.SuccessCount += other.SuccessCount
+	s.FailureCount += other.FailureCount
+	s.LengthMismatchCount += other.LengthMismatchCount
+	s.ValidationFailedCount += other.ValidationFailedCount
+	s.ValidationFailures = mergeCounts(s.ValidationFailures, other.ValidationFailures)
+	s.StatusCodes = mergeCounts(s.StatusCodes, other.StatusCodes)
+	s.ResponseCodes = mergeCounts(s.ResponseCodes, other.ResponseCodes)
+	s.NetworkFailedCount += other.NetworkFailedCount
+	s.NetworkFailures = mergeCounts(s.NetworkFailures, other.NetworkFailures)
+	s.IOFailedCount += other.IOFailedCount
+	s.InterruptedCount += other.InterruptedCount
+	s.ThrottledCount += other.ThrottledCount
+	s.Backoff += other.Backoff
+	s.Latency += other.Latency
+	for class, l := range other.ClassLatencies {
+		if s.ClassLatencies == nil {
+			s.ClassLatencies = make(map[int]Latencies)
+		}
+		merged := s.ClassLatencies[class]
+		merged.Merge(l)
+		s.ClassLatencies[class] = merged
+	}
+	s.NewConnections += other.NewConnections
+	s.ReusedConnections += other.ReusedConnections
+	s.Protocols = mergeCounts(s.Protocols, other.Protocols)
+	s.IPFamilies = mergeCounts(s.IPFamilies, other.IPFamilies)
+	s.TLSVersions = mergeCounts(s.TLSVersions, other.TLSVersions)
+	s.CipherSuites = mergeCounts(s.CipherSuites, other.CipherSuites)
+	for phase, h := range other.Phases {
+		if s.Phases == nil {
+			s.Phases = make(map[string]DurationHistogram)
+		}
+		merged := s.Phases[phase]
+		merged.Merge(h)
+		s.Phases[phase] = merged
+	}
+	for step, st := range other.Steps {
+		if s.Steps == nil {
+			s.Steps = make(map[string]StepStatistic)
+		}
+		merged := s.Steps[step]
+		merged.Merge(st)
+		s.Steps[step] = merged
+	}
+}
+
+// mergeCounts adds the counts to the sums, which are created if needed.
+func mergeCounts[K comparable](sums, counts map[K]int) map[K]int {
+	for k, n := range counts {
+		if sums == nil {
+			sums = make(map[K]int, len(counts))
+		}
+		sums[k] += n
+	}
+	return sums
+}
+
+// countRequest counts a request, before it is sent.
+func (c *Client) countRequest() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.Statistic.RequestCount++
+}
+
+// Snapshot returns a copy of the statistic, which is safe to call while the client performs requests
+// in another goroutine, e.g. to report intermediate results.
+func (c *Client) Snapshot() Statistic {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.Statistic.clone()
+}
+
+// clone returns a copy of the statistic, which does not share its maps.
+func (s Statistic) clone() Statistic {
+	s.ValidationFailures = maps.Clone(s.ValidationFailures)
+	s.StatusCodes = maps.Clone(s.StatusCodes)
+	s.ResponseCodes = maps.Clone(s.ResponseCodes)
+	s.NetworkFailures = maps.Clone(s.NetworkFailures)
+	s.Protocols = maps.Clone(s.Protocols)
+	s.IPFamilies = maps.Clone(s.IPFamilies)
+	s.TLSVersions = maps.Clone(s.TLSVersions)
+	s.CipherSuites = maps.Clone(s.CipherSuites)
+	s.ClassLatencies = maps.Clone(s.ClassLatencies)
+	if s.Phases != nil {
+		phases := make(map[string]DurationHistogram, len(s.Phases))
+		for phase, h := range s.Phases {
+			h.Buckets = maps.Clone(h.Buckets)
+			phases[phase] = h
+		}
+		s.Phases = phases
+	}
+	if s.Steps != nil {
+		steps := make(map[string]StepStatistic, len(s.Steps))
+		for step, st := range s.Steps {
+			st.Latencies.Buckets = maps.Clone(st.Latencies.Buckets)
+			steps[step] = st
+		}
+		s.Steps = steps
+	}
+	return s
+}
+
+// withTimeout returns the context of a request, which is done after the timeout of the client.
+func (c *Client) withTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	if c.Timeout <= 0 {
+		return context.WithCancel(ctx)
 	}
-	body, err := io.ReadAll(resp.Body)
+	return context.WithTimeout(ctx, c.Timeout)
+}
+
+// lengthMismatch returns true, if the number of received body bytes differs from the Content-Length
+// of a response, which is expected to have a body.
+func lengthMismatch(req *http.Request, resp *http.Response, received int64) bool {
+	if resp.ContentLength < 0 || req.Method == http.MethodHead ||
+		resp.StatusCode == http.StatusNoContent || resp.StatusCode == http.StatusNotModified {
+		return false
+	}
+	return received != resp.ContentLength
+}
+
+// dumpRequest returns the wire representation of the request, optionally without its body.
+func dumpRequest(req *http.Request, body bool) string {
+	dump, err := httputil.DumpRequestOut(req, body)
+	if err != nil {
+		return fmt.Sprintf("could not dump request: %s", err)
+	}
+	return string(bytes.TrimRight(dump, "\r\n"))
+}
+
+// dumpResponse returns the wire representation of the response with the already read body.
+func dumpResponse(resp *http.Response, body []byte) string {
+	dump, err := httputil.DumpResponse(resp, false)
 	if err != nil {
-		c.Statistic.IOFailedCount++
+		return fmt.Sprintf("could not dump response: %s", err)
 	}
-	c.Statistic.ReadThroughput += int64(len(body))
-	c.Statistic.WriteThroughput += int64(len(c.Request.PostBody))
+	return string(dump) + string(body)
 }
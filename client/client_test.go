@@ -5,8 +5,13 @@ package client
 import (
 	"context"
 	"io"
+	"math/rand"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
 	"testing"
 	"time"
 
@@ -15,26 +20,76 @@ import (
 
 func TestNewRequest(t *testing.T) {
 	// action
-	result := NewRequest(
+	result, err := NewRequest(
 		"http://localhost",
-		"",
-		"{\"test\":\"value\"}",
-		"application/json",
-		true,
-		"authorizationHeader",
-		"key1=value1,key2=value2, key3=value3",
+		WithMethod(http.MethodPut),
+		WithBody([]byte("{\"test\":\"value\"}")),
+		WithHeader("Content-Type", "application/json"),
+		WithKeepAlive(true),
+		WithHeader("Authorization", "authorizationHeader"),
+		WithHeader("key1", "value1"),
 	)
 	// verify
-	verify.NotNil(t, result, "Request is nil")
+	verify.Ok(t, err)
 	verify.Equals(t, "http://localhost", result.URL)
+	verify.Equals(t, http.MethodPut, result.Method)
 	verify.Equals(t, []byte("{\"test\":\"value\"}"), result.PostBody)
 	verify.Equals(t, "application/json", result.ContentType)
 	verify.Equals(t, true, result.KeepAlive)
-	verify.Equals(t, 4, len(result.AdditionalHeaders))
-	verify.Equals(t, "authorizationHeader", result.AdditionalHeaders["Authorization"])
-	verify.Equals(t, "value1", result.AdditionalHeaders["key1"])
-	verify.Equals(t, "value2", result.AdditionalHeaders["key2"])
-	verify.Equals(t, "value3", result.AdditionalHeaders["key3"])
+	verify.Equals(t, map[string]string{"Authorization": "authorizationHeader", "key1": "value1"}, result.AdditionalHeaders)
+}
+
+func TestNewRequest_withBodyFile(t *testing.T) {
+	// arrange
+	filePath := filepath.Join(t.TempDir(), "body.json")
+	verify.Ok(t, os.WriteFile(filePath, []byte("{}"), 0o600))
+	// action
+	result, err := NewRequest("http://localhost", WithBodyFile(filePath))
+	_, missingErr := NewRequest("http://localhost", WithBodyFile(filePath+".missing"))
+	// verify
+	verify.Ok(t, err)
+	verify.Equals(t, []byte("{}"), result.PostBody)
+	verify.Assert(t, missingErr != nil, "Missing body file not detected")
+}
+
+// roundTripperFunc is a transport calling itself.
+type roundTripperFunc func(req *http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+func TestNewClient_withTransport(t *testing.T) {
+	// arrange
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer mockServer.Close()
+	var roundTrips int
+	transport := roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		roundTrips++
+		return http.DefaultTransport.RoundTrip(req)
+	})
+	unit := NewClient(Request{URL: mockServer.URL}, WithTransport(transport))
+	// action
+	unit.RunForAmount(2)
+	// verify
+	verify.Equals(t, 2, roundTrips)
+	verify.Equals(t, 2, unit.Statistic.SuccessCount)
+	verify.Equals(t, 2, unit.Statistic.Phases[PhaseTTFB].Count)
+}
+
+func TestParseHeaders(t *testing.T) {
+	// action
+	result := ParseHeaders("key1=value1,key2=value2, key3=value3,malformed")
+	// verify
+	verify.Equals(t, map[string]string{"key1": "value1", "key2": "value2", "key3": "value3"}, result)
+}
+
+func TestNewClient(t *testing.T) {
+	// action
+	result := NewClient(Request{URL: "http://localhost"}, WithTimeout(time.Second))
+	// verify
+	verify.Equals(t, "http://localhost", result.Request.URL)
+	verify.Equals(t, time.Second, result.Timeout)
 }
 
 func TestPerformRequest_get(t *testing.T) {
@@ -55,6 +110,56 @@ func TestPerformRequest_get(t *testing.T) {
 	verify.Equals(t, 0, unit.Statistic.IOFailedCount)
 	verify.Equals(t, int64(len([]byte("test response"))), unit.Statistic.ReadThroughput)
 	verify.Equals(t, int64(0), unit.Statistic.WriteThroughput)
+	verify.Assert(t, unit.Statistic.Latency > 0, "Latency was not measured")
+}
+
+func TestAverageLatency(t *testing.T) {
+	// arrange
+	unit := Statistic{
+		SuccessCount:       3,
+		FailureCount:       1,
+		NetworkFailedCount: 2,
+		Latency:            8 * time.Millisecond,
+	}
+	// action
+	result := unit.AverageLatency()
+	// verify
+	verify.Equals(t, 2*time.Millisecond, result)
+	verify.Equals(t, time.Duration(0), Statistic{}.AverageLatency())
+}
+
+func TestStatistic_Merge(t *testing.T) {
+	// arrange
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/missing" {
+			w.WriteHeader(http.StatusNotFound)
+		}
+		w.Write([]byte("test response"))
+	}))
+	defer mockServer.Close()
+	a := Client{Request: Request{URL: mockServer.URL, KeepAlive: true}}
+	a.RunForAmount(3)
+	b := Client{Request: Request{URL: mockServer.URL + "/missing"}}
+	b.RunForAmount(2)
+	var unit Statistic
+	// action
+	unit.Merge(a.Statistic)
+	unit.Merge(b.Statistic)
+	// verify
+	verify.Equals(t, 5, unit.RequestCount)
+	verify.Equals(t, 3, unit.SuccessCount)
+	verify.Equals(t, 2, unit.FailureCount)
+	verify.Equals(t, map[int]int{http.StatusOK: 3, http.StatusNotFound: 2}, unit.StatusCodes)
+	verify.Equals(t, 5*int64(len("test response")), unit.ReadThroughput)
+	verify.Equals(t, 5, unit.ResponseSizes.Count)
+	verify.Equals(t, a.Statistic.Latency+b.Statistic.Latency, unit.Latency)
+	verify.Equals(t, 3, unit.ClassLatencies[2].Count)
+	verify.Equals(t, 2, unit.ClassLatencies[4].Count)
+	verify.Equals(t, a.Statistic.NewConnections+b.Statistic.NewConnections, unit.NewConnections)
+	verify.Equals(t, 5, unit.NewConnections+unit.ReusedConnections)
+	verify.Equals(t, map[string]int{"HTTP/1.1": unit.NewConnections}, unit.Protocols)
+	verify.Equals(t, 5, unit.Phases[PhaseTTFB].Count)
+	verify.Equals(t, 3, a.Statistic.RequestCount)
 }
 
 func TestPerformRequestWithContext_shouldCancelRequestAfterDeadline(t *testing.T) {
@@ -77,6 +182,26 @@ func TestPerformRequestWithContext_shouldCancelRequestAfterDeadline(t *testing.T
 	verify.Equals(t, 0, unit.Statistic.IOFailedCount)
 }
 
+func TestPerformRequest_timeoutWhileReadingBody(t *testing.T) {
+	// arrange
+	release := make(chan struct{})
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("first"))
+		w.(http.Flusher).Flush()
+		<-release
+	}))
+	defer mockServer.Close()
+	defer close(release)
+	unit := NewClient(Request{URL: mockServer.URL}, WithTimeout(50*time.Millisecond))
+	// action
+	start := time.Now()
+	unit.PerformRequest()
+	// verify
+	verify.Equals(t, 1, unit.Statistic.RequestCount)
+	verify.Equals(t, 1, unit.Statistic.IOFailedCount)
+	verify.Assert(t, time.Since(start) < time.Second, "Reading the body did not time out")
+}
+
 func TestPerformRequest_withCustomHeader(t *testing.T) {
 	// arrange
 	requestReceived := false
@@ -175,3 +300,407 @@ func TestRunForDuration(t *testing.T) {
 	verify.Equals(t, unit.Statistic.RequestCount, unit.Statistic.SuccessCount)
 	verify.Equals(t, int64(unit.Statistic.SuccessCount*len([]byte("test response"))), unit.Statistic.ReadThroughput)
 }
+
+func TestRunForDuration_interrupted(t *testing.T) {
+	// arrange
+	release := make(chan struct{})
+	requests := 0
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if requests == 1 {
+			conn, _, _ := w.(http.Hijacker).Hijack()
+			conn.Close()
+			return
+		}
+		<-release
+	}))
+	defer mockServer.Close()
+	defer close(release)
+	var results []Result
+	unit := Client{Request: Request{URL: mockServer.URL}, OnResult: func(r Result) { results = append(results, r) }}
+	// action
+	unit.RunForDuration(200 * time.Millisecond)
+	// verify
+	verify.Equals(t, 1, unit.Statistic.RequestCount)
+	verify.Equals(t, 1, unit.Statistic.NetworkFailedCount)
+	verify.Equals(t, map[string]int{FailureReset: 1}, unit.Statistic.NetworkFailures)
+	verify.Equals(t, 1, unit.Statistic.InterruptedCount)
+	verify.Equals(t, ClassInterrupted, results[len(results)-1].Class)
+}
+
+func TestRunForAmountContext(t *testing.T) {
+	// arrange
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("test response"))
+	}))
+	defer mockServer.Close()
+	ctx, cancel := context.WithCancel(context.Background())
+	requested := 0
+	unit := Client{
+		Request: Request{URL: mockServer.URL},
+		OnResult: func(Result) {
+			requested++
+			if requested == 3 {
+				cancel()
+			}
+		},
+	}
+	// action
+	unit.RunForAmountContext(ctx, 10)
+	// verify
+	verify.Equals(t, 3, unit.Statistic.RequestCount)
+	verify.Equals(t, 3, unit.Statistic.SuccessCount)
+}
+
+func TestRunForDurationContext(t *testing.T) {
+	// arrange
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("test response"))
+	}))
+	defer mockServer.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	unit := Client{Request: Request{URL: mockServer.URL}}
+	startTime := time.Now()
+	// action
+	unit.RunForDurationContext(ctx, time.Minute)
+	// verify
+	verify.Assert(t, time.Since(startTime) < 10*time.Second, "Run not stopped by context")
+	verify.Assert(t, unit.Statistic.SuccessCount > 0, "No request received")
+	verify.Equals(t, int64(unit.Statistic.SuccessCount*len("test response")), unit.Statistic.ReadThroughput)
+}
+
+func TestSnapshot(t *testing.T) {
+	// arrange
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("test response"))
+	}))
+	defer mockServer.Close()
+	unit := Client{Request: Request{URL: mockServer.URL}}
+	done := make(chan struct{})
+	go func() {
+		unit.RunForAmount(50)
+		close(done)
+	}()
+	// action
+	var snapshots []Statistic
+	for running := true; running; {
+		select {
+		case <-done:
+			running = false
+		default:
+			snapshots = append(snapshots, unit.Snapshot())
+			time.Sleep(time.Millisecond)
+		}
+	}
+	// verify
+	for _, snapshot := range snapshots {
+		verify.Assert(t, snapshot.SuccessCount <= snapshot.RequestCount, "Snapshot counts a response before its request")
+		verify.Equals(t, int64(snapshot.SuccessCount*len("test response")), snapshot.ReadThroughput)
+	}
+	final := unit.Snapshot()
+	verify.Equals(t, 50, final.SuccessCount)
+	final.StatusCodes[http.StatusOK] = 0
+	verify.Equals(t, 50, unit.Statistic.StatusCodes[http.StatusOK])
+}
+
+func TestRunInterleavedForAmount(t *testing.T) {
+	// arrange
+	var receivedPaths []string
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		receivedPaths = append(receivedPaths, r.URL.Path)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer mockServer.Close()
+	unitA := Client{Request: Request{URL: mockServer.URL + "/a"}}
+	unitB := Client{Request: Request{URL: mockServer.URL + "/b"}}
+	// action
+	RunInterleavedForAmount(2, &unitA, &unitB)
+	// verify
+	verify.Equals(t, []string{"/a", "/b", "/a", "/b"}, receivedPaths)
+	verify.Equals(t, 2, unitA.Statistic.SuccessCount)
+	verify.Equals(t, 2, unitB.Statistic.SuccessCount)
+}
+
+func TestPerformRequest_withMethod(t *testing.T) {
+	// arrange
+	var receivedMethod string
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		receivedMethod = r.Method
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer mockServer.Close()
+	unit := Client{Request: Request{URL: mockServer.URL, Method: http.MethodDelete}}
+	// action
+	unit.PerformRequest()
+	// verify
+	verify.Equals(t, http.MethodDelete, receivedMethod)
+	verify.Equals(t, 1, unit.Statistic.SuccessCount)
+}
+
+func TestRunForAmount_withRateLimit(t *testing.T) {
+	// arrange
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer mockServer.Close()
+	unit := Client{Request: Request{URL: mockServer.URL}, RateLimit: 20}
+	startTime := time.Now()
+	// action
+	unit.RunForAmount(5)
+	// verify
+	verify.Equals(t, 5, unit.Statistic.SuccessCount)
+	verify.Assert(t, time.Since(startTime) >= 200*time.Millisecond, "Rate limit not applied: %s", time.Since(startTime))
+}
+
+func TestNewHTTPRequest(t *testing.T) {
+	// arrange
+	unit := Request{
+		URL:               "http://localhost/path",
+		PostBody:          []byte("test body"),
+		ContentType:       "text/plain",
+		AdditionalHeaders: map[string]string{"key1": "value1"},
+	}
+	// action
+	result, err := unit.NewHTTPRequest(context.Background())
+	// verify
+	verify.Ok(t, err)
+	verify.Equals(t, http.MethodPost, result.Method)
+	verify.Equals(t, "http://localhost/path", result.URL.String())
+	verify.Equals(t, "text/plain", result.Header.Get("Content-Type"))
+	verify.Equals(t, "close", result.Header.Get("Connection"))
+	verify.Equals(t, "value1", result.Header.Get("key1"))
+	body, err := io.ReadAll(result.Body)
+	verify.Ok(t, err)
+	verify.Equals(t, "test body", string(body))
+}
+
+func TestNewHTTPRequest_host(t *testing.T) {
+	// arrange
+	unit := Request{URL: "http://127.0.0.1/path", AdditionalHeaders: map[string]string{"host": "header.example.com"}}
+	// action
+	fromHeader, err1 := unit.NewHTTPRequest(context.Background())
+	unit.Host = "www.example.com"
+	fromHost, err2 := unit.NewHTTPRequest(context.Background())
+	// verify
+	verify.Ok(t, err1)
+	verify.Ok(t, err2)
+	verify.Equals(t, "header.example.com", fromHeader.Host)
+	verify.Equals(t, "", fromHeader.Header.Get("Host"))
+	verify.Equals(t, "www.example.com", fromHost.Host)
+	verify.Equals(t, "127.0.0.1", fromHost.URL.Host)
+}
+
+func TestPerformRequest_withDebug(t *testing.T) {
+	// arrange
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTeapot)
+		w.Write([]byte("test response"))
+	}))
+	defer mockServer.Close()
+	var debug strings.Builder
+	unit := Client{Request: Request{URL: mockServer.URL + "/path"}, DebugCount: 1, DebugWriter: &debug}
+	// action
+	unit.RunForAmount(2)
+	// verify
+	verify.Equals(t, 1, strings.Count(debug.String(), ">>> request"))
+	verify.Assert(t, strings.Contains(debug.String(), "GET /path HTTP/1.1"), "Request not dumped: %s", debug.String())
+	verify.Assert(t, strings.Contains(debug.String(), "HTTP/1.1 418 I'm a teapot"), "Response not dumped: %s", debug.String())
+	verify.Assert(t, strings.Contains(debug.String(), "test response"), "Body not dumped: %s", debug.String())
+	verify.Equals(t, int64(2*len("test response")), unit.Statistic.ReadThroughput)
+}
+
+func TestPerformRequest_putWithBody(t *testing.T) {
+	// arrange
+	var receivedMethod string
+	var receivedBody []byte
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		receivedMethod = r.Method
+		receivedBody, _ = io.ReadAll(r.Body)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer mockServer.Close()
+	unit := Client{Request: Request{URL: mockServer.URL, Method: http.MethodPut, PostBody: []byte("test body")}}
+	// action
+	unit.PerformRequest()
+	// verify
+	verify.Equals(t, http.MethodPut, receivedMethod)
+	verify.Equals(t, "test body", string(receivedBody))
+	verify.Equals(t, 1, unit.Statistic.SuccessCount)
+	verify.Equals(t, int64(len("test body")), unit.Statistic.WriteThroughput)
+}
+
+func TestPerformRequest_methods(t *testing.T) {
+	for _, tc := range []struct {
+		method   string
+		body     []byte
+		expected string
+	}{
+		{"", nil, http.MethodGet},
+		{"", []byte("test body"), http.MethodPost},
+		{http.MethodPatch, []byte("test body"), http.MethodPatch},
+		{http.MethodDelete, []byte("test body"), http.MethodDelete},
+		{http.MethodDelete, nil, http.MethodDelete},
+		{http.MethodHead, nil, http.MethodHead},
+		{http.MethodOptions, nil, http.MethodOptions},
+	} {
+		t.Run(tc.expected+"/"+string(tc.body), func(t *testing.T) {
+			// arrange
+			var receivedMethod string
+			var receivedBody []byte
+			mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				receivedMethod = r.Method
+				receivedBody, _ = io.ReadAll(r.Body)
+				w.Write([]byte("test response"))
+			}))
+			defer mockServer.Close()
+			unit := Client{Request: Request{URL: mockServer.URL, Method: tc.method, PostBody: tc.body}}
+			// action
+			unit.PerformRequest()
+			// verify
+			verify.Equals(t, tc.expected, receivedMethod)
+			verify.Equals(t, string(tc.body), string(receivedBody))
+			verify.Equals(t, 1, unit.Statistic.SuccessCount)
+		})
+	}
+}
+
+func TestPerformRequest_withBodyFile(t *testing.T) {
+	// arrange
+	path := filepath.Join(t.TempDir(), "body")
+	verify.Ok(t, os.WriteFile(path, []byte("streamed body"), 0o600))
+	var received []string
+	var receivedLength int64
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		received = append(received, r.Method+" "+string(body))
+		receivedLength = r.ContentLength
+	}))
+	defer mockServer.Close()
+	unit := Client{Request: Request{URL: mockServer.URL, BodyFile: path, ContentType: "text/plain"}}
+	// action
+	unit.RunForAmount(2)
+	verify.Ok(t, os.Remove(path))
+	unit.PerformRequest()
+	// verify
+	verify.Equals(t, []string{"POST streamed body", "POST streamed body"}, received)
+	verify.Equals(t, int64(len("streamed body")), receivedLength)
+	verify.Equals(t, 2, unit.Statistic.SuccessCount)
+	verify.Equals(t, 1, unit.Statistic.NetworkFailedCount)
+	verify.Equals(t, int64(2*len("streamed body")), unit.Statistic.WriteThroughput)
+}
+
+func TestRunForAmount_withNextRequest(t *testing.T) {
+	// arrange
+	var receivedPaths []string
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		receivedPaths = append(receivedPaths, r.Method+" "+r.URL.Path)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer mockServer.Close()
+	requests := []Request{
+		{URL: mockServer.URL + "/a"},
+		{URL: mockServer.URL + "/b", PostBody: []byte("test body")},
+	}
+	unit := Client{NextRequest: Sequential(requests, 1)}
+	// action
+	unit.RunForAmount(3)
+	// verify
+	verify.Equals(t, []string{"POST /b", "GET /a", "POST /b"}, receivedPaths)
+	verify.Equals(t, 3, unit.Statistic.SuccessCount)
+	verify.Equals(t, int64(2*len("test body")), unit.Statistic.WriteThroughput)
+}
+
+func TestShuffled(t *testing.T) {
+	// arrange
+	requests := []Request{{URL: "a"}, {URL: "b"}, {URL: "c"}}
+	unit := Shuffled(requests, rand.New(rand.NewSource(1)))
+	// action
+	var first, second []string
+	for i := 0; i < 3; i++ {
+		first = append(first, next(t, unit))
+	}
+	for i := 0; i < 3; i++ {
+		second = append(second, next(t, unit))
+	}
+	// verify
+	sort.Strings(first)
+	sort.Strings(second)
+	verify.Equals(t, []string{"a", "b", "c"}, first)
+	verify.Equals(t, []string{"a", "b", "c"}, second)
+	verify.Equals(t, "a", requests[0].URL)
+}
+
+func TestRandom(t *testing.T) {
+	// arrange
+	requests := []Request{{URL: "a"}, {URL: "b"}}
+	unit := Random(requests, rand.New(rand.NewSource(1)))
+	// action
+	counts := make(map[string]int)
+	for i := 0; i < 100; i++ {
+		counts[next(t, unit)]++
+	}
+	// verify
+	verify.Equals(t, 100, counts["a"]+counts["b"])
+	verify.Assert(t, counts["a"] > 0 && counts["b"] > 0, "Requests not sampled: %v", counts)
+}
+
+func TestRunForAmount_withFromChannel(t *testing.T) {
+	// arrange
+	receivedCount := 0
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		receivedCount++
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer mockServer.Close()
+	requests := make(chan Request, 3)
+	for i := 0; i < 3; i++ {
+		requests <- Request{URL: mockServer.URL}
+	}
+	close(requests)
+	unit := Client{NextRequest: FromChannel(requests)}
+	// action
+	unit.RunForAmount(10)
+	// verify
+	verify.Equals(t, 3, receivedCount)
+	verify.Equals(t, 3, unit.Statistic.RequestCount)
+}
+
+func TestRunForDuration_withBlockingFromChannel(t *testing.T) {
+	// arrange
+	requests := make(chan Request)
+	unit := Client{NextRequest: FromChannel(requests)}
+	startTime := time.Now()
+	// action
+	unit.RunForDuration(100 * time.Millisecond)
+	// verify
+	verify.Assert(t, time.Since(startTime) < time.Second, "Client blocked after duration")
+	verify.Equals(t, 0, unit.Statistic.RequestCount)
+}
+
+func next(t *testing.T, nextRequest func(ctx context.Context) (Request, bool)) string {
+	t.Helper()
+	request, ok := nextRequest(context.Background())
+	verify.Assert(t, ok, "No request returned")
+	return request.URL
+}
+
+func TestPerformRequest_statusCodes(t *testing.T) {
+	// arrange
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/missing":
+			w.WriteHeader(http.StatusNotFound)
+		case "/unavailable":
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer mockServer.Close()
+	unit := NewClient(Request{})
+	unit.NextRequest = Sequential([]Request{{URL: mockServer.URL}, {URL: mockServer.URL + "/missing"}, {URL: mockServer.URL + "/unavailable"}}, 0)
+	// action
+	unit.RunForAmount(5)
+	// verify
+	verify.Equals(t, map[int]int{200: 2, 404: 2, 503: 1}, unit.Statistic.StatusCodes)
+	verify.Equals(t, 3, unit.Statistic.FailureCount)
+}
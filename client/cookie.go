@@ -0,0 +1,41 @@
+// SPDX-FileCopyrightText: 2021 Eric Neidhardt
+// SPDX-License-Identifier: MIT
+package client
+
+import (
+	"net/http"
+	"net/http/cookiejar"
+	"net/url"
+)
+
+// NewCookieJar creates a cookie jar, which honors Set-Cookie headers for subsequent requests
+// of a client and initially contains the given cookies. Cookies with a domain are set for this domain,
+// cookies without a domain for each of the given URLs.
+func NewCookieJar(cookies []*http.Cookie, urls []string) (http.CookieJar, error) {
+	jar, err := cookiejar.New(nil)
+	if err != nil {
+		return nil, err
+	}
+	var hostCookies []*http.Cookie
+	for _, cookie := range cookies {
+		if cookie.Domain == "" {
+			hostCookies = append(hostCookies, cookie)
+			continue
+		}
+		u := &url.URL{Scheme: "http", Host: cookie.Domain, Path: cookie.Path}
+		if cookie.Secure {
+			u.Scheme = "https"
+		}
+		jar.SetCookies(u, []*http.Cookie{cookie})
+	}
+	if len(hostCookies) > 0 {
+		for _, rawURL := range urls {
+			u, err := url.Parse(rawURL)
+			if err != nil {
+				return nil, err
+			}
+			jar.SetCookies(u, hostCookies)
+		}
+	}
+	return jar, nil
+}
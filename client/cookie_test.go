@@ -0,0 +1,48 @@
+// SPDX-FileCopyrightText: 2021 Eric Neidhardt
+// SPDX-License-Identifier: MIT
+package client
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/EricNeid/go-bench/internal/verify"
+)
+
+func TestNewCookieJar(t *testing.T) {
+	// arrange
+	cookies := []*http.Cookie{
+		{Name: "session", Value: "abc"},
+		{Name: "tracking", Value: "def", Domain: "example.com", Path: "/"},
+	}
+	// action
+	jar, err := NewCookieJar(cookies, []string{"http://localhost:8080/api"})
+	// verify
+	verify.Ok(t, err)
+	local, _ := url.Parse("http://localhost:8080/api/users")
+	verify.Equals(t, 1, len(jar.Cookies(local)))
+	verify.Equals(t, "abc", jar.Cookies(local)[0].Value)
+	example, _ := url.Parse("http://www.example.com/")
+	verify.Equals(t, 1, len(jar.Cookies(example)))
+	verify.Equals(t, "def", jar.Cookies(example)[0].Value)
+}
+
+func TestPerformRequest_withCookieJar(t *testing.T) {
+	// arrange
+	var receivedCookies []string
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		receivedCookies = append(receivedCookies, r.Header.Get("Cookie"))
+		http.SetCookie(w, &http.Cookie{Name: "session", Value: "server"})
+	}))
+	defer mockServer.Close()
+	unit := NewClient(Request{URL: mockServer.URL})
+	jar, err := NewCookieJar([]*http.Cookie{{Name: "seed", Value: "1"}}, []string{mockServer.URL})
+	verify.Ok(t, err)
+	unit.HTTPClient.Jar = jar
+	// action
+	unit.RunForAmount(2)
+	// verify
+	verify.Equals(t, []string{"seed=1", "seed=1; session=server"}, receivedCookies)
+}
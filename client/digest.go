@@ -0,0 +1,219 @@
+// SPDX-FileCopyrightText: 2021 Eric Neidhardt
+// SPDX-License-Identifier: MIT
+package client
+
+import (
+	"crypto/md5" //nolint:gosec // required by digest authentication
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// Digest authenticates requests with HTTP digest authentication (RFC 7616).
+// The challenge of the server is reused for subsequent requests until the server rejects its nonce as stale,
+// so only the first request needs an additional round trip. Each client needs a digest of its own,
+// but it is safe for concurrent use.
+type Digest struct {
+	User     string
+	Password string
+
+	mu        sync.Mutex
+	challenge *digestChallenge
+	count     int
+}
+
+// digestChallenge is the parsed WWW-Authenticate header of a digest challenge.
+type digestChallenge struct {
+	realm     string
+	nonce     string
+	opaque    string
+	algorithm string
+	qop       string
+}
+
+// Transport returns a round tripper, which authenticates every request sent by base.
+// If base is nil, http.DefaultTransport is used.
+func (d *Digest) Transport(base http.RoundTripper) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &digestTransport{digest: d, base: base}
+}
+
+type digestTransport struct {
+	digest *Digest
+	base   http.RoundTripper
+}
+
+func (t *digestTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	// a round tripper must not modify the given request
+	authorized := req.Clone(req.Context())
+	if authorization, ok := t.digest.authorization(req.Method, req.URL.RequestURI()); ok {
+		authorized.Header.Set("Authorization", authorization)
+	}
+	resp, err := t.base.RoundTrip(authorized)
+	if err != nil || resp.StatusCode != http.StatusUnauthorized {
+		return resp, err
+	}
+
+	challenge := parseDigestChallenge(resp.Header.Values("WWW-Authenticate"))
+	if challenge == nil || (req.Body != nil && req.Body != http.NoBody && req.GetBody == nil) {
+		return resp, nil
+	}
+	_, _ = io.Copy(io.Discard, resp.Body)
+	resp.Body.Close()
+
+	t.digest.mu.Lock()
+	t.digest.challenge = challenge
+	t.digest.count = 0
+	t.digest.mu.Unlock()
+
+	retry := req.Clone(req.Context())
+	if req.GetBody != nil {
+		if retry.Body, err = req.GetBody(); err != nil {
+			return nil, err
+		}
+	}
+	authorization, _ := t.digest.authorization(req.Method, req.URL.RequestURI())
+	retry.Header.Set("Authorization", authorization)
+	return t.base.RoundTrip(retry)
+}
+
+// authorization returns the Authorization header for the current challenge, false if there is none.
+func (d *Digest) authorization(method, uri string) (string, bool) {
+	d.mu.Lock()
+	c := d.challenge
+	d.count++
+	count := d.count
+	d.mu.Unlock()
+	if c == nil {
+		return "", false
+	}
+
+	var h func() hash.Hash
+	switch strings.ToUpper(strings.TrimSuffix(strings.ToLower(c.algorithm), "-sess")) {
+	case "SHA-256":
+		h = sha256.New
+	default:
+		h = md5.New
+	}
+	digest := func(values ...string) string {
+		hash := h()
+		io.WriteString(hash, strings.Join(values, ":")) //nolint:errcheck // writing to a hash never fails
+		return hex.EncodeToString(hash.Sum(nil))
+	}
+
+	cnonce := newCnonce()
+	nc := fmt.Sprintf("%08x", count)
+	ha1 := digest(d.User, c.realm, d.Password)
+	if strings.HasSuffix(strings.ToLower(c.algorithm), "-sess") {
+		ha1 = digest(ha1, c.nonce, cnonce)
+	}
+	ha2 := digest(method, uri)
+
+	var b strings.Builder
+	fmt.Fprintf(&b, `Digest username="%s", realm="%s", nonce="%s", uri="%s"`, d.User, c.realm, c.nonce, uri)
+	if c.qop != "" {
+		response := digest(ha1, c.nonce, nc, cnonce, c.qop, ha2)
+		fmt.Fprintf(&b, `, qop=%s, nc=%s, cnonce="%s", response="%s"`, c.qop, nc, cnonce, response)
+	} else {
+		fmt.Fprintf(&b, `, response="%s"`, digest(ha1, c.nonce, ha2))
+	}
+	if c.algorithm != "" {
+		fmt.Fprintf(&b, ", algorithm=%s", c.algorithm)
+	}
+	if c.opaque != "" {
+		fmt.Fprintf(&b, `, opaque="%s"`, c.opaque)
+	}
+	return b.String(), true
+}
+
+// parseDigestChallenge returns the first supported digest challenge of the WWW-Authenticate headers or nil.
+func parseDigestChallenge(headers []string) *digestChallenge {
+	for _, header := range headers {
+		if !strings.HasPrefix(strings.ToLower(header), "digest ") {
+			continue
+		}
+		params := parseAuthParams(header[len("digest "):])
+		c := &digestChallenge{
+			realm:     params["realm"],
+			nonce:     params["nonce"],
+			opaque:    params["opaque"],
+			algorithm: params["algorithm"],
+		}
+		switch strings.ToUpper(c.algorithm) {
+		case "", "MD5", "MD5-SESS", "SHA-256", "SHA-256-SESS":
+		default:
+			continue
+		}
+		if qop, ok := params["qop"]; ok {
+			for _, option := range strings.Split(qop, ",") {
+				if strings.TrimSpace(option) == "auth" {
+					c.qop = "auth"
+				}
+			}
+			if c.qop == "" {
+				// only integrity protection is offered, which is not supported
+				continue
+			}
+		}
+		if c.nonce != "" {
+			return c
+		}
+	}
+	return nil
+}
+
+// parseAuthParams parses comma separated key=value pairs, whose values may be quoted.
+func parseAuthParams(value string) map[string]string {
+	params := make(map[string]string)
+	for value != "" {
+		value = strings.TrimLeft(value, " ,")
+		eq := strings.Index(value, "=")
+		if eq < 0 {
+			break
+		}
+		key := strings.ToLower(strings.TrimSpace(value[:eq]))
+		value = strings.TrimLeft(value[eq+1:], " ")
+		var v string
+		if strings.HasPrefix(value, `"`) {
+			var b strings.Builder
+			i := 1
+			for ; i < len(value) && value[i] != '"'; i++ {
+				if value[i] == '\\' && i+1 < len(value) {
+					i++
+				}
+				b.WriteByte(value[i])
+			}
+			v = b.String()
+			if i < len(value) {
+				i++
+			}
+			value = value[i:]
+		} else {
+			end := strings.Index(value, ",")
+			if end < 0 {
+				end = len(value)
+			}
+			v = strings.TrimSpace(value[:end])
+			value = value[end:]
+		}
+		params[key] = v
+	}
+	return params
+}
+
+// newCnonce returns a random client nonce.
+func newCnonce() string {
+	var b [12]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		panic(err)
+	}
+	return hex.EncodeToString(b[:])
+}
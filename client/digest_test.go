@@ -0,0 +1,99 @@
+// SPDX-FileCopyrightText: 2021 Eric Neidhardt
+// SPDX-License-Identifier: MIT
+package client
+
+import (
+	"crypto/md5" //nolint:gosec // required by digest authentication
+	"encoding/hex"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/EricNeid/go-bench/internal/verify"
+)
+
+func md5Hex(value string) string {
+	hash := md5.Sum([]byte(value)) //nolint:gosec // required by digest authentication
+	return hex.EncodeToString(hash[:])
+}
+
+func TestDigest(t *testing.T) {
+	// arrange
+	challenges := 0
+	var counts []string
+	var bodies []string
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		params := parseAuthParams(r.Header.Get("Authorization"))
+		if params["nonce"] != "abc" {
+			challenges++
+			w.Header().Set("WWW-Authenticate", `Digest realm="test, realm", qop="auth,auth-int", nonce="abc", opaque="xyz"`)
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		ha1 := md5Hex("max:test, realm:secret")
+		ha2 := md5Hex(r.Method + ":" + params["uri"])
+		expected := md5Hex(ha1 + ":abc:" + params["nc"] + ":" + params["cnonce"] + ":auth:" + ha2)
+		if params["response"] != expected || params["opaque"] != "xyz" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		counts = append(counts, params["nc"])
+		bodies = append(bodies, string(body))
+	}))
+	defer mockServer.Close()
+	digest := &Digest{User: "max", Password: "secret"}
+	unit := NewClient(Request{URL: mockServer.URL + "/users?page=1", PostBody: []byte("body"), KeepAlive: true})
+	unit.HTTPClient.Transport = digest.Transport(nil)
+	// action
+	unit.RunForAmount(3)
+	// verify
+	verify.Equals(t, 3, unit.Statistic.SuccessCount)
+	verify.Equals(t, 1, challenges)
+	verify.Equals(t, []string{"00000001", "00000002", "00000003"}, counts)
+	verify.Equals(t, []string{"body", "body", "body"}, bodies)
+}
+
+func TestDigest_withoutQop(t *testing.T) {
+	// arrange
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		params := parseAuthParams(r.Header.Get("Authorization"))
+		expected := md5Hex(md5Hex("max:test:secret") + ":abc:" + md5Hex("GET:/"))
+		if params["response"] != expected {
+			w.Header().Set("WWW-Authenticate", `Digest realm="test", nonce="abc"`)
+			w.WriteHeader(http.StatusUnauthorized)
+		}
+	}))
+	defer mockServer.Close()
+	digest := &Digest{User: "max", Password: "secret"}
+	unit := NewClient(Request{URL: mockServer.URL + "/"})
+	unit.HTTPClient.Transport = digest.Transport(nil)
+	// action
+	unit.PerformRequest()
+	// verify
+	verify.Equals(t, 1, unit.Statistic.SuccessCount)
+}
+
+func TestDigest_wrongPassword(t *testing.T) {
+	// arrange
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("WWW-Authenticate", `Digest realm="test", qop="auth", nonce="abc", algorithm=SHA-256`)
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer mockServer.Close()
+	digest := &Digest{User: "max", Password: "wrong"}
+	unit := NewClient(Request{URL: mockServer.URL})
+	unit.HTTPClient.Transport = digest.Transport(nil)
+	// action
+	unit.PerformRequest()
+	// verify
+	verify.Equals(t, 1, unit.Statistic.FailureCount)
+}
+
+func TestParseAuthParams(t *testing.T) {
+	params := parseAuthParams(`realm="a \"b\", c", qop=auth, nonce="n"`)
+	verify.Equals(t, map[string]string{"realm": `a "b", c`, "qop": "auth", "nonce": "n"}, params)
+	verify.Assert(t, parseDigestChallenge([]string{`Basic realm="x"`}) == nil, "Basic challenge parsed as digest")
+	verify.Assert(t, parseDigestChallenge([]string{`Digest nonce="n", qop="auth-int"`}) == nil, "auth-int only challenge parsed")
+}
@@ -0,0 +1,113 @@
+// SPDX-FileCopyrightText: 2021 Eric Neidhardt
+// SPDX-License-Identifier: MIT
+package client
+
+import (
+	"context"
+	"net"
+	"net/http/httptrace"
+	"sync"
+)
+
+// DNSCache resolves each host once and dials the cached addresses for the rest of the run,
+// so that repeated lookups do not dominate benchmarks of short requests. Failed lookups are not cached.
+// The lookups are reported to the trace of the request, see PhaseDNS. It is safe for concurrent use.
+type DNSCache struct {
+	// Resolver used for the lookups, net.DefaultResolver if nil.
+	Resolver *net.Resolver
+
+	mu      sync.Mutex
+	entries map[string]*dnsEntry
+}
+
+// dnsEntry is a cached lookup, which is done once the lookup finished.
+type dnsEntry struct {
+	done  chan struct{}
+	addrs []string
+	err   error
+}
+
+// DialContext returns a function for http.Transport.DialContext, which dials the cached addresses of the host
+// with dial. If dial is nil, a net.Dialer is used.
+func (c *DNSCache) DialContext(
+	dial func(ctx context.Context, network, address string) (net.Conn, error),
+) func(ctx context.Context, network, address string) (net.Conn, error) {
+	if dial == nil {
+		dial = (&net.Dialer{}).DialContext
+	}
+	return func(ctx context.Context, network, address string) (net.Conn, error) {
+		host, port, err := net.SplitHostPort(address)
+		if err != nil || net.ParseIP(host) != nil {
+			return dial(ctx, network, address)
+		}
+		addrs, err := c.lookup(ctx, host)
+		if err != nil {
+			return nil, err
+		}
+		for _, addr := range addrs {
+			var conn net.Conn
+			if conn, err = dial(ctx, network, net.JoinHostPort(addr, port)); err == nil {
+				return conn, nil
+			}
+		}
+		return nil, err
+	}
+}
+
+// lookup returns the cached addresses of the host, looking them up if they are not cached yet.
+func (c *DNSCache) lookup(ctx context.Context, host string) ([]string, error) {
+	c.mu.Lock()
+	if c.entries == nil {
+		c.entries = make(map[string]*dnsEntry)
+	}
+	entry, ok := c.entries[host]
+	if !ok {
+		entry = &dnsEntry{done: make(chan struct{})}
+		c.entries[host] = entry
+	}
+	c.mu.Unlock()
+	if ok {
+		select {
+		case <-entry.done:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+		return entry.addrs, entry.err
+	}
+
+	trace := httptrace.ContextClientTrace(ctx)
+	if trace != nil && trace.DNSStart != nil {
+		trace.DNSStart(httptrace.DNSStartInfo{Host: host})
+	}
+	resolver := c.Resolver
+	if resolver == nil {
+		resolver = net.DefaultResolver
+	}
+	entry.addrs, entry.err = resolver.LookupHost(ctx, host)
+	if trace != nil && trace.DNSDone != nil {
+		trace.DNSDone(httptrace.DNSDoneInfo{Err: entry.err})
+	}
+	if entry.err != nil {
+		// the lookup is retried by the next request
+		c.mu.Lock()
+		delete(c.entries, host)
+		c.mu.Unlock()
+	}
+	close(entry.done)
+	return entry.addrs, entry.err
+}
+
+// NewResolver returns a resolver, which sends its queries to the given DNS server instead of the ones
+// configured by the system. The port of the server defaults to 53.
+func NewResolver(server string) *net.Resolver {
+	if _, _, err := net.SplitHostPort(server); err != nil {
+		server = net.JoinHostPort(server, "53")
+	}
+	return &net.Resolver{
+		PreferGo: true,
+		Dial: func(ctx context.Context, network, _ string) (net.Conn, error) {
+			var dialer net.Dialer
+			return dialer.DialContext(ctx, network, server)
+		},
+	}
+}
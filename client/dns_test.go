@@ -0,0 +1,90 @@
+// SPDX-FileCopyrightText: 2021 Eric Neidhardt
+// SPDX-License-Identifier: MIT
+package client
+
+import (
+	"context"
+	"encoding/binary"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/EricNeid/go-bench/internal/verify"
+)
+
+func TestDNSCache(t *testing.T) {
+	// arrange
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer mockServer.Close()
+	cache := &DNSCache{}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.DialContext = cache.DialContext(nil)
+	unit := NewClient(Request{URL: strings.Replace(mockServer.URL, "127.0.0.1", "localhost", 1)})
+	unit.HTTPClient.Transport = transport
+	// action
+	unit.RunForAmount(3)
+	// verify
+	verify.Equals(t, 3, unit.Statistic.SuccessCount)
+	verify.Equals(t, 3, unit.Statistic.NewConnections)
+	verify.Equals(t, 1, unit.Statistic.Phases[PhaseDNS].Count)
+}
+
+func TestDNSCache_failed(t *testing.T) {
+	// arrange
+	cache := &DNSCache{}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.DialContext = cache.DialContext(nil)
+	unit := NewClient(Request{URL: "http://gobench.invalid"})
+	unit.HTTPClient.Transport = transport
+	// action
+	unit.RunForAmount(2)
+	// verify
+	verify.Equals(t, map[string]int{FailureDNS: 2}, unit.Statistic.NetworkFailures)
+	verify.Equals(t, 0, len(cache.entries))
+}
+
+func TestNewResolver(t *testing.T) {
+	// arrange
+	server, err := net.ListenPacket("udp", "127.0.0.1:0")
+	verify.Ok(t, err)
+	defer server.Close()
+	go serveDNS(server, net.IPv4(10, 0, 0, 5))
+	unit := NewResolver(server.LocalAddr().String())
+	// action
+	addrs, err := unit.LookupHost(context.Background(), "api.gobench.test")
+	// verify
+	verify.Ok(t, err)
+	verify.Equals(t, []string{"10.0.0.5"}, addrs)
+}
+
+// serveDNS answers A queries with ip and other queries without answers, until conn is closed.
+func serveDNS(conn net.PacketConn, ip net.IP) {
+	buf := make([]byte, 512)
+	for {
+		n, addr, err := conn.ReadFrom(buf)
+		if err != nil {
+			return
+		}
+		// header of 12 bytes, followed by the question: labels, type and class
+		end := 12
+		for end < n && buf[end] != 0 {
+			end += int(buf[end]) + 1
+		}
+		end += 5
+		if end > n {
+			continue
+		}
+		resp := append([]byte{}, buf[:end]...)
+		resp[2], resp[3] = 0x81, 0x80
+		resp[6], resp[7], resp[8], resp[9], resp[10], resp[11] = 0, 0, 0, 0, 0, 0
+		if binary.BigEndian.Uint16(buf[end-4:]) == 1 {
+			resp[7] = 1
+			// name as pointer to the question, type A, class IN, ttl 60 and the address
+			resp = append(resp, 0xc0, 12, 0, 1, 0, 1, 0, 0, 0, 60, 0, 4)
+			resp = append(resp, ip.To4()...)
+		}
+		_, _ = conn.WriteTo(resp, addr)
+	}
+}
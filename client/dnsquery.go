@@ -0,0 +1,317 @@
+// SPDX-FileCopyrightText: 2021 Eric Neidhardt
+// SPDX-License-Identifier: MIT
+package client
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+// DNSTarget is a query sent to a DNS server, given by an URL like dns://8.8.8.8/example.com?type=AAAA.
+// The scheme dns queries over UDP, dns+tcp over TCP and dns+tls over TLS (DoT), the port defaults to 53 or 853.
+// The type defaults to A.
+type DNSTarget struct {
+	// Network of the server: udp, tcp or tls.
+	Network string
+	// Address of the server, e.g. 8.8.8.8:53.
+	Address string
+	// Name to query, e.g. example.com.
+	Name string
+	// Type to query.
+	Type dnsmessage.Type
+}
+
+// dnsTypes are the names of the supported query types.
+var dnsTypes = map[string]dnsmessage.Type{
+	"A": dnsmessage.TypeA, "AAAA": dnsmessage.TypeAAAA, "CNAME": dnsmessage.TypeCNAME, "MX": dnsmessage.TypeMX,
+	"NS": dnsmessage.TypeNS, "PTR": dnsmessage.TypePTR, "SOA": dnsmessage.TypeSOA, "SRV": dnsmessage.TypeSRV,
+	"TXT": dnsmessage.TypeTXT, "CAA": 257, "HTTPS": 65, "ANY": dnsmessage.TypeALL,
+}
+
+// ParseDNSTarget parses the URL of a query, see DNSTarget. Types are given by name or as number, e.g. TYPE65.
+func ParseDNSTarget(rawURL string) (DNSTarget, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return DNSTarget{}, err
+	}
+	var target DNSTarget
+	port := "53"
+	switch u.Scheme {
+	case "dns":
+		target.Network = "udp"
+	case "dns+tcp":
+		target.Network = "tcp"
+	case "dns+tls":
+		target.Network, port = "tls", "853"
+	default:
+		return DNSTarget{}, fmt.Errorf("unsupported dns scheme %s", u.Scheme)
+	}
+	if u.Port() != "" {
+		port = u.Port()
+	}
+	target.Address = net.JoinHostPort(u.Hostname(), port)
+	target.Name = strings.Trim(u.Path, "/")
+	if target.Name == "" {
+		return DNSTarget{}, fmt.Errorf("%s: name to query is missing", rawURL)
+	}
+	typeName := strings.ToUpper(u.Query().Get("type"))
+	switch n, err := strconv.ParseUint(strings.TrimPrefix(typeName, "TYPE"), 10, 16); {
+	case typeName == "":
+		target.Type = dnsmessage.TypeA
+	case dnsTypes[typeName] != 0:
+		target.Type = dnsTypes[typeName]
+	case strings.HasPrefix(typeName, "TYPE") && err == nil:
+		target.Type = dnsmessage.Type(n)
+	default:
+		return DNSTarget{}, fmt.Errorf("unsupported dns type %s", typeName)
+	}
+	if _, err := target.query(); err != nil {
+		return DNSTarget{}, err
+	}
+	return target, nil
+}
+
+// query returns the message of the query with id 0, recursion is desired.
+func (t DNSTarget) query() ([]byte, error) {
+	name, err := dnsmessage.NewName(strings.TrimSuffix(t.Name, ".") + ".")
+	if err != nil {
+		return nil, fmt.Errorf("invalid dns name %s: %w", t.Name, err)
+	}
+	builder := dnsmessage.NewBuilder(nil, dnsmessage.Header{RecursionDesired: true})
+	if err := builder.StartQuestions(); err != nil {
+		return nil, err
+	}
+	if err := builder.Question(dnsmessage.Question{Name: name, Type: t.Type, Class: dnsmessage.ClassINET}); err != nil {
+		return nil, err
+	}
+	return builder.Finish()
+}
+
+// DNSQuery benchmarks a DNS server with queries over a connection, which is opened by the first operation and
+// established again after a failure. Each operation sends the query of Target and counts the round trip until
+// the response as PhaseRoundTrip, the response codes are counted as Statistic.ResponseCodes. Responses with
+// NOERROR or NXDOMAIN are successful, all other codes, e.g. SERVFAIL or REFUSED, are counted as failed status.
+type DNSQuery struct {
+	Target DNSTarget
+	// TLSConfig for tls, its ServerName defaults to the host of the address.
+	TLSConfig *tls.Config
+	// Dial, if set, establishes the connections instead of a net.Dialer.
+	Dial func(ctx context.Context, network, addr string) (net.Conn, error)
+	// Timeout of each query, including establishing the connection, 0 for no timeout.
+	Timeout time.Duration
+	// RateLimit is the maximum number of queries per second, unlimited if <= 0.
+	RateLimit float64
+
+	Recorder
+
+	conn    net.Conn
+	query   []byte
+	id      uint16
+	buf     []byte
+	limiter rateLimiter
+}
+
+// Perform sends the query and receives the response, after opening the connection if necessary.
+func (d *DNSQuery) Perform(ctx context.Context) (Result, bool) {
+	d.limiter.wait(ctx, d.RateLimit)
+	if ctx.Err() != nil {
+		return Result{}, false
+	}
+	runCtx := ctx
+	if d.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, d.Timeout)
+		defer cancel()
+	}
+	result := Result{Request: Request{URL: d.Target.Address}, Start: time.Now(), Phases: make(map[string]time.Duration)}
+	fresh := d.conn == nil
+	rcode, err := d.exchange(ctx, &result)
+	result.Latency = time.Since(result.Start)
+	switch {
+	case err == nil && (rcode == dnsmessage.RCodeSuccess || rcode == dnsmessage.RCodeNameError):
+		result.Class = ClassSuccess
+	case err == nil:
+		result.Class, result.Err = ClassStatus, fmt.Errorf("unexpected response code %s", rcodeName(rcode))
+	case RunDone(runCtx):
+		d.Close()
+		result.Class, result.Err = ClassInterrupted, err
+	default:
+		d.Close()
+		if ctx.Err() != nil {
+			// the query was interrupted by the deadline of the connection
+			err = ctx.Err()
+		}
+		result.Class, result.Err = ClassifyError(err), err
+	}
+	if err == nil {
+		d.Update(func(s *Statistic) {
+			if s.ResponseCodes == nil {
+				s.ResponseCodes = make(map[string]int)
+			}
+			s.ResponseCodes[rcodeName(rcode)]++
+			switch {
+			case d.Target.Network == "udp":
+			case fresh:
+				s.NewConnections++
+			default:
+				s.ReusedConnections++
+			}
+		})
+	}
+	d.Record(result)
+	return result, true
+}
+
+// exchange sends the query and returns the response code of the matching response.
+func (d *DNSQuery) exchange(ctx context.Context, result *Result) (dnsmessage.RCode, error) {
+	if d.query == nil {
+		query, err := d.Target.query()
+		if err != nil {
+			return 0, err
+		}
+		if d.Target.Network != "udp" {
+			// prefixed by its length over TCP and TLS
+			query = append(binary.BigEndian.AppendUint16(nil, uint16(len(query))), query...)
+		}
+		d.query = query
+	}
+	if d.conn == nil {
+		if err := d.connect(ctx, result.Phases); err != nil {
+			return 0, err
+		}
+	}
+	// interrupt the query, when the context is done
+	stop := context.AfterFunc(ctx, func() {
+		d.conn.SetDeadline(time.Now())
+	})
+	defer stop()
+	d.id++
+	offset := 0
+	if d.Target.Network != "udp" {
+		offset = 2
+	}
+	binary.BigEndian.PutUint16(d.query[offset:], d.id)
+	start := time.Now()
+	if _, err := d.conn.Write(d.query); err != nil {
+		return 0, err
+	}
+	result.BytesWritten = int64(len(d.query))
+	for {
+		response, err := d.read()
+		if err != nil {
+			return 0, err
+		}
+		result.BytesRead += int64(len(response))
+		var parser dnsmessage.Parser
+		header, err := parser.Start(response)
+		if err != nil {
+			return 0, fmt.Errorf("invalid dns response: %w", err)
+		}
+		if header.ID == d.id && header.Response {
+			result.Phases[PhaseRoundTrip] = time.Since(start)
+			return header.RCode, nil
+		}
+		// responses to earlier queries, which timed out, are skipped
+	}
+}
+
+// read receives a response, over TCP and TLS prefixed by its length.
+func (d *DNSQuery) read() ([]byte, error) {
+	if d.Target.Network == "udp" {
+		n, err := d.conn.Read(d.buf)
+		return d.buf[:n], err
+	}
+	if _, err := io.ReadFull(d.conn, d.buf[:2]); err != nil {
+		return nil, err
+	}
+	n := int(binary.BigEndian.Uint16(d.buf))
+	_, err := io.ReadFull(d.conn, d.buf[:n])
+	return d.buf[:n], err
+}
+
+// connect opens the connection and adds the durations of its phases.
+func (d *DNSQuery) connect(ctx context.Context, phases map[string]time.Duration) error {
+	dial := d.Dial
+	if dial == nil {
+		dial = (&net.Dialer{}).DialContext
+	}
+	network := d.Target.Network
+	if network == "tls" {
+		network = "tcp"
+	}
+	start := time.Now()
+	conn, err := dial(ctx, network, d.Target.Address)
+	if err != nil {
+		return err
+	}
+	if network == "tcp" {
+		phases[PhaseConnect] = time.Since(start)
+	}
+	if d.Target.Network == "tls" {
+		config := d.TLSConfig.Clone()
+		if config == nil {
+			config = &tls.Config{}
+		}
+		if config.ServerName == "" {
+			config.ServerName, _, _ = net.SplitHostPort(d.Target.Address)
+		}
+		tlsConn := tls.Client(conn, config)
+		start = time.Now()
+		if err := tlsConn.HandshakeContext(ctx); err != nil {
+			conn.Close()
+			return err
+		}
+		if tlsConn.ConnectionState().DidResume {
+			phases[PhaseTLSResumed] = time.Since(start)
+		} else {
+			phases[PhaseTLS] = time.Since(start)
+		}
+		conn = tlsConn
+	}
+	d.conn = conn
+	if d.buf == nil {
+		// large enough for any message
+		d.buf = make([]byte, 64*1024)
+	}
+	return nil
+}
+
+// Close closes the connection, if it is open. The next operation opens a new connection.
+func (d *DNSQuery) Close() error {
+	if d.conn == nil {
+		return nil
+	}
+	err := d.conn.Close()
+	d.conn = nil
+	return err
+}
+
+// rcodeName returns the name of a response code, e.g. NXDOMAIN.
+func rcodeName(rcode dnsmessage.RCode) string {
+	switch rcode {
+	case dnsmessage.RCodeSuccess:
+		return "NOERROR"
+	case dnsmessage.RCodeFormatError:
+		return "FORMERR"
+	case dnsmessage.RCodeServerFailure:
+		return "SERVFAIL"
+	case dnsmessage.RCodeNameError:
+		return "NXDOMAIN"
+	case dnsmessage.RCodeNotImplemented:
+		return "NOTIMP"
+	case dnsmessage.RCodeRefused:
+		return "REFUSED"
+	default:
+		return "RCODE" + strconv.Itoa(int(rcode))
+	}
+}
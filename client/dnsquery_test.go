@@ -0,0 +1,131 @@
+// SPDX-FileCopyrightText: 2021 Eric Neidhardt
+// SPDX-License-Identifier: MIT
+package client
+
+import (
+	"context"
+	"encoding/binary"
+	"io"
+	"net"
+	"testing"
+
+	"github.com/EricNeid/go-bench/internal/verify"
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+// dnsResponse returns the response to the query: NXDOMAIN for missing.test, SERVFAIL for fail.test
+// and NOERROR otherwise.
+func dnsResponse(query []byte) []byte {
+	var parser dnsmessage.Parser
+	header, err := parser.Start(query)
+	if err != nil {
+		return nil
+	}
+	question, err := parser.Question()
+	if err != nil {
+		return nil
+	}
+	header.Response = true
+	switch question.Name.String() {
+	case "missing.test.":
+		header.RCode = dnsmessage.RCodeNameError
+	case "fail.test.":
+		header.RCode = dnsmessage.RCodeServerFailure
+	}
+	builder := dnsmessage.NewBuilder(nil, header)
+	builder.StartQuestions()
+	builder.Question(question)
+	response, _ := builder.Finish()
+	return response
+}
+
+// dnsServer serves queries over UDP and TCP at the same port, until it is closed.
+func dnsServer(t *testing.T) (string, func()) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	verify.Ok(t, err)
+	packets, err := net.ListenPacket("udp", listener.Addr().String())
+	verify.Ok(t, err)
+	go func() {
+		buf := make([]byte, 512)
+		for {
+			n, addr, err := packets.ReadFrom(buf)
+			if err != nil {
+				return
+			}
+			packets.WriteTo(dnsResponse(buf[:n]), addr)
+		}
+	}()
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				for {
+					var length uint16
+					if binary.Read(conn, binary.BigEndian, &length) != nil {
+						return
+					}
+					query := make([]byte, length)
+					if _, err := io.ReadFull(conn, query); err != nil {
+						return
+					}
+					response := dnsResponse(query)
+					conn.Write(append(binary.BigEndian.AppendUint16(nil, uint16(len(response))), response...))
+				}
+			}()
+		}
+	}()
+	return listener.Addr().String(), func() {
+		listener.Close()
+		packets.Close()
+	}
+}
+
+func TestParseDNSTarget(t *testing.T) {
+	// action
+	udp, udpErr := ParseDNSTarget("dns://8.8.8.8/example.com")
+	dot, dotErr := ParseDNSTarget("dns+tls://1.1.1.1/example.com?type=aaaa")
+	numbered, numberedErr := ParseDNSTarget("dns+tcp://[::1]:5353/example.com.?type=TYPE65")
+	_, missingName := ParseDNSTarget("dns://8.8.8.8")
+	_, unknownType := ParseDNSTarget("dns://8.8.8.8/example.com?type=XYZ")
+	// verify
+	verify.Ok(t, udpErr)
+	verify.Ok(t, dotErr)
+	verify.Ok(t, numberedErr)
+	verify.Equals(t, DNSTarget{Network: "udp", Address: "8.8.8.8:53", Name: "example.com", Type: dnsmessage.TypeA}, udp)
+	verify.Equals(t, DNSTarget{Network: "tls", Address: "1.1.1.1:853", Name: "example.com", Type: dnsmessage.TypeAAAA}, dot)
+	verify.Equals(t, DNSTarget{Network: "tcp", Address: "[::1]:5353", Name: "example.com.", Type: 65}, numbered)
+	verify.Assert(t, missingName != nil, "missing name not rejected")
+	verify.Assert(t, unknownType != nil, "unknown type not rejected")
+}
+
+func TestDNSQuery(t *testing.T) {
+	// arrange
+	address, closeServer := dnsServer(t)
+	defer closeServer()
+	var units []*DNSQuery
+	for _, network := range []string{"udp", "tcp"} {
+		for _, name := range []string{"example.test", "missing.test", "fail.test"} {
+			units = append(units, &DNSQuery{Target: DNSTarget{Network: network, Address: address, Name: name, Type: dnsmessage.TypeA}})
+		}
+	}
+	// action
+	for _, unit := range units {
+		RunForAmount(context.Background(), 2, unit)
+	}
+	// verify
+	var s Statistic
+	for _, unit := range units {
+		s.Merge(unit.Snapshot())
+	}
+	verify.Equals(t, 12, s.RequestCount)
+	verify.Equals(t, 8, s.SuccessCount)
+	verify.Equals(t, 4, s.FailureCount)
+	verify.Equals(t, map[string]int{"NOERROR": 4, "NXDOMAIN": 4, "SERVFAIL": 4}, s.ResponseCodes)
+	verify.Equals(t, 3, s.NewConnections)
+	verify.Equals(t, 3, s.ReusedConnections)
+	verify.Equals(t, 12, s.Phases[PhaseRoundTrip].Count)
+}
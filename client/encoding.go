@@ -0,0 +1,83 @@
+// SPDX-FileCopyrightText: 2021 Eric Neidhardt
+// SPDX-License-Identifier: MIT
+package client
+
+import (
+	"bufio"
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"io"
+	"strings"
+
+	"github.com/andybalholm/brotli"
+)
+
+// decodeBody decompresses a body with the given content encoding. Bodies of unsupported encodings,
+// e.g. zstd, are returned as they are, since they cannot be decompressed.
+func decodeBody(encoding string, body []byte) ([]byte, error) {
+	reader, err := newDecoder(encoding, bytes.NewReader(body))
+	if err != nil || reader == nil {
+		return body, err
+	}
+	defer reader.Close()
+	return io.ReadAll(reader)
+}
+
+// drainBody reads the body without keeping it and returns the number of bytes as received and after
+// decompression with the given content encoding, if decode is true.
+func drainBody(body io.Reader, encoding string, decode bool) (wire, read int64, err error) {
+	counter := &countingReader{reader: body}
+	if !decode {
+		read, err = io.Copy(io.Discard, counter)
+		return counter.count, read, err
+	}
+	reader, err := newDecoder(encoding, counter)
+	if err != nil {
+		return counter.count, 0, err
+	}
+	if reader == nil {
+		read, err = io.Copy(io.Discard, counter)
+		return counter.count, read, err
+	}
+	defer reader.Close()
+	read, err = io.Copy(io.Discard, reader)
+	if err == nil {
+		// the decoders may leave trailing data of the body unread
+		_, err = io.Copy(io.Discard, counter)
+	}
+	return counter.count, read, err
+}
+
+// newDecoder returns a reader, which decompresses the given content encoding,
+// or nil, if the encoding is not supported or no encoding at all.
+func newDecoder(encoding string, r io.Reader) (io.ReadCloser, error) {
+	switch strings.ToLower(strings.TrimSpace(encoding)) {
+	case "gzip", "x-gzip":
+		return gzip.NewReader(r)
+	case "deflate":
+		// deflate should be zlib wrapped, but some servers send raw deflate
+		buffered := bufio.NewReader(r)
+		if header, err := buffered.Peek(2); err == nil && (uint16(header[0])<<8|uint16(header[1]))%31 == 0 && header[0]&0x0f == 8 {
+			return zlib.NewReader(buffered)
+		}
+		return flate.NewReader(buffered), nil
+	case "br":
+		return io.NopCloser(brotli.NewReader(r)), nil
+	default:
+		return nil, nil
+	}
+}
+
+// countingReader counts the bytes read from reader.
+type countingReader struct {
+	reader io.Reader
+	count  int64
+}
+
+func (r *countingReader) Read(p []byte) (int, error) {
+	n, err := r.reader.Read(p)
+	r.count += int64(n)
+	return n, err
+}
@@ -0,0 +1,150 @@
+// SPDX-FileCopyrightText: 2021 Eric Neidhardt
+// SPDX-License-Identifier: MIT
+package client
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/andybalholm/brotli"
+
+	"github.com/EricNeid/go-bench/internal/verify"
+)
+
+func TestPerformRequest_withAcceptEncoding(t *testing.T) {
+	// arrange
+	plain := strings.Repeat("compressible ", 100)
+	var compressed bytes.Buffer
+	writer := gzip.NewWriter(&compressed)
+	_, _ = writer.Write([]byte(plain))
+	verify.Ok(t, writer.Close())
+	var receivedEncoding string
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		receivedEncoding = r.Header.Get("Accept-Encoding")
+		w.Header().Set("Content-Encoding", "gzip")
+		w.Write(compressed.Bytes())
+	}))
+	defer mockServer.Close()
+	var receivedBody string
+	unit := NewClient(Request{URL: mockServer.URL, AcceptEncoding: "gzip, br"})
+	unit.OnResponse = func(request Request, resp *http.Response, body []byte) {
+		receivedBody = string(body)
+	}
+	// action
+	unit.PerformRequest()
+	// verify
+	verify.Equals(t, "gzip, br", receivedEncoding)
+	verify.Equals(t, plain, receivedBody)
+	verify.Equals(t, int64(len(plain)), unit.Statistic.ReadThroughput)
+	verify.Equals(t, int64(compressed.Len()), unit.Statistic.WireReadThroughput)
+}
+
+func TestPerformRequest_withInvalidEncoding(t *testing.T) {
+	// arrange
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Encoding", "gzip")
+		w.Write([]byte("not compressed"))
+	}))
+	defer mockServer.Close()
+	unit := NewClient(Request{URL: mockServer.URL, AcceptEncoding: "gzip"})
+	// action
+	unit.PerformRequest()
+	// verify
+	verify.Equals(t, 1, unit.Statistic.IOFailedCount)
+	verify.Equals(t, int64(len("not compressed")), unit.Statistic.WireReadThroughput)
+}
+
+func TestDecodeBody(t *testing.T) {
+	// arrange
+	var compressed bytes.Buffer
+	writer := brotli.NewWriter(&compressed)
+	_, _ = writer.Write([]byte("brotli"))
+	verify.Ok(t, writer.Close())
+	// action
+	identity, err1 := decodeBody("", []byte("plain"))
+	decoded, err2 := decodeBody("br", compressed.Bytes())
+	unsupported, err3 := decodeBody("zstd", []byte("encoded"))
+	// verify
+	verify.Ok(t, err1)
+	verify.Ok(t, err2)
+	verify.Ok(t, err3)
+	verify.Equals(t, "plain", string(identity))
+	verify.Equals(t, "brotli", string(decoded))
+	verify.Equals(t, "encoded", string(unsupported))
+}
+
+func TestDrainBody_brotli(t *testing.T) {
+	// arrange
+	plain := strings.Repeat("compressible ", 100)
+	var compressed bytes.Buffer
+	writer := brotli.NewWriter(&compressed)
+	_, _ = writer.Write([]byte(plain))
+	verify.Ok(t, writer.Close())
+	// action
+	wire, read, err := drainBody(bytes.NewReader(compressed.Bytes()), "br", true)
+	// verify
+	verify.Ok(t, err)
+	verify.Equals(t, int64(compressed.Len()), wire)
+	verify.Equals(t, int64(len(plain)), read)
+}
+
+func TestPerformRequest_withDiscardBody(t *testing.T) {
+	// arrange
+	plain := strings.Repeat("compressible ", 100)
+	var compressed bytes.Buffer
+	writer := gzip.NewWriter(&compressed)
+	_, _ = writer.Write([]byte(plain))
+	verify.Ok(t, writer.Close())
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/gzip" {
+			w.Header().Set("Content-Encoding", "gzip")
+			w.Write(compressed.Bytes())
+			return
+		}
+		w.Write([]byte(plain))
+	}))
+	defer mockServer.Close()
+	var receivedBody []byte
+	unit := NewClient(Request{})
+	unit.DiscardBody = true
+	unit.OnResponse = func(request Request, resp *http.Response, body []byte) {
+		receivedBody = body
+	}
+	unit.NextRequest = Sequential([]Request{{URL: mockServer.URL}, {URL: mockServer.URL + "/gzip", AcceptEncoding: "gzip"}}, 0)
+	// action
+	unit.RunForAmount(2)
+	// verify
+	verify.Equals(t, 2, unit.Statistic.SuccessCount)
+	verify.Equals(t, 0, unit.Statistic.IOFailedCount)
+	verify.Assert(t, receivedBody == nil, "Discarded body was kept")
+	verify.Equals(t, int64(2*len(plain)), unit.Statistic.ReadThroughput)
+	verify.Equals(t, int64(len(plain)+compressed.Len()), unit.Statistic.WireReadThroughput)
+}
+
+func TestDrainBody_deflate(t *testing.T) {
+	// arrange
+	var raw bytes.Buffer
+	writer, _ := flate.NewWriter(&raw, flate.BestSpeed)
+	_, _ = writer.Write([]byte("raw deflate"))
+	verify.Ok(t, writer.Close())
+	var wrapped bytes.Buffer
+	zwriter := zlib.NewWriter(&wrapped)
+	_, _ = zwriter.Write([]byte("zlib deflate"))
+	verify.Ok(t, zwriter.Close())
+	// action
+	rawWire, rawRead, err1 := drainBody(bytes.NewReader(raw.Bytes()), "deflate", true)
+	wrappedWire, wrappedRead, err2 := drainBody(bytes.NewReader(wrapped.Bytes()), "deflate", true)
+	// verify
+	verify.Ok(t, err1)
+	verify.Ok(t, err2)
+	verify.Equals(t, int64(raw.Len()), rawWire)
+	verify.Equals(t, int64(len("raw deflate")), rawRead)
+	verify.Equals(t, int64(wrapped.Len()), wrappedWire)
+	verify.Equals(t, int64(len("zlib deflate")), wrappedRead)
+}
@@ -0,0 +1,87 @@
+// SPDX-FileCopyrightText: 2021 Eric Neidhardt
+// SPDX-License-Identifier: MIT
+package client
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"io"
+	"net"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// Causes of network failures, see Statistic.NetworkFailures.
+const (
+	FailureTimeout = "timeout"
+	// FailureConnectTimeout is a connection, which was not established within the timeout of the dialer,
+	// e.g. net.Dialer.Timeout. Timeouts of the request while connecting are counted as FailureTimeout.
+	FailureConnectTimeout = "connect-timeout"
+	FailureCanceled       = "canceled"
+	FailureRefused        = "refused"
+	FailureReset          = "reset"
+	FailureDNS            = "dns"
+	FailureTLS            = "tls"
+	FailureOther          = "other"
+)
+
+// FailureCauses are the causes of network failures in the order they are reported.
+var FailureCauses = []string{
+	FailureTimeout, FailureConnectTimeout, FailureCanceled, FailureRefused, FailureReset, FailureDNS, FailureTLS, FailureOther,
+}
+
+// ClassifyError returns the cause of a failed request, one of FailureCauses.
+func ClassifyError(err error) string {
+	var dnsErr *net.DNSError
+	var netErr net.Error
+	var opErr *net.OpError
+	var recordErr tls.RecordHeaderError
+	var unknownAuthority x509.UnknownAuthorityError
+	var hostnameErr x509.HostnameError
+	var invalidCert x509.CertificateInvalidError
+	switch {
+	case errors.As(err, &dnsErr):
+		return FailureDNS
+	case errors.As(err, &opErr) && opErr.Op == "dial" && opErr.Timeout():
+		return FailureConnectTimeout
+	case errors.Is(err, context.DeadlineExceeded), errors.As(err, &netErr) && netErr.Timeout():
+		return FailureTimeout
+	case errors.Is(err, context.Canceled):
+		return FailureCanceled
+	case errors.Is(err, syscall.ECONNREFUSED):
+		return FailureRefused
+	case errors.Is(err, syscall.ECONNRESET), errors.Is(err, syscall.EPIPE),
+		errors.Is(err, io.EOF), errors.Is(err, io.ErrUnexpectedEOF):
+		return FailureReset
+	case errors.As(err, &recordErr), errors.As(err, &unknownAuthority), errors.As(err, &hostnameErr),
+		errors.As(err, &invalidCert), strings.Contains(err.Error(), "tls: "):
+		return FailureTLS
+	default:
+		return FailureOther
+	}
+}
+
+// countNetworkFailure counts a failed request by its cause, which is returned.
+func (c *Client) countNetworkFailure(err error) string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.Statistic.NetworkFailedCount++
+	if c.Statistic.NetworkFailures == nil {
+		c.Statistic.NetworkFailures = make(map[string]int)
+	}
+	cause := ClassifyError(err)
+	c.Statistic.NetworkFailures[cause]++
+	return cause
+}
+
+// interrupted counts a request, which failed as the context of the run was done, see Statistic.InterruptedCount.
+func (c *Client) interrupted(request Request, startTime time.Time, err error) Result {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.Statistic.RequestCount--
+	c.Statistic.InterruptedCount++
+	return Result{Request: request, Start: startTime, Latency: time.Since(startTime), Class: ClassInterrupted, Err: err}
+}
@@ -0,0 +1,71 @@
+// SPDX-FileCopyrightText: 2021 Eric Neidhardt
+// SPDX-License-Identifier: MIT
+package client
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/EricNeid/go-bench/internal/verify"
+)
+
+func TestClassifyError(t *testing.T) {
+	verify.Equals(t, FailureTimeout, ClassifyError(context.DeadlineExceeded))
+	verify.Equals(t, FailureConnectTimeout, ClassifyError(&net.OpError{Op: "dial", Net: "tcp", Err: os.ErrDeadlineExceeded}))
+	verify.Equals(t, FailureCanceled, ClassifyError(context.Canceled))
+	verify.Equals(t, FailureDNS, ClassifyError(&net.DNSError{Err: "no such host", Name: "example.invalid", IsNotFound: true}))
+	verify.Equals(t, FailureOther, ClassifyError(errors.New("unknown")))
+}
+
+func TestPerformRequest_networkFailures(t *testing.T) {
+	// arrange
+	slowServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(200 * time.Millisecond)
+	}))
+	defer slowServer.Close()
+	resetServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, _, err := w.(http.Hijacker).Hijack()
+		verify.Ok(t, err)
+		conn.Close()
+	}))
+	defer resetServer.Close()
+	tlsServer := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer tlsServer.Close()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	verify.Ok(t, err)
+	refusedURL := "http://" + listener.Addr().String()
+	listener.Close()
+
+	// only the slow server is expected to time out, the handshake of the TLS server may be slow, e.g. with -race
+	slow := NewClient(Request{URL: slowServer.URL}, WithTimeout(50*time.Millisecond))
+	unit := NewClient(Request{}, WithTimeout(5*time.Second))
+	unit.NextRequest = Sequential([]Request{{URL: resetServer.URL}, {URL: tlsServer.URL}, {URL: refusedURL}}, 0)
+	// action
+	slow.PerformRequest()
+	unit.RunForAmount(3)
+	// verify
+	verify.Equals(t, map[string]int{FailureTimeout: 1}, slow.Statistic.NetworkFailures)
+	verify.Equals(t, 3, unit.Statistic.NetworkFailedCount)
+	verify.Equals(t, map[string]int{FailureReset: 1, FailureTLS: 1, FailureRefused: 1}, unit.Statistic.NetworkFailures)
+}
+
+func TestPerformRequest_connectTimeout(t *testing.T) {
+	// arrange
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer mockServer.Close()
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	// expires before any connection is established
+	transport.DialContext = (&net.Dialer{Timeout: time.Nanosecond}).DialContext
+	unit := NewClient(Request{URL: mockServer.URL}, WithTimeout(time.Second))
+	unit.HTTPClient.Transport = transport
+	// action
+	unit.PerformRequest()
+	// verify
+	verify.Equals(t, map[string]int{FailureConnectTimeout: 1}, unit.Statistic.NetworkFailures)
+}
@@ -0,0 +1,87 @@
+// SPDX-FileCopyrightText: 2021 Eric Neidhardt
+// SPDX-License-Identifier: MIT
+package client
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"net/http"
+	"net/http/httputil"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// FailureRecorder saves responses, which are not counted as success, to files, so failures can be diagnosed
+// after a run. Each file contains the request line, the reason of the failure, the status line,
+// the headers and the body of the response.
+// A FailureRecorder is safe for concurrent use.
+type FailureRecorder struct {
+	Dir string
+	// Maximum number of saved responses, unlimited if <= 0.
+	Max int
+	// Only every Sample-th failure is saved, every failure if <= 1.
+	Sample int
+	// Maximum number of saved bytes of each body, unlimited if <= 0.
+	MaxBody int
+
+	mu       sync.Mutex
+	failures int
+	saved    int
+}
+
+// Record saves the failed response, if it is sampled and the maximum is not reached yet.
+func (f *FailureRecorder) Record(request Request, resp *http.Response, body []byte, reason error) error {
+	f.mu.Lock()
+	f.failures++
+	sampled := f.Sample <= 1 || (f.failures-1)%f.Sample == 0
+	if !sampled || (f.Max > 0 && f.saved >= f.Max) {
+		f.mu.Unlock()
+		return nil
+	}
+	f.saved++
+	index := f.saved
+	f.mu.Unlock()
+
+	dump, err := httputil.DumpResponse(resp, false)
+	if err != nil {
+		return err
+	}
+	var content bytes.Buffer
+	method := request.Method
+	if method == "" && resp.Request != nil {
+		method = resp.Request.Method
+	}
+	fmt.Fprintf(&content, "%s %s\n%s\n\n", method, request.URL, reason)
+	content.Write(dump)
+	if f.MaxBody > 0 && len(body) > f.MaxBody {
+		content.Write(body[:f.MaxBody])
+		fmt.Fprintf(&content, "\n... %d more bytes", len(body)-f.MaxBody)
+	} else {
+		content.Write(body)
+	}
+	name := fmt.Sprintf("failure-%06d-%d.txt", index, resp.StatusCode)
+	return os.WriteFile(filepath.Join(f.Dir, name), content.Bytes(), 0o644)
+}
+
+// Saved returns the number of saved responses.
+func (f *FailureRecorder) Saved() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.saved
+}
+
+// Attach records the failures of the given client in addition to its current OnFailure.
+// Responses, which cannot be saved, are logged.
+func (f *FailureRecorder) Attach(client *Client) {
+	onFailure := client.OnFailure
+	client.OnFailure = func(request Request, resp *http.Response, body []byte, reason error) {
+		if onFailure != nil {
+			onFailure(request, resp, body, reason)
+		}
+		if err := f.Record(request, resp, body, reason); err != nil {
+			log.Printf("Could not save failure: %s", err)
+		}
+	}
+}
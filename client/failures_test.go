@@ -0,0 +1,45 @@
+// SPDX-FileCopyrightText: 2021 Eric Neidhardt
+// SPDX-License-Identifier: MIT
+package client
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/EricNeid/go-bench/internal/verify"
+)
+
+func TestFailureRecorder(t *testing.T) {
+	// arrange
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/ok" {
+			return
+		}
+		w.Header().Set("X-Reason", "overloaded")
+		w.WriteHeader(http.StatusServiceUnavailable)
+		w.Write([]byte("try again later"))
+	}))
+	defer mockServer.Close()
+	dir := t.TempDir()
+	recorder := &FailureRecorder{Dir: dir, Max: 2, Sample: 2, MaxBody: 3}
+	unit := NewClient(Request{})
+	unit.NextRequest = Sequential([]Request{{URL: mockServer.URL + "/ok"}, {URL: mockServer.URL + "/fail"}}, 0)
+	recorder.Attach(unit)
+	// action
+	unit.RunForAmount(12)
+	// verify
+	verify.Equals(t, 6, unit.Statistic.FailureCount)
+	verify.Equals(t, 2, recorder.Saved())
+	files, err := filepath.Glob(filepath.Join(dir, "*"))
+	verify.Ok(t, err)
+	verify.Equals(t, []string{filepath.Join(dir, "failure-000001-503.txt"), filepath.Join(dir, "failure-000002-503.txt")}, files)
+	content, err := os.ReadFile(files[0])
+	verify.Ok(t, err)
+	verify.Assert(t, strings.HasPrefix(string(content), "GET "+mockServer.URL+"/fail\nunexpected status 503 Service Unavailable\n\nHTTP/1.1 503 Service Unavailable\r\n"), "Unexpected content %s", content)
+	verify.Assert(t, strings.Contains(string(content), "X-Reason: overloaded"), "Missing header in %s", content)
+	verify.Assert(t, strings.HasSuffix(string(content), "\r\n\r\ntry\n... 12 more bytes"), "Unexpected body in %s", content)
+}
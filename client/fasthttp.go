@@ -0,0 +1,228 @@
+// SPDX-FileCopyrightText: 2021 Eric Neidhardt
+// SPDX-License-Identifier: MIT
+package client
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"math"
+	"net"
+	"net/http"
+	"net/http/httptrace"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/valyala/fasthttp"
+)
+
+// Engines, which send the requests, see NewFastHTTPTransport.
+const (
+	// EngineNetHTTP sends the requests with the transports of net/http, which support all protocols.
+	EngineNetHTTP = "nethttp"
+	// EngineFastHTTP sends the requests with fasthttp, which generates more requests per second
+	// with fewer allocations, but supports HTTP/1.1 only.
+	EngineFastHTTP = "fasthttp"
+)
+
+// NewFastHTTPTransport returns a round tripper, which sends requests with HTTP/1.1 using fasthttp.
+// Its responses are converted to the ones of net/http, so that they are counted like the ones of other
+// round trippers, including the phases of new connections. New connections are dialed with dial,
+// a net.Dialer is used if it is nil. The tls config may be nil.
+func NewFastHTTPTransport(
+	tlsConfig *tls.Config,
+	dial func(ctx context.Context, network, address string) (net.Conn, error),
+) http.RoundTripper {
+	if tlsConfig == nil {
+		tlsConfig = &tls.Config{}
+	} else {
+		tlsConfig = tlsConfig.Clone()
+	}
+	tlsConfig.NextProtos = []string{"http/1.1"}
+	if dial == nil {
+		dial = (&net.Dialer{}).DialContext
+	}
+	t := &fastHTTPTransport{tlsConfig: tlsConfig, dial: dial, conns: make(map[string]*phaseTrace)}
+	t.client = &fasthttp.Client{
+		// as many connections as net/http opens, the clients limit the number of requests in flight
+		MaxConnsPerHost:          math.MaxInt32,
+		StreamResponseBody:       true,
+		NoDefaultUserAgentHeader: true,
+		ConfigureClient: func(hc *fasthttp.HostClient) error {
+			isTLS := hc.IsTLS
+			hc.DialTimeout = func(address string, timeout time.Duration) (net.Conn, error) {
+				return t.dialConn(address, timeout, isTLS)
+			}
+			return nil
+		},
+	}
+	return t
+}
+
+type fastHTTPTransport struct {
+	client    *fasthttp.Client
+	tlsConfig *tls.Config
+	dial      func(ctx context.Context, network, address string) (net.Conn, error)
+
+	// phases of new connections by their local address, until they are claimed by their first request
+	mu    sync.Mutex
+	conns map[string]*phaseTrace
+}
+
+// dialConn dials a connection and performs its TLS handshake, whose phases are kept until the connection
+// is used by a request. The dial of fasthttp does not know the request, for which it is dialed.
+func (t *fastHTTPTransport) dialConn(address string, timeout time.Duration, isTLS bool) (net.Conn, error) {
+	phases, trace := newPhaseTrace(time.Now())
+	ctx := httptrace.WithClientTrace(context.Background(), trace)
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	address = fasthttp.AddMissingPort(address, isTLS)
+	conn, err := t.dial(ctx, "tcp", address)
+	if err != nil && ctx.Err() != nil {
+		// the timeout of the request, not the one of the dialer
+		return nil, ctx.Err()
+	} else if err != nil {
+		return nil, err
+	}
+	if isTLS {
+		config := t.tlsConfig
+		if config.ServerName == "" {
+			host, _, _ := net.SplitHostPort(address)
+			config = config.Clone()
+			config.ServerName = host
+		}
+		tlsConn := tls.Client(conn, config)
+		trace.TLSHandshakeStart()
+		err = tlsConn.HandshakeContext(ctx)
+		trace.TLSHandshakeDone(tlsConn.ConnectionState(), err)
+		if err != nil {
+			conn.Close()
+			return nil, err
+		}
+		conn = tlsConn
+	}
+	t.mu.Lock()
+	t.conns[conn.LocalAddr().String()] = phases
+	t.mu.Unlock()
+	return conn, nil
+}
+
+// claim returns the phases of a new connection, nil if the connection has been used before.
+func (t *fastHTTPTransport) claim(local net.Addr) *phaseTrace {
+	if local == nil {
+		return nil
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	phases, ok := t.conns[local.String()]
+	if ok {
+		delete(t.conns, local.String())
+	}
+	return phases
+}
+
+func (t *fastHTTPTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	freq := fasthttp.AcquireRequest()
+	defer fasthttp.ReleaseRequest(freq)
+	freq.Header.SetMethod(req.Method)
+	freq.SetRequestURI(req.URL.String())
+	if req.Host != "" && req.Host != req.URL.Host {
+		freq.UseHostHeader = true
+		freq.Header.SetHost(req.Host)
+	}
+	for key, values := range req.Header {
+		for _, value := range values {
+			freq.Header.Add(key, value)
+		}
+	}
+	if strings.EqualFold(req.Header.Get("Connection"), "close") || req.Close {
+		freq.SetConnectionClose()
+	}
+	if req.Body != nil && req.Body != http.NoBody {
+		// a round tripper has to close the body of the request
+		defer req.Body.Close()
+		size := -1
+		if req.ContentLength > 0 {
+			size = int(req.ContentLength)
+		}
+		freq.SetBodyStream(io.NopCloser(req.Body), size)
+	}
+
+	fresp := fasthttp.AcquireResponse()
+	var err error
+	if deadline, ok := req.Context().Deadline(); ok {
+		// covers reading the body as well, as the deadline of the connection is kept
+		err = t.client.DoDeadline(freq, fresp, deadline)
+	} else {
+		err = t.client.Do(freq, fresp)
+	}
+	if err == nil {
+		err = req.Context().Err()
+	}
+	if err != nil {
+		fasthttp.ReleaseResponse(fresp)
+		return nil, err
+	}
+
+	trace := httptrace.ContextClientTrace(req.Context())
+	if phases, ok := req.Context().Value(phaseTraceKey{}).(*phaseTrace); ok {
+		phases.connected(t.claim(fresp.LocalAddr()), fresp.RemoteAddr())
+	}
+	if trace != nil && trace.GotFirstResponseByte != nil {
+		trace.GotFirstResponseByte()
+	}
+	return newFastHTTPResponse(req, fresp), nil
+}
+
+// newFastHTTPResponse converts the response, whose body is released when the returned body is closed.
+func newFastHTTPResponse(req *http.Request, fresp *fasthttp.Response) *http.Response {
+	statusCode := fresp.StatusCode()
+	resp := &http.Response{
+		Status:        fmt.Sprintf("%d %s", statusCode, fasthttp.StatusMessage(statusCode)),
+		StatusCode:    statusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        make(http.Header),
+		ContentLength: int64(fresp.Header.ContentLength()),
+		Close:         fresp.ConnectionClose(),
+		Request:       req,
+	}
+	for key, value := range fresp.Header.All() {
+		if !strings.EqualFold(string(key), "Transfer-Encoding") {
+			resp.Header.Add(string(key), string(value))
+		}
+	}
+	if resp.ContentLength < 0 {
+		// chunked or until the connection is closed
+		resp.ContentLength = -1
+	}
+	body := fresp.BodyStream()
+	if body == nil {
+		// the body has already been read, e.g. because it was small
+		body = bytes.NewReader(fresp.Body())
+	}
+	resp.Body = &fastHTTPBody{Reader: body, resp: fresp}
+	return resp
+}
+
+// fastHTTPBody releases the response of fasthttp when it is closed, which releases its connection.
+type fastHTTPBody struct {
+	io.Reader
+	once sync.Once
+	resp *fasthttp.Response
+}
+
+func (b *fastHTTPBody) Close() error {
+	b.once.Do(func() {
+		_ = b.resp.CloseBodyStream()
+		fasthttp.ReleaseResponse(b.resp)
+	})
+	return nil
+}
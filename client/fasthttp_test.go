@@ -0,0 +1,94 @@
+// SPDX-FileCopyrightText: 2021 Eric Neidhardt
+// SPDX-License-Identifier: MIT
+package client
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/EricNeid/go-bench/internal/verify"
+)
+
+// phaseCounts returns the number of recorded durations of each phase, which are the same for both engines.
+func phaseCounts(s Statistic) map[string]int {
+	counts := make(map[string]int)
+	for phase, h := range s.Phases {
+		counts[phase] = h.Count
+	}
+	return counts
+}
+
+func TestNewFastHTTPTransport(t *testing.T) {
+	for name, keepAlive := range map[string]bool{"keep-alive": true, "close": false} {
+		for scheme, newServer := range map[string]func(http.Handler) *httptest.Server{"http": httptest.NewServer, "https": httptest.NewTLSServer} {
+			t.Run(scheme+"/"+name, func(t *testing.T) {
+				// arrange
+				mockServer := newServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					w.Write([]byte("hello"))
+				}))
+				defer mockServer.Close()
+				transport, err := ConfigureProtocol(mockServer.Client().Transport.(*http.Transport).Clone(), ProtocolHTTP1)
+				verify.Ok(t, err)
+				expected := NewClient(Request{URL: mockServer.URL, KeepAlive: keepAlive})
+				expected.HTTPClient.Transport = transport
+				tlsConfig := mockServer.Client().Transport.(*http.Transport).TLSClientConfig
+				unit := NewClient(Request{URL: mockServer.URL, KeepAlive: keepAlive})
+				unit.HTTPClient.Transport = NewFastHTTPTransport(tlsConfig, nil)
+				// action
+				expected.RunForAmount(3)
+				unit.RunForAmount(3)
+				// verify
+				verify.Equals(t, 3, unit.Statistic.SuccessCount)
+				verify.Equals(t, expected.Statistic.StatusCodes, unit.Statistic.StatusCodes)
+				verify.Equals(t, expected.Statistic.ReadThroughput, unit.Statistic.ReadThroughput)
+				verify.Equals(t, expected.Statistic.NewConnections, unit.Statistic.NewConnections)
+				verify.Equals(t, expected.Statistic.ReusedConnections, unit.Statistic.ReusedConnections)
+				verify.Equals(t, expected.Statistic.Protocols, unit.Statistic.Protocols)
+				verify.Equals(t, expected.Statistic.IPFamilies, unit.Statistic.IPFamilies)
+				verify.Equals(t, expected.Statistic.TLSVersions, unit.Statistic.TLSVersions)
+				verify.Equals(t, phaseCounts(expected.Statistic), phaseCounts(unit.Statistic))
+			})
+		}
+	}
+}
+
+func TestNewFastHTTPTransport_postBody(t *testing.T) {
+	// arrange
+	var received []byte
+	var host string
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received, _ = io.ReadAll(r.Body)
+		host = r.Host
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer mockServer.Close()
+	unit := NewClient(Request{URL: mockServer.URL, PostBody: []byte("payload"), Host: "www.example.com"})
+	unit.HTTPClient.Transport = NewFastHTTPTransport(nil, nil)
+	// action
+	unit.PerformRequest()
+	// verify
+	verify.Equals(t, 1, unit.Statistic.SuccessCount)
+	verify.Equals(t, "payload", string(received))
+	verify.Equals(t, "www.example.com", host)
+	verify.Equals(t, int64(len("payload")), unit.Statistic.WriteThroughput)
+}
+
+func TestNewFastHTTPTransport_timeout(t *testing.T) {
+	// arrange
+	release := make(chan struct{})
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer mockServer.Close()
+	defer close(release)
+	unit := NewClient(Request{URL: mockServer.URL}, WithTimeout(50*time.Millisecond))
+	unit.HTTPClient.Transport = NewFastHTTPTransport(nil, nil)
+	// action
+	unit.PerformRequest()
+	// verify
+	verify.Equals(t, 0, unit.Statistic.SuccessCount)
+	verify.Equals(t, map[string]int{FailureTimeout: 1}, unit.Statistic.NetworkFailures)
+}
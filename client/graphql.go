@@ -0,0 +1,52 @@
+// SPDX-FileCopyrightText: 2021 Eric Neidhardt
+// SPDX-License-Identifier: MIT
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+// GraphQLBody returns the JSON body of a GraphQL request, which is sent by POST with content type application/json.
+// The variables are a JSON object, which is inserted as is, so placeholders of Template may be used as values
+// of the variables, e.g. {"id": {{seq}}}. Variables and operation name may be empty.
+func GraphQLBody(query string, variables []byte, operationName string) []byte {
+	// json.Marshal of a string cannot fail
+	body, _ := json.Marshal(query)
+	body = append([]byte(`{"query":`), body...)
+	if len(variables) > 0 {
+		body = append(append(body, `,"variables":`...), variables...)
+	}
+	if operationName != "" {
+		name, _ := json.Marshal(operationName)
+		body = append(append(body, `,"operationName":`...), name...)
+	}
+	return append(body, '}')
+}
+
+// GraphQLErrors returns a validation of kind graphql, which fails if the body is no GraphQL response
+// or if it contains errors. GraphQL servers report failed queries with status 200 and an errors array.
+func GraphQLErrors() Validation {
+	return Validation{
+		Kind: "graphql",
+		Check: func(_ *http.Response, body []byte) error {
+			var response struct {
+				Errors []struct {
+					Message string `json:"message"`
+				} `json:"errors"`
+			}
+			if err := json.Unmarshal(body, &response); err != nil {
+				return fmt.Errorf("invalid graphql response: %w", err)
+			}
+			switch len(response.Errors) {
+			case 0:
+				return nil
+			case 1:
+				return fmt.Errorf("graphql error: %s", response.Errors[0].Message)
+			default:
+				return fmt.Errorf("%d graphql errors, first: %s", len(response.Errors), response.Errors[0].Message)
+			}
+		},
+	}
+}
@@ -0,0 +1,48 @@
+// SPDX-FileCopyrightText: 2021 Eric Neidhardt
+// SPDX-License-Identifier: MIT
+package client
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/EricNeid/go-bench/internal/verify"
+)
+
+func TestGraphQLBody(t *testing.T) {
+	// action
+	plain := GraphQLBody(`{ user { name } }`, nil, "")
+	withVariables := GraphQLBody(`query Q($id: ID!) { user(id: $id) { name } }`, []byte(`{"id":{{seq}}}`), "Q")
+	// verify
+	verify.Equals(t, `{"query":"{ user { name } }"}`, string(plain))
+	verify.Equals(t, `{"query":"query Q($id: ID!) { user(id: $id) { name } }","variables":{"id":{{seq}}},"operationName":"Q"}`, string(withVariables))
+}
+
+func TestPerformRequest_withGraphQLErrors(t *testing.T) {
+	// arrange
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/error":
+			w.Write([]byte(`{"data":null,"errors":[{"message":"user not found"}]}`))
+		case "/html":
+			w.Write([]byte(`<html></html>`))
+		default:
+			w.Write([]byte(`{"data":{"user":{"name":"max"}}}`))
+		}
+	}))
+	defer mockServer.Close()
+	var reasons []string
+	unit := NewClient(Request{})
+	unit.Validations = []Validation{GraphQLErrors()}
+	unit.NextRequest = Sequential([]Request{{URL: mockServer.URL}, {URL: mockServer.URL + "/error"}, {URL: mockServer.URL + "/html"}}, 0)
+	unit.OnFailure = func(request Request, resp *http.Response, body []byte, reason error) {
+		reasons = append(reasons, reason.Error())
+	}
+	// action
+	unit.RunForAmount(3)
+	// verify
+	verify.Equals(t, 1, unit.Statistic.SuccessCount)
+	verify.Equals(t, map[string]int{"graphql": 2}, unit.Statistic.ValidationFailures)
+	verify.Equals(t, "graphql error: user not found", reasons[0])
+}
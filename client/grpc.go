@@ -0,0 +1,246 @@
+// SPDX-FileCopyrightText: 2021 Eric Neidhardt
+// SPDX-License-Identifier: MIT
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// ContentTypeGRPC is the content type of gRPC calls.
+const ContentTypeGRPC = "application/grpc"
+
+// Maximum length of received messages, like the default receive limit of grpc-go, so that a corrupt
+// or hostile length prefix does not allocate gigabytes.
+const grpcMaxMessage = 4 << 20
+
+// Paths of the reflection service, which are tried in order, see ReflectGRPC.
+var grpcReflectionPaths = []string{
+	"/grpc.reflection.v1.ServerReflection/ServerReflectionInfo",
+	"/grpc.reflection.v1alpha.ServerReflection/ServerReflectionInfo",
+}
+
+// GRPCMethod is a method of a gRPC service, whose input messages are encoded from JSON, see ProtoRegistry.Method.
+type GRPCMethod struct {
+	// Path of the method, to which calls are sent, e.g. /helloworld.Greeter/SayHello.
+	Path string
+	// Set for methods receiving or sending a stream of messages.
+	ClientStreaming bool
+	ServerStreaming bool
+
+	input    string
+	registry *ProtoRegistry
+}
+
+// GRPCPath returns the path of the method of the given name, e.g. /helloworld.Greeter/SayHello
+// for helloworld.Greeter/SayHello or helloworld.Greeter.SayHello.
+func GRPCPath(name string) string {
+	name = strings.TrimPrefix(name, "/")
+	if !strings.Contains(name, "/") {
+		if i := strings.LastIndex(name, "."); i >= 0 {
+			name = name[:i] + "/" + name[i+1:]
+		}
+	}
+	return "/" + name
+}
+
+// Encode encodes the JSON message as input of the method, framed as message of a call.
+func (m *GRPCMethod) Encode(message []byte) ([]byte, error) {
+	encoded, err := m.registry.encodeJSON(m.input, message)
+	if err != nil {
+		return nil, err
+	}
+	return grpcFrame(encoded), nil
+}
+
+// Middleware sends the requests of a client as unary calls of the method. The bodies of the requests
+// are JSON messages, e.g. rendered templates, which are encoded per request. Requests, whose body
+// cannot be encoded, fail without being sent.
+func (m *GRPCMethod) Middleware() Middleware {
+	// bodies without placeholders are encoded once
+	var mu sync.Mutex
+	var lastMessage, lastFrame []byte
+	return func(next Doer) Doer {
+		return DoerFunc(func(req *http.Request) (*http.Response, error) {
+			var message []byte
+			if req.Body != nil {
+				var err error
+				message, err = io.ReadAll(req.Body)
+				req.Body.Close()
+				if err != nil {
+					return nil, err
+				}
+			}
+			mu.Lock()
+			frame := lastFrame
+			if frame == nil || !bytes.Equal(message, lastMessage) {
+				var err error
+				if frame, err = m.Encode(message); err != nil {
+					mu.Unlock()
+					return nil, err
+				}
+				lastMessage, lastFrame = message, frame
+			}
+			mu.Unlock()
+			setGRPCBody(req, frame)
+			return next.Do(req)
+		})
+	}
+}
+
+// setGRPCBody sets the framed messages as body and the headers of a gRPC call.
+func setGRPCBody(req *http.Request, frames []byte) {
+	req.Method = http.MethodPost
+	req.Body = io.NopCloser(bytes.NewReader(frames))
+	req.GetBody = func() (io.ReadCloser, error) {
+		return io.NopCloser(bytes.NewReader(frames)), nil
+	}
+	req.ContentLength = int64(len(frames))
+	req.Header.Set("Content-Type", ContentTypeGRPC)
+	req.Header.Set("Te", "trailers")
+}
+
+// GRPCStatus returns a validation of kind grpc-status, which fails if a call did not end with status 0 (OK).
+// The status is sent as trailer, or as header by calls failing without a response message.
+func GRPCStatus() Validation {
+	return Validation{
+		Kind: "grpc-status",
+		Check: func(resp *http.Response, _ []byte) error {
+			return grpcStatus(resp)
+		},
+	}
+}
+
+// grpcStatus returns an error, if the call of the response, whose body has been read, failed.
+func grpcStatus(resp *http.Response) error {
+	status := resp.Trailer.Get("Grpc-Status")
+	message := resp.Trailer.Get("Grpc-Message")
+	if status == "" {
+		status, message = resp.Header.Get("Grpc-Status"), resp.Header.Get("Grpc-Message")
+	}
+	switch status {
+	case "0":
+		return nil
+	case "":
+		return errors.New("missing grpc-status")
+	default:
+		return fmt.Errorf("grpc-status %s: %s", status, message)
+	}
+}
+
+// grpcFrame prefixes the encoded message with its length, as uncompressed message of a call.
+func grpcFrame(message []byte) []byte {
+	frame := make([]byte, 5, 5+len(message))
+	binary.BigEndian.PutUint32(frame[1:], uint32(len(message))) //nolint:gosec // messages are smaller than 4 GiB
+	return append(frame, message...)
+}
+
+// readGRPCFrame reads the next message of a call, io.EOF at the end of the call.
+func readGRPCFrame(r io.Reader) ([]byte, error) {
+	var prefix [5]byte
+	if _, err := io.ReadFull(r, prefix[:]); err != nil {
+		return nil, err
+	}
+	if prefix[0] != 0 {
+		return nil, errors.New("compressed grpc messages are not supported")
+	}
+	length := binary.BigEndian.Uint32(prefix[1:])
+	if length > grpcMaxMessage {
+		return nil, fmt.Errorf("grpc message of %d bytes exceeds the limit of %d bytes", length, grpcMaxMessage)
+	}
+	message := make([]byte, length)
+	if _, err := io.ReadFull(r, message); err != nil {
+		return nil, io.ErrUnexpectedEOF
+	}
+	return message, nil
+}
+
+// ReflectGRPC receives the descriptors of the service of a method by the server reflection of the server
+// at baseURL, e.g. http://localhost:50051, and returns the method. The client has to support HTTP/2,
+// e.g. with ProtocolH2C for http URLs.
+func ReflectGRPC(ctx context.Context, httpClient *http.Client, baseURL, name string) (*GRPCMethod, error) {
+	path := GRPCPath(name)
+	service := strings.TrimPrefix(path[:strings.LastIndex(path, "/")], "/")
+	// ServerReflectionRequest with file_containing_symbol
+	request := grpcFrame(appendBytesField(nil, 4, []byte(service)))
+	var err error
+	for _, reflectionPath := range grpcReflectionPaths {
+		var files [][]byte
+		if files, err = reflectFiles(ctx, httpClient, strings.TrimSuffix(baseURL, "/")+reflectionPath, request); err != nil {
+			continue
+		}
+		registry := NewProtoRegistry()
+		for _, file := range files {
+			if err := registry.AddFile(file); err != nil {
+				return nil, fmt.Errorf("invalid descriptor of reflection: %w", err)
+			}
+		}
+		return registry.Method(name)
+	}
+	return nil, fmt.Errorf("server reflection failed: %w", err)
+}
+
+// reflectFiles sends the request to the reflection service and returns the encoded file descriptors of the response.
+func reflectFiles(ctx context.Context, httpClient *http.Client, url string, request []byte) ([][]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, http.NoBody)
+	if err != nil {
+		return nil, err
+	}
+	setGRPCBody(req, request)
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	var files [][]byte
+	var failure error
+	for {
+		message, err := readGRPCFrame(resp.Body)
+		if errors.Is(err, io.EOF) {
+			break
+		} else if err != nil {
+			return nil, err
+		}
+		err = readProto(message, func(number, _ int, _ uint64, b []byte) error {
+			switch number {
+			case 4:
+				// FileDescriptorResponse with file_descriptor_proto
+				return readProto(b, func(number, _ int, _ uint64, b []byte) error {
+					if number == 1 {
+						files = append(files, b)
+					}
+					return nil
+				})
+			case 7:
+				// ErrorResponse with error_message
+				return readProto(b, func(number, _ int, _ uint64, b []byte) error {
+					if number == 2 {
+						failure = errors.New(string(b))
+					}
+					return nil
+				})
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	if err := grpcStatus(resp); err != nil {
+		return nil, err
+	}
+	if failure != nil {
+		return nil, failure
+	}
+	return files, nil
+}
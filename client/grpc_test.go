@@ -0,0 +1,129 @@
+// SPDX-FileCopyrightText: 2021 Eric Neidhardt
+// SPDX-License-Identifier: MIT
+package client
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/EricNeid/go-bench/internal/verify"
+)
+
+// grpcServer starts an HTTP/2 server, whose handler serves the given gRPC paths.
+func grpcServer(handlers map[string]http.HandlerFunc) *httptest.Server {
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		handler, ok := handlers[r.URL.Path]
+		if !ok || r.Header.Get("Content-Type") != ContentTypeGRPC {
+			// trailers-only response of an unknown method
+			w.Header().Set("Content-Type", ContentTypeGRPC)
+			w.Header().Set("Grpc-Status", "12")
+			return
+		}
+		handler(w, r)
+	}))
+	server.EnableHTTP2 = true
+	server.StartTLS()
+	return server
+}
+
+// writeGRPC writes the messages and the status of a call.
+func writeGRPC(w http.ResponseWriter, status string, messages ...[]byte) {
+	w.Header().Set("Content-Type", ContentTypeGRPC)
+	for _, m := range messages {
+		w.Write(grpcFrame(m))
+	}
+	w.Header().Set(http.TrailerPrefix+"Grpc-Status", status)
+}
+
+func TestGRPCMethod_Middleware(t *testing.T) {
+	// arrange
+	registry, err := ParseDescriptorSet(appendBytesField(nil, 1, testDescriptor()))
+	verify.Ok(t, err)
+	method, err := registry.Method("test.Pinger/Ping")
+	verify.Ok(t, err)
+	server := grpcServer(map[string]http.HandlerFunc{
+		"/test.Pinger/Ping": func(w http.ResponseWriter, r *http.Request) {
+			message, err := readGRPCFrame(r.Body)
+			switch {
+			case err != nil:
+				writeGRPC(w, "13")
+			case strings.Contains(string(message), "fail"):
+				writeGRPC(w, "3")
+			default:
+				writeGRPC(w, "0", message)
+			}
+		},
+	})
+	defer server.Close()
+	requests := []Request{
+		{URL: server.URL + method.Path, PostBody: []byte(`{"name":"max"}`)},
+		{URL: server.URL + method.Path, PostBody: []byte(`{"name":"fail"}`)},
+		{URL: server.URL + "/test.Pinger/Unknown", PostBody: []byte(`{"name":"max"}`)},
+	}
+	unit := NewClient(requests[0], WithTransport(server.Client().Transport), WithMiddleware(method.Middleware()))
+	unit.NextRequest = Sequential(requests, 0)
+	unit.Validations = []Validation{GRPCStatus()}
+	// action
+	unit.RunForAmount(4)
+	// verify
+	verify.Equals(t, 4, unit.Statistic.RequestCount)
+	verify.Equals(t, 2, unit.Statistic.SuccessCount)
+	verify.Equals(t, map[string]int{"grpc-status": 2}, unit.Statistic.ValidationFailures)
+	verify.Equals(t, map[string]int{"HTTP/2.0": unit.Statistic.NewConnections}, unit.Statistic.Protocols)
+}
+
+func TestReflectGRPC(t *testing.T) {
+	// arrange
+	var symbol string
+	server := grpcServer(map[string]http.HandlerFunc{
+		// only the deprecated version is supported
+		grpcReflectionPaths[1]: func(w http.ResponseWriter, r *http.Request) {
+			request, err := readGRPCFrame(r.Body)
+			verify.Ok(t, err)
+			verify.Ok(t, readProto(request, func(number, _ int, _ uint64, b []byte) error {
+				if number == 4 {
+					symbol = string(b)
+				}
+				return nil
+			}))
+			writeGRPC(w, "0", appendBytesField(nil, 4, appendBytesField(nil, 1, testDescriptor())))
+		},
+	})
+	defer server.Close()
+	// action
+	method, err := ReflectGRPC(context.Background(), server.Client(), server.URL, "test.Pinger/Watch")
+	_, unknown := ReflectGRPC(context.Background(), server.Client(), server.URL, "test.Pinger/Unknown")
+	// verify
+	verify.Ok(t, err)
+	verify.Equals(t, "test.Pinger", symbol)
+	verify.Equals(t, "/test.Pinger/Watch", method.Path)
+	verify.Assert(t, method.ServerStreaming, "server streaming not detected")
+	verify.Assert(t, unknown != nil, "unknown method not rejected")
+}
+
+func TestReadGRPCFrame(t *testing.T) {
+	// arrange
+	r := bytes.NewReader(append(grpcFrame([]byte("hello")), 0, 0, 0, 0))
+	// action
+	message, err := readGRPCFrame(r)
+	_, errEnd := readGRPCFrame(r)
+	// verify
+	verify.Ok(t, err)
+	verify.Equals(t, "hello", string(message))
+	verify.Equals(t, io.ErrUnexpectedEOF, errEnd)
+}
+
+func TestReadGRPCFrame_tooLarge(t *testing.T) {
+	// arrange
+	r := bytes.NewReader([]byte{0, 0xff, 0xff, 0xff, 0xff})
+	// action
+	_, err := readGRPCFrame(r)
+	// verify
+	verify.Assert(t, err != nil, "too large message not rejected")
+	verify.Assert(t, strings.Contains(err.Error(), "exceeds the limit"), "unexpected error: %v", err)
+}
@@ -0,0 +1,153 @@
+// SPDX-FileCopyrightText: 2021 Eric Neidhardt
+// SPDX-License-Identifier: MIT
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptrace"
+	"time"
+)
+
+// GRPCStream benchmarks a streaming method of a gRPC service. Each operation is a call, which sends Messages
+// and receives the messages of the response until the server ends the stream. The received messages are
+// counted as Statistic.MessageCount while they arrive, the time until the headers of the response as
+// PhaseStreamSetup and until the first message as PhaseFirstMessage. Calls ending with another grpc-status
+// than 0 are counted as failed validations of kind grpc-status, see GRPCStatus.
+type GRPCStream struct {
+	// URL of the method, e.g. http://localhost:50051/helloworld.Greeter/SayHelloStream.
+	URL string
+	// Header sent in addition.
+	Header http.Header
+	// Framed messages sent by each call, e.g. by GRPCMethod.Encode. Bidi-streaming methods receive them
+	// at once, while the messages of the response are received.
+	Messages []byte
+	// HTTPClient sends the calls, it has to support HTTP/2, e.g. with ProtocolH2C for http URLs.
+	HTTPClient *http.Client
+	// Timeout until the headers of the response, and between its messages, are received, 0 for no timeout.
+	Timeout time.Duration
+	// RateLimit is the maximum number of calls per second, unlimited if <= 0.
+	RateLimit float64
+
+	Recorder
+
+	limiter rateLimiter
+}
+
+// Perform performs a call and receives its messages.
+func (g *GRPCStream) Perform(ctx context.Context) (Result, bool) {
+	g.limiter.wait(ctx, g.RateLimit)
+	if ctx.Err() != nil {
+		return Result{}, false
+	}
+	runCtx := ctx
+	ctx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+	var idle *time.Timer
+	if g.Timeout > 0 {
+		idle = time.AfterFunc(g.Timeout, func() {
+			cancel(context.DeadlineExceeded)
+		})
+		defer idle.Stop()
+	}
+	result := Result{
+		Request:      Request{URL: g.URL},
+		Start:        time.Now(),
+		BytesWritten: int64(len(g.Messages)),
+		Phases:       make(map[string]time.Duration),
+	}
+	phases, trace := newPhaseTrace(result.Start)
+	resp, err := g.call(httptrace.WithClientTrace(ctx, trace), idle, &result)
+	result.Latency = time.Since(result.Start)
+	switch {
+	case resp == nil && RunDone(runCtx):
+		result.Class, result.Err = ClassInterrupted, err
+	case resp == nil:
+		if cause := context.Cause(ctx); cause != nil {
+			// the call was canceled by the idle timeout
+			err = cause
+		}
+		result.Class, result.Err = ClassifyError(err), err
+	case RunDone(runCtx) && err != nil:
+		result.Class, result.Err = ClassInterrupted, err
+	default:
+		result.Class, result.Err = classifyCall(resp, err), err
+		g.Update(func(s *Statistic) {
+			phases.record(s, resp.Proto, time.Now())
+			if result.Class == ClassValidation {
+				if s.ValidationFailures == nil {
+					s.ValidationFailures = make(map[string]int)
+				}
+				s.ValidationFailures["grpc-status"]++
+			}
+		})
+	}
+	g.Record(result)
+	return result, true
+}
+
+// classifyCall returns the class of a call, which received a response and ended with err.
+func classifyCall(resp *http.Response, err error) string {
+	switch {
+	case err == nil:
+		return ClassSuccess
+	case resp.StatusCode != http.StatusOK:
+		return ClassStatus
+	default:
+		return ClassValidation
+	}
+}
+
+// call sends the messages and receives the messages of the response. The response is returned,
+// if it was received, even if the call failed afterwards.
+func (g *GRPCStream) call(ctx context.Context, idle *time.Timer, result *Result) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, g.URL, http.NoBody)
+	if err != nil {
+		return nil, err
+	}
+	for key, values := range g.Header {
+		req.Header[key] = values
+	}
+	setGRPCBody(req, g.Messages)
+	httpClient := g.HTTPClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	result.StatusCode = resp.StatusCode
+	result.Phases[PhaseStreamSetup] = time.Since(result.Start)
+	if resp.StatusCode != http.StatusOK {
+		n, _ := io.Copy(io.Discard, resp.Body)
+		result.BytesRead = n
+		return resp, fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	for {
+		message, err := readGRPCFrame(resp.Body)
+		if errors.Is(err, io.EOF) {
+			break
+		} else if err != nil {
+			if cause := context.Cause(ctx); cause != nil {
+				err = cause
+			}
+			return nil, err
+		}
+		if idle != nil {
+			idle.Reset(g.Timeout)
+		}
+		if _, ok := result.Phases[PhaseFirstMessage]; !ok {
+			result.Phases[PhaseFirstMessage] = time.Since(result.Start)
+		}
+		result.BytesRead += int64(5 + len(message))
+		g.Update(func(s *Statistic) {
+			s.MessageCount++
+		})
+	}
+	return resp, grpcStatus(resp)
+}
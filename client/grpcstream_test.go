@@ -0,0 +1,116 @@
+// SPDX-FileCopyrightText: 2021 Eric Neidhardt
+// SPDX-License-Identifier: MIT
+package client
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/EricNeid/go-bench/internal/verify"
+)
+
+func TestGRPCStream_serverStreaming(t *testing.T) {
+	// arrange
+	server := grpcServer(map[string]http.HandlerFunc{
+		"/test.Pinger/Watch": func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", ContentTypeGRPC)
+			for i := 0; i < 3; i++ {
+				w.Write(grpcFrame([]byte("pong")))
+				w.(http.Flusher).Flush()
+			}
+			w.Header().Set(http.TrailerPrefix+"Grpc-Status", "0")
+		},
+	})
+	defer server.Close()
+	unit := &GRPCStream{URL: server.URL + "/test.Pinger/Watch", Messages: grpcFrame([]byte("ping")), HTTPClient: server.Client()}
+	// action
+	RunForAmount(context.Background(), 2, unit)
+	// verify
+	s := unit.Snapshot()
+	verify.Equals(t, 2, s.SuccessCount)
+	verify.Equals(t, 6, s.MessageCount)
+	verify.Equals(t, int64(2*3*9), s.ReadThroughput)
+	verify.Equals(t, 2, s.Phases[PhaseStreamSetup].Count)
+	verify.Equals(t, 2, s.Phases[PhaseFirstMessage].Count)
+	verify.Equals(t, 1, s.NewConnections)
+	verify.Equals(t, 1, s.ReusedConnections)
+}
+
+func TestGRPCStream_bidiStreaming(t *testing.T) {
+	// arrange
+	server := grpcServer(map[string]http.HandlerFunc{
+		"/test.Pinger/Chat": func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", ContentTypeGRPC)
+			for {
+				message, err := readGRPCFrame(r.Body)
+				if err != nil {
+					break
+				}
+				w.Write(grpcFrame(message))
+				w.(http.Flusher).Flush()
+			}
+			w.Header().Set(http.TrailerPrefix+"Grpc-Status", "0")
+		},
+	})
+	defer server.Close()
+	messages := append(grpcFrame([]byte("a")), grpcFrame([]byte("b"))...)
+	unit := &GRPCStream{URL: server.URL + "/test.Pinger/Chat", Messages: messages, HTTPClient: server.Client()}
+	// action
+	result, ok := unit.Perform(context.Background())
+	// verify
+	verify.Assert(t, ok, "call should be performed")
+	verify.Ok(t, result.Err)
+	verify.Equals(t, 2, unit.Snapshot().MessageCount)
+	verify.Equals(t, int64(len(messages)), result.BytesRead)
+}
+
+func TestGRPCStream_failures(t *testing.T) {
+	// arrange
+	server := grpcServer(map[string]http.HandlerFunc{
+		"/test.Pinger/Fail": func(w http.ResponseWriter, r *http.Request) {
+			writeGRPC(w, "5", []byte("pong"))
+		},
+		"/test.Pinger/Idle": func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", ContentTypeGRPC)
+			w.Write(grpcFrame([]byte("pong")))
+			w.(http.Flusher).Flush()
+			<-r.Context().Done()
+		},
+	})
+	defer server.Close()
+	failing := &GRPCStream{URL: server.URL + "/test.Pinger/Fail", HTTPClient: server.Client()}
+	idle := &GRPCStream{URL: server.URL + "/test.Pinger/Idle", HTTPClient: server.Client(), Timeout: 50 * time.Millisecond}
+	// action
+	failed, _ := failing.Perform(context.Background())
+	timedOut, _ := idle.Perform(context.Background())
+	// verify
+	verify.Equals(t, ClassValidation, failed.Class)
+	verify.Equals(t, map[string]int{"grpc-status": 1}, failing.Snapshot().ValidationFailures)
+	verify.Equals(t, FailureTimeout, timedOut.Class)
+	verify.Assert(t, errors.Is(timedOut.Err, context.DeadlineExceeded), "unexpected error %v", timedOut.Err)
+	verify.Equals(t, 1, idle.Snapshot().MessageCount)
+}
+
+func TestGRPCStream_interrupted(t *testing.T) {
+	// arrange
+	server := grpcServer(map[string]http.HandlerFunc{
+		"/test.Pinger/Watch": func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", ContentTypeGRPC)
+			w.(http.Flusher).Flush()
+			io.Copy(io.Discard, r.Body)
+			<-r.Context().Done()
+		},
+	})
+	defer server.Close()
+	unit := &GRPCStream{URL: server.URL + "/test.Pinger/Watch", HTTPClient: server.Client()}
+	// action
+	RunForDuration(context.Background(), 50*time.Millisecond, unit)
+	// verify
+	s := unit.Snapshot()
+	verify.Equals(t, 0, s.RequestCount)
+	verify.Equals(t, 1, s.InterruptedCount)
+}
@@ -0,0 +1,106 @@
+// SPDX-FileCopyrightText: 2021 Eric Neidhardt
+// SPDX-License-Identifier: MIT
+package client
+
+import (
+	"math"
+	"sort"
+	"time"
+)
+
+// histogramGrowth is the factor, by which the bounds of subsequent buckets of a DurationHistogram grow,
+// so that percentiles are accurate to about 1%.
+const histogramGrowth = 1.01
+
+// DurationHistogram records durations in exponentially growing buckets, so that percentiles can be computed
+// without keeping every duration and histograms of several clients can be merged.
+type DurationHistogram struct {
+	Count int
+	Min   time.Duration
+	Max   time.Duration
+	// Number of durations per bucket, see histogramBucket.
+	Buckets map[int]int
+}
+
+// histogramBucket returns the bucket of a duration, buckets are based on microseconds.
+func histogramBucket(d time.Duration) int {
+	return int(math.Log1p(float64(d.Microseconds())) / math.Log(histogramGrowth))
+}
+
+// histogramValue returns the middle of a bucket.
+func histogramValue(bucket int) time.Duration {
+	lower := math.Expm1(float64(bucket) * math.Log(histogramGrowth))
+	upper := math.Expm1(float64(bucket+1) * math.Log(histogramGrowth))
+	return time.Duration((lower + upper) / 2 * float64(time.Microsecond))
+}
+
+// Add records a duration.
+func (h *DurationHistogram) Add(d time.Duration) {
+	if h.Count == 0 || d < h.Min {
+		h.Min = d
+	}
+	if d > h.Max {
+		h.Max = d
+	}
+	h.Count++
+	if h.Buckets == nil {
+		h.Buckets = make(map[int]int)
+	}
+	h.Buckets[histogramBucket(d)]++
+}
+
+// Merge adds the durations recorded by other.
+func (h *DurationHistogram) Merge(other DurationHistogram) {
+	if other.Count == 0 {
+		return
+	}
+	if h.Count == 0 || other.Min < h.Min {
+		h.Min = other.Min
+	}
+	if other.Max > h.Max {
+		h.Max = other.Max
+	}
+	h.Count += other.Count
+	if h.Buckets == nil {
+		h.Buckets = make(map[int]int)
+	}
+	for bucket, n := range other.Buckets {
+		h.Buckets[bucket] += n
+	}
+}
+
+// Percentile returns the duration, which is not exceeded by the given percentage of the durations, e.g. 99.
+func (h DurationHistogram) Percentile(p float64) time.Duration {
+	if h.Count == 0 {
+		return 0
+	}
+	buckets := make([]int, 0, len(h.Buckets))
+	for bucket := range h.Buckets {
+		buckets = append(buckets, bucket)
+	}
+	sort.Ints(buckets)
+	// the exact bounds are known
+	rank := int(math.Ceil(p / 100 * float64(h.Count)))
+	if rank <= 1 {
+		return h.Min
+	}
+	if rank >= h.Count {
+		return h.Max
+	}
+	seen := 0
+	value := h.Max
+	for _, bucket := range buckets {
+		seen += h.Buckets[bucket]
+		if seen >= rank {
+			value = histogramValue(bucket)
+			break
+		}
+	}
+	if value < h.Min {
+		return h.Min
+	}
+	if value > h.Max {
+		return h.Max
+	}
+	return value
+}
@@ -0,0 +1,49 @@
+// SPDX-FileCopyrightText: 2021 Eric Neidhardt
+// SPDX-License-Identifier: MIT
+package client
+
+import (
+	"testing"
+	"time"
+
+	"github.com/EricNeid/go-bench/internal/verify"
+)
+
+func TestDurationHistogram_Percentile(t *testing.T) {
+	// arrange
+	var unit DurationHistogram
+	for i := 1; i <= 1000; i++ {
+		unit.Add(time.Duration(i) * time.Millisecond)
+	}
+	// verify
+	verify.Equals(t, 1000, unit.Count)
+	verify.Equals(t, time.Millisecond, unit.Percentile(0))
+	verify.Equals(t, time.Second, unit.Percentile(100))
+	for _, p := range []float64{50, 90, 99} {
+		expected := time.Duration(p*10) * time.Millisecond
+		actual := unit.Percentile(p)
+		verify.Assert(t, actual > expected*99/100 && actual < expected*101/100, "Unexpected p%v %s", p, actual)
+	}
+}
+
+func TestDurationHistogram_Merge(t *testing.T) {
+	// arrange
+	var unit, other DurationHistogram
+	unit.Add(10 * time.Millisecond)
+	other.Add(time.Millisecond)
+	other.Add(2 * time.Second)
+	// action
+	unit.Merge(other)
+	unit.Merge(DurationHistogram{})
+	// verify
+	verify.Equals(t, 3, unit.Count)
+	verify.Equals(t, time.Millisecond, unit.Min)
+	verify.Equals(t, 2*time.Second, unit.Max)
+	actual := unit.Percentile(50)
+	verify.Assert(t, actual > 9900*time.Microsecond && actual < 10100*time.Microsecond, "Unexpected median %s", actual)
+}
+
+func TestDurationHistogram_empty(t *testing.T) {
+	var unit DurationHistogram
+	verify.Equals(t, time.Duration(0), unit.Percentile(99))
+}
@@ -0,0 +1,191 @@
+// SPDX-FileCopyrightText: 2021 Eric Neidhardt
+// SPDX-License-Identifier: MIT
+package client
+
+import (
+	"context"
+	"crypto/tls"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptrace"
+	"strings"
+	"sync"
+
+	"github.com/quic-go/quic-go"
+	"github.com/quic-go/quic-go/http3"
+)
+
+// NewHTTP3Transport returns a round tripper, which sends requests with HTTP/3 over QUIC.
+// The QUIC handshakes are measured as PhaseTLS and PhaseTLSResumed, as they include the TLS handshakes.
+// Requests without keep-alive are sent over a QUIC connection of their own, which resumes the TLS session
+// of previous connections. If zeroRTT is set, GET and HEAD requests are sent as 0-RTT early data
+// of resumed connections. The tls config may be nil, sessions are not resumed if it disables session tickets.
+func NewHTTP3Transport(tlsConfig *tls.Config, zeroRTT bool) http.RoundTripper {
+	if tlsConfig == nil {
+		tlsConfig = &tls.Config{}
+	} else {
+		tlsConfig = tlsConfig.Clone()
+	}
+	if tlsConfig.ClientSessionCache == nil && !tlsConfig.SessionTicketsDisabled {
+		tlsConfig.ClientSessionCache = tls.NewLRUClientSessionCache(0)
+	}
+	dialer := &quicDialer{}
+	return &http3Transport{
+		tlsConfig: tlsConfig,
+		zeroRTT:   zeroRTT,
+		shared:    &http3.Transport{TLSClientConfig: tlsConfig, Dial: dialer.dial},
+	}
+}
+
+type http3Transport struct {
+	tlsConfig *tls.Config
+	zeroRTT   bool
+	shared    *http3.Transport
+}
+
+func (t *http3Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	method := req.Method
+	if t.zeroRTT && method == http.MethodGet {
+		method = http3.MethodGet0RTT
+	} else if t.zeroRTT && method == http.MethodHead {
+		method = http3.MethodHead0RTT
+	}
+	handshake := &pendingHandshake{}
+	// a round tripper must not modify the given request
+	req = req.Clone(context.WithValue(req.Context(), pendingHandshakeKey{}, handshake))
+	req.Method = method
+
+	transport, dialer := t.shared, (*quicDialer)(nil)
+	if strings.EqualFold(req.Header.Get("Connection"), "close") {
+		dialer = &quicDialer{}
+		transport = &http3.Transport{TLSClientConfig: t.tlsConfig, Dial: dialer.dial}
+	}
+	resp, err := transport.RoundTrip(req)
+	if err == nil {
+		err = handshake.wait(req.Context())
+	}
+	if dialer == nil {
+		return resp, err
+	}
+	if err != nil {
+		if resp != nil {
+			resp.Body.Close()
+		}
+		transport.Close()
+		dialer.Close()
+		return nil, err
+	}
+	resp.Body = &closingBody{ReadCloser: resp.Body, closers: []io.Closer{transport, dialer}}
+	return resp, nil
+}
+
+// pendingHandshakeKey is the context key of the pendingHandshake of a request.
+type pendingHandshakeKey struct{}
+
+// pendingHandshake is the handshake of a connection dialed for a request, which has not been completed
+// when the request was sent as 0-RTT early data.
+type pendingHandshake struct {
+	mu    sync.Mutex
+	conn  *quic.Conn
+	trace *httptrace.ClientTrace
+}
+
+// wait waits until the handshake is completed and reports it to the trace.
+func (h *pendingHandshake) wait(ctx context.Context) error {
+	h.mu.Lock()
+	conn, trace := h.conn, h.trace
+	h.mu.Unlock()
+	if conn == nil {
+		return nil
+	}
+	select {
+	case <-conn.HandshakeComplete():
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	if trace != nil && trace.TLSHandshakeDone != nil {
+		trace.TLSHandshakeDone(conn.ConnectionState().TLS, nil)
+	}
+	return nil
+}
+
+// quicDialer dials QUIC connections over a single UDP socket, which is created by the first dial.
+type quicDialer struct {
+	mu        sync.Mutex
+	transport *quic.Transport
+}
+
+func (d *quicDialer) dial(ctx context.Context, address string, tlsConfig *tls.Config, config *quic.Config) (*quic.Conn, error) {
+	d.mu.Lock()
+	if d.transport == nil {
+		conn, err := net.ListenUDP("udp", nil)
+		if err != nil {
+			d.mu.Unlock()
+			return nil, err
+		}
+		d.transport = &quic.Transport{Conn: conn}
+	}
+	transport := d.transport
+	d.mu.Unlock()
+
+	host, port, err := net.SplitHostPort(address)
+	if err != nil {
+		return nil, err
+	}
+	udpPort, err := net.DefaultResolver.LookupPort(ctx, "udp", port)
+	if err != nil {
+		return nil, err
+	}
+	ips, err := net.DefaultResolver.LookupIPAddr(ctx, host)
+	if err != nil {
+		return nil, err
+	}
+	addr := &net.UDPAddr{IP: ips[0].IP, Port: udpPort, Zone: ips[0].Zone}
+
+	// there is no connect phase, the connection is established by the handshake
+	trace := httptrace.ContextClientTrace(ctx)
+	if trace != nil && trace.TLSHandshakeStart != nil {
+		trace.TLSHandshakeStart()
+	}
+	conn, err := transport.DialEarly(ctx, addr, tlsConfig, config)
+	if err != nil {
+		if trace != nil && trace.TLSHandshakeDone != nil {
+			trace.TLSHandshakeDone(tls.ConnectionState{}, err)
+		}
+		return nil, err
+	}
+	// the state of the handshake, e.g. whether it resumed a session, is only known when it is completed,
+	// which happens after early data is sent
+	if handshake, ok := ctx.Value(pendingHandshakeKey{}).(*pendingHandshake); ok {
+		handshake.mu.Lock()
+		handshake.conn, handshake.trace = conn, trace
+		handshake.mu.Unlock()
+	}
+	return conn, nil
+}
+
+func (d *quicDialer) Close() error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.transport == nil {
+		return nil
+	}
+	err := d.transport.Close()
+	d.transport.Conn.Close()
+	return err
+}
+
+// closingBody closes the connections of a response together with its body.
+type closingBody struct {
+	io.ReadCloser
+	closers []io.Closer
+}
+
+func (b *closingBody) Close() error {
+	err := b.ReadCloser.Close()
+	for _, c := range b.closers {
+		c.Close()
+	}
+	return err
+}
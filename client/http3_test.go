@@ -0,0 +1,86 @@
+// SPDX-FileCopyrightText: 2021 Eric Neidhardt
+// SPDX-License-Identifier: MIT
+package client
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/quic-go/quic-go/http3"
+
+	"github.com/EricNeid/go-bench/internal/verify"
+)
+
+// newHTTP3Server starts a HTTP/3 server and returns its URL and the tls config of clients trusting it.
+func newHTTP3Server(t *testing.T, handler http.Handler) (string, *tls.Config) {
+	// the certificate of httptest is valid for 127.0.0.1
+	certServer := httptest.NewTLSServer(handler)
+	certServer.Close()
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	verify.Ok(t, err)
+	server := &http3.Server{
+		Handler:   handler,
+		TLSConfig: http3.ConfigureTLSConfig(&tls.Config{Certificates: certServer.TLS.Certificates}),
+	}
+	go server.Serve(conn) //nolint:errcheck // fails when closed by the test
+	t.Cleanup(func() {
+		server.Close()
+		conn.Close()
+	})
+	tlsConfig := certServer.Client().Transport.(*http.Transport).TLSClientConfig
+	return fmt.Sprintf("https://%s", conn.LocalAddr()), tlsConfig
+}
+
+func TestNewHTTP3Transport(t *testing.T) {
+	// arrange
+	var mu sync.Mutex
+	var proto string
+	url, tlsConfig := newHTTP3Server(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		proto = r.Proto
+		mu.Unlock()
+		w.Write([]byte("test response"))
+	}))
+	unit := NewClient(Request{URL: url, KeepAlive: true})
+	unit.HTTPClient.Transport = NewHTTP3Transport(tlsConfig, false)
+	// action
+	unit.RunForAmount(2)
+	// verify
+	verify.Equals(t, 2, unit.Statistic.SuccessCount)
+	mu.Lock()
+	verify.Equals(t, "HTTP/3.0", proto)
+	mu.Unlock()
+	verify.Equals(t, map[string]int{"HTTP/3.0": 1}, unit.Statistic.Protocols)
+	verify.Equals(t, 1, unit.Statistic.ReusedConnections)
+	verify.Equals(t, 1, unit.Statistic.Phases[PhaseTLS].Count)
+	verify.Equals(t, 0, unit.Statistic.Phases[PhaseConnect].Count)
+	verify.Equals(t, int64(26), unit.Statistic.ReadThroughput)
+}
+
+func TestNewHTTP3Transport_zeroRTT(t *testing.T) {
+	// arrange
+	var mu sync.Mutex
+	var early []bool
+	url, tlsConfig := newHTTP3Server(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		early = append(early, r.TLS != nil && !r.TLS.HandshakeComplete)
+		mu.Unlock()
+	}))
+	unit := NewClient(Request{URL: url})
+	unit.HTTPClient.Transport = NewHTTP3Transport(tlsConfig, true)
+	// action
+	unit.RunForAmount(3)
+	// verify
+	verify.Equals(t, 3, unit.Statistic.SuccessCount)
+	verify.Equals(t, 3, unit.Statistic.NewConnections)
+	verify.Equals(t, 1, unit.Statistic.Phases[PhaseTLS].Count)
+	verify.Equals(t, 2, unit.Statistic.Phases[PhaseTLSResumed].Count)
+	mu.Lock()
+	defer mu.Unlock()
+	verify.Equals(t, []bool{false, true, true}, early)
+}
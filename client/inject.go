@@ -0,0 +1,74 @@
+// SPDX-FileCopyrightText: 2021 Eric Neidhardt
+// SPDX-License-Identifier: MIT
+package client
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"log"
+	"strconv"
+	"sync/atomic"
+)
+
+// Supported values of Injector.
+const (
+	InjectSeq    = "seq"
+	InjectRandom = "random"
+	InjectUUID   = "uuid"
+)
+
+// Injector makes bodies unique per request by replacing a marker with a sequence number,
+// a random suffix or a UUID. Unlike Template it does not parse the body, so it is suited for large bodies
+// and bodies, which contain braces of their own. An Injector is safe for concurrent use.
+type Injector struct {
+	Marker string
+	// Mode of the injected values: seq, random or uuid.
+	Mode string
+
+	seq uint64
+}
+
+// Inject returns the body with every occurrence of the marker replaced by a new value.
+func (in *Injector) Inject(body []byte) ([]byte, error) {
+	marker := []byte(in.Marker)
+	if len(marker) == 0 || !bytes.Contains(body, marker) {
+		return body, nil
+	}
+	var value string
+	switch in.Mode {
+	case InjectRandom:
+		var b [8]byte
+		if _, err := rand.Read(b[:]); err != nil {
+			return body, err
+		}
+		value = hex.EncodeToString(b[:])
+	case InjectUUID:
+		var err error
+		if value, err = newUUID(); err != nil {
+			return body, err
+		}
+	default:
+		value = strconv.FormatUint(atomic.AddUint64(&in.seq, 1), 10)
+	}
+	return bytes.ReplaceAll(body, marker, []byte(value)), nil
+}
+
+// Next returns a function for Client.NextRequest, which injects values into the bodies of the requests
+// returned by next. Requests, whose body cannot be changed, are logged and returned as is.
+func (in *Injector) Next(next func(ctx context.Context) (Request, bool)) func(ctx context.Context) (Request, bool) {
+	return func(ctx context.Context) (Request, bool) {
+		request, ok := next(ctx)
+		if !ok {
+			return request, false
+		}
+		body, err := in.Inject(request.PostBody)
+		if err != nil {
+			log.Printf("Could not inject into body: %s", err)
+			return request, true
+		}
+		request.PostBody = body
+		return request, true
+	}
+}
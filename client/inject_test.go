@@ -0,0 +1,52 @@
+// SPDX-FileCopyrightText: 2021 Eric Neidhardt
+// SPDX-License-Identifier: MIT
+package client
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/EricNeid/go-bench/internal/verify"
+)
+
+func TestInjector(t *testing.T) {
+	// arrange
+	var bodies []string
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		bodies = append(bodies, string(body))
+	}))
+	defer mockServer.Close()
+	injector := &Injector{Marker: "__ID__"}
+	requests := []Request{{URL: mockServer.URL, PostBody: []byte(`{"id":"__ID__","ref":"__ID__"}`)}}
+	first := Client{NextRequest: injector.Next(Sequential(requests, 0))}
+	second := Client{NextRequest: injector.Next(Sequential(requests, 0))}
+	// action
+	RunInterleavedForAmount(1, &first, &second)
+	first.PerformRequest()
+	// verify
+	verify.Equals(t, []string{`{"id":"1","ref":"1"}`, `{"id":"2","ref":"2"}`, `{"id":"3","ref":"3"}`}, bodies)
+	verify.Equals(t, `{"id":"__ID__","ref":"__ID__"}`, string(requests[0].PostBody))
+}
+
+func TestInjector_Inject(t *testing.T) {
+	// arrange
+	random := &Injector{Marker: "-X", Mode: InjectRandom}
+	uuid := &Injector{Marker: "-X", Mode: InjectUUID}
+	// action
+	first, err1 := random.Inject([]byte("name-X"))
+	second, err2 := random.Inject([]byte("name-X"))
+	id, err3 := uuid.Inject([]byte("-X"))
+	unchanged, err4 := random.Inject([]byte("name"))
+	// verify
+	verify.Ok(t, err1)
+	verify.Ok(t, err2)
+	verify.Ok(t, err3)
+	verify.Ok(t, err4)
+	verify.Equals(t, len("name")+16, len(first))
+	verify.Assert(t, string(first) != string(second), "Random suffix is not unique")
+	verify.Equals(t, 36, len(id))
+	verify.Equals(t, "name", string(unchanged))
+}
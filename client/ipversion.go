@@ -0,0 +1,57 @@
+// SPDX-FileCopyrightText: 2021 Eric Neidhardt
+// SPDX-License-Identifier: MIT
+package client
+
+import (
+	"context"
+	"net"
+	"strings"
+)
+
+// IP families of connections, see Statistic.IPFamilies.
+const (
+	IPv4 = "IPv4"
+	IPv6 = "IPv6"
+)
+
+// IPVersion returns a function for http.Transport.DialContext, which dials only addresses of IP version 4 or 6
+// with dial, e.g. to benchmark a dual-stack target per family. If dial is nil, a net.Dialer is used.
+// If the connections are made through a SOCKS5 proxy dialed with dial, the version applies to the proxy.
+func IPVersion(
+	version int,
+	dial func(ctx context.Context, network, address string) (net.Conn, error),
+) func(ctx context.Context, network, address string) (net.Conn, error) {
+	if dial == nil {
+		dial = (&net.Dialer{}).DialContext
+	}
+	suffix := "4"
+	if version == 6 {
+		suffix = "6"
+	}
+	return func(ctx context.Context, network, address string) (net.Conn, error) {
+		if network == "tcp" || network == "udp" {
+			network += suffix
+		}
+		return dial(ctx, network, address)
+	}
+}
+
+// ipFamily returns the IP family of the address of a connection, empty if it is no IP address.
+func ipFamily(addr net.Addr) string {
+	if addr == nil {
+		return ""
+	}
+	host, _, err := net.SplitHostPort(addr.String())
+	if err != nil {
+		host = addr.String()
+	}
+	ip := net.ParseIP(strings.TrimSuffix(strings.TrimPrefix(host, "["), "]"))
+	switch {
+	case ip == nil:
+		return ""
+	case ip.To4() != nil:
+		return IPv4
+	default:
+		return IPv6
+	}
+}
@@ -0,0 +1,35 @@
+// SPDX-FileCopyrightText: 2021 Eric Neidhardt
+// SPDX-License-Identifier: MIT
+package client
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/EricNeid/go-bench/internal/verify"
+)
+
+func TestIPVersion(t *testing.T) {
+	// arrange
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer mockServer.Close()
+	newClient := func(version int) *Client {
+		transport := http.DefaultTransport.(*http.Transport).Clone()
+		transport.DialContext = IPVersion(version, nil)
+		c := NewClient(Request{URL: strings.Replace(mockServer.URL, "127.0.0.1", "localhost", 1)})
+		c.HTTPClient.Transport = transport
+		return c
+	}
+	ipv4 := newClient(4)
+	ipv6 := newClient(6)
+	// action
+	ipv4.PerformRequest()
+	ipv6.PerformRequest()
+	// verify
+	verify.Equals(t, 1, ipv4.Statistic.SuccessCount)
+	verify.Equals(t, map[string]int{IPv4: 1}, ipv4.Statistic.IPFamilies)
+	// the server listens on 127.0.0.1 only
+	verify.Equals(t, 1, ipv6.Statistic.NetworkFailedCount)
+}
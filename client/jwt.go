@@ -0,0 +1,182 @@
+// SPDX-FileCopyrightText: 2021 Eric Neidhardt
+// SPDX-License-Identifier: MIT
+package client
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+)
+
+// JWT signs JSON web tokens, which are sent as bearer tokens.
+// The algorithm is given by the key: HS256 for secrets, RS256 for RSA and ES256 for P-256 ECDSA keys.
+type JWT struct {
+	// Algorithm of the signature.
+	Algorithm string
+	// Claims as template, see Template, which has to render a JSON object.
+	// If missing, iat and exp are added to the claims.
+	Claims string
+	// Lifetime of the tokens.
+	TTL      time.Duration
+	Template *Template
+
+	key interface{}
+}
+
+// NewJWT creates a signer for the given key, which is either a PEM encoded RSA or ECDSA private key
+// or a secret for HMAC. Trailing line breaks of secrets are ignored.
+func NewJWT(key []byte, claims string, ttl time.Duration, template *Template) (*JWT, error) {
+	j := &JWT{Claims: claims, TTL: ttl, Template: template}
+	block, _ := pem.Decode(key)
+	if block == nil {
+		// secret files usually end with a line break, which is not part of the secret
+		key = bytes.TrimRight(key, "\r\n")
+		if len(key) == 0 {
+			return nil, errors.New("empty jwt secret")
+		}
+		j.Algorithm, j.key = "HS256", key
+		return j, nil
+	}
+
+	var parsed interface{}
+	var err error
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		parsed, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "EC PRIVATE KEY":
+		parsed, err = x509.ParseECPrivateKey(block.Bytes)
+	case "PRIVATE KEY":
+		parsed, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	default:
+		return nil, fmt.Errorf("unsupported key type %s", block.Type)
+	}
+	if err != nil {
+		return nil, err
+	}
+	switch k := parsed.(type) {
+	case *rsa.PrivateKey:
+		j.Algorithm = "RS256"
+	case *ecdsa.PrivateKey:
+		if k.Curve != elliptic.P256() {
+			return nil, errors.New("only ECDSA keys with curve P-256 are supported")
+		}
+		j.Algorithm = "ES256"
+	default:
+		return nil, fmt.Errorf("unsupported key %T", parsed)
+	}
+	j.key = parsed
+	return j, nil
+}
+
+// Sign returns a new token, whose claims are rendered with the given variables.
+func (j *JWT) Sign(vars map[string]string) (string, error) {
+	claims := make(map[string]interface{})
+	if j.Claims != "" {
+		rendered, err := j.Template.render(j.Claims, vars)
+		if err != nil {
+			return "", err
+		}
+		if err := json.Unmarshal([]byte(rendered), &claims); err != nil {
+			return "", fmt.Errorf("invalid claims: %w", err)
+		}
+	}
+	now := time.Now()
+	if _, ok := claims["iat"]; !ok {
+		claims["iat"] = now.Unix()
+	}
+	if _, ok := claims["exp"]; !ok && j.TTL > 0 {
+		claims["exp"] = now.Add(j.TTL).Unix()
+	}
+
+	header, err := json.Marshal(map[string]string{"alg": j.Algorithm, "typ": "JWT"})
+	if err != nil {
+		return "", err
+	}
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		return "", err
+	}
+	unsigned := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+	signature, err := j.sign([]byte(unsigned))
+	if err != nil {
+		return "", err
+	}
+	return unsigned + "." + base64.RawURLEncoding.EncodeToString(signature), nil
+}
+
+func (j *JWT) sign(data []byte) ([]byte, error) {
+	hash := sha256.Sum256(data)
+	switch key := j.key.(type) {
+	case []byte:
+		mac := hmac.New(sha256.New, key)
+		mac.Write(data)
+		return mac.Sum(nil), nil
+	case *rsa.PrivateKey:
+		return rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, hash[:])
+	case *ecdsa.PrivateKey:
+		r, s, err := ecdsa.Sign(rand.Reader, key, hash[:])
+		if err != nil {
+			return nil, err
+		}
+		// JWS uses the fixed size concatenation of r and s
+		signature := make([]byte, 64)
+		r.FillBytes(signature[:32])
+		s.FillBytes(signature[32:])
+		return signature, nil
+	default:
+		return nil, fmt.Errorf("unsupported key %T", j.key)
+	}
+}
+
+// Next returns a function for Client.NextRequest, which sets a bearer token on the requests returned by next.
+// If reuse is false, a new token is signed for every request, otherwise a token is used until
+// it is about to expire. The variables of the claims are requested for every token, vars may be nil.
+// Requests, for which no token can be signed, are logged and returned as is.
+func (j *JWT) Next(
+	next func(ctx context.Context) (Request, bool),
+	vars func() map[string]string,
+	reuse bool,
+) func(ctx context.Context) (Request, bool) {
+	var token string
+	var refresh time.Time
+	return func(ctx context.Context) (Request, bool) {
+		request, ok := next(ctx)
+		if !ok {
+			return request, false
+		}
+		if !reuse || token == "" || (j.TTL > 0 && time.Now().After(refresh)) {
+			var v map[string]string
+			if vars != nil {
+				v = vars()
+			}
+			signed, err := j.Sign(v)
+			if err != nil {
+				log.Printf("Could not sign jwt: %s", err)
+				return request, true
+			}
+			token = signed
+			refresh = time.Now().Add(j.TTL - j.TTL/5)
+		}
+		headers := make(map[string]string, len(request.AdditionalHeaders)+1)
+		for k, v := range request.AdditionalHeaders {
+			headers[k] = v
+		}
+		headers["Authorization"] = "Bearer " + token
+		request.AdditionalHeaders = headers
+		return request, true
+	}
+}
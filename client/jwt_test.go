@@ -0,0 +1,118 @@
+// SPDX-FileCopyrightText: 2021 Eric Neidhardt
+// SPDX-License-Identifier: MIT
+package client
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/EricNeid/go-bench/internal/verify"
+)
+
+// decodeJWT returns the signed part, the header, the claims and the signature of the token.
+func decodeJWT(t *testing.T, token string) (string, map[string]interface{}, map[string]interface{}, []byte) {
+	t.Helper()
+	parts := strings.Split(token, ".")
+	verify.Equals(t, 3, len(parts))
+	var header, claims map[string]interface{}
+	data, err := base64.RawURLEncoding.DecodeString(parts[0])
+	verify.Ok(t, err)
+	verify.Ok(t, json.Unmarshal(data, &header))
+	data, err = base64.RawURLEncoding.DecodeString(parts[1])
+	verify.Ok(t, err)
+	verify.Ok(t, json.Unmarshal(data, &claims))
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	verify.Ok(t, err)
+	return parts[0] + "." + parts[1], header, claims, signature
+}
+
+func TestJWTSign_hmac(t *testing.T) {
+	// arrange
+	unit, err := NewJWT([]byte("secret"), `{"sub":"{{.user}}","jti":"{{seq}}"}`, time.Minute, NewTemplate())
+	verify.Ok(t, err)
+	// action
+	token, err := unit.Sign(map[string]string{"user": "max"})
+	// verify
+	verify.Ok(t, err)
+	signed, header, claims, signature := decodeJWT(t, token)
+	verify.Equals(t, "HS256", header["alg"])
+	verify.Equals(t, "max", claims["sub"])
+	verify.Equals(t, "1", claims["jti"])
+	verify.Equals(t, 60.0, claims["exp"].(float64)-claims["iat"].(float64))
+	mac := hmac.New(sha256.New, []byte("secret"))
+	mac.Write([]byte(signed))
+	verify.Equals(t, mac.Sum(nil), signature)
+}
+
+func TestJWTSign_rsa(t *testing.T) {
+	// arrange
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	verify.Ok(t, err)
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+	unit, err := NewJWT(keyPEM, `{"iat":1}`, 0, NewTemplate())
+	verify.Ok(t, err)
+	// action
+	token, err := unit.Sign(nil)
+	// verify
+	verify.Ok(t, err)
+	signed, header, claims, signature := decodeJWT(t, token)
+	verify.Equals(t, "RS256", header["alg"])
+	verify.Equals(t, 1.0, claims["iat"])
+	verify.Assert(t, claims["exp"] == nil, "Expected no exp without ttl")
+	hash := sha256.Sum256([]byte(signed))
+	verify.Ok(t, rsa.VerifyPKCS1v15(&key.PublicKey, crypto.SHA256, hash[:], signature))
+}
+
+func TestJWTSign_ecdsa(t *testing.T) {
+	// arrange
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	verify.Ok(t, err)
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	verify.Ok(t, err)
+	unit, err := NewJWT(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), "", time.Minute, NewTemplate())
+	verify.Ok(t, err)
+	// action
+	token, err := unit.Sign(nil)
+	// verify
+	verify.Ok(t, err)
+	signed, header, _, signature := decodeJWT(t, token)
+	verify.Equals(t, "ES256", header["alg"])
+	hash := sha256.Sum256([]byte(signed))
+	r := new(big.Int).SetBytes(signature[:32])
+	s := new(big.Int).SetBytes(signature[32:])
+	verify.Assert(t, ecdsa.Verify(&key.PublicKey, hash[:], r, s), "Invalid signature")
+}
+
+func TestJWTNext(t *testing.T) {
+	// arrange
+	unit, err := NewJWT([]byte("secret"), `{"jti":"{{seq}}"}`, time.Minute, NewTemplate())
+	verify.Ok(t, err)
+	requests := []Request{{URL: "http://localhost", AdditionalHeaders: map[string]string{"Accept": "text/plain"}}}
+	perRequest := unit.Next(Sequential(requests, 0), nil, false)
+	perClient := unit.Next(Sequential(requests, 0), nil, true)
+	// action
+	first, _ := perRequest(context.Background())
+	second, _ := perRequest(context.Background())
+	third, _ := perClient(context.Background())
+	fourth, _ := perClient(context.Background())
+	// verify
+	verify.Assert(t, strings.HasPrefix(first.AdditionalHeaders["Authorization"], "Bearer "), "Expected bearer token")
+	verify.Equals(t, "text/plain", first.AdditionalHeaders["Accept"])
+	verify.Assert(t, first.AdditionalHeaders["Authorization"] != second.AdditionalHeaders["Authorization"], "Expected token per request")
+	verify.Equals(t, third.AdditionalHeaders["Authorization"], fourth.AdditionalHeaders["Authorization"])
+	verify.Equals(t, "", requests[0].AdditionalHeaders["Authorization"])
+}
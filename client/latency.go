@@ -0,0 +1,48 @@
+// SPDX-FileCopyrightText: 2021 Eric Neidhardt
+// SPDX-License-Identifier: MIT
+package client
+
+import "time"
+
+// Latencies summarizes the latencies of responses.
+type Latencies struct {
+	Count int
+	Total time.Duration
+	Min   time.Duration
+	Max   time.Duration
+}
+
+// Add records the latency of a response.
+func (l *Latencies) Add(latency time.Duration) {
+	if l.Count == 0 || latency < l.Min {
+		l.Min = latency
+	}
+	if latency > l.Max {
+		l.Max = latency
+	}
+	l.Count++
+	l.Total += latency
+}
+
+// Merge adds the latencies recorded by other.
+func (l *Latencies) Merge(other Latencies) {
+	if other.Count == 0 {
+		return
+	}
+	if l.Count == 0 || other.Min < l.Min {
+		l.Min = other.Min
+	}
+	if other.Max > l.Max {
+		l.Max = other.Max
+	}
+	l.Count += other.Count
+	l.Total += other.Total
+}
+
+// Mean returns the mean latency of the responses.
+func (l Latencies) Mean() time.Duration {
+	if l.Count == 0 {
+		return 0
+	}
+	return l.Total / time.Duration(l.Count)
+}
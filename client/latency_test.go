@@ -0,0 +1,49 @@
+// SPDX-FileCopyrightText: 2021 Eric Neidhardt
+// SPDX-License-Identifier: MIT
+package client
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/EricNeid/go-bench/internal/verify"
+)
+
+func TestLatencies(t *testing.T) {
+	// arrange
+	var unit, other Latencies
+	unit.Add(3 * time.Millisecond)
+	unit.Add(time.Millisecond)
+	other.Add(8 * time.Millisecond)
+	// action
+	unit.Merge(other)
+	// verify
+	verify.Equals(t, 3, unit.Count)
+	verify.Equals(t, time.Millisecond, unit.Min)
+	verify.Equals(t, 8*time.Millisecond, unit.Max)
+	verify.Equals(t, 4*time.Millisecond, unit.Mean())
+}
+
+func TestPerformRequest_classLatencies(t *testing.T) {
+	// arrange
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/unavailable" {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		time.Sleep(20 * time.Millisecond)
+	}))
+	defer mockServer.Close()
+	unit := NewClient(Request{})
+	unit.NextRequest = Sequential([]Request{{URL: mockServer.URL}, {URL: mockServer.URL + "/unavailable"}}, 0)
+	// action
+	unit.RunForAmount(4)
+	// verify
+	verify.Equals(t, 2, len(unit.Statistic.ClassLatencies))
+	verify.Equals(t, 2, unit.Statistic.ClassLatencies[2].Count)
+	verify.Equals(t, 2, unit.Statistic.ClassLatencies[5].Count)
+	verify.Assert(t, unit.Statistic.ClassLatencies[2].Min >= 20*time.Millisecond, "Unexpected latency of 2xx %s", unit.Statistic.ClassLatencies[2].Min)
+	verify.Assert(t, unit.Statistic.ClassLatencies[5].Max < unit.Statistic.ClassLatencies[2].Min, "Unexpected latency of 5xx %s", unit.Statistic.ClassLatencies[5].Max)
+}
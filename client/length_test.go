@@ -0,0 +1,71 @@
+// SPDX-FileCopyrightText: 2021 Eric Neidhardt
+// SPDX-License-Identifier: MIT
+package client
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/EricNeid/go-bench/internal/verify"
+)
+
+// newTruncatingServer returns a server, which sends only the first half of the body of /truncated.
+func newTruncatingServer(t *testing.T) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/truncated" {
+			w.Write([]byte("0123456789"))
+			return
+		}
+		conn, buf, err := w.(http.Hijacker).Hijack()
+		verify.Ok(t, err)
+		defer conn.Close()
+		buf.WriteString("HTTP/1.1 200 OK\r\nContent-Length: 10\r\n\r\n01234")
+		buf.Flush()
+	}))
+}
+
+func TestPerformRequest_lengthMismatch(t *testing.T) {
+	// arrange
+	mockServer := newTruncatingServer(t)
+	defer mockServer.Close()
+	var reasons []string
+	unit := NewClient(Request{})
+	unit.NextRequest = Sequential([]Request{{URL: mockServer.URL}, {URL: mockServer.URL + "/truncated"}}, 0)
+	unit.OnFailure = func(request Request, resp *http.Response, body []byte, reason error) {
+		reasons = append(reasons, reason.Error())
+	}
+	// action
+	unit.RunForAmount(2)
+	// verify
+	verify.Equals(t, 2, unit.Statistic.RequestCount)
+	verify.Equals(t, 1, unit.Statistic.SuccessCount)
+	verify.Equals(t, 1, unit.Statistic.LengthMismatchCount)
+	verify.Equals(t, 1, unit.Statistic.IOFailedCount)
+	verify.Equals(t, []string{"received 5 bytes instead of Content-Length 10"}, reasons)
+}
+
+func TestPerformRequest_lengthMismatchDiscarded(t *testing.T) {
+	// arrange
+	mockServer := newTruncatingServer(t)
+	defer mockServer.Close()
+	unit := NewClient(Request{URL: mockServer.URL + "/truncated"})
+	unit.DiscardBody = true
+	// action
+	unit.PerformRequest()
+	// verify
+	verify.Equals(t, 1, unit.Statistic.LengthMismatchCount)
+	verify.Equals(t, 0, unit.Statistic.SuccessCount)
+}
+
+func TestPerformRequest_lengthOfHead(t *testing.T) {
+	// arrange
+	mockServer := newTruncatingServer(t)
+	defer mockServer.Close()
+	unit := NewClient(Request{URL: mockServer.URL, Method: http.MethodHead})
+	// action
+	unit.PerformRequest()
+	// verify
+	verify.Equals(t, 0, unit.Statistic.LengthMismatchCount)
+	verify.Equals(t, 1, unit.Statistic.SuccessCount)
+}
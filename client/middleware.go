@@ -0,0 +1,37 @@
+// SPDX-FileCopyrightText: 2021 Eric Neidhardt
+// SPDX-License-Identifier: MIT
+package client
+
+import "net/http"
+
+// Doer sends a request and returns its response, like http.Client.Do.
+type Doer interface {
+	Do(req *http.Request) (*http.Response, error)
+}
+
+// DoerFunc is a function used as Doer.
+type DoerFunc func(req *http.Request) (*http.Response, error)
+
+// Do calls f.
+func (f DoerFunc) Do(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+// Middleware wraps the Doer sending the requests of a client, e.g. to modify requests or to observe responses.
+// It calls next to send the request, see Client.Middlewares.
+type Middleware func(next Doer) Doer
+
+// doer returns the Doer sending the requests of the client, which is built once from its middlewares.
+func (c *Client) doer() Doer {
+	if len(c.Middlewares) == 0 {
+		return &c.HTTPClient
+	}
+	if c.chain == nil {
+		var d Doer = &c.HTTPClient
+		for i := len(c.Middlewares) - 1; i >= 0; i-- {
+			d = c.Middlewares[i](d)
+		}
+		c.chain = d
+	}
+	return c.chain
+}
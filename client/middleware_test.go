@@ -0,0 +1,47 @@
+// SPDX-FileCopyrightText: 2021 Eric Neidhardt
+// SPDX-License-Identifier: MIT
+package client
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/EricNeid/go-bench/internal/verify"
+)
+
+func TestClient_Middlewares(t *testing.T) {
+	// arrange
+	var receivedHeaders []string
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		receivedHeaders = append(receivedHeaders, strings.Join(r.Header.Values("X-Order"), ","))
+	}))
+	defer mockServer.Close()
+	var statusCodes []int
+	appendOrder := func(name string) Middleware {
+		return func(next Doer) Doer {
+			return DoerFunc(func(req *http.Request) (*http.Response, error) {
+				req.Header.Add("X-Order", name)
+				return next.Do(req)
+			})
+		}
+	}
+	observe := func(next Doer) Doer {
+		return DoerFunc(func(req *http.Request) (*http.Response, error) {
+			resp, err := next.Do(req)
+			if err == nil {
+				statusCodes = append(statusCodes, resp.StatusCode)
+			}
+			return resp, err
+		})
+	}
+	unit := NewClient(Request{URL: mockServer.URL}, WithMiddleware(appendOrder("a"), observe), WithMiddleware(appendOrder("b")))
+	// action
+	unit.RunForAmount(2)
+	// verify
+	verify.Equals(t, []string{"a,b", "a,b"}, receivedHeaders)
+	verify.Equals(t, []int{http.StatusOK, http.StatusOK}, statusCodes)
+	verify.Equals(t, 2, unit.Statistic.SuccessCount)
+	verify.Equals(t, 2, unit.Statistic.Phases[PhaseTTFB].Count)
+}
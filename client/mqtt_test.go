@@ -0,0 +1,147 @@
+// SPDX-FileCopyrightText: 2021 Eric Neidhardt
+// SPDX-License-Identifier: MIT
+package client
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/EricNeid/go-bench/internal/verify"
+)
+
+// mqttBroker accepts connections with the given connack return code and delivers published messages
+// to all subscribers, until it is closed.
+func mqttBroker(t *testing.T, returnCode byte) net.Listener {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	verify.Ok(t, err)
+	var mu sync.Mutex
+	var subscribers []net.Conn
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				reader := bufio.NewReader(conn)
+				for {
+					header, body, err := readMQTTPacket(reader)
+					if err != nil {
+						return
+					}
+					switch header >> 4 {
+					case mqttConnect:
+						conn.Write(mqttPacket(mqttConnAck<<4, []byte{0, returnCode}))
+					case mqttSubscribe:
+						mu.Lock()
+						subscribers = append(subscribers, conn)
+						mu.Unlock()
+						conn.Write(mqttPacket(mqttSubAck<<4, append(body[:2:2], body[len(body)-1])))
+					case mqttPublish:
+						if qos := header >> 1 & 0x03; qos > 0 {
+							topic := 2 + int(binary.BigEndian.Uint16(body))
+							conn.Write(mqttPacket(mqttPubAck<<4, body[topic:topic+2]))
+						}
+						mu.Lock()
+						for _, s := range subscribers {
+							s.Write(mqttPacket(header, body))
+						}
+						mu.Unlock()
+					}
+				}
+			}()
+		}
+	}()
+	return listener
+}
+
+func TestMQTT_publish(t *testing.T) {
+	// arrange
+	broker := mqttBroker(t, 0)
+	defer broker.Close()
+	unit := &MQTT{URL: "mqtt://user:secret@" + broker.Addr().String() + "/sensors/1", QoS: 1, Payload: []byte("21.5"), Subscribe: true}
+	// action
+	RunForAmount(context.Background(), 5, unit)
+	// wait for the deliveries of the last messages
+	for i := 0; i < 100 && unit.Snapshot().MessageCount < 5; i++ {
+		time.Sleep(10 * time.Millisecond)
+	}
+	unit.Close()
+	// verify
+	s := unit.Snapshot()
+	verify.Equals(t, 5, s.SuccessCount)
+	verify.Equals(t, 1, s.NewConnections)
+	verify.Equals(t, 4, s.ReusedConnections)
+	verify.Equals(t, 5, s.MessageCount)
+	verify.Equals(t, 5, s.Phases[PhaseDelivery].Count)
+	verify.Equals(t, 5, s.Phases[PhaseRoundTrip].Count)
+	verify.Equals(t, 1, s.Phases[PhaseHandshake].Count)
+}
+
+func TestMQTT_qos0(t *testing.T) {
+	// arrange
+	broker := mqttBroker(t, 0)
+	defer broker.Close()
+	unit := &MQTT{URL: "mqtt://" + broker.Addr().String() + "/sensors/1", Payload: []byte("21.5")}
+	// action
+	RunForAmount(context.Background(), 3, unit)
+	unit.Close()
+	// verify
+	s := unit.Snapshot()
+	verify.Equals(t, 3, s.SuccessCount)
+	verify.Equals(t, int64(3*(2+2+len("sensors/1")+mqttTimestamp+4)), s.WriteThroughput)
+	verify.Equals(t, 0, s.MessageCount)
+}
+
+func TestMQTT_refused(t *testing.T) {
+	// arrange
+	broker := mqttBroker(t, 5)
+	defer broker.Close()
+	unit := &MQTT{URL: "mqtt://" + broker.Addr().String() + "/sensors/1"}
+	// action
+	result, _ := unit.Perform(context.Background())
+	// verify
+	verify.Equals(t, FailureOther, result.Class)
+	verify.Equals(t, "mqtt connection refused with return code 5", result.Err.Error())
+}
+
+func TestParseMQTTPublish_corrupt(t *testing.T) {
+	// arrange
+	packets := [][]byte{
+		// topic length exceeding the packet
+		mqttPacket(mqttPublish<<4, []byte{0xff, 0xff, 's'}),
+		// truncated topic length
+		mqttPacket(mqttPublish<<4, []byte{0}),
+		// missing packet ID of qos 1
+		mqttPacket(mqttPublish<<4|1<<1, []byte{0, 1, 's', 0}),
+	}
+	for _, packet := range packets {
+		reader := bufio.NewReader(bytes.NewReader(packet))
+		kind, body, err := readMQTTPacket(reader)
+		verify.Ok(t, err)
+		// action
+		_, _, ok := parseMQTTPublish(kind, body)
+		// verify
+		verify.Assert(t, !ok, "Corrupt packet %v not detected", packet)
+	}
+}
+
+func TestParseMQTTPublish(t *testing.T) {
+	// arrange
+	reader := bufio.NewReader(bytes.NewReader(mqttPacket(mqttPublish<<4|1<<1, []byte{0, 1, 's', 0, 7, 'x'})))
+	kind, body, err := readMQTTPacket(reader)
+	verify.Ok(t, err)
+	// action
+	packetID, payload, ok := parseMQTTPublish(kind, body)
+	// verify
+	verify.Assert(t, ok, "Valid packet not parsed")
+	verify.Equals(t, []byte{0, 7}, packetID)
+	verify.Equals(t, []byte("x"), payload)
+}
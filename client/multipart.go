@@ -0,0 +1,63 @@
+// SPDX-FileCopyrightText: 2021 Eric Neidhardt
+// SPDX-License-Identifier: MIT
+package client
+
+import (
+	"bytes"
+	"fmt"
+	"mime/multipart"
+	"net/textproto"
+	"strings"
+)
+
+// Part is a form field or a file of a multipart/form-data body.
+type Part struct {
+	Name string
+	// Value of a form field, ignored if a file name is given.
+	Value string
+	// FileName of an uploaded file, whose data is Content.
+	FileName string
+	Content  []byte
+	// ContentType of a file, application/octet-stream if empty.
+	ContentType string
+}
+
+// MultipartBody encodes the parts as multipart/form-data and returns the body with its content type.
+// The body is encoded once and can be sent by any number of requests.
+func MultipartBody(parts []Part) ([]byte, string, error) {
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	for _, part := range parts {
+		if part.FileName == "" {
+			if err := writer.WriteField(part.Name, part.Value); err != nil {
+				return nil, "", err
+			}
+			continue
+		}
+		contentType := part.ContentType
+		if contentType == "" {
+			contentType = "application/octet-stream"
+		}
+		header := make(textproto.MIMEHeader)
+		header.Set("Content-Disposition", fmt.Sprintf(`form-data; name="%s"; filename="%s"`,
+			escapeQuotes(part.Name), escapeQuotes(part.FileName)))
+		header.Set("Content-Type", contentType)
+		w, err := writer.CreatePart(header)
+		if err != nil {
+			return nil, "", err
+		}
+		if _, err := w.Write(part.Content); err != nil {
+			return nil, "", err
+		}
+	}
+	if err := writer.Close(); err != nil {
+		return nil, "", err
+	}
+	return body.Bytes(), writer.FormDataContentType(), nil
+}
+
+var quoteEscaper = strings.NewReplacer("\\", "\\\\", `"`, "\\\"")
+
+func escapeQuotes(s string) string {
+	return quoteEscaper.Replace(s)
+}
@@ -0,0 +1,41 @@
+// SPDX-FileCopyrightText: 2021 Eric Neidhardt
+// SPDX-License-Identifier: MIT
+package client
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/EricNeid/go-bench/internal/verify"
+)
+
+func TestMultipartBody(t *testing.T) {
+	// arrange
+	var name, file, fileName, fileType string
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		verify.Ok(t, r.ParseMultipartForm(1024))
+		name = r.FormValue("name")
+		f, header, err := r.FormFile("avatar")
+		verify.Ok(t, err)
+		content, err := io.ReadAll(f)
+		verify.Ok(t, err)
+		file, fileName, fileType = string(content), header.Filename, header.Header.Get("Content-Type")
+	}))
+	defer mockServer.Close()
+	body, contentType, err := MultipartBody([]Part{
+		{Name: "name", Value: "max"},
+		{Name: "avatar", FileName: "avatar.png", Content: []byte("png"), ContentType: "image/png"},
+	})
+	verify.Ok(t, err)
+	unit := NewClient(Request{URL: mockServer.URL, PostBody: body, ContentType: contentType})
+	// action
+	unit.PerformRequest()
+	// verify
+	verify.Equals(t, 1, unit.Statistic.SuccessCount)
+	verify.Equals(t, "max", name)
+	verify.Equals(t, "png", file)
+	verify.Equals(t, "avatar.png", fileName)
+	verify.Equals(t, "image/png", fileType)
+}
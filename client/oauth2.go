@@ -0,0 +1,118 @@
+// SPDX-FileCopyrightText: 2021 Eric Neidhardt
+// SPDX-License-Identifier: MIT
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// maxRefreshMargin is the maximum time before expiry, at which a token is refreshed.
+const maxRefreshMargin = time.Minute
+
+// ClientCredentials fetches OAuth2 access tokens with the client credentials grant
+// and refreshes them before they expire. It is safe for concurrent use.
+type ClientCredentials struct {
+	TokenURL     string
+	ClientID     string
+	ClientSecret string
+	Scopes       []string
+	// HTTPClient used for requesting tokens, http.DefaultClient if nil.
+	HTTPClient *http.Client
+
+	mu      sync.Mutex
+	token   string
+	refresh time.Time
+}
+
+// Token returns a valid access token, requesting a new one if there is none or it is about to expire.
+func (c *ClientCredentials) Token(ctx context.Context) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.token != "" && (c.refresh.IsZero() || time.Now().Before(c.refresh)) {
+		return c.token, nil
+	}
+
+	form := url.Values{"grant_type": {"client_credentials"}}
+	if len(c.Scopes) > 0 {
+		form.Set("scope", strings.Join(c.Scopes, " "))
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.TokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.SetBasicAuth(url.QueryEscape(c.ClientID), url.QueryEscape(c.ClientSecret))
+
+	httpClient := c.HTTPClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return "", fmt.Errorf("token request returned %s: %s", resp.Status, body)
+	}
+
+	var token struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int64  `json:"expires_in"`
+	}
+	if err := json.Unmarshal(body, &token); err != nil {
+		return "", fmt.Errorf("invalid token response: %w", err)
+	}
+	if token.AccessToken == "" {
+		return "", errors.New("token response contains no access_token")
+	}
+	c.token = token.AccessToken
+	c.refresh = time.Time{}
+	if token.ExpiresIn > 0 {
+		lifetime := time.Duration(token.ExpiresIn) * time.Second
+		margin := lifetime / 5
+		if margin > maxRefreshMargin {
+			margin = maxRefreshMargin
+		}
+		c.refresh = time.Now().Add(lifetime - margin)
+	}
+	return c.token, nil
+}
+
+// Transport returns a round tripper, which sets the access token as bearer token of every request sent by base.
+// If base is nil, http.DefaultTransport is used.
+func (c *ClientCredentials) Transport(base http.RoundTripper) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &bearerTransport{credentials: c, base: base}
+}
+
+type bearerTransport struct {
+	credentials *ClientCredentials
+	base        http.RoundTripper
+}
+
+func (t *bearerTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	token, err := t.credentials.Token(req.Context())
+	if err != nil {
+		return nil, fmt.Errorf("could not get access token: %w", err)
+	}
+	// a round tripper must not modify the given request
+	authorized := req.Clone(req.Context())
+	authorized.Header.Set("Authorization", "Bearer "+token)
+	return t.base.RoundTrip(authorized)
+}
@@ -0,0 +1,83 @@
+// SPDX-FileCopyrightText: 2021 Eric Neidhardt
+// SPDX-License-Identifier: MIT
+package client
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/EricNeid/go-bench/internal/verify"
+)
+
+func TestClientCredentials(t *testing.T) {
+	// arrange
+	issued := 0
+	var receivedForm string
+	tokenServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user, password, _ := r.BasicAuth()
+		verify.Equals(t, "id", user)
+		verify.Equals(t, "secret", password)
+		verify.Ok(t, r.ParseForm())
+		receivedForm = r.PostForm.Encode()
+		issued++
+		fmt.Fprintf(w, `{"access_token":"token-%d","token_type":"Bearer","expires_in":%d}`, issued, 3600)
+	}))
+	defer tokenServer.Close()
+	var receivedAuthorization []string
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		receivedAuthorization = append(receivedAuthorization, r.Header.Get("Authorization"))
+	}))
+	defer mockServer.Close()
+
+	credentials := &ClientCredentials{TokenURL: tokenServer.URL, ClientID: "id", ClientSecret: "secret", Scopes: []string{"read", "write"}}
+	unit := NewClient(Request{URL: mockServer.URL})
+	unit.HTTPClient.Transport = credentials.Transport(nil)
+	// action
+	unit.RunForAmount(2)
+	// verify
+	verify.Equals(t, 1, issued)
+	verify.Equals(t, "grant_type=client_credentials&scope=read+write", receivedForm)
+	verify.Equals(t, []string{"Bearer token-1", "Bearer token-1"}, receivedAuthorization)
+	verify.Equals(t, 2, unit.Statistic.SuccessCount)
+}
+
+func TestClientCredentials_refresh(t *testing.T) {
+	// arrange
+	issued := 0
+	tokenServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		issued++
+		fmt.Fprintf(w, `{"access_token":"token-%d","expires_in":60}`, issued)
+	}))
+	defer tokenServer.Close()
+	unit := &ClientCredentials{TokenURL: tokenServer.URL}
+	// action
+	first, err1 := unit.Token(context.Background())
+	refreshIn := time.Until(unit.refresh)
+	unit.refresh = time.Now().Add(-time.Second)
+	second, err2 := unit.Token(context.Background())
+	// verify
+	verify.Ok(t, err1)
+	verify.Ok(t, err2)
+	verify.Equals(t, "token-1", first)
+	verify.Equals(t, "token-2", second)
+	verify.Assert(t, refreshIn > 40*time.Second && refreshIn <= 48*time.Second, "Unexpected refresh in %s", refreshIn)
+}
+
+func TestClientCredentials_failed(t *testing.T) {
+	// arrange
+	tokenServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer tokenServer.Close()
+	credentials := &ClientCredentials{TokenURL: tokenServer.URL}
+	unit := NewClient(Request{URL: "http://localhost"})
+	unit.HTTPClient.Transport = credentials.Transport(nil)
+	// action
+	unit.PerformRequest()
+	// verify
+	verify.Equals(t, 1, unit.Statistic.NetworkFailedCount)
+}
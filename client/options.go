@@ -0,0 +1,88 @@
+// SPDX-FileCopyrightText: 2021 Eric Neidhardt
+// SPDX-License-Identifier: MIT
+package client
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"time"
+)
+
+// RequestOption configures a request created by NewRequest.
+type RequestOption func(r *Request) error
+
+// ClientOption configures a client created by NewClient.
+type ClientOption func(c *Client)
+
+// WithMethod sets the HTTP method of the request, see Request.Method.
+func WithMethod(method string) RequestOption {
+	return func(r *Request) error {
+		r.Method = method
+		return nil
+	}
+}
+
+// WithBody sets the body of the request, see Request.PostBody.
+func WithBody(body []byte) RequestOption {
+	return func(r *Request) error {
+		r.PostBody = body
+		return nil
+	}
+}
+
+// WithBodyFile reads the body of the request from the given file.
+func WithBodyFile(filePath string) RequestOption {
+	return func(r *Request) error {
+		data, err := os.ReadFile(filePath)
+		if err != nil {
+			return fmt.Errorf("could not read body from %s: %w", filePath, err)
+		}
+		r.PostBody = data
+		return nil
+	}
+}
+
+// WithHeader adds a header field to the request, see Request.AdditionalHeaders.
+// Content-Type sets Request.ContentType.
+func WithHeader(key, value string) RequestOption {
+	return func(r *Request) error {
+		if http.CanonicalHeaderKey(key) == "Content-Type" {
+			r.ContentType = value
+			return nil
+		}
+		r.AdditionalHeaders[key] = value
+		return nil
+	}
+}
+
+// WithKeepAlive sets, whether the connection of the request is kept alive, see Request.KeepAlive.
+func WithKeepAlive(keepAlive bool) RequestOption {
+	return func(r *Request) error {
+		r.KeepAlive = keepAlive
+		return nil
+	}
+}
+
+// WithTimeout sets the timeout of each request of the client, see Client.Timeout.
+func WithTimeout(timeout time.Duration) ClientOption {
+	return func(c *Client) {
+		c.Timeout = timeout
+	}
+}
+
+// WithTransport sets the transport of the client, see http.Client.Transport. It can wrap another transport,
+// e.g. for instrumentation or record and replay. The phases of the requests are measured, as long as it passes
+// on their context, which holds their httptrace.ClientTrace.
+func WithTransport(transport http.RoundTripper) ClientOption {
+	return func(c *Client) {
+		c.HTTPClient.Transport = transport
+	}
+}
+
+// WithMiddleware adds middlewares to the client, see Client.Middlewares.
+func WithMiddleware(middlewares ...Middleware) ClientOption {
+	return func(c *Client) {
+		c.Middlewares = append(c.Middlewares, middlewares...)
+	}
+}
@@ -0,0 +1,42 @@
+// SPDX-FileCopyrightText: 2021 Eric Neidhardt
+// SPDX-License-Identifier: MIT
+package client
+
+import (
+	"context"
+	"math/rand"
+)
+
+// bodyPattern is repeated by patterned bodies.
+const bodyPattern = "0123456789abcdefghijklmnopqrstuvwxyz\n"
+
+// GenerateBody returns a body of the given size, which is either random or a repeated pattern.
+// Random bodies contain no '{', so they are never mistaken for placeholders, see Template.
+func GenerateBody(size int, pattern bool, rng *rand.Rand) []byte {
+	body := make([]byte, size)
+	if pattern {
+		for i := 0; i < size; {
+			i += copy(body[i:], bodyPattern)
+		}
+		return body
+	}
+	rng.Read(body)
+	for i, b := range body {
+		if b == '{' {
+			body[i] = '}'
+		}
+	}
+	return body
+}
+
+// RandomBodies returns a function for Client.NextRequest, which replaces the body of the requests
+// returned by next with a new random body of the given size. The returned function is not safe for concurrent use.
+func RandomBodies(next func(ctx context.Context) (Request, bool), size int, rng *rand.Rand) func(ctx context.Context) (Request, bool) {
+	return func(ctx context.Context) (Request, bool) {
+		request, ok := next(ctx)
+		if ok {
+			request.PostBody = GenerateBody(size, false, rng)
+		}
+		return request, ok
+	}
+}
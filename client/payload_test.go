@@ -0,0 +1,43 @@
+// SPDX-FileCopyrightText: 2021 Eric Neidhardt
+// SPDX-License-Identifier: MIT
+package client
+
+import (
+	"bytes"
+	"io"
+	"math/rand"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/EricNeid/go-bench/internal/verify"
+)
+
+func TestGenerateBody(t *testing.T) {
+	// action
+	random := GenerateBody(64*1024, false, rand.New(rand.NewSource(1)))
+	pattern := GenerateBody(40, true, nil)
+	// verify
+	verify.Equals(t, 64*1024, len(random))
+	verify.Assert(t, !bytes.Contains(random, []byte("{")), "Random body contains placeholder characters")
+	verify.Equals(t, "0123456789abcdefghijklmnopqrstuvwxyz\n012", string(pattern))
+}
+
+func TestRandomBodies(t *testing.T) {
+	// arrange
+	var bodies [][]byte
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		bodies = append(bodies, body)
+	}))
+	defer mockServer.Close()
+	unit := Client{}
+	unit.NextRequest = RandomBodies(Sequential([]Request{{URL: mockServer.URL}}, 0), 16, rand.New(rand.NewSource(1)))
+	// action
+	unit.RunForAmount(2)
+	// verify
+	verify.Equals(t, 2, len(bodies))
+	verify.Equals(t, 16, len(bodies[0]))
+	verify.Assert(t, !bytes.Equal(bodies[0], bodies[1]), "Body was not regenerated")
+	verify.Equals(t, int64(32), unit.Statistic.WriteThroughput)
+}
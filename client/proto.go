@@ -0,0 +1,556 @@
+// SPDX-FileCopyrightText: 2021 Eric Neidhardt
+// SPDX-License-Identifier: MIT
+package client
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Wire types of protobuf fields.
+const (
+	wireVarint  = 0
+	wireFixed64 = 1
+	wireBytes   = 2
+	wireFixed32 = 5
+)
+
+// Types of protobuf fields, as of google.protobuf.FieldDescriptorProto.Type.
+const (
+	protoDouble   = 1
+	protoFloat    = 2
+	protoInt64    = 3
+	protoUint64   = 4
+	protoInt32    = 5
+	protoFixed64  = 6
+	protoFixed32  = 7
+	protoBool     = 8
+	protoString   = 9
+	protoMessage  = 11
+	protoBytes    = 12
+	protoUint32   = 13
+	protoEnum     = 14
+	protoSfixed32 = 15
+	protoSfixed64 = 16
+	protoSint32   = 17
+	protoSint64   = 18
+)
+
+var errInvalidProto = errors.New("invalid protobuf message")
+
+// readProto calls f for each field of the encoded message. Varint and fixed values are passed as v,
+// length-delimited values as b.
+func readProto(data []byte, f func(number, wireType int, v uint64, b []byte) error) error {
+	for len(data) > 0 {
+		key, n := binary.Uvarint(data)
+		if n <= 0 {
+			return errInvalidProto
+		}
+		data = data[n:]
+		number, wireType := int(key>>3), int(key&7)
+		var v uint64
+		var b []byte
+		switch wireType {
+		case wireVarint:
+			if v, n = binary.Uvarint(data); n <= 0 {
+				return errInvalidProto
+			}
+			data = data[n:]
+		case wireFixed64:
+			if len(data) < 8 {
+				return errInvalidProto
+			}
+			v, data = binary.LittleEndian.Uint64(data), data[8:]
+		case wireFixed32:
+			if len(data) < 4 {
+				return errInvalidProto
+			}
+			v, data = uint64(binary.LittleEndian.Uint32(data)), data[4:]
+		case wireBytes:
+			length, n := binary.Uvarint(data)
+			if n <= 0 || length > uint64(len(data)-n) {
+				return errInvalidProto
+			}
+			b, data = data[n:n+int(length)], data[n+int(length):]
+		default:
+			return fmt.Errorf("unsupported protobuf wire type %d", wireType)
+		}
+		if err := f(number, wireType, v, b); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// appendTag appends the key of a field.
+func appendTag(buf []byte, number, wireType int) []byte {
+	return binary.AppendUvarint(buf, uint64(number)<<3|uint64(wireType))
+}
+
+// appendBytesField appends a length-delimited field, e.g. a string or an embedded message.
+func appendBytesField(buf []byte, number int, b []byte) []byte {
+	buf = appendTag(buf, number, wireBytes)
+	buf = binary.AppendUvarint(buf, uint64(len(b)))
+	return append(buf, b...)
+}
+
+// ProtoRegistry holds the messages, enums and services of proto files, whose descriptors are read from
+// a descriptor set or received by server reflection, see ReflectGRPC. Its methods encode JSON messages
+// like their canonical JSON mapping, except for well-known types, which are encoded as ordinary messages.
+type ProtoRegistry struct {
+	messages map[string]*protoMessageType
+	enums    map[string]map[string]int32
+	methods  map[string]*GRPCMethod
+}
+
+// protoMessageType is a message type, whose fields are keyed by their names and JSON names.
+type protoMessageType struct {
+	name     string
+	fields   map[string]*protoField
+	mapEntry bool
+}
+
+type protoField struct {
+	name     string
+	number   int
+	kind     int
+	repeated bool
+	// full name of a message or enum type without leading dot
+	typeName string
+}
+
+// NewProtoRegistry returns an empty registry.
+func NewProtoRegistry() *ProtoRegistry {
+	return &ProtoRegistry{
+		messages: make(map[string]*protoMessageType),
+		enums:    make(map[string]map[string]int32),
+		methods:  make(map[string]*GRPCMethod),
+	}
+}
+
+// ParseDescriptorSet reads a google.protobuf.FileDescriptorSet, which is written by
+// protoc --descriptor_set_out --include_imports.
+func ParseDescriptorSet(data []byte) (*ProtoRegistry, error) {
+	r := NewProtoRegistry()
+	err := readProto(data, func(number, wireType int, _ uint64, b []byte) error {
+		if number == 1 && wireType == wireBytes {
+			return r.AddFile(b)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("invalid descriptor set: %w", err)
+	}
+	return r, nil
+}
+
+// AddFile adds the types of an encoded google.protobuf.FileDescriptorProto.
+func (r *ProtoRegistry) AddFile(data []byte) error {
+	var pkg string
+	var messages, enums, services [][]byte
+	err := readProto(data, func(number, _ int, _ uint64, b []byte) error {
+		switch number {
+		case 2:
+			pkg = string(b)
+		case 4:
+			messages = append(messages, b)
+		case 5:
+			enums = append(enums, b)
+		case 6:
+			services = append(services, b)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	for _, m := range messages {
+		if err := r.addMessage(pkg, m); err != nil {
+			return err
+		}
+	}
+	for _, e := range enums {
+		if err := r.addEnum(pkg, e); err != nil {
+			return err
+		}
+	}
+	for _, s := range services {
+		if err := r.addService(pkg, s); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// addMessage adds an encoded google.protobuf.DescriptorProto and its nested types.
+func (r *ProtoRegistry) addMessage(scope string, data []byte) error {
+	m := &protoMessageType{fields: make(map[string]*protoField)}
+	var fields, nested, enums [][]byte
+	err := readProto(data, func(number, _ int, _ uint64, b []byte) error {
+		switch number {
+		case 1:
+			m.name = fullName(scope, string(b))
+		case 2:
+			fields = append(fields, b)
+		case 3:
+			nested = append(nested, b)
+		case 4:
+			enums = append(enums, b)
+		case 7:
+			// MessageOptions.map_entry
+			return readProto(b, func(number, _ int, v uint64, _ []byte) error {
+				if number == 7 {
+					m.mapEntry = v != 0
+				}
+				return nil
+			})
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	for _, data := range fields {
+		f := &protoField{}
+		var jsonName string
+		err := readProto(data, func(number, _ int, v uint64, b []byte) error {
+			switch number {
+			case 1:
+				f.name = string(b)
+			case 3:
+				f.number = int(v)
+			case 4:
+				f.repeated = v == 3
+			case 5:
+				f.kind = int(v)
+			case 6:
+				f.typeName = strings.TrimPrefix(string(b), ".")
+			case 10:
+				jsonName = string(b)
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		m.fields[f.name] = f
+		if jsonName != "" {
+			m.fields[jsonName] = f
+		}
+	}
+	r.messages[m.name] = m
+	for _, n := range nested {
+		if err := r.addMessage(m.name, n); err != nil {
+			return err
+		}
+	}
+	for _, e := range enums {
+		if err := r.addEnum(m.name, e); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// addEnum adds an encoded google.protobuf.EnumDescriptorProto.
+func (r *ProtoRegistry) addEnum(scope string, data []byte) error {
+	var name string
+	values := make(map[string]int32)
+	err := readProto(data, func(number, _ int, _ uint64, b []byte) error {
+		switch number {
+		case 1:
+			name = fullName(scope, string(b))
+		case 2:
+			var valueName string
+			var value int32
+			err := readProto(b, func(number, _ int, v uint64, b []byte) error {
+				switch number {
+				case 1:
+					valueName = string(b)
+				case 2:
+					value = int32(v) //nolint:gosec // negative values are encoded sign-extended
+				}
+				return nil
+			})
+			values[valueName] = value
+			return err
+		}
+		return nil
+	})
+	r.enums[name] = values
+	return err
+}
+
+// addService adds the methods of an encoded google.protobuf.ServiceDescriptorProto.
+func (r *ProtoRegistry) addService(pkg string, data []byte) error {
+	var name string
+	var methods [][]byte
+	err := readProto(data, func(number, _ int, _ uint64, b []byte) error {
+		switch number {
+		case 1:
+			name = fullName(pkg, string(b))
+		case 2:
+			methods = append(methods, b)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	for _, data := range methods {
+		m := &GRPCMethod{registry: r}
+		var methodName string
+		err := readProto(data, func(number, _ int, v uint64, b []byte) error {
+			switch number {
+			case 1:
+				methodName = string(b)
+			case 2:
+				m.input = strings.TrimPrefix(string(b), ".")
+			case 5:
+				m.ClientStreaming = v != 0
+			case 6:
+				m.ServerStreaming = v != 0
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		m.Path = "/" + name + "/" + methodName
+		r.methods[m.Path] = m
+	}
+	return nil
+}
+
+func fullName(scope, name string) string {
+	if scope == "" {
+		return name
+	}
+	return scope + "." + name
+}
+
+// Method returns the method of the given name, e.g. helloworld.Greeter/SayHello or helloworld.Greeter.SayHello.
+func (r *ProtoRegistry) Method(name string) (*GRPCMethod, error) {
+	m, ok := r.methods[GRPCPath(name)]
+	if !ok {
+		return nil, fmt.Errorf("unknown grpc method %s", name)
+	}
+	return m, nil
+}
+
+// encodeJSON encodes the JSON message as message of the given type.
+func (r *ProtoRegistry) encodeJSON(typeName string, message []byte) ([]byte, error) {
+	if len(bytes.TrimSpace(message)) == 0 {
+		// the message with default values
+		message = []byte("{}")
+	}
+	decoder := json.NewDecoder(bytes.NewReader(message))
+	decoder.UseNumber()
+	var value map[string]any
+	if err := decoder.Decode(&value); err != nil {
+		return nil, fmt.Errorf("invalid json message: %w", err)
+	}
+	return r.encodeMessage(nil, typeName, value)
+}
+
+// encodeMessage appends the fields of the JSON object as message of the given type.
+func (r *ProtoRegistry) encodeMessage(buf []byte, typeName string, value map[string]any) ([]byte, error) {
+	m, ok := r.messages[typeName]
+	if !ok {
+		return nil, fmt.Errorf("unknown message type %s", typeName)
+	}
+	// fields are encoded in the order of their numbers, like protoc does
+	keys := make([]string, 0, len(value))
+	for key := range value {
+		if _, ok := m.fields[key]; !ok {
+			return nil, fmt.Errorf("unknown field %s of %s", key, typeName)
+		}
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		return m.fields[keys[i]].number < m.fields[keys[j]].number
+	})
+	var err error
+	for _, key := range keys {
+		f, v := m.fields[key], value[key]
+		switch {
+		case v == nil:
+			// null is the default value
+		case f.repeated && f.kind == protoMessage && r.messages[f.typeName] != nil && r.messages[f.typeName].mapEntry:
+			if buf, err = r.encodeMap(buf, f, v); err != nil {
+				return nil, err
+			}
+		case f.repeated:
+			values, ok := v.([]any)
+			if !ok {
+				return nil, fmt.Errorf("field %s of %s must be an array", key, typeName)
+			}
+			for _, v := range values {
+				if buf, err = r.encodeValue(buf, f, v); err != nil {
+					return nil, err
+				}
+			}
+		default:
+			if buf, err = r.encodeValue(buf, f, v); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return buf, nil
+}
+
+// encodeMap appends the entries of a JSON object as map field, whose entries have a key 1 and value 2.
+func (r *ProtoRegistry) encodeMap(buf []byte, f *protoField, v any) ([]byte, error) {
+	entries, ok := v.(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("field %s must be an object", f.name)
+	}
+	entryType := r.messages[f.typeName]
+	keyField, valueField := entryType.fields["key"], entryType.fields["value"]
+	if keyField == nil || valueField == nil {
+		return nil, fmt.Errorf("invalid map entry %s", f.typeName)
+	}
+	keys := make([]string, 0, len(entries))
+	for key := range entries {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		entry, err := r.encodeValue(nil, keyField, key)
+		if err != nil {
+			return nil, err
+		}
+		if entries[key] != nil {
+			if entry, err = r.encodeValue(entry, valueField, entries[key]); err != nil {
+				return nil, err
+			}
+		}
+		buf = appendBytesField(buf, f.number, entry)
+	}
+	return buf, nil
+}
+
+// encodeValue appends a single value of the field. Integers may be given as numbers or strings,
+// bytes are base64 encoded and enums are given by name or number.
+func (r *ProtoRegistry) encodeValue(buf []byte, f *protoField, v any) ([]byte, error) {
+	invalid := func(err error) error {
+		return fmt.Errorf("invalid value %v of field %s: %w", v, f.name, err)
+	}
+	switch f.kind {
+	case protoDouble, protoFloat:
+		n, err := strconv.ParseFloat(jsonScalar(v), 64)
+		if err != nil {
+			return nil, invalid(err)
+		}
+		if f.kind == protoFloat {
+			return binary.LittleEndian.AppendUint32(appendTag(buf, f.number, wireFixed32), math.Float32bits(float32(n))), nil
+		}
+		return binary.LittleEndian.AppendUint64(appendTag(buf, f.number, wireFixed64), math.Float64bits(n)), nil
+	case protoInt32, protoInt64, protoSint32, protoSint64, protoSfixed32, protoSfixed64:
+		bits := 64
+		if f.kind == protoInt32 || f.kind == protoSint32 || f.kind == protoSfixed32 {
+			bits = 32
+		}
+		n, err := strconv.ParseInt(jsonScalar(v), 10, bits)
+		if err != nil {
+			return nil, invalid(err)
+		}
+		switch f.kind {
+		case protoSint32, protoSint64:
+			return binary.AppendUvarint(appendTag(buf, f.number, wireVarint), uint64(n<<1)^uint64(n>>63)), nil //nolint:gosec // zigzag encoding
+		case protoSfixed32:
+			return binary.LittleEndian.AppendUint32(appendTag(buf, f.number, wireFixed32), uint32(n)), nil //nolint:gosec // two's complement
+		case protoSfixed64:
+			return binary.LittleEndian.AppendUint64(appendTag(buf, f.number, wireFixed64), uint64(n)), nil //nolint:gosec // two's complement
+		}
+		return binary.AppendUvarint(appendTag(buf, f.number, wireVarint), uint64(n)), nil //nolint:gosec // two's complement
+	case protoUint32, protoUint64, protoFixed32, protoFixed64:
+		bits := 64
+		if f.kind == protoUint32 || f.kind == protoFixed32 {
+			bits = 32
+		}
+		n, err := strconv.ParseUint(jsonScalar(v), 10, bits)
+		if err != nil {
+			return nil, invalid(err)
+		}
+		switch f.kind {
+		case protoFixed32:
+			return binary.LittleEndian.AppendUint32(appendTag(buf, f.number, wireFixed32), uint32(n)), nil
+		case protoFixed64:
+			return binary.LittleEndian.AppendUint64(appendTag(buf, f.number, wireFixed64), n), nil
+		}
+		return binary.AppendUvarint(appendTag(buf, f.number, wireVarint), n), nil
+	case protoBool:
+		b, err := strconv.ParseBool(jsonScalar(v))
+		if err != nil {
+			return nil, invalid(err)
+		}
+		var n uint64
+		if b {
+			n = 1
+		}
+		return binary.AppendUvarint(appendTag(buf, f.number, wireVarint), n), nil
+	case protoString:
+		s, ok := v.(string)
+		if !ok {
+			return nil, invalid(errors.New("expected a string"))
+		}
+		return appendBytesField(buf, f.number, []byte(s)), nil
+	case protoBytes:
+		s, ok := v.(string)
+		if !ok {
+			return nil, invalid(errors.New("expected a base64 string"))
+		}
+		b, err := base64.StdEncoding.DecodeString(s)
+		if err != nil {
+			if b, err = base64.URLEncoding.DecodeString(s); err != nil {
+				return nil, invalid(err)
+			}
+		}
+		return appendBytesField(buf, f.number, b), nil
+	case protoEnum:
+		n, err := strconv.ParseInt(jsonScalar(v), 10, 32)
+		if err != nil {
+			value, ok := r.enums[f.typeName][jsonScalar(v)]
+			if !ok {
+				return nil, invalid(fmt.Errorf("unknown value of %s", f.typeName))
+			}
+			n = int64(value)
+		}
+		return binary.AppendUvarint(appendTag(buf, f.number, wireVarint), uint64(n)), nil //nolint:gosec // two's complement
+	case protoMessage:
+		object, ok := v.(map[string]any)
+		if !ok {
+			return nil, invalid(errors.New("expected an object"))
+		}
+		message, err := r.encodeMessage(nil, f.typeName, object)
+		if err != nil {
+			return nil, err
+		}
+		return appendBytesField(buf, f.number, message), nil
+	default:
+		return nil, fmt.Errorf("unsupported type %d of field %s", f.kind, f.name)
+	}
+}
+
+// jsonScalar returns a JSON number, string or bool as string.
+func jsonScalar(v any) string {
+	switch v := v.(type) {
+	case json.Number:
+		return v.String()
+	case string:
+		return v
+	case bool:
+		return strconv.FormatBool(v)
+	default:
+		return fmt.Sprint(v)
+	}
+}
@@ -0,0 +1,155 @@
+// SPDX-FileCopyrightText: 2021 Eric Neidhardt
+// SPDX-License-Identifier: MIT
+package client
+
+import (
+	"encoding/binary"
+	"math"
+	"testing"
+
+	"github.com/EricNeid/go-bench/internal/verify"
+)
+
+// protoFieldDescriptor encodes a google.protobuf.FieldDescriptorProto.
+func protoFieldDescriptor(name string, number, kind int, typeName string, repeated bool) []byte {
+	b := appendBytesField(nil, 1, []byte(name))
+	b = binary.AppendUvarint(appendTag(b, 3, wireVarint), uint64(number))
+	label := uint64(1)
+	if repeated {
+		label = 3
+	}
+	b = binary.AppendUvarint(appendTag(b, 4, wireVarint), label)
+	b = binary.AppendUvarint(appendTag(b, 5, wireVarint), uint64(kind))
+	if typeName != "" {
+		b = appendBytesField(b, 6, []byte(typeName))
+	}
+	return b
+}
+
+// testDescriptor encodes the google.protobuf.FileDescriptorProto of:
+//
+//	package test;
+//	enum Kind { UNKNOWN = 0; FAST = 1; }
+//	message Inner { string id = 1; }
+//	message Ping {
+//	  string name = 1; int32 count = 2; repeated int64 ids = 3; Kind kind = 4; Inner inner = 5;
+//	  map<string, int32> tags = 6; bytes data = 7; bool flag = 8; sint32 delta = 9; double ratio = 10;
+//	}
+//	service Pinger { rpc Ping(Ping) returns (Ping); rpc Watch(Ping) returns (stream Ping); }
+func testDescriptor() []byte {
+	tagsEntry := appendBytesField(nil, 1, []byte("TagsEntry"))
+	tagsEntry = appendBytesField(tagsEntry, 2, protoFieldDescriptor("key", 1, protoString, "", false))
+	tagsEntry = appendBytesField(tagsEntry, 2, protoFieldDescriptor("value", 2, protoInt32, "", false))
+	tagsEntry = appendBytesField(tagsEntry, 7, binary.AppendUvarint(appendTag(nil, 7, wireVarint), 1))
+
+	ping := appendBytesField(nil, 1, []byte("Ping"))
+	for _, f := range [][]byte{
+		protoFieldDescriptor("name", 1, protoString, "", false),
+		protoFieldDescriptor("count", 2, protoInt32, "", false),
+		protoFieldDescriptor("ids", 3, protoInt64, "", true),
+		protoFieldDescriptor("kind", 4, protoEnum, ".test.Kind", false),
+		protoFieldDescriptor("inner", 5, protoMessage, ".test.Inner", false),
+		protoFieldDescriptor("tags", 6, protoMessage, ".test.Ping.TagsEntry", true),
+		protoFieldDescriptor("data", 7, protoBytes, "", false),
+		protoFieldDescriptor("flag", 8, protoBool, "", false),
+		protoFieldDescriptor("delta", 9, protoSint32, "", false),
+		protoFieldDescriptor("ratio", 10, protoDouble, "", false),
+	} {
+		ping = appendBytesField(ping, 2, f)
+	}
+	ping = appendBytesField(ping, 3, tagsEntry)
+
+	inner := appendBytesField(nil, 1, []byte("Inner"))
+	inner = appendBytesField(inner, 2, protoFieldDescriptor("id", 1, protoString, "", false))
+
+	kind := appendBytesField(nil, 1, []byte("Kind"))
+	kind = appendBytesField(kind, 2, binary.AppendUvarint(appendTag(appendBytesField(nil, 1, []byte("UNKNOWN")), 2, wireVarint), 0))
+	kind = appendBytesField(kind, 2, binary.AppendUvarint(appendTag(appendBytesField(nil, 1, []byte("FAST")), 2, wireVarint), 1))
+
+	method := appendBytesField(nil, 1, []byte("Ping"))
+	method = appendBytesField(method, 2, []byte(".test.Ping"))
+	method = appendBytesField(method, 3, []byte(".test.Ping"))
+	watch := appendBytesField(nil, 1, []byte("Watch"))
+	watch = appendBytesField(watch, 2, []byte(".test.Ping"))
+	watch = appendBytesField(watch, 3, []byte(".test.Ping"))
+	watch = binary.AppendUvarint(appendTag(watch, 6, wireVarint), 1)
+	service := appendBytesField(nil, 1, []byte("Pinger"))
+	service = appendBytesField(service, 2, method)
+	service = appendBytesField(service, 2, watch)
+
+	file := appendBytesField(nil, 1, []byte("test.proto"))
+	file = appendBytesField(file, 2, []byte("test"))
+	file = appendBytesField(file, 4, inner)
+	file = appendBytesField(file, 4, ping)
+	file = appendBytesField(file, 5, kind)
+	return appendBytesField(file, 6, service)
+}
+
+func TestParseDescriptorSet(t *testing.T) {
+	// arrange
+	set := appendBytesField(nil, 1, testDescriptor())
+	// action
+	registry, err := ParseDescriptorSet(set)
+	verify.Ok(t, err)
+	ping, pingErr := registry.Method("test.Pinger.Ping")
+	watch, watchErr := registry.Method("/test.Pinger/Watch")
+	_, unknownErr := registry.Method("test.Pinger/Unknown")
+	// verify
+	verify.Ok(t, pingErr)
+	verify.Ok(t, watchErr)
+	verify.Equals(t, "/test.Pinger/Ping", ping.Path)
+	verify.Assert(t, !ping.ServerStreaming && !ping.ClientStreaming, "unary method detected as streaming")
+	verify.Assert(t, watch.ServerStreaming, "server streaming not detected")
+	verify.Assert(t, unknownErr != nil, "unknown method not rejected")
+}
+
+func TestGRPCMethod_Encode(t *testing.T) {
+	// arrange
+	registry, err := ParseDescriptorSet(appendBytesField(nil, 1, testDescriptor()))
+	verify.Ok(t, err)
+	unit, err := registry.Method("test.Pinger/Ping")
+	verify.Ok(t, err)
+	// action
+	frame, err := unit.Encode([]byte(`{"name":"max","count":-1,"ids":[1,"2"],"kind":"FAST","inner":{"id":"a"},
+		"tags":{"x":3},"data":"AQI=","flag":true,"delta":-2,"ratio":0.5}`))
+	// verify
+	verify.Ok(t, err)
+	verify.Equals(t, uint32(len(frame)-5), binary.BigEndian.Uint32(frame[1:5]))
+	fields := make(map[int][]any)
+	verify.Ok(t, readProto(frame[5:], func(number, _ int, v uint64, b []byte) error {
+		if b != nil {
+			fields[number] = append(fields[number], string(b))
+		} else {
+			fields[number] = append(fields[number], v)
+		}
+		return nil
+	}))
+	verify.Equals(t, []any{"max"}, fields[1])
+	verify.Equals(t, []any{uint64(math.MaxUint64)}, fields[2])
+	verify.Equals(t, []any{uint64(1), uint64(2)}, fields[3])
+	verify.Equals(t, []any{uint64(1)}, fields[4])
+	verify.Equals(t, []any{"\n\x01a"}, fields[5])
+	verify.Equals(t, []any{"\n\x01x\x10\x03"}, fields[6])
+	verify.Equals(t, []any{"\x01\x02"}, fields[7])
+	verify.Equals(t, []any{uint64(1)}, fields[8])
+	verify.Equals(t, []any{uint64(3)}, fields[9])
+	verify.Equals(t, []any{math.Float64bits(0.5)}, fields[10])
+}
+
+func TestGRPCMethod_Encode_invalid(t *testing.T) {
+	// arrange
+	registry, err := ParseDescriptorSet(appendBytesField(nil, 1, testDescriptor()))
+	verify.Ok(t, err)
+	unit, err := registry.Method("test.Pinger/Ping")
+	verify.Ok(t, err)
+	// action
+	_, unknownField := unit.Encode([]byte(`{"unknown":1}`))
+	_, wrongType := unit.Encode([]byte(`{"count":"many"}`))
+	_, unknownEnum := unit.Encode([]byte(`{"kind":"SLOW"}`))
+	_, invalidJSON := unit.Encode([]byte(`{`))
+	// verify
+	verify.Assert(t, unknownField != nil, "unknown field not rejected")
+	verify.Assert(t, wrongType != nil, "invalid integer not rejected")
+	verify.Assert(t, unknownEnum != nil, "unknown enum value not rejected")
+	verify.Assert(t, invalidJSON != nil, "invalid json not rejected")
+}
@@ -0,0 +1,98 @@
+// SPDX-FileCopyrightText: 2021 Eric Neidhardt
+// SPDX-License-Identifier: MIT
+package client
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/http"
+
+	"golang.org/x/net/http2"
+)
+
+// Protocols of requests, see ConfigureProtocol.
+const (
+	// ProtocolAuto prefers HTTP/2 over TLS, if the server supports it, and uses HTTP/1.1 otherwise.
+	ProtocolAuto = "auto"
+	// ProtocolHTTP1 uses HTTP/1.1 only.
+	ProtocolHTTP1 = "http1"
+	// ProtocolHTTP2 forces HTTP/2 over TLS, requests to servers not supporting it fail.
+	ProtocolHTTP2 = "http2"
+	// ProtocolH2C uses HTTP/2 with prior knowledge for http URLs, without TLS and upgrade.
+	// Requests to https URLs are sent as with ProtocolAuto.
+	ProtocolH2C = "h2c"
+	// ProtocolHTTP3 uses HTTP/3 over QUIC, see NewHTTP3Transport. The dialer of the transport is not used.
+	ProtocolHTTP3 = "http3"
+)
+
+// ConfigureProtocol configures the transport to use the given protocol and returns the round tripper,
+// which sends the requests with it. The negotiated protocols are counted in Statistic.Protocols.
+func ConfigureProtocol(transport *http.Transport, protocol string) (http.RoundTripper, error) {
+	switch protocol {
+	case "", ProtocolAuto:
+		transport.ForceAttemptHTTP2 = true
+		return transport, nil
+	case ProtocolHTTP1:
+		// a non-nil empty map disables HTTP/2
+		transport.ForceAttemptHTTP2 = false
+		transport.TLSNextProto = make(map[string]func(string, *tls.Conn) http.RoundTripper)
+		if transport.TLSClientConfig != nil {
+			transport.TLSClientConfig = transport.TLSClientConfig.Clone()
+			transport.TLSClientConfig.NextProtos = []string{"http/1.1"}
+		}
+		return transport, nil
+	case ProtocolHTTP2:
+		transport.ForceAttemptHTTP2 = true
+		return &http2OnlyTransport{base: transport}, nil
+	case ProtocolH2C:
+		transport.ForceAttemptHTTP2 = true
+		dial := transport.DialContext
+		if dial == nil {
+			dial = (&net.Dialer{}).DialContext
+		}
+		h2c := &http2.Transport{
+			AllowHTTP: true,
+			// the connection is not encrypted, although the transport asks for TLS
+			DialTLSContext: func(ctx context.Context, network, address string, _ *tls.Config) (net.Conn, error) {
+				return dial(ctx, network, address)
+			},
+		}
+		return &h2cTransport{h2c: h2c, base: transport}, nil
+	case ProtocolHTTP3:
+		return NewHTTP3Transport(transport.TLSClientConfig, false), nil
+	default:
+		return nil, fmt.Errorf("unsupported protocol %s", protocol)
+	}
+}
+
+// http2OnlyTransport rejects responses, which were not received with HTTP/2.
+type http2OnlyTransport struct {
+	base http.RoundTripper
+}
+
+func (t *http2OnlyTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.ProtoMajor != 2 {
+		resp.Body.Close()
+		return nil, fmt.Errorf("server responded with %s instead of HTTP/2", resp.Proto)
+	}
+	return resp, nil
+}
+
+// h2cTransport sends requests to http URLs with HTTP/2 prior knowledge.
+type h2cTransport struct {
+	h2c  *http2.Transport
+	base http.RoundTripper
+}
+
+func (t *h2cTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.URL.Scheme == "http" {
+		return t.h2c.RoundTrip(req)
+	}
+	return t.base.RoundTrip(req)
+}
@@ -0,0 +1,77 @@
+// SPDX-FileCopyrightText: 2021 Eric Neidhardt
+// SPDX-License-Identifier: MIT
+package client
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/h2c"
+
+	"github.com/EricNeid/go-bench/internal/verify"
+)
+
+func newHTTP2Server() *httptest.Server {
+	mockServer := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	mockServer.EnableHTTP2 = true
+	mockServer.StartTLS()
+	return mockServer
+}
+
+func TestConfigureProtocol(t *testing.T) {
+	for protocol, expected := range map[string]string{ProtocolAuto: "HTTP/2.0", ProtocolHTTP1: "HTTP/1.1", ProtocolHTTP2: "HTTP/2.0"} {
+		t.Run(protocol, func(t *testing.T) {
+			// arrange
+			mockServer := newHTTP2Server()
+			defer mockServer.Close()
+			transport, err := ConfigureProtocol(mockServer.Client().Transport.(*http.Transport).Clone(), protocol)
+			verify.Ok(t, err)
+			unit := NewClient(Request{URL: mockServer.URL, KeepAlive: true})
+			unit.HTTPClient.Transport = transport
+			// action
+			unit.RunForAmount(2)
+			// verify
+			verify.Equals(t, 2, unit.Statistic.SuccessCount)
+			verify.Equals(t, map[string]int{expected: 1}, unit.Statistic.Protocols)
+		})
+	}
+}
+
+func TestConfigureProtocol_http2NotSupported(t *testing.T) {
+	// arrange
+	mockServer := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer mockServer.Close()
+	transport, err := ConfigureProtocol(mockServer.Client().Transport.(*http.Transport).Clone(), ProtocolHTTP2)
+	verify.Ok(t, err)
+	unit := NewClient(Request{URL: mockServer.URL})
+	unit.HTTPClient.Transport = transport
+	// action
+	unit.PerformRequest()
+	// verify
+	verify.Equals(t, 0, unit.Statistic.SuccessCount)
+	verify.Equals(t, 1, unit.Statistic.NetworkFailedCount)
+	_, err = ConfigureProtocol(&http.Transport{}, "spdy")
+	verify.Assert(t, err != nil, "Unsupported protocol not detected")
+}
+
+func TestConfigureProtocol_h2c(t *testing.T) {
+	// arrange
+	var proto string
+	mockServer := httptest.NewServer(h2c.NewHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		proto = r.Proto
+	}), &http2.Server{}))
+	defer mockServer.Close()
+	transport, err := ConfigureProtocol(http.DefaultTransport.(*http.Transport).Clone(), ProtocolH2C)
+	verify.Ok(t, err)
+	unit := NewClient(Request{URL: mockServer.URL, KeepAlive: true})
+	unit.HTTPClient.Transport = transport
+	// action
+	unit.RunForAmount(2)
+	// verify
+	verify.Equals(t, 2, unit.Statistic.SuccessCount)
+	verify.Equals(t, "HTTP/2.0", proto)
+	verify.Equals(t, map[string]int{"HTTP/2.0": 1}, unit.Statistic.Protocols)
+	verify.Equals(t, 1, unit.Statistic.Phases[PhaseConnect].Count)
+}
@@ -0,0 +1,37 @@
+// SPDX-FileCopyrightText: 2021 Eric Neidhardt
+// SPDX-License-Identifier: MIT
+package client
+
+import (
+	"context"
+	"time"
+)
+
+// rateLimiter spaces the operations of a single benchmarker evenly, e.g. the requests of a client.
+type rateLimiter struct {
+	next time.Time
+}
+
+// wait blocks until the next operation is allowed by the rate, which is unlimited if <= 0,
+// or the context is done.
+func (l *rateLimiter) wait(ctx context.Context, rate float64) {
+	if rate <= 0 {
+		return
+	}
+	now := time.Now()
+	if l.next.After(now) {
+		timer := time.NewTimer(l.next.Sub(now))
+		select {
+		case <-ctx.Done():
+		case <-timer.C:
+		}
+		timer.Stop()
+		now = time.Now()
+	}
+	interval := time.Duration(float64(time.Second) / rate)
+	if l.next.Before(now.Add(-interval)) {
+		// do not catch up on operations missed while idle
+		l.next = now
+	}
+	l.next = l.next.Add(interval)
+}
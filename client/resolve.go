@@ -0,0 +1,61 @@
+// SPDX-FileCopyrightText: 2021 Eric Neidhardt
+// SPDX-License-Identifier: MIT
+package client
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strings"
+)
+
+// HostOverrides are the addresses, to which connections of host:port are made instead of the resolved ones,
+// like curl --resolve. The Host header and TLS server name of the requests stay the same,
+// e.g. to benchmark a single instance behind a load balancer.
+type HostOverrides map[string][]string
+
+// Add adds an override in the format of curl --resolve: host:port:address[,address]...
+// IPv6 addresses are given in brackets, e.g. example.com:443:[::1].
+func (o HostOverrides) Add(value string) error {
+	host, rest, ok := strings.Cut(value, ":")
+	port, addrs, ok2 := strings.Cut(rest, ":")
+	if !ok || !ok2 || host == "" || port == "" || addrs == "" {
+		return fmt.Errorf("invalid host override %s, expected host:port:address", value)
+	}
+	key := net.JoinHostPort(strings.ToLower(host), port)
+	for _, addr := range strings.Split(addrs, ",") {
+		addr = strings.TrimSuffix(strings.TrimPrefix(strings.TrimSpace(addr), "["), "]")
+		if net.ParseIP(addr) == nil {
+			return fmt.Errorf("invalid address %s of host override %s", addr, value)
+		}
+		o[key] = append(o[key], addr)
+	}
+	return nil
+}
+
+// DialContext returns a function for http.Transport.DialContext, which dials the overridden addresses
+// of a host with dial and other hosts as is. If dial is nil, a net.Dialer is used.
+func (o HostOverrides) DialContext(
+	dial func(ctx context.Context, network, address string) (net.Conn, error),
+) func(ctx context.Context, network, address string) (net.Conn, error) {
+	if dial == nil {
+		dial = (&net.Dialer{}).DialContext
+	}
+	return func(ctx context.Context, network, address string) (net.Conn, error) {
+		host, port, err := net.SplitHostPort(address)
+		if err != nil {
+			return dial(ctx, network, address)
+		}
+		addrs, ok := o[net.JoinHostPort(strings.ToLower(host), port)]
+		if !ok {
+			return dial(ctx, network, address)
+		}
+		for _, addr := range addrs {
+			var conn net.Conn
+			if conn, err = dial(ctx, network, net.JoinHostPort(addr, port)); err == nil {
+				return conn, nil
+			}
+		}
+		return nil, err
+	}
+}
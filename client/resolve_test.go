@@ -0,0 +1,47 @@
+// SPDX-FileCopyrightText: 2021 Eric Neidhardt
+// SPDX-License-Identifier: MIT
+package client
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/EricNeid/go-bench/internal/verify"
+)
+
+func TestHostOverrides_Add(t *testing.T) {
+	// arrange
+	unit := HostOverrides{}
+	// action
+	err1 := unit.Add("Example.com:443:10.0.0.5,10.0.0.6")
+	err2 := unit.Add("example.org:80:[::1]")
+	// verify
+	verify.Ok(t, err1)
+	verify.Ok(t, err2)
+	verify.Equals(t, HostOverrides{"example.com:443": {"10.0.0.5", "10.0.0.6"}, "example.org:80": {"::1"}}, unit)
+	verify.Assert(t, unit.Add("example.com:443") != nil, "Missing address not detected")
+	verify.Assert(t, unit.Add("example.com:443:backend") != nil, "Invalid address not detected")
+}
+
+func TestHostOverrides_DialContext(t *testing.T) {
+	// arrange
+	var receivedHost string
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		receivedHost = r.Host
+	}))
+	defer mockServer.Close()
+	_, port, _ := net.SplitHostPort(mockServer.Listener.Addr().String())
+	overrides := HostOverrides{}
+	verify.Ok(t, overrides.Add("api.gobench.invalid:"+port+":127.0.0.1"))
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.DialContext = overrides.DialContext(nil)
+	unit := NewClient(Request{URL: "http://api.gobench.invalid:" + port})
+	unit.HTTPClient.Transport = transport
+	// action
+	unit.PerformRequest()
+	// verify
+	verify.Equals(t, 1, unit.Statistic.SuccessCount)
+	verify.Equals(t, "api.gobench.invalid:"+port, receivedHost)
+}
@@ -0,0 +1,46 @@
+// SPDX-FileCopyrightText: 2021 Eric Neidhardt
+// SPDX-License-Identifier: MIT
+package client
+
+import "time"
+
+// Classes of requests, which received a response or were interrupted, see Result.Class. Requests failing
+// without a response are classified by their cause, one of FailureCauses.
+const (
+	// ClassSuccess is a response counted as Statistic.SuccessCount.
+	ClassSuccess = "success"
+	// ClassStatus is a response with an unexpected status, counted as Statistic.FailureCount.
+	ClassStatus = "status"
+	// ClassLengthMismatch is a response, whose body differs from its Content-Length.
+	ClassLengthMismatch = "length-mismatch"
+	// ClassValidation is a response, which failed a validation, see Client.Validations.
+	ClassValidation = "validation"
+	// ClassInterrupted is a request in flight, when the context of the run was done, see Statistic.InterruptedCount.
+	ClassInterrupted = "interrupted"
+)
+
+// Result is the outcome of a single request, as it is counted in the statistic of the client.
+type Result struct {
+	Request Request
+	// Time the request was sent.
+	Start time.Time
+	// Time until the body of the response was read, or until the request failed.
+	Latency time.Duration
+	// Status code of the response, 0 if none was received.
+	StatusCode int
+	// Body bytes read, after decompression, and written.
+	BytesRead    int64
+	BytesWritten int64
+	// Class of the request, ClassSuccess or one of the other classes and FailureCauses.
+	Class string
+	// Reason, why the request was not counted as success, nil otherwise.
+	Err error
+	// Durations of the phases of an operation of another Benchmarker, see Recorder.Record.
+	// The phases of the requests of a Client are counted by the client itself.
+	Phases map[string]time.Duration
+}
+
+// Success returns true, if the request was counted as success.
+func (r Result) Success() bool {
+	return r.Class == ClassSuccess
+}
@@ -0,0 +1,86 @@
+// SPDX-FileCopyrightText: 2021 Eric Neidhardt
+// SPDX-License-Identifier: MIT
+package client
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/EricNeid/go-bench/internal/verify"
+)
+
+func TestPerformRequest_result(t *testing.T) {
+	// arrange
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/missing" {
+			w.WriteHeader(http.StatusNotFound)
+		}
+		w.Write([]byte("test response"))
+	}))
+	defer mockServer.Close()
+	unit := NewClient(Request{URL: mockServer.URL, PostBody: []byte("payload")})
+	// action
+	result, ok := unit.PerformRequest()
+	// verify
+	verify.Assert(t, ok, "No request performed")
+	verify.Assert(t, result.Success(), "Request not successful")
+	verify.Equals(t, ClassSuccess, result.Class)
+	verify.Equals(t, http.StatusOK, result.StatusCode)
+	verify.Equals(t, int64(len("test response")), result.BytesRead)
+	verify.Equals(t, int64(len("payload")), result.BytesWritten)
+	verify.Equals(t, unit.Statistic.Latency, result.Latency)
+	verify.Equals(t, nil, result.Err)
+	verify.Equals(t, mockServer.URL, result.Request.URL)
+}
+
+func TestPerformRequest_resultOfFailure(t *testing.T) {
+	// arrange
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	unit := NewClient(Request{URL: mockServer.URL})
+	// action
+	status, _ := unit.PerformRequest()
+	mockServer.Close()
+	refused, _ := unit.PerformRequest()
+	// verify
+	verify.Equals(t, ClassStatus, status.Class)
+	verify.Equals(t, http.StatusNotFound, status.StatusCode)
+	verify.Assert(t, status.Err != nil, "Unexpected status not reported")
+	verify.Equals(t, FailureRefused, refused.Class)
+	verify.Equals(t, 0, refused.StatusCode)
+	verify.Assert(t, refused.Err != nil, "Network failure not reported")
+}
+
+func TestPerformRequest_resultWithoutRequests(t *testing.T) {
+	// arrange
+	requests := make(chan Request)
+	close(requests)
+	unit := NewClient(Request{})
+	unit.NextRequest = FromChannel(requests)
+	// action
+	_, ok := unit.PerformRequest()
+	// verify
+	verify.Assert(t, !ok, "Request performed without requests")
+	verify.Equals(t, 0, unit.Statistic.RequestCount)
+}
+
+func TestPerformRequest_onResult(t *testing.T) {
+	// arrange
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	unit := NewClient(Request{URL: mockServer.URL})
+	var results []Result
+	unit.OnResult = func(result Result) {
+		results = append(results, result)
+	}
+	// action
+	unit.RunForAmount(2)
+	mockServer.Close()
+	unit.PerformRequest()
+	// verify
+	verify.Equals(t, 3, len(results))
+	verify.Equals(t, ClassSuccess, results[0].Class)
+	verify.Equals(t, ClassSuccess, results[1].Class)
+	verify.Equals(t, FailureRefused, results[2].Class)
+}
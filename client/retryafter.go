@@ -0,0 +1,59 @@
+// SPDX-FileCopyrightText: 2021 Eric Neidhardt
+// SPDX-License-Identifier: MIT
+package client
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// retryAfter returns the delay requested by the Retry-After header of a 429 or 503 response received at now,
+// which is given in seconds or as HTTP date. It returns false for other responses and invalid headers.
+func retryAfter(resp *http.Response, now time.Time) (time.Duration, bool) {
+	if resp.StatusCode != http.StatusTooManyRequests && resp.StatusCode != http.StatusServiceUnavailable {
+		return 0, false
+	}
+	value := strings.TrimSpace(resp.Header.Get("Retry-After"))
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.ParseInt(value, 10, 64); err == nil {
+		if seconds < 0 {
+			return 0, false
+		}
+		return time.Duration(seconds) * time.Second, true
+	}
+	date, err := http.ParseTime(value)
+	if err != nil {
+		return 0, false
+	}
+	if delay := date.Sub(now); delay > 0 {
+		return delay, true
+	}
+	return 0, true
+}
+
+// waitForRetryAfter blocks until the delay of the last Retry-After header is over.
+// It returns false, if the context is done before.
+func (c *Client) waitForRetryAfter(ctx context.Context) bool {
+	start := time.Now()
+	if !c.retryAt.After(start) {
+		return true
+	}
+	timer := time.NewTimer(c.retryAt.Sub(start))
+	defer timer.Stop()
+	defer func() {
+		c.mu.Lock()
+		c.Statistic.Backoff += time.Since(start)
+		c.mu.Unlock()
+	}()
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
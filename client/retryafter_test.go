@@ -0,0 +1,72 @@
+// SPDX-FileCopyrightText: 2021 Eric Neidhardt
+// SPDX-License-Identifier: MIT
+package client
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/EricNeid/go-bench/internal/verify"
+)
+
+func TestRetryAfter(t *testing.T) {
+	// arrange
+	now := time.Date(2021, 6, 1, 12, 0, 0, 0, time.UTC)
+	response := func(status int, value string) *http.Response {
+		return &http.Response{StatusCode: status, Header: http.Header{"Retry-After": {value}}}
+	}
+	// action
+	seconds, okSeconds := retryAfter(response(http.StatusTooManyRequests, "120"), now)
+	date, okDate := retryAfter(response(http.StatusServiceUnavailable, "Tue, 01 Jun 2021 12:00:30 GMT"), now)
+	past, okPast := retryAfter(response(http.StatusServiceUnavailable, "Tue, 01 Jun 2021 11:00:00 GMT"), now)
+	_, okInvalid := retryAfter(response(http.StatusTooManyRequests, "soon"), now)
+	_, okStatus := retryAfter(response(http.StatusOK, "120"), now)
+	_, okMissing := retryAfter(&http.Response{StatusCode: http.StatusTooManyRequests}, now)
+	// verify
+	verify.Assert(t, okSeconds && okDate && okPast, "Valid Retry-After not detected")
+	verify.Equals(t, 120*time.Second, seconds)
+	verify.Equals(t, 30*time.Second, date)
+	verify.Equals(t, time.Duration(0), past)
+	verify.Assert(t, !okInvalid && !okStatus && !okMissing, "Unexpected Retry-After")
+}
+
+func TestPerformRequest_retryAfter(t *testing.T) {
+	// arrange
+	var requests int32
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&requests, 1) == 1 {
+			w.Header().Set("Retry-After", "60")
+			w.WriteHeader(http.StatusTooManyRequests)
+		}
+	}))
+	defer mockServer.Close()
+	unit := NewClient(Request{URL: mockServer.URL})
+	unit.RetryAfter = 100 * time.Millisecond
+	// action
+	start := time.Now()
+	unit.RunForAmount(2)
+	elapsed := time.Since(start)
+	// verify
+	verify.Equals(t, 1, unit.Statistic.SuccessCount)
+	verify.Equals(t, 1, unit.Statistic.ThrottledCount)
+	verify.Assert(t, unit.Statistic.Backoff >= 100*time.Millisecond, "Unexpected backoff %s", unit.Statistic.Backoff)
+	verify.Assert(t, elapsed < 10*time.Second, "Delay of Retry-After not limited")
+}
+
+func TestPerformRequest_retryAfterIgnored(t *testing.T) {
+	// arrange
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Retry-After", "60")
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer mockServer.Close()
+	unit := NewClient(Request{URL: mockServer.URL})
+	// action
+	unit.RunForAmount(2)
+	// verify
+	verify.Equals(t, 2, unit.Statistic.ThrottledCount)
+	verify.Equals(t, time.Duration(0), unit.Statistic.Backoff)
+}
@@ -0,0 +1,102 @@
+// SPDX-FileCopyrightText: 2021 Eric Neidhardt
+// SPDX-License-Identifier: MIT
+package client
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Runner runs slots of benchmarkers, e.g. clients, concurrently, each slot in a goroutine of its own.
+// The benchmarkers of a slot perform their operations in turn, see RunForAmount. All slots hold the same number
+// of benchmarkers, the i-th one of every slot belongs to the i-th workload and their statistics are summed up,
+// see Statistics.
+type Runner struct {
+	Slots [][]Benchmarker
+	// Number of operations performed by each benchmarker. If 0, they run for Duration.
+	Requests int
+	Duration time.Duration
+	// OnInterval is called in this interval with the statistics of the workloads while running,
+	// if both are set. It is called from a goroutine of its own, but never concurrently.
+	Interval   time.Duration
+	OnInterval func(statistics []Statistic, elapsed time.Duration)
+}
+
+// NewRunner creates a runner of the given number of clients, which perform the same request, see NewClient.
+func NewRunner(concurrency int, request Request, options ...ClientOption) *Runner {
+	r := &Runner{}
+	for i := 0; i < concurrency; i++ {
+		r.Slots = append(r.Slots, []Benchmarker{NewClient(request, options...)})
+	}
+	return r
+}
+
+// Run runs the benchmarkers until each performed its operations, the duration passed or the context is done,
+// and returns the elapsed time. It must not be called concurrently.
+func (r *Runner) Run(ctx context.Context) time.Duration {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var done sync.WaitGroup
+	done.Add(len(r.Slots))
+	startTime := time.Now()
+	for _, slot := range r.Slots {
+		go func(slot []Benchmarker) {
+			defer done.Done()
+			if r.Requests > 0 {
+				RunForAmount(ctx, r.Requests, slot...)
+			} else {
+				RunForDuration(ctx, r.Duration, slot...)
+			}
+		}(slot)
+	}
+
+	intervalsDone := make(chan struct{})
+	go func() {
+		defer close(intervalsDone)
+		if r.Interval <= 0 || r.OnInterval == nil {
+			return
+		}
+		ticker := time.NewTicker(r.Interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				r.OnInterval(r.Statistics(), time.Since(startTime))
+			}
+		}
+	}()
+
+	done.Wait()
+	elapsed := time.Since(startTime)
+	cancel()
+	<-intervalsDone
+	return elapsed
+}
+
+// Statistics returns the statistics of the workloads, summed up over all slots.
+// It can be called while the runner is running.
+func (r *Runner) Statistics() []Statistic {
+	var statistics []Statistic
+	for _, slot := range r.Slots {
+		for i, b := range slot {
+			if i == len(statistics) {
+				statistics = append(statistics, Statistic{})
+			}
+			statistics[i].Merge(b.Snapshot())
+		}
+	}
+	return statistics
+}
+
+// Statistic returns the statistics of all benchmarkers summed up, see Statistics.
+func (r *Runner) Statistic() Statistic {
+	var s Statistic
+	for _, workload := range r.Statistics() {
+		s.Merge(workload)
+	}
+	return s
+}
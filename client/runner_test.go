@@ -0,0 +1,97 @@
+// SPDX-FileCopyrightText: 2021 Eric Neidhardt
+// SPDX-License-Identifier: MIT
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/EricNeid/go-bench/internal/verify"
+)
+
+func TestRunner_Run(t *testing.T) {
+	// arrange
+	var receivedCount atomic.Int64
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		receivedCount.Add(1)
+		w.Write([]byte("test response"))
+	}))
+	defer mockServer.Close()
+	unit := NewRunner(4, Request{URL: mockServer.URL}, WithTimeout(time.Second))
+	unit.Requests = 5
+	// action
+	unit.Run(context.Background())
+	// verify
+	verify.Equals(t, int64(20), receivedCount.Load())
+	verify.Equals(t, 20, unit.Statistic().SuccessCount)
+	for _, slot := range unit.Slots {
+		verify.Equals(t, 5, slot[0].Snapshot().SuccessCount)
+	}
+}
+
+func TestRunner_Statistics(t *testing.T) {
+	// arrange
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/b" {
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer mockServer.Close()
+	unit := &Runner{Requests: 2}
+	for i := 0; i < 3; i++ {
+		unit.Slots = append(unit.Slots, []Benchmarker{
+			&Client{Request: Request{URL: mockServer.URL + "/a"}},
+			&Client{Request: Request{URL: mockServer.URL + "/b"}},
+		})
+	}
+	// action
+	unit.Run(context.Background())
+	statistics := unit.Statistics()
+	// verify
+	verify.Equals(t, 2, len(statistics))
+	verify.Equals(t, 6, statistics[0].SuccessCount)
+	verify.Equals(t, 6, statistics[1].FailureCount)
+	verify.Equals(t, 12, unit.Statistic().RequestCount)
+}
+
+func TestRunner_Run_canceled(t *testing.T) {
+	// arrange
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(time.Millisecond)
+	}))
+	defer mockServer.Close()
+	unit := NewRunner(2, Request{URL: mockServer.URL}, WithTimeout(time.Second))
+	unit.Duration = time.Minute
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	// action
+	elapsed := unit.Run(ctx)
+	// verify
+	verify.Assert(t, elapsed < 10*time.Second, "Runner not stopped by context, elapsed %s", elapsed)
+	verify.Assert(t, unit.Statistic().SuccessCount > 0, "No request received")
+}
+
+func TestRunner_OnInterval(t *testing.T) {
+	// arrange
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer mockServer.Close()
+	unit := NewRunner(2, Request{URL: mockServer.URL}, WithTimeout(time.Second))
+	unit.Duration = 200 * time.Millisecond
+	unit.Interval = 20 * time.Millisecond
+	var intervals []Statistic
+	unit.OnInterval = func(statistics []Statistic, elapsed time.Duration) {
+		intervals = append(intervals, statistics[0])
+	}
+	// action
+	unit.Run(context.Background())
+	// verify
+	verify.Assert(t, len(intervals) > 0, "OnInterval not called")
+	for i := 1; i < len(intervals); i++ {
+		verify.Assert(t, intervals[i-1].RequestCount <= intervals[i].RequestCount, "Intervals not summed up since the start")
+	}
+	verify.Assert(t, intervals[len(intervals)-1].SuccessCount <= unit.Statistic().SuccessCount, "Interval counts more than final statistic")
+}
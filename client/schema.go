@@ -0,0 +1,395 @@
+// SPDX-FileCopyrightText: 2021 Eric Neidhardt
+// SPDX-License-Identifier: MIT
+package client
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// Schema is a JSON Schema, against which response bodies are validated.
+// The structural keywords are supported: type, enum, const, properties, required, additionalProperties,
+// items, minItems, maxItems, minLength, maxLength, pattern, minimum, maximum, exclusiveMinimum,
+// exclusiveMaximum, allOf, anyOf, oneOf, not and local $ref. Other keywords, e.g. format, are ignored.
+// It is safe for concurrent use.
+type Schema struct {
+	root     interface{}
+	patterns map[string]*regexp.Regexp
+}
+
+// ParseSchema parses a JSON Schema.
+func ParseSchema(data []byte) (*Schema, error) {
+	var root interface{}
+	if err := json.Unmarshal(data, &root); err != nil {
+		return nil, fmt.Errorf("invalid schema: %w", err)
+	}
+	switch root.(type) {
+	case map[string]interface{}, bool:
+	default:
+		return nil, errors.New("invalid schema: expected an object or a boolean")
+	}
+	s := &Schema{root: root, patterns: make(map[string]*regexp.Regexp)}
+	if err := s.compilePatterns(root); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// compilePatterns compiles the patterns of all nested schemas in advance.
+func (s *Schema) compilePatterns(node interface{}) error {
+	switch n := node.(type) {
+	case map[string]interface{}:
+		for key, value := range n {
+			switch key {
+			case "enum", "const":
+				// values, not schemas
+				continue
+			case "pattern":
+				if pattern, ok := value.(string); ok {
+					re, err := regexp.Compile(pattern)
+					if err != nil {
+						return fmt.Errorf("invalid schema pattern: %w", err)
+					}
+					s.patterns[pattern] = re
+					continue
+				}
+			}
+			if err := s.compilePatterns(value); err != nil {
+				return err
+			}
+		}
+	case []interface{}:
+		for _, value := range n {
+			if err := s.compilePatterns(value); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Validate returns an error describing the first violation of the schema by the JSON document.
+func (s *Schema) Validate(document []byte) error {
+	decoder := json.NewDecoder(bytes.NewReader(document))
+	decoder.UseNumber()
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		return fmt.Errorf("invalid json: %w", err)
+	}
+	if decoder.More() {
+		return errors.New("invalid json: unexpected data after the document")
+	}
+	return s.validate(s.root, value, "$", 0)
+}
+
+// maxSchemaDepth limits the resolution of references, which might be recursive.
+const maxSchemaDepth = 64
+
+func (s *Schema) validate(schema, value interface{}, path string, depth int) error {
+	if depth > maxSchemaDepth {
+		return fmt.Errorf("%s: schema nested too deeply", path)
+	}
+	var node map[string]interface{}
+	switch sc := schema.(type) {
+	case bool:
+		if !sc {
+			return fmt.Errorf("%s: not allowed", path)
+		}
+		return nil
+	case map[string]interface{}:
+		node = sc
+	default:
+		return nil
+	}
+
+	if ref, ok := node["$ref"].(string); ok {
+		resolved, err := s.resolve(ref)
+		if err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+		if err := s.validate(resolved, value, path, depth+1); err != nil {
+			return err
+		}
+	}
+	if t, ok := node["type"]; ok {
+		if err := checkType(t, value, path); err != nil {
+			return err
+		}
+	}
+	if enum, ok := node["enum"].([]interface{}); ok {
+		found := false
+		for _, e := range enum {
+			if jsonEqual(e, value) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return fmt.Errorf("%s: value is not one of the enum", path)
+		}
+	}
+	if c, ok := node["const"]; ok && !jsonEqual(c, value) {
+		return fmt.Errorf("%s: value is not the constant", path)
+	}
+
+	switch v := value.(type) {
+	case map[string]interface{}:
+		if err := s.validateObject(node, v, path, depth); err != nil {
+			return err
+		}
+	case []interface{}:
+		if err := s.validateArray(node, v, path, depth); err != nil {
+			return err
+		}
+	case string:
+		length := float64(utf8.RuneCountInString(v))
+		if limit, ok := schemaNumber(node, "minLength"); ok && length < limit {
+			return fmt.Errorf("%s: string shorter than %v", path, limit)
+		}
+		if limit, ok := schemaNumber(node, "maxLength"); ok && length > limit {
+			return fmt.Errorf("%s: string longer than %v", path, limit)
+		}
+		if pattern, ok := node["pattern"].(string); ok && !s.patterns[pattern].MatchString(v) {
+			return fmt.Errorf("%s: string does not match %s", path, pattern)
+		}
+	case json.Number:
+		n, _ := v.Float64()
+		if limit, ok := schemaNumber(node, "minimum"); ok && n < limit {
+			return fmt.Errorf("%s: %s is less than %v", path, v, limit)
+		}
+		if limit, ok := schemaNumber(node, "maximum"); ok && n > limit {
+			return fmt.Errorf("%s: %s is greater than %v", path, v, limit)
+		}
+		if limit, ok := schemaNumber(node, "exclusiveMinimum"); ok && n <= limit {
+			return fmt.Errorf("%s: %s is not greater than %v", path, v, limit)
+		}
+		if limit, ok := schemaNumber(node, "exclusiveMaximum"); ok && n >= limit {
+			return fmt.Errorf("%s: %s is not less than %v", path, v, limit)
+		}
+	}
+
+	if allOf, ok := node["allOf"].([]interface{}); ok {
+		for _, sub := range allOf {
+			if err := s.validate(sub, value, path, depth+1); err != nil {
+				return err
+			}
+		}
+	}
+	if anyOf, ok := node["anyOf"].([]interface{}); ok {
+		matched := false
+		for _, sub := range anyOf {
+			if s.validate(sub, value, path, depth+1) == nil {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return fmt.Errorf("%s: value matches none of anyOf", path)
+		}
+	}
+	if oneOf, ok := node["oneOf"].([]interface{}); ok {
+		matched := 0
+		for _, sub := range oneOf {
+			if s.validate(sub, value, path, depth+1) == nil {
+				matched++
+			}
+		}
+		if matched != 1 {
+			return fmt.Errorf("%s: value matches %d of oneOf instead of one", path, matched)
+		}
+	}
+	if not, ok := node["not"]; ok && s.validate(not, value, path, depth+1) == nil {
+		return fmt.Errorf("%s: value matches not", path)
+	}
+	return nil
+}
+
+func (s *Schema) validateObject(node, object map[string]interface{}, path string, depth int) error {
+	if required, ok := node["required"].([]interface{}); ok {
+		for _, name := range required {
+			if name, ok := name.(string); ok {
+				if _, ok := object[name]; !ok {
+					return fmt.Errorf("%s: missing property %s", path, name)
+				}
+			}
+		}
+	}
+	properties, _ := node["properties"].(map[string]interface{})
+	additional, hasAdditional := node["additionalProperties"]
+	// sorted, so that the same violation is reported for every response
+	names := make([]string, 0, len(object))
+	for name := range object {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		propertyPath := path + "." + name
+		if sub, ok := properties[name]; ok {
+			if err := s.validate(sub, object[name], propertyPath, depth+1); err != nil {
+				return err
+			}
+		} else if hasAdditional {
+			if err := s.validate(additional, object[name], propertyPath, depth+1); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (s *Schema) validateArray(node map[string]interface{}, array []interface{}, path string, depth int) error {
+	length := float64(len(array))
+	if limit, ok := schemaNumber(node, "minItems"); ok && length < limit {
+		return fmt.Errorf("%s: fewer than %v items", path, limit)
+	}
+	if limit, ok := schemaNumber(node, "maxItems"); ok && length > limit {
+		return fmt.Errorf("%s: more than %v items", path, limit)
+	}
+	if items, ok := node["items"]; ok {
+		for i, item := range array {
+			if err := s.validate(items, item, fmt.Sprintf("%s[%d]", path, i), depth+1); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// resolve returns the schema of a local reference, e.g. #/$defs/user.
+func (s *Schema) resolve(ref string) (interface{}, error) {
+	if ref != "#" && !strings.HasPrefix(ref, "#/") {
+		return nil, fmt.Errorf("unsupported reference %s", ref)
+	}
+	node := s.root
+	for _, token := range strings.Split(strings.TrimPrefix(ref, "#"), "/")[1:] {
+		token = strings.ReplaceAll(strings.ReplaceAll(token, "~1", "/"), "~0", "~")
+		switch n := node.(type) {
+		case map[string]interface{}:
+			var ok bool
+			if node, ok = n[token]; !ok {
+				return nil, fmt.Errorf("unresolved reference %s", ref)
+			}
+		case []interface{}:
+			i, err := strconv.Atoi(token)
+			if err != nil || i < 0 || i >= len(n) {
+				return nil, fmt.Errorf("unresolved reference %s", ref)
+			}
+			node = n[i]
+		default:
+			return nil, fmt.Errorf("unresolved reference %s", ref)
+		}
+	}
+	return node, nil
+}
+
+// checkType checks the value against the type keyword, which is either a type or a list of types.
+func checkType(t, value interface{}, path string) error {
+	var types []string
+	switch tt := t.(type) {
+	case string:
+		types = []string{tt}
+	case []interface{}:
+		for _, name := range tt {
+			if name, ok := name.(string); ok {
+				types = append(types, name)
+			}
+		}
+	default:
+		return nil
+	}
+	actual := jsonType(value)
+	for _, name := range types {
+		if name == actual || (name == "number" && actual == "integer") {
+			return nil
+		}
+	}
+	return fmt.Errorf("%s: expected %s, got %s", path, strings.Join(types, " or "), actual)
+}
+
+// jsonType returns the JSON Schema type of a decoded value.
+func jsonType(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case string:
+		return "string"
+	case json.Number:
+		if n, err := v.Float64(); err == nil && n == math.Trunc(n) {
+			return "integer"
+		}
+		return "number"
+	case []interface{}:
+		return "array"
+	default:
+		return "object"
+	}
+}
+
+// jsonEqual compares decoded values, numbers by their value.
+func jsonEqual(a, b interface{}) bool {
+	if n, ok := b.(json.Number); ok {
+		f, err := n.Float64()
+		if err != nil {
+			return false
+		}
+		other, ok := a.(float64)
+		return ok && other == f
+	}
+	switch bv := b.(type) {
+	case []interface{}:
+		av, ok := a.([]interface{})
+		if !ok || len(av) != len(bv) {
+			return false
+		}
+		for i := range av {
+			if !jsonEqual(av[i], bv[i]) {
+				return false
+			}
+		}
+		return true
+	case map[string]interface{}:
+		av, ok := a.(map[string]interface{})
+		if !ok || len(av) != len(bv) {
+			return false
+		}
+		for k, v := range bv {
+			if other, ok := av[k]; !ok || !jsonEqual(other, v) {
+				return false
+			}
+		}
+		return true
+	}
+	return reflect.DeepEqual(a, b)
+}
+
+// schemaNumber returns the numeric keyword of a schema.
+func schemaNumber(node map[string]interface{}, keyword string) (float64, bool) {
+	n, ok := node[keyword].(float64)
+	return n, ok
+}
+
+// MatchesSchema returns a validation of kind schema, which fails if the body is no JSON document
+// valid against the schema.
+func MatchesSchema(schema *Schema) Validation {
+	return Validation{
+		Kind: "schema",
+		Check: func(_ *http.Response, body []byte) error {
+			if err := schema.Validate(body); err != nil {
+				return fmt.Errorf("schema violated: %w", err)
+			}
+			return nil
+		},
+	}
+}
@@ -0,0 +1,98 @@
+// SPDX-FileCopyrightText: 2021 Eric Neidhardt
+// SPDX-License-Identifier: MIT
+package client
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/EricNeid/go-bench/internal/verify"
+)
+
+const userSchema = `{
+	"type": "object",
+	"required": ["id", "name", "roles"],
+	"properties": {
+		"id": {"type": "integer", "minimum": 1},
+		"name": {"type": "string", "minLength": 1, "pattern": "^[a-z]+$"},
+		"roles": {"type": "array", "minItems": 1, "items": {"$ref": "#/$defs/role"}},
+		"email": {"type": ["string", "null"]}
+	},
+	"additionalProperties": false,
+	"$defs": {
+		"role": {"enum": ["admin", "user"]}
+	}
+}`
+
+func TestSchema_Validate(t *testing.T) {
+	// arrange
+	unit, err := ParseSchema([]byte(userSchema))
+	verify.Ok(t, err)
+	// action
+	valid := unit.Validate([]byte(`{"id": 1, "name": "max", "roles": ["admin"], "email": null}`))
+	// verify
+	verify.Ok(t, valid)
+	violations := map[string]string{
+		`{"id": 1, "name": "max", "roles": ["admin"]`:                `invalid json: unexpected EOF`,
+		`{"id": 1, "name": "max"}`:                                   `$: missing property roles`,
+		`{"id": 1.5, "name": "max", "roles": ["admin"]}`:             `$.id: expected integer, got number`,
+		`{"id": 0, "name": "max", "roles": ["admin"]}`:               `$.id: 0 is less than 1`,
+		`{"id": 1, "name": "Max", "roles": ["admin"]}`:               `$.name: string does not match ^[a-z]+$`,
+		`{"id": 1, "name": "max", "roles": ["admin", "root"]}`:       `$.roles[1]: value is not one of the enum`,
+		`{"id": 1, "name": "max", "roles": []}`:                      `$.roles: fewer than 1 items`,
+		`{"id": 1, "name": "max", "roles": ["user"], "admin": true}`: `$.admin: not allowed`,
+		`{"id": 1, "name": "max", "roles": ["user"], "email": 5}`:    `$.email: expected string or null, got integer`,
+		`[{"id": 1, "name": "max", "roles": ["user"]}]`:              `$: expected object, got array`,
+		`{"id": 1, "name": "max", "roles": ["user"]} {"id": 2}`:      `invalid json: unexpected data after the document`,
+	}
+	for document, expected := range violations {
+		err := unit.Validate([]byte(document))
+		verify.Assert(t, err != nil, "Violation of %s not detected", document)
+		verify.Equals(t, expected, err.Error())
+	}
+}
+
+func TestSchema_combinations(t *testing.T) {
+	// arrange
+	unit, err := ParseSchema([]byte(`{"oneOf": [{"type": "integer"}, {"type": "number", "maximum": 10}], "not": {"const": 3}}`))
+	verify.Ok(t, err)
+	// verify
+	verify.Ok(t, unit.Validate([]byte(`12`)))
+	verify.Ok(t, unit.Validate([]byte(`2.5`)))
+	verify.Assert(t, unit.Validate([]byte(`5`)) != nil, "Match of both oneOf not detected")
+	verify.Assert(t, unit.Validate([]byte(`11.5`)) != nil, "Match of no oneOf not detected")
+	verify.Assert(t, unit.Validate([]byte(`3`)) != nil, "Match of not not detected")
+}
+
+func TestParseSchema_invalid(t *testing.T) {
+	_, err := ParseSchema([]byte(`{"type": "object"`))
+	verify.Assert(t, err != nil, "Invalid json not detected")
+	_, err = ParseSchema([]byte(`"object"`))
+	verify.Assert(t, err != nil, "Invalid schema not detected")
+	_, err = ParseSchema([]byte(`{"properties": {"name": {"pattern": "("}}}`))
+	verify.Assert(t, err != nil, "Invalid pattern not detected")
+}
+
+func TestPerformRequest_withSchema(t *testing.T) {
+	// arrange
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/truncated" {
+			w.Write([]byte(`{"id": 1, "name": "max", "ro`))
+			return
+		}
+		w.Write([]byte(`{"id": 1, "name": "max", "roles": ["user"]}`))
+	}))
+	defer mockServer.Close()
+	schema, err := ParseSchema([]byte(userSchema))
+	verify.Ok(t, err)
+	unit := NewClient(Request{})
+	unit.Validations = []Validation{MatchesSchema(schema)}
+	unit.NextRequest = Sequential([]Request{{URL: mockServer.URL}, {URL: mockServer.URL + "/truncated"}}, 0)
+	// action
+	unit.RunForAmount(4)
+	// verify
+	verify.Equals(t, 2, unit.Statistic.SuccessCount)
+	verify.Equals(t, 2, unit.Statistic.ValidationFailedCount)
+	verify.Equals(t, map[string]int{"schema": 2}, unit.Statistic.ValidationFailures)
+}
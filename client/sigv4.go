@@ -0,0 +1,192 @@
+// SPDX-FileCopyrightText: 2021 Eric Neidhardt
+// SPDX-License-Identifier: MIT
+package client
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	// sigV4Algorithm is the signing algorithm of AWS Signature Version 4.
+	sigV4Algorithm = "AWS4-HMAC-SHA256"
+	// sigV4UnsignedPayload is sent by S3 requests instead of the hash of their payload, which is not signed then.
+	sigV4UnsignedPayload = "UNSIGNED-PAYLOAD"
+)
+
+// SigV4 signs requests with AWS Signature Version 4, e.g. for API Gateway or S3.
+type SigV4 struct {
+	AccessKeyID     string
+	SecretAccessKey string
+	// SessionToken of temporary credentials, optional.
+	SessionToken string
+	Region       string
+	Service      string
+}
+
+// Sign adds the signature headers to the request with the given body, signed at the given time.
+func (s *SigV4) Sign(req *http.Request, body []byte, now time.Time) {
+	s.sign(req, sha256Hex(body), now)
+}
+
+// sign adds the signature headers to the request with the given hex encoded hash of its body.
+func (s *SigV4) sign(req *http.Request, payloadHash string, now time.Time) {
+	now = now.UTC()
+	amzDate := now.Format("20060102T150405Z")
+	date := now.Format("20060102")
+
+	req.Header.Set("X-Amz-Date", amzDate)
+	if s.SessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", s.SessionToken)
+	}
+	if s.Service == "s3" {
+		req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+	}
+
+	host := req.Host
+	if host == "" {
+		host = req.URL.Host
+	}
+	headers := map[string]string{"host": host}
+	for k, v := range req.Header {
+		if name := strings.ToLower(k); strings.HasPrefix(name, "x-amz-") {
+			headers[name] = strings.TrimSpace(strings.Join(v, ","))
+		}
+	}
+	var names []string
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + headers[name] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	path := req.URL.Path
+	if path == "" {
+		path = "/"
+	}
+	canonicalURI := awsURIEncode(path, false)
+	if s.Service != "s3" {
+		// all services but S3 expect the path to be encoded twice
+		canonicalURI = awsURIEncode(canonicalURI, false)
+	}
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		canonicalURI,
+		canonicalQuery(req),
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+	scope := date + "/" + s.Region + "/" + s.Service + "/aws4_request"
+	stringToSign := strings.Join([]string{sigV4Algorithm, amzDate, scope, sha256Hex([]byte(canonicalRequest))}, "\n")
+
+	key := hmacSHA256([]byte("AWS4"+s.SecretAccessKey), date)
+	key = hmacSHA256(key, s.Region)
+	key = hmacSHA256(key, s.Service)
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("%s Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		sigV4Algorithm, s.AccessKeyID, scope, signedHeaders, signature))
+}
+
+// Transport returns a round tripper, which signs every request sent by base.
+// If base is nil, http.DefaultTransport is used.
+func (s *SigV4) Transport(base http.RoundTripper) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &sigV4Transport{signer: s, base: base}
+}
+
+type sigV4Transport struct {
+	signer *SigV4
+	base   http.RoundTripper
+}
+
+func (t *sigV4Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	payloadHash := sha256Hex(nil)
+	switch {
+	case streamedBody(req) && t.signer.Service == "s3":
+		// body files are not read twice for every request, S3 accepts them unsigned
+		payloadHash = sigV4UnsignedPayload
+	case req.GetBody != nil:
+		content, err := req.GetBody()
+		if err != nil {
+			return nil, err
+		}
+		hash := sha256.New()
+		_, err = io.Copy(hash, content)
+		content.Close()
+		if err != nil {
+			return nil, err
+		}
+		payloadHash = hex.EncodeToString(hash.Sum(nil))
+	}
+	// a round tripper must not modify the given request
+	signed := req.Clone(req.Context())
+	t.signer.sign(signed, payloadHash, time.Now())
+	return t.base.RoundTrip(signed)
+}
+
+// canonicalQuery returns the query parameters sorted and encoded for signing.
+func canonicalQuery(req *http.Request) string {
+	query := req.URL.Query()
+	var pairs [][2]string
+	for k, values := range query {
+		for _, v := range values {
+			pairs = append(pairs, [2]string{awsURIEncode(k, true), awsURIEncode(v, true)})
+		}
+	}
+	sort.Slice(pairs, func(i, j int) bool {
+		if pairs[i][0] != pairs[j][0] {
+			return pairs[i][0] < pairs[j][0]
+		}
+		return pairs[i][1] < pairs[j][1]
+	})
+	encoded := make([]string, len(pairs))
+	for i, pair := range pairs {
+		encoded[i] = pair[0] + "=" + pair[1]
+	}
+	return strings.Join(encoded, "&")
+}
+
+// awsURIEncode encodes all but the unreserved characters of RFC 3986, optionally keeping slashes.
+func awsURIEncode(value string, encodeSlash bool) string {
+	var b strings.Builder
+	for i := 0; i < len(value); i++ {
+		c := value[i]
+		switch {
+		case c >= 'A' && c <= 'Z', c >= 'a' && c <= 'z', c >= '0' && c <= '9', c == '-', c == '_', c == '.', c == '~':
+			b.WriteByte(c)
+		case c == '/' && !encodeSlash:
+			b.WriteByte(c)
+		default:
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
+
+func sha256Hex(data []byte) string {
+	hash := sha256.Sum256(data)
+	return hex.EncodeToString(hash[:])
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
@@ -0,0 +1,106 @@
+// SPDX-FileCopyrightText: 2021 Eric Neidhardt
+// SPDX-License-Identifier: MIT
+package client
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/EricNeid/go-bench/internal/verify"
+)
+
+// test vectors of the AWS Signature Version 4 test suite
+var testSigV4 = &SigV4{
+	AccessKeyID:     "AKIDEXAMPLE",
+	SecretAccessKey: "wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY",
+	Region:          "us-east-1",
+	Service:         "service",
+}
+
+var testSigV4Time = time.Date(2015, 8, 30, 12, 36, 0, 0, time.UTC)
+
+func TestSigV4Sign(t *testing.T) {
+	// arrange
+	req, err := http.NewRequest(http.MethodGet, "https://example.amazonaws.com/", http.NoBody)
+	verify.Ok(t, err)
+	// action
+	testSigV4.Sign(req, nil, testSigV4Time)
+	// verify
+	verify.Equals(t, "20150830T123600Z", req.Header.Get("X-Amz-Date"))
+	verify.Equals(t,
+		"AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20150830/us-east-1/service/aws4_request, SignedHeaders=host;x-amz-date, "+
+			"Signature=5fa00fa31553b73ebf1942676e86291e8372ff2a2260956d9b8aae1d763fbf31",
+		req.Header.Get("Authorization"))
+}
+
+func TestSigV4Sign_query(t *testing.T) {
+	// arrange
+	req, err := http.NewRequest(http.MethodGet, "https://example.amazonaws.com/?Param2=value2&Param1=value1", http.NoBody)
+	verify.Ok(t, err)
+	// action
+	testSigV4.Sign(req, nil, testSigV4Time)
+	// verify
+	verify.Assert(t,
+		strings.HasSuffix(req.Header.Get("Authorization"), "Signature=b97d918cfa904a5beff61c982a1b6f458b799221646efd99d3219ec94cdf2500"),
+		"Unexpected authorization %s", req.Header.Get("Authorization"))
+}
+
+func TestSigV4Transport(t *testing.T) {
+	// arrange
+	var received *http.Request
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = r
+	}))
+	defer mockServer.Close()
+	signer := &SigV4{AccessKeyID: "id", SecretAccessKey: "secret", SessionToken: "session", Region: "eu-central-1", Service: "s3"}
+	unit := NewClient(Request{URL: mockServer.URL + "/bucket/key", PostBody: []byte("content")})
+	unit.HTTPClient.Transport = signer.Transport(nil)
+	// action
+	unit.PerformRequest()
+	// verify
+	verify.Equals(t, 1, unit.Statistic.SuccessCount)
+	verify.Equals(t, "session", received.Header.Get("X-Amz-Security-Token"))
+	verify.Equals(t, sha256Hex([]byte("content")), received.Header.Get("X-Amz-Content-Sha256"))
+	verify.Assert(t,
+		strings.Contains(received.Header.Get("Authorization"), "SignedHeaders=host;x-amz-content-sha256;x-amz-date;x-amz-security-token,"),
+		"Unexpected authorization %s", received.Header.Get("Authorization"))
+}
+
+func TestSigV4Transport_bodyFile(t *testing.T) {
+	// arrange
+	var received *http.Request
+	var body []byte
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = r
+		body, _ = io.ReadAll(r.Body)
+	}))
+	defer mockServer.Close()
+	bodyFile := filepath.Join(t.TempDir(), "upload.bin")
+	verify.Ok(t, os.WriteFile(bodyFile, []byte("content"), 0o644))
+	s3 := &SigV4{AccessKeyID: "id", SecretAccessKey: "secret", Region: "eu-central-1", Service: "s3"}
+	unit := NewClient(Request{URL: mockServer.URL + "/bucket/key", Method: http.MethodPut, BodyFile: bodyFile})
+	// an outer round tripper wrapping the body
+	signing := s3.Transport(nil)
+	unit.HTTPClient.Transport = roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		wrapped := req.Clone(req.Context())
+		wrapped.Body = io.NopCloser(req.Body)
+		return signing.RoundTrip(wrapped)
+	})
+	// action
+	unit.PerformRequest()
+	// verify
+	verify.Equals(t, 1, unit.Statistic.SuccessCount)
+	verify.Equals(t, "UNSIGNED-PAYLOAD", received.Header.Get("X-Amz-Content-Sha256"))
+	verify.Equals(t, []byte("content"), body)
+}
+
+func TestAWSURIEncode(t *testing.T) {
+	verify.Equals(t, "/a%20b/c~d", awsURIEncode("/a b/c~d", false))
+	verify.Equals(t, "%2Fa%2Bb", awsURIEncode("/a+b", true))
+}
@@ -0,0 +1,60 @@
+// SPDX-FileCopyrightText: 2021 Eric Neidhardt
+// SPDX-License-Identifier: MIT
+package client
+
+// ResponseSizeBuckets are the inclusive upper bounds of the buckets of ResponseSizes in bytes.
+// The last bucket of ResponseSizes counts the larger responses.
+var ResponseSizeBuckets = [...]int64{1 << 10, 4 << 10, 16 << 10, 64 << 10, 256 << 10, 1 << 20, 4 << 20, 16 << 20}
+
+// ResponseSizes is the distribution of the body sizes of responses, after decompression.
+type ResponseSizes struct {
+	Count int
+	Total int64
+	Min   int64
+	Max   int64
+	// Number of responses per bucket, see ResponseSizeBuckets.
+	Buckets [len(ResponseSizeBuckets) + 1]int
+}
+
+// Add records the size of a response.
+func (s *ResponseSizes) Add(size int64) {
+	if s.Count == 0 || size < s.Min {
+		s.Min = size
+	}
+	if size > s.Max {
+		s.Max = size
+	}
+	s.Count++
+	s.Total += size
+	i := 0
+	for i < len(ResponseSizeBuckets) && size > ResponseSizeBuckets[i] {
+		i++
+	}
+	s.Buckets[i]++
+}
+
+// Merge adds the sizes recorded by other.
+func (s *ResponseSizes) Merge(other ResponseSizes) {
+	if other.Count == 0 {
+		return
+	}
+	if s.Count == 0 || other.Min < s.Min {
+		s.Min = other.Min
+	}
+	if other.Max > s.Max {
+		s.Max = other.Max
+	}
+	s.Count += other.Count
+	s.Total += other.Total
+	for i, n := range other.Buckets {
+		s.Buckets[i] += n
+	}
+}
+
+// Mean returns the mean size of the responses.
+func (s ResponseSizes) Mean() int64 {
+	if s.Count == 0 {
+		return 0
+	}
+	return s.Total / int64(s.Count)
+}
@@ -0,0 +1,65 @@
+// SPDX-FileCopyrightText: 2021 Eric Neidhardt
+// SPDX-License-Identifier: MIT
+package client
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/EricNeid/go-bench/internal/verify"
+)
+
+func TestResponseSizes(t *testing.T) {
+	// arrange
+	var unit ResponseSizes
+	// action
+	unit.Add(0)
+	unit.Add(1024)
+	unit.Add(1025)
+	unit.Add(20 << 20)
+	// verify
+	verify.Equals(t, 4, unit.Count)
+	verify.Equals(t, int64(0), unit.Min)
+	verify.Equals(t, int64(20<<20), unit.Max)
+	verify.Equals(t, int64(0+1024+1025+20<<20)/4, unit.Mean())
+	verify.Equals(t, [len(ResponseSizeBuckets) + 1]int{2, 1, 0, 0, 0, 0, 0, 0, 1}, unit.Buckets)
+}
+
+func TestResponseSizes_Merge(t *testing.T) {
+	// arrange
+	var unit, other ResponseSizes
+	unit.Add(100)
+	other.Add(10)
+	other.Add(5000)
+	// action
+	unit.Merge(other)
+	unit.Merge(ResponseSizes{})
+	// verify
+	verify.Equals(t, 3, unit.Count)
+	verify.Equals(t, int64(10), unit.Min)
+	verify.Equals(t, int64(5000), unit.Max)
+	verify.Equals(t, [len(ResponseSizeBuckets) + 1]int{2, 0, 1, 0, 0, 0, 0, 0, 0}, unit.Buckets)
+}
+
+func TestPerformRequest_responseSizes(t *testing.T) {
+	// arrange
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/large" {
+			w.Write([]byte(strings.Repeat("x", 2000)))
+			return
+		}
+		w.Write([]byte("small"))
+	}))
+	defer mockServer.Close()
+	unit := NewClient(Request{})
+	unit.NextRequest = Sequential([]Request{{URL: mockServer.URL}, {URL: mockServer.URL + "/large"}}, 0)
+	// action
+	unit.RunForAmount(2)
+	// verify
+	verify.Equals(t, int64(5), unit.Statistic.ResponseSizes.Min)
+	verify.Equals(t, int64(2000), unit.Statistic.ResponseSizes.Max)
+	verify.Equals(t, 1, unit.Statistic.ResponseSizes.Buckets[0])
+	verify.Equals(t, 1, unit.Statistic.ResponseSizes.Buckets[1])
+}
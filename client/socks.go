@@ -0,0 +1,41 @@
+// SPDX-FileCopyrightText: 2021 Eric Neidhardt
+// SPDX-License-Identifier: MIT
+package client
+
+import (
+	"context"
+	"net"
+
+	"golang.org/x/net/proxy"
+)
+
+// SOCKS5 returns a function for http.Transport.DialContext, which connects through the SOCKS5 proxy
+// at address, e.g. a bastion host or the dynamic forwarding of ssh -D. The proxy is dialed with dial,
+// if dial is nil, a net.Dialer is used. If user is set, the proxy authenticates with user and password.
+// Hosts are resolved by the proxy, unless they are resolved before, e.g. by a DNSCache or HostOverrides.
+func SOCKS5(
+	address, user, password string,
+	dial func(ctx context.Context, network, address string) (net.Conn, error),
+) func(ctx context.Context, network, address string) (net.Conn, error) {
+	if dial == nil {
+		dial = (&net.Dialer{}).DialContext
+	}
+	var auth *proxy.Auth
+	if user != "" {
+		auth = &proxy.Auth{User: user, Password: password}
+	}
+	// never fails for tcp
+	dialer, _ := proxy.SOCKS5("tcp", address, auth, contextDialer(dial))
+	return dialer.(proxy.ContextDialer).DialContext
+}
+
+// contextDialer dials the proxy with the context of the request.
+type contextDialer func(ctx context.Context, network, address string) (net.Conn, error)
+
+func (d contextDialer) Dial(network, address string) (net.Conn, error) {
+	return d(context.Background(), network, address)
+}
+
+func (d contextDialer) DialContext(ctx context.Context, network, address string) (net.Conn, error) {
+	return d(ctx, network, address)
+}
@@ -0,0 +1,106 @@
+// SPDX-FileCopyrightText: 2021 Eric Neidhardt
+// SPDX-License-Identifier: MIT
+package client
+
+import (
+	"encoding/binary"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/EricNeid/go-bench/internal/verify"
+)
+
+// serveSOCKS5 serves a SOCKS5 proxy with user password authentication (RFC 1929),
+// which connects to 127.0.0.1 for every host and sends the requested hosts to the channel.
+func serveSOCKS5(t *testing.T, user, password string, hosts chan<- string) string {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	verify.Ok(t, err)
+	t.Cleanup(func() { listener.Close() })
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				buf := make([]byte, 512)
+				// greeting, user password authentication only
+				if _, err := io.ReadFull(conn, buf[:2]); err != nil {
+					return
+				}
+				if _, err := io.ReadFull(conn, buf[:buf[1]]); err != nil {
+					return
+				}
+				_, _ = conn.Write([]byte{5, 2})
+				if _, err := io.ReadFull(conn, buf[:2]); err != nil {
+					return
+				}
+				receivedUser := make([]byte, buf[1])
+				_, _ = io.ReadFull(conn, receivedUser)
+				_, _ = io.ReadFull(conn, buf[:1])
+				receivedPassword := make([]byte, buf[0])
+				_, _ = io.ReadFull(conn, receivedPassword)
+				if string(receivedUser) != user || string(receivedPassword) != password {
+					_, _ = conn.Write([]byte{1, 1})
+					return
+				}
+				_, _ = conn.Write([]byte{1, 0})
+				// connect request with a domain name
+				if _, err := io.ReadFull(conn, buf[:5]); err != nil || buf[3] != 3 {
+					return
+				}
+				host := make([]byte, buf[4])
+				_, _ = io.ReadFull(conn, host)
+				_, _ = io.ReadFull(conn, buf[:2])
+				port := strconv.Itoa(int(binary.BigEndian.Uint16(buf[:2])))
+				hosts <- string(host)
+				upstream, err := net.Dial("tcp", net.JoinHostPort("127.0.0.1", port))
+				if err != nil {
+					return
+				}
+				defer upstream.Close()
+				_, _ = conn.Write([]byte{5, 0, 0, 1, 127, 0, 0, 1, 0, 0})
+				go func() { _, _ = io.Copy(upstream, conn) }()
+				_, _ = io.Copy(conn, upstream)
+			}()
+		}
+	}()
+	return listener.Addr().String()
+}
+
+func TestSOCKS5(t *testing.T) {
+	// arrange
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer mockServer.Close()
+	_, port, _ := net.SplitHostPort(mockServer.Listener.Addr().String())
+	hosts := make(chan string, 1)
+	address := serveSOCKS5(t, "max", "secret", hosts)
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.DialContext = SOCKS5(address, "max", "secret", nil)
+	unit := NewClient(Request{URL: "http://api.gobench.invalid:" + port})
+	unit.HTTPClient.Transport = transport
+	// action
+	unit.PerformRequest()
+	// verify
+	verify.Equals(t, 1, unit.Statistic.SuccessCount)
+	verify.Equals(t, "api.gobench.invalid", <-hosts)
+}
+
+func TestSOCKS5_invalidCredentials(t *testing.T) {
+	// arrange
+	address := serveSOCKS5(t, "max", "secret", make(chan string, 1))
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.DialContext = SOCKS5(address, "max", "wrong", nil)
+	unit := NewClient(Request{URL: "http://api.gobench.invalid"})
+	unit.HTTPClient.Transport = transport
+	// action
+	unit.PerformRequest()
+	// verify
+	verify.Equals(t, 1, unit.Statistic.NetworkFailedCount)
+}
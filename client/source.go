@@ -0,0 +1,81 @@
+// SPDX-FileCopyrightText: 2021 Eric Neidhardt
+// SPDX-License-Identifier: MIT
+package client
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strings"
+	"sync/atomic"
+)
+
+// SourceAddresses are local addresses, to which new connections are bound in turn, e.g. to avoid the exhaustion
+// of the ephemeral ports of a single address or to appear as many distinct clients to rate limiters.
+// The addresses have to be assigned to an interface of the host. Connections to IP addresses are bound
+// to the next address of their family, connections to hosts are only made to addresses of the family
+// of the bound address. It is safe for concurrent use.
+type SourceAddresses struct {
+	IPs []net.IP
+
+	next uint64
+}
+
+// InterfaceAddresses returns the addresses of the network interface, e.g. eth1, so that connections leave
+// the host through that interface. Link-local addresses are omitted.
+func InterfaceAddresses(name string) (*SourceAddresses, error) {
+	iface, err := net.InterfaceByName(name)
+	if err != nil {
+		return nil, fmt.Errorf("invalid interface %s: %w", name, err)
+	}
+	addrs, err := iface.Addrs()
+	if err != nil {
+		return nil, fmt.Errorf("invalid interface %s: %w", name, err)
+	}
+	sources := &SourceAddresses{}
+	for _, addr := range addrs {
+		if ipNet, ok := addr.(*net.IPNet); ok && !ipNet.IP.IsLinkLocalUnicast() {
+			sources.IPs = append(sources.IPs, ipNet.IP)
+		}
+	}
+	if len(sources.IPs) == 0 {
+		return nil, fmt.Errorf("interface %s has no addresses", name)
+	}
+	return sources, nil
+}
+
+// DialContext returns a function for http.Transport.DialContext, which dials with a copy of dialer,
+// whose local address is the next of the addresses. If dialer is nil, a net.Dialer is used.
+func (a *SourceAddresses) DialContext(dialer *net.Dialer) func(ctx context.Context, network, address string) (net.Conn, error) {
+	if dialer == nil {
+		dialer = &net.Dialer{}
+	}
+	return func(ctx context.Context, network, address string) (net.Conn, error) {
+		if len(a.IPs) == 0 {
+			return dialer.DialContext(ctx, network, address)
+		}
+		n := atomic.AddUint64(&a.next, 1) - 1
+		local := a.IPs[n%uint64(len(a.IPs))]
+		host, _, _ := net.SplitHostPort(address)
+		var family string
+		switch ip := net.ParseIP(host); {
+		case ip != nil:
+			family = ipFamily(&net.IPAddr{IP: ip})
+		case strings.HasSuffix(network, "4"):
+			family = IPv4
+		case strings.HasSuffix(network, "6"):
+			family = IPv6
+		}
+		// prefer the next address of the family of the destination
+		for i := uint64(1); family != "" && ipFamily(&net.IPAddr{IP: local}) != family && i < uint64(len(a.IPs)); i++ {
+			local = a.IPs[(n+i)%uint64(len(a.IPs))]
+		}
+		bound := *dialer
+		if strings.HasPrefix(network, "udp") {
+			bound.LocalAddr = &net.UDPAddr{IP: local}
+		} else {
+			bound.LocalAddr = &net.TCPAddr{IP: local}
+		}
+		return bound.DialContext(ctx, network, address)
+	}
+}
@@ -0,0 +1,77 @@
+// SPDX-FileCopyrightText: 2021 Eric Neidhardt
+// SPDX-License-Identifier: MIT
+package client
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/EricNeid/go-bench/internal/verify"
+)
+
+func TestSourceAddresses_DialContext(t *testing.T) {
+	// arrange
+	remoteAddrs := make(map[string]int)
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		host, _, _ := net.SplitHostPort(r.RemoteAddr)
+		remoteAddrs[host]++
+	}))
+	defer mockServer.Close()
+	sources := &SourceAddresses{IPs: []net.IP{net.ParseIP("127.0.0.1"), net.ParseIP("127.0.0.2")}}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.DialContext = sources.DialContext(nil)
+	transport.DisableKeepAlives = true
+	unit := NewClient(Request{URL: mockServer.URL})
+	unit.HTTPClient.Transport = transport
+	// action
+	unit.RunForAmount(4)
+	// verify
+	verify.Equals(t, 4, unit.Statistic.SuccessCount)
+	verify.Equals(t, map[string]int{"127.0.0.1": 2, "127.0.0.2": 2}, remoteAddrs)
+}
+
+func TestSourceAddresses_DialContext_family(t *testing.T) {
+	// arrange
+	var remoteAddr string
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		remoteAddr, _, _ = net.SplitHostPort(r.RemoteAddr)
+	}))
+	defer mockServer.Close()
+	sources := &SourceAddresses{IPs: []net.IP{net.ParseIP("::1"), net.ParseIP("127.0.0.2")}}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.DialContext = sources.DialContext(nil)
+	unit := NewClient(Request{URL: mockServer.URL})
+	unit.HTTPClient.Transport = transport
+	// action
+	unit.PerformRequest()
+	// verify
+	verify.Equals(t, 1, unit.Statistic.SuccessCount)
+	verify.Equals(t, "127.0.0.2", remoteAddr)
+}
+
+func TestInterfaceAddresses(t *testing.T) {
+	// arrange
+	interfaces, err := net.Interfaces()
+	verify.Ok(t, err)
+	var loopback string
+	for _, iface := range interfaces {
+		if iface.Flags&net.FlagLoopback != 0 {
+			loopback = iface.Name
+		}
+	}
+	if loopback == "" {
+		t.Skip("no loopback interface")
+	}
+	// action
+	result, err := InterfaceAddresses(loopback)
+	_, errUnknown := InterfaceAddresses("gobench-invalid0")
+	// verify
+	verify.Ok(t, err)
+	verify.Assert(t, len(result.IPs) > 0, "Missing addresses of %s", loopback)
+	for _, ip := range result.IPs {
+		verify.Assert(t, ip.IsLoopback(), "Unexpected address %s of %s", ip, loopback)
+	}
+	verify.Assert(t, errUnknown != nil, "Unknown interface not detected")
+}
@@ -0,0 +1,136 @@
+// SPDX-FileCopyrightText: 2021 Eric Neidhardt
+// SPDX-License-Identifier: MIT
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// SRVTargets are the targets of a DNS SRV record, e.g. _api._tcp.service.consul, to which connections are made
+// instead of the host of the URL. The Host header and TLS server name of the requests stay the same, so that
+// benchmarks follow service discovery instead of hardcoded hosts. New connections are distributed over the
+// targets of the lowest priority by their weights, like RFC 2782 describes.
+type SRVTargets struct {
+	// Name of the record.
+	Name string
+	// Resolver looking up the record, net.DefaultResolver if nil.
+	Resolver *net.Resolver
+	// Interval, in which the record is resolved again, so that new connections are made to the current
+	// targets. It is resolved once if 0. Failed lookups keep the previous targets.
+	Refresh time.Duration
+
+	mutex    sync.Mutex
+	records  []*net.SRV
+	resolved time.Time
+}
+
+// Lookup resolves the record and returns its targets as host:port.
+func (s *SRVTargets) Lookup(ctx context.Context) ([]string, error) {
+	records, err := s.lookup(ctx)
+	if err != nil {
+		return nil, err
+	}
+	targets := make([]string, len(records))
+	for i, record := range records {
+		targets[i] = srvAddress(record)
+	}
+	return targets, nil
+}
+
+// lookup returns the records, which are resolved if they have not been yet or Refresh passed.
+func (s *SRVTargets) lookup(ctx context.Context) ([]*net.SRV, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.records != nil && (s.Refresh <= 0 || time.Since(s.resolved) < s.Refresh) {
+		return s.records, nil
+	}
+	resolver := s.Resolver
+	if resolver == nil {
+		resolver = net.DefaultResolver
+	}
+	_, records, err := resolver.LookupSRV(ctx, "", "", s.Name)
+	if err == nil && len(records) == 0 {
+		err = errors.New("no records found")
+	}
+	if err != nil {
+		if s.records != nil {
+			// retried with the next refresh
+			s.resolved = time.Now()
+			return s.records, nil
+		}
+		return nil, fmt.Errorf("could not resolve srv record %s: %w", s.Name, err)
+	}
+	s.records, s.resolved = records, time.Now()
+	return records, nil
+}
+
+// srvOrder returns the targets in the order new connections try them: the first one is chosen from the
+// lowest priority by weight, followed by the other ones.
+func srvOrder(records []*net.SRV) []*net.SRV {
+	var lowest []*net.SRV
+	total := 0
+	for _, record := range records {
+		if len(lowest) > 0 && record.Priority > lowest[0].Priority {
+			continue
+		}
+		if len(lowest) > 0 && record.Priority < lowest[0].Priority {
+			lowest, total = nil, 0
+		}
+		lowest = append(lowest, record)
+		total += int(record.Weight)
+	}
+	first := lowest[rand.Intn(len(lowest))] //nolint:gosec // no security relevance
+	if total > 0 {
+		n := rand.Intn(total) //nolint:gosec // no security relevance
+		for _, record := range lowest {
+			if n -= int(record.Weight); n < 0 {
+				first = record
+				break
+			}
+		}
+	}
+	ordered := []*net.SRV{first}
+	for _, record := range records {
+		if record != first {
+			ordered = append(ordered, record)
+		}
+	}
+	return ordered
+}
+
+// srvAddress returns host:port of the target of a record.
+func srvAddress(record *net.SRV) string {
+	return net.JoinHostPort(strings.TrimSuffix(record.Target, "."), strconv.Itoa(int(record.Port)))
+}
+
+// DialContext returns a function for http.Transport.DialContext, which dials the targets of the record with
+// dial instead of the given address. If the chosen target fails, the other ones are tried. If dial is nil,
+// a net.Dialer is used.
+func (s *SRVTargets) DialContext(
+	dial func(ctx context.Context, network, address string) (net.Conn, error),
+) func(ctx context.Context, network, address string) (net.Conn, error) {
+	if dial == nil {
+		dial = (&net.Dialer{}).DialContext
+	}
+	return func(ctx context.Context, network, _ string) (net.Conn, error) {
+		records, err := s.lookup(ctx)
+		if err != nil {
+			return nil, &net.DNSError{Err: err.Error(), Name: s.Name}
+		}
+		for _, record := range srvOrder(records) {
+			var conn net.Conn
+			if conn, err = dial(ctx, network, srvAddress(record)); err == nil {
+				return conn, nil
+			}
+		}
+		return nil, err
+	}
+}
@@ -0,0 +1,115 @@
+// SPDX-FileCopyrightText: 2021 Eric Neidhardt
+// SPDX-License-Identifier: MIT
+package client
+
+import (
+	"context"
+	"encoding/binary"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/EricNeid/go-bench/internal/verify"
+)
+
+func TestSRVTargets_DialContext(t *testing.T) {
+	// arrange
+	var receivedHost string
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		receivedHost = r.Host
+	}))
+	defer mockServer.Close()
+	_, port, _ := net.SplitHostPort(mockServer.Listener.Addr().String())
+	server, err := net.ListenPacket("udp", "127.0.0.1:0")
+	verify.Ok(t, err)
+	defer server.Close()
+	p, _ := strconv.Atoi(port)
+	go serveSRV(server, []net.SRV{{Target: "localhost.", Port: uint16(p), Priority: 1, Weight: 10}})
+	srv := &SRVTargets{Name: "_api._tcp.gobench.test", Resolver: NewResolver(server.LocalAddr().String())}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.DialContext = srv.DialContext(nil)
+	unit := NewClient(Request{URL: "http://api.gobench.invalid"})
+	unit.HTTPClient.Transport = transport
+	// action
+	targets, err := srv.Lookup(context.Background())
+	unit.PerformRequest()
+	// verify
+	verify.Ok(t, err)
+	verify.Equals(t, []string{"localhost:" + port}, targets)
+	verify.Equals(t, 1, unit.Statistic.SuccessCount)
+	verify.Equals(t, "api.gobench.invalid", receivedHost)
+}
+
+func TestSRVTargets_Lookup_noRecords(t *testing.T) {
+	// arrange
+	server, err := net.ListenPacket("udp", "127.0.0.1:0")
+	verify.Ok(t, err)
+	defer server.Close()
+	go serveSRV(server, nil)
+	unit := &SRVTargets{Name: "_api._tcp.gobench.test", Resolver: NewResolver(server.LocalAddr().String())}
+	// action
+	_, err = unit.Lookup(context.Background())
+	// verify
+	verify.Assert(t, err != nil, "lookup should fail")
+}
+
+func TestSRVOrder(t *testing.T) {
+	// arrange
+	backup := &net.SRV{Target: "backup.", Priority: 2, Weight: 100}
+	weighted := &net.SRV{Target: "weighted.", Priority: 1, Weight: 100}
+	unused := &net.SRV{Target: "unused.", Priority: 1, Weight: 0}
+	// action
+	counts := make(map[string]int)
+	for i := 0; i < 100; i++ {
+		ordered := srvOrder([]*net.SRV{weighted, unused, backup})
+		verify.Equals(t, 3, len(ordered))
+		counts[ordered[0].Target]++
+	}
+	// verify
+	verify.Equals(t, map[string]int{"weighted.": 100}, counts)
+}
+
+// serveSRV answers SRV queries with records and other queries without answers, until conn is closed.
+func serveSRV(conn net.PacketConn, records []net.SRV) {
+	buf := make([]byte, 512)
+	for {
+		n, addr, err := conn.ReadFrom(buf)
+		if err != nil {
+			return
+		}
+		// header of 12 bytes, followed by the question: labels, type and class
+		end := 12
+		for end < n && buf[end] != 0 {
+			end += int(buf[end]) + 1
+		}
+		end += 5
+		if end > n {
+			continue
+		}
+		resp := append([]byte{}, buf[:end]...)
+		resp[2], resp[3] = 0x81, 0x80
+		resp[6], resp[7], resp[8], resp[9], resp[10], resp[11] = 0, 0, 0, 0, 0, 0
+		if binary.BigEndian.Uint16(buf[end-4:]) == 33 {
+			resp[7] = byte(len(records))
+			for _, record := range records {
+				var target []byte
+				for _, label := range strings.Split(strings.TrimSuffix(record.Target, "."), ".") {
+					target = append(target, byte(len(label)))
+					target = append(target, label...)
+				}
+				target = append(target, 0)
+				// name as pointer to the question, type SRV, class IN, ttl 60 and the record
+				resp = append(resp, 0xc0, 12, 0, 33, 0, 1, 0, 0, 0, 60)
+				resp = binary.BigEndian.AppendUint16(resp, uint16(6+len(target)))
+				resp = binary.BigEndian.AppendUint16(resp, record.Priority)
+				resp = binary.BigEndian.AppendUint16(resp, record.Weight)
+				resp = binary.BigEndian.AppendUint16(resp, record.Port)
+				resp = append(resp, target...)
+			}
+		}
+		_, _ = conn.WriteTo(resp, addr)
+	}
+}
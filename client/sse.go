@@ -0,0 +1,175 @@
+// SPDX-FileCopyrightText: 2021 Eric Neidhardt
+// SPDX-License-Identifier: MIT
+package client
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/http/httptrace"
+	"time"
+)
+
+// ContentTypeEventStream is the content type of Server-Sent Events.
+const ContentTypeEventStream = "text/event-stream"
+
+// SSE benchmarks an endpoint of Server-Sent Events. Each operation opens a stream and receives its events
+// until the server closes it, like an EventSource reconnecting afterwards. Events are counted as
+// Statistic.MessageCount while they arrive, the time until the headers of the response as PhaseStreamSetup
+// and until the first event as PhaseFirstMessage. Streams ending before the run is done are counted as
+// Statistic.DroppedCount, normally they are interrupted at the end of the run.
+type SSE struct {
+	// URL of the event stream.
+	URL string
+	// Header sent in addition.
+	Header http.Header
+	// HTTPClient opens the streams, it must not have a timeout.
+	HTTPClient *http.Client
+	// Timeout until the headers of the response, and between its events or comments, are received, 0 for no timeout.
+	Timeout time.Duration
+	// RateLimit is the maximum number of streams opened per second, unlimited if <= 0.
+	RateLimit float64
+
+	Recorder
+
+	limiter rateLimiter
+}
+
+// Perform opens a stream and receives its events.
+func (e *SSE) Perform(ctx context.Context) (Result, bool) {
+	e.limiter.wait(ctx, e.RateLimit)
+	if ctx.Err() != nil {
+		return Result{}, false
+	}
+	runCtx := ctx
+	ctx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+	var idle *time.Timer
+	if e.Timeout > 0 {
+		idle = time.AfterFunc(e.Timeout, func() {
+			cancel(context.DeadlineExceeded)
+		})
+		defer idle.Stop()
+	}
+	result := Result{
+		Request: Request{URL: e.URL},
+		Start:   time.Now(),
+	}
+	phases, trace := newPhaseTrace(result.Start)
+	resp, err := e.stream(httptrace.WithClientTrace(ctx, trace), idle, phases, &result)
+	result.Latency = time.Since(result.Start)
+	if cause := context.Cause(ctx); cause != nil && !RunDone(runCtx) {
+		// the stream was canceled by the idle timeout
+		err = cause
+	}
+	switch {
+	case resp == nil && RunDone(runCtx):
+		result.Class, result.Err = ClassInterrupted, err
+	case resp == nil:
+		result.Class, result.Err = ClassifyError(err), err
+	case resp.StatusCode != http.StatusOK:
+		result.Class, result.Err = ClassStatus, err
+	case errors.Is(err, errNoEventStream):
+		result.Class, result.Err = ClassValidation, err
+	case RunDone(runCtx):
+		result.Class, result.Err = ClassInterrupted, err
+	case err != nil:
+		result.Class, result.Err = ClassifyError(err), err
+	default:
+		result.Class = ClassSuccess
+	}
+	if resp != nil {
+		e.Update(func(s *Statistic) {
+			// streams are measured, even if they are interrupted
+			phases.record(s, resp.Proto, time.Now())
+			switch {
+			case result.Class == ClassInterrupted:
+				s.ReadThroughput += result.BytesRead
+				s.WireReadThroughput += result.BytesRead
+			case result.Class == ClassValidation:
+				if s.ValidationFailures == nil {
+					s.ValidationFailures = make(map[string]int)
+				}
+				s.ValidationFailures["sse"]++
+			case resp.StatusCode == http.StatusOK:
+				s.DroppedCount++
+			}
+		})
+	}
+	e.Record(result)
+	return result, true
+}
+
+// errNoEventStream is returned by stream for responses with another content type than ContentTypeEventStream.
+var errNoEventStream = errors.New("no event stream")
+
+// stream opens the stream and receives its events. The response is returned, if it was received,
+// even if the stream failed afterwards.
+func (e *SSE) stream(ctx context.Context, idle *time.Timer, phases *phaseTrace, result *Result) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, e.URL, http.NoBody)
+	if err != nil {
+		return nil, err
+	}
+	for key, values := range e.Header {
+		req.Header[key] = values
+	}
+	req.Header.Set("Accept", ContentTypeEventStream)
+	req.Header.Set("Cache-Control", "no-cache")
+	httpClient := e.HTTPClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	result.StatusCode = resp.StatusCode
+	phases.end(PhaseStreamSetup, result.Start)
+	if resp.StatusCode != http.StatusOK {
+		n, _ := io.Copy(io.Discard, resp.Body)
+		result.BytesRead = n
+		return resp, fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	if mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type")); mediaType != ContentTypeEventStream {
+		return resp, fmt.Errorf("%w: content type %s", errNoEventStream, resp.Header.Get("Content-Type"))
+	}
+	reader := bufio.NewReader(resp.Body)
+	data := false
+	for {
+		line, err := reader.ReadSlice('\n')
+		result.BytesRead += int64(len(line))
+		trimmed := bytes.TrimRight(line, "\r\n")
+		blank := len(trimmed) == 0 && err == nil
+		field := bytes.Equal(trimmed, []byte("data")) || bytes.HasPrefix(line, []byte("data:"))
+		for errors.Is(err, bufio.ErrBufferFull) {
+			// the rest of long lines is skipped, only the field name is needed
+			line, err = reader.ReadSlice('\n')
+			result.BytesRead += int64(len(line))
+		}
+		if errors.Is(err, io.EOF) {
+			return resp, nil
+		} else if err != nil {
+			return resp, err
+		}
+		if idle != nil {
+			idle.Reset(e.Timeout)
+		}
+		switch {
+		case blank && data:
+			// a blank line dispatches the event
+			data = false
+			phases.end(PhaseFirstMessage, result.Start)
+			e.Update(func(s *Statistic) {
+				s.MessageCount++
+			})
+		case field:
+			data = true
+		}
+	}
+}
@@ -0,0 +1,82 @@
+// SPDX-FileCopyrightText: 2021 Eric Neidhardt
+// SPDX-License-Identifier: MIT
+package client
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/EricNeid/go-bench/internal/verify"
+)
+
+// sseServer serves event streams, which send the events and are closed afterwards, if close is set.
+func sseServer(events string, closeStream bool) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/json" {
+			w.Write([]byte(`{}`))
+			return
+		}
+		w.Header().Set("Content-Type", ContentTypeEventStream+"; charset=utf-8")
+		w.Write([]byte(events))
+		w.(http.Flusher).Flush()
+		if !closeStream {
+			<-r.Context().Done()
+		}
+	}))
+}
+
+func TestSSE_interrupted(t *testing.T) {
+	// arrange
+	server := sseServer(": heartbeat\n\nevent: update\ndata: a\ndata: b\n\nid: 2\ndata\r\n\r\nretry: 100\n\n", false)
+	defer server.Close()
+	unit := &SSE{URL: server.URL}
+	// action
+	RunForDuration(context.Background(), 100*time.Millisecond, unit)
+	// verify
+	s := unit.Snapshot()
+	verify.Equals(t, 0, s.RequestCount)
+	verify.Equals(t, 1, s.InterruptedCount)
+	verify.Equals(t, 2, s.MessageCount)
+	verify.Equals(t, 0, s.DroppedCount)
+	verify.Equals(t, 1, s.Phases[PhaseStreamSetup].Count)
+	verify.Equals(t, 1, s.Phases[PhaseFirstMessage].Count)
+	verify.Equals(t, 1, s.NewConnections)
+	verify.Assert(t, s.ReadThroughput > 0, "read bytes not counted")
+}
+
+func TestSSE_dropped(t *testing.T) {
+	// arrange
+	server := sseServer("data: a\n\ndata: b\n\n", true)
+	defer server.Close()
+	unit := &SSE{URL: server.URL}
+	// action
+	RunForAmount(context.Background(), 3, unit)
+	// verify
+	s := unit.Snapshot()
+	verify.Equals(t, 3, s.SuccessCount)
+	verify.Equals(t, 3, s.DroppedCount)
+	verify.Equals(t, 6, s.MessageCount)
+	verify.Equals(t, int64(3*len("data: a\n\ndata: b\n\n")), s.ReadThroughput)
+}
+
+func TestSSE_failures(t *testing.T) {
+	// arrange
+	server := sseServer("data: a\n\n", false)
+	defer server.Close()
+	noStream := &SSE{URL: server.URL + "/json"}
+	idle := &SSE{URL: server.URL, Timeout: 50 * time.Millisecond}
+	// action
+	invalid, _ := noStream.Perform(context.Background())
+	timedOut, _ := idle.Perform(context.Background())
+	// verify
+	verify.Equals(t, ClassValidation, invalid.Class)
+	verify.Equals(t, map[string]int{"sse": 1}, noStream.Snapshot().ValidationFailures)
+	verify.Equals(t, FailureTimeout, timedOut.Class)
+	verify.Assert(t, errors.Is(timedOut.Err, context.DeadlineExceeded), "unexpected error %v", timedOut.Err)
+	verify.Equals(t, 1, idle.Snapshot().DroppedCount)
+	verify.Equals(t, 1, idle.Snapshot().MessageCount)
+}
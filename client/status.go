@@ -0,0 +1,88 @@
+// SPDX-FileCopyrightText: 2021 Eric Neidhardt
+// SPDX-License-Identifier: MIT
+package client
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// StatusRange is an inclusive range of status codes.
+type StatusRange struct {
+	Min int
+	Max int
+}
+
+// ExpectedStatus is the set of status codes, which count as success. If empty, 2xx is expected.
+type ExpectedStatus []StatusRange
+
+// ParseExpectedStatus parses a comma separated list of status codes and ranges, e.g. 200,201,429,300-399 or 2xx.
+func ParseExpectedStatus(value string) (ExpectedStatus, error) {
+	var expected ExpectedStatus
+	for _, field := range strings.Split(value, ",") {
+		field = strings.TrimSpace(field)
+		if field == "" {
+			continue
+		}
+		var r StatusRange
+		var err1, err2 error
+		switch {
+		case len(field) == 3 && strings.HasSuffix(strings.ToLower(field), "xx"):
+			r.Min, err1 = strconv.Atoi(field[:1])
+			r.Min *= 100
+			r.Max = r.Min + 99
+		case strings.Contains(field, "-"):
+			min, max, _ := strings.Cut(field, "-")
+			r.Min, err1 = strconv.Atoi(strings.TrimSpace(min))
+			r.Max, err2 = strconv.Atoi(strings.TrimSpace(max))
+		default:
+			r.Min, err1 = strconv.Atoi(field)
+			r.Max = r.Min
+		}
+		if err1 != nil || err2 != nil || r.Min < 100 || r.Max > 999 || r.Min > r.Max {
+			return nil, fmt.Errorf("invalid status code %s, expected e.g. 200, 300-399 or 4xx", field)
+		}
+		expected = append(expected, r)
+	}
+	return expected, nil
+}
+
+// Contains returns true if the status code is expected.
+func (e ExpectedStatus) Contains(code int) bool {
+	if len(e) == 0 {
+		return code >= 200 && code <= 299
+	}
+	for _, r := range e {
+		if code >= r.Min && code <= r.Max {
+			return true
+		}
+	}
+	return false
+}
+
+// ExpectsRedirect returns true if any 3xx status code is expected, in which case redirects should not be followed.
+func (e ExpectedStatus) ExpectsRedirect() bool {
+	for _, r := range e {
+		if r.Min <= 399 && r.Max >= 300 {
+			return true
+		}
+	}
+	return false
+}
+
+// String returns the expected status codes as they are parsed by ParseExpectedStatus.
+func (e ExpectedStatus) String() string {
+	if len(e) == 0 {
+		return "2xx"
+	}
+	fields := make([]string, len(e))
+	for i, r := range e {
+		if r.Min == r.Max {
+			fields[i] = strconv.Itoa(r.Min)
+		} else {
+			fields[i] = fmt.Sprintf("%d-%d", r.Min, r.Max)
+		}
+	}
+	return strings.Join(fields, ",")
+}
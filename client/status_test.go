@@ -0,0 +1,58 @@
+// SPDX-FileCopyrightText: 2021 Eric Neidhardt
+// SPDX-License-Identifier: MIT
+package client
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/EricNeid/go-bench/internal/verify"
+)
+
+func TestParseExpectedStatus(t *testing.T) {
+	// action
+	result, err := ParseExpectedStatus("200, 201,429,300-399,5xx")
+	_, invalid := ParseExpectedStatus("20x")
+	_, reversed := ParseExpectedStatus("399-300")
+	// verify
+	verify.Ok(t, err)
+	verify.Equals(t, ExpectedStatus{{200, 200}, {201, 201}, {429, 429}, {300, 399}, {500, 599}}, result)
+	verify.Equals(t, "200,201,429,300-399,500-599", result.String())
+	verify.Assert(t, invalid != nil, "Invalid status not detected")
+	verify.Assert(t, reversed != nil, "Reversed range not detected")
+}
+
+func TestExpectedStatus_Contains(t *testing.T) {
+	expected := ExpectedStatus{{429, 429}, {300, 399}}
+	verify.Assert(t, expected.Contains(429), "429 not expected")
+	verify.Assert(t, expected.Contains(302), "302 not expected")
+	verify.Assert(t, !expected.Contains(200), "200 expected")
+	verify.Assert(t, ExpectedStatus(nil).Contains(204), "204 not expected by default")
+	verify.Assert(t, !ExpectedStatus(nil).Contains(404), "404 expected by default")
+	verify.Assert(t, expected.ExpectsRedirect(), "Redirect not expected")
+	verify.Assert(t, !ExpectedStatus{{200, 299}, {400, 499}}.ExpectsRedirect(), "Redirect expected")
+}
+
+func TestPerformRequest_withExpectStatus(t *testing.T) {
+	// arrange
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/limited" {
+			w.WriteHeader(http.StatusTooManyRequests)
+		}
+	}))
+	defer mockServer.Close()
+	var reasons []string
+	unit := NewClient(Request{})
+	unit.ExpectStatus = ExpectedStatus{{429, 429}}
+	unit.NextRequest = Sequential([]Request{{URL: mockServer.URL + "/limited"}, {URL: mockServer.URL}}, 0)
+	unit.OnFailure = func(request Request, resp *http.Response, body []byte, reason error) {
+		reasons = append(reasons, reason.Error())
+	}
+	// action
+	unit.RunForAmount(2)
+	// verify
+	verify.Equals(t, 1, unit.Statistic.SuccessCount)
+	verify.Equals(t, 1, unit.Statistic.FailureCount)
+	verify.Equals(t, []string{"unexpected status 200 OK"}, reasons)
+}
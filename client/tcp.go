@@ -0,0 +1,165 @@
+// SPDX-FileCopyrightText: 2021 Eric Neidhardt
+// SPDX-License-Identifier: MIT
+package client
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"io"
+	"net"
+	"net/url"
+	"time"
+)
+
+// TCP benchmarks a server with plain TCP connections, e.g. a proxy, a TLS terminator or a custom protocol.
+// Each operation establishes a new connection, sends Payload and reads ReadSize bytes or until the server
+// closes the connection. The phases are counted as PhaseConnect, PhaseTLS or PhaseTLSResumed, PhaseTTFB
+// and PhaseTransfer, the successful operations are the connections per second.
+type TCP struct {
+	// URL of the server with scheme tcp or tls, e.g. tcp://localhost:9000.
+	URL string
+	// TLSConfig for tls, its ServerName defaults to the host of the URL.
+	TLSConfig *tls.Config
+	// Dial, if set, establishes the connections instead of a net.Dialer.
+	Dial func(ctx context.Context, network, addr string) (net.Conn, error)
+	// Payload sent after connecting, nothing is sent if it is empty.
+	Payload []byte
+	// ReadSize is the number of bytes read after sending the payload. If it is 0, the sending side of
+	// the connection is closed and all bytes are read until the server closes the connection.
+	ReadSize int64
+	// Timeout of each operation, including establishing the connection, 0 for no timeout.
+	Timeout time.Duration
+	// RateLimit is the maximum number of connections per second, unlimited if <= 0.
+	RateLimit float64
+
+	Recorder
+
+	limiter rateLimiter
+}
+
+// Perform establishes a connection, sends the payload and reads the response.
+func (t *TCP) Perform(ctx context.Context) (Result, bool) {
+	t.limiter.wait(ctx, t.RateLimit)
+	if ctx.Err() != nil {
+		return Result{}, false
+	}
+	runCtx := ctx
+	if t.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, t.Timeout)
+		defer cancel()
+	}
+	result := Result{Request: Request{URL: t.URL}, Start: time.Now(), Phases: make(map[string]time.Duration)}
+	err := t.exchange(ctx, &result)
+	result.Latency = time.Since(result.Start)
+	switch {
+	case err == nil:
+		result.Class = ClassSuccess
+		t.Update(func(s *Statistic) {
+			s.NewConnections++
+		})
+	case RunDone(runCtx):
+		result.Class, result.Err = ClassInterrupted, err
+	default:
+		if ctx.Err() != nil {
+			// the operation was interrupted by the deadline of the connection
+			err = ctx.Err()
+		}
+		result.Class, result.Err = ClassifyError(err), err
+	}
+	t.Record(result)
+	return result, true
+}
+
+// exchange connects, sends the payload and reads the response into result.
+func (t *TCP) exchange(ctx context.Context, result *Result) error {
+	conn, err := t.connect(ctx, result.Phases)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	// interrupt the operation, when the context is done
+	stop := context.AfterFunc(ctx, func() {
+		conn.SetDeadline(time.Now())
+	})
+	defer stop()
+	start := time.Now()
+	if len(t.Payload) > 0 {
+		n, err := conn.Write(t.Payload)
+		result.BytesWritten = int64(n)
+		if err != nil {
+			return err
+		}
+	}
+	var body io.Reader = conn
+	if t.ReadSize > 0 {
+		body = io.LimitReader(conn, t.ReadSize)
+	} else if closer, ok := conn.(interface{ CloseWrite() error }); ok {
+		// servers like echo servers respond until they read the end of the payload
+		if err := closer.CloseWrite(); err != nil {
+			return err
+		}
+	}
+	buf := make([]byte, 32*1024)
+	for {
+		n, err := body.Read(buf)
+		if n > 0 && result.BytesRead == 0 {
+			result.Phases[PhaseTTFB] = time.Since(start)
+			start = time.Now()
+		}
+		result.BytesRead += int64(n)
+		if errors.Is(err, io.EOF) {
+			break
+		} else if err != nil {
+			return err
+		}
+	}
+	if result.BytesRead < t.ReadSize {
+		return io.ErrUnexpectedEOF
+	}
+	if result.BytesRead > 0 {
+		result.Phases[PhaseTransfer] = time.Since(start)
+	}
+	return nil
+}
+
+// connect establishes the connection and adds the durations of its phases.
+func (t *TCP) connect(ctx context.Context, phases map[string]time.Duration) (net.Conn, error) {
+	u, err := url.Parse(t.URL)
+	if err != nil {
+		return nil, err
+	}
+	dial := t.Dial
+	if dial == nil {
+		dial = (&net.Dialer{}).DialContext
+	}
+	start := time.Now()
+	conn, err := dial(ctx, "tcp", u.Host)
+	if err != nil {
+		return nil, err
+	}
+	phases[PhaseConnect] = time.Since(start)
+	if u.Scheme != "tls" {
+		return conn, nil
+	}
+	config := t.TLSConfig.Clone()
+	if config == nil {
+		config = &tls.Config{}
+	}
+	if config.ServerName == "" {
+		config.ServerName = u.Hostname()
+	}
+	tlsConn := tls.Client(conn, config)
+	start = time.Now()
+	if err := tlsConn.HandshakeContext(ctx); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	if tlsConn.ConnectionState().DidResume {
+		phases[PhaseTLSResumed] = time.Since(start)
+	} else {
+		phases[PhaseTLS] = time.Since(start)
+	}
+	return tlsConn, nil
+}
@@ -0,0 +1,102 @@
+// SPDX-FileCopyrightText: 2021 Eric Neidhardt
+// SPDX-License-Identifier: MIT
+package client
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/EricNeid/go-bench/internal/verify"
+)
+
+// tcpServer accepts connections and serves them with handle, until it is closed.
+func tcpServer(t *testing.T, handle func(conn net.Conn)) net.Listener {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	verify.Ok(t, err)
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				handle(conn)
+			}()
+		}
+	}()
+	return listener
+}
+
+func TestTCP_echo(t *testing.T) {
+	// arrange
+	server := tcpServer(t, func(conn net.Conn) {
+		io.Copy(conn, conn)
+	})
+	defer server.Close()
+	unit := &TCP{URL: "tcp://" + server.Addr().String(), Payload: []byte("ping")}
+	// action
+	RunForAmount(context.Background(), 3, unit)
+	// verify
+	s := unit.Snapshot()
+	verify.Equals(t, 3, s.SuccessCount)
+	verify.Equals(t, 3, s.NewConnections)
+	verify.Equals(t, int64(12), s.ReadThroughput)
+	verify.Equals(t, int64(12), s.WriteThroughput)
+	verify.Equals(t, 3, s.Phases[PhaseConnect].Count)
+	verify.Equals(t, 3, s.Phases[PhaseTTFB].Count)
+}
+
+func TestTCP_readSize(t *testing.T) {
+	// arrange
+	server := tcpServer(t, func(conn net.Conn) {
+		conn.Write([]byte(strings.Repeat("x", 100)))
+	})
+	defer server.Close()
+	partial := &TCP{URL: "tcp://" + server.Addr().String(), ReadSize: 10}
+	truncated := &TCP{URL: "tcp://" + server.Addr().String(), ReadSize: 200}
+	// action
+	partialResult, _ := partial.Perform(context.Background())
+	truncatedResult, _ := truncated.Perform(context.Background())
+	// verify
+	verify.Ok(t, partialResult.Err)
+	verify.Equals(t, int64(10), partialResult.BytesRead)
+	verify.Assert(t, truncatedResult.Err != nil, "truncated response not detected")
+	verify.Equals(t, 1, truncated.Snapshot().NetworkFailedCount)
+}
+
+func TestTCP_tls(t *testing.T) {
+	// arrange
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("hello"))
+	}))
+	defer server.Close()
+	unit := &TCP{
+		URL:       "tls://" + strings.TrimPrefix(server.URL, "https://"),
+		TLSConfig: server.Client().Transport.(*http.Transport).TLSClientConfig,
+		Payload:   []byte("GET / HTTP/1.1\r\nHost: localhost\r\nConnection: close\r\n\r\n"),
+	}
+	// action
+	result, ok := unit.Perform(context.Background())
+	// verify
+	verify.Assert(t, ok, "operation should be performed")
+	verify.Ok(t, result.Err)
+	verify.Assert(t, result.Phases[PhaseTLS] > 0, "tls handshake not measured")
+	verify.Assert(t, result.BytesRead > int64(len("hello")), "response not read")
+}
+
+func TestTCP_refused(t *testing.T) {
+	// arrange
+	server := tcpServer(t, func(net.Conn) {})
+	server.Close()
+	unit := &TCP{URL: "tcp://" + server.Addr().String()}
+	// action
+	result, _ := unit.Perform(context.Background())
+	// verify
+	verify.Equals(t, FailureRefused, result.Class)
+}
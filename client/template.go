@@ -0,0 +1,167 @@
+// SPDX-FileCopyrightText: 2021 Eric Neidhardt
+// SPDX-License-Identifier: MIT
+package client
+
+import (
+	"context"
+	"crypto/rand"
+	"fmt"
+	mathrand "math/rand"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"text/template"
+	"time"
+)
+
+// Template renders placeholders in URL, header values and body of requests, evaluated per request.
+// Besides variables ({{.name}}) the following functions are available:
+//
+//	{{uuid}}            random UUID (version 4)
+//	{{randInt 1 1000}}  random integer in [1, 1000]
+//	{{timestamp}}       current unix time in seconds
+//	{{timestampMs}}     current unix time in milliseconds
+//	{{seq}}             sequence number, unique for all requests rendered by the template
+//
+// A Template is safe for concurrent use.
+type Template struct {
+	seq uint64
+
+	mu    sync.Mutex
+	cache map[string]*template.Template
+}
+
+// NewTemplate creates a new template, whose sequence starts with 1.
+func NewTemplate() *Template {
+	return &Template{cache: make(map[string]*template.Template)}
+}
+
+// HasPlaceholders returns true if URL, header values or body of the request contain placeholders.
+func HasPlaceholders(r Request) bool {
+	if strings.Contains(r.URL, "{{") || strings.Contains(string(r.PostBody), "{{") {
+		return true
+	}
+	for _, v := range r.AdditionalHeaders {
+		if strings.Contains(v, "{{") {
+			return true
+		}
+	}
+	return false
+}
+
+// Render returns a copy of the request with all placeholders evaluated.
+func (t *Template) Render(r Request, vars map[string]string) (Request, error) {
+	var err error
+	if r.URL, err = t.render(r.URL, vars); err != nil {
+		return r, err
+	}
+	if r.PostBody != nil {
+		body, err := t.render(string(r.PostBody), vars)
+		if err != nil {
+			return r, err
+		}
+		r.PostBody = []byte(body)
+	}
+	if len(r.AdditionalHeaders) > 0 {
+		headers := make(map[string]string, len(r.AdditionalHeaders))
+		for k, v := range r.AdditionalHeaders {
+			if headers[k], err = t.render(v, vars); err != nil {
+				return r, err
+			}
+		}
+		r.AdditionalHeaders = headers
+	}
+	return r, nil
+}
+
+// Next returns a function for Client.NextRequest, which renders the requests returned by next.
+// The variables are requested for every request, vars may be nil.
+// Requests, which cannot be rendered, fail when they are performed and are counted as network failures.
+func (t *Template) Next(
+	next func(ctx context.Context) (Request, bool),
+	vars func() map[string]string,
+) func(ctx context.Context) (Request, bool) {
+	return func(ctx context.Context) (Request, bool) {
+		request, ok := next(ctx)
+		if !ok {
+			return request, false
+		}
+		var v map[string]string
+		if vars != nil {
+			v = vars()
+		}
+		rendered, err := t.Render(request, v)
+		if err != nil {
+			request.err = fmt.Errorf("could not render request %s: %w", request.URL, err)
+			return request, true
+		}
+		return rendered, true
+	}
+}
+
+// Cycle returns variables for Template.Next, which hands out the rows in turn to all callers
+// and starts over at the end. It is safe for concurrent use.
+func Cycle(rows []map[string]string) func() map[string]string {
+	var next uint64
+	return func() map[string]string {
+		i := atomic.AddUint64(&next, 1) - 1
+		return rows[i%uint64(len(rows))]
+	}
+}
+
+func (t *Template) render(text string, vars map[string]string) (string, error) {
+	if !strings.Contains(text, "{{") {
+		return text, nil
+	}
+	tmpl, err := t.parse(text)
+	if err != nil {
+		return text, err
+	}
+	var b strings.Builder
+	if err := tmpl.Execute(&b, vars); err != nil {
+		return text, err
+	}
+	return b.String(), nil
+}
+
+func (t *Template) parse(text string) (*template.Template, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if tmpl, ok := t.cache[text]; ok {
+		return tmpl, nil
+	}
+	tmpl, err := template.New("request").
+		Option("missingkey=error").
+		Funcs(template.FuncMap{
+			"uuid":        newUUID,
+			"randInt":     randInt,
+			"timestamp":   func() int64 { return time.Now().Unix() },
+			"timestampMs": func() int64 { return time.Now().UnixNano() / int64(time.Millisecond) },
+			"seq":         func() uint64 { return atomic.AddUint64(&t.seq, 1) },
+		}).
+		Parse(text)
+	if err != nil {
+		return nil, err
+	}
+	t.cache[text] = tmpl
+	return tmpl, nil
+}
+
+// newUUID returns a random UUID (version 4).
+func newUUID() (string, error) {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return "", err
+	}
+	b[6] = (b[6] & 0x0f) | 0x40
+	b[8] = (b[8] & 0x3f) | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:]), nil
+}
+
+// randInt returns a random integer in [min, max].
+func randInt(min, max int) (int, error) {
+	if max < min {
+		return 0, fmt.Errorf("randInt: max %d is less than min %d", max, min)
+	}
+	return min + mathrand.Intn(max-min+1), nil //nolint:gosec // no security relevance
+}
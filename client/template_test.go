@@ -0,0 +1,103 @@
+// SPDX-FileCopyrightText: 2021 Eric Neidhardt
+// SPDX-License-Identifier: MIT
+package client
+
+import (
+	"regexp"
+	"strconv"
+	"testing"
+
+	"github.com/EricNeid/go-bench/internal/verify"
+)
+
+func TestTemplateRender(t *testing.T) {
+	// arrange
+	unit := NewTemplate()
+	request := Request{
+		URL:               "http://localhost/users/{{seq}}?id={{.id}}",
+		PostBody:          []byte(`{"id":"{{uuid}}","value":{{randInt 5 5}}}`),
+		AdditionalHeaders: map[string]string{"X-Seq": "{{seq}}", "Accept": "text/plain"},
+	}
+	// action
+	first, err1 := unit.Render(request, map[string]string{"id": "max"})
+	second, err2 := unit.Render(request, map[string]string{"id": "tom"})
+	// verify
+	verify.Ok(t, err1)
+	verify.Ok(t, err2)
+	verify.Equals(t, "http://localhost/users/1?id=max", first.URL)
+	verify.Equals(t, "2", first.AdditionalHeaders["X-Seq"])
+	verify.Equals(t, "text/plain", first.AdditionalHeaders["Accept"])
+	verify.Equals(t, "http://localhost/users/3?id=tom", second.URL)
+	verify.Assert(t,
+		regexp.MustCompile(`^\{"id":"[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}","value":5\}$`).Match(first.PostBody),
+		"Unexpected body %s", first.PostBody)
+	verify.Assert(t, string(first.PostBody) != string(second.PostBody), "Expected a new uuid per request")
+	verify.Equals(t, "{{seq}}", request.AdditionalHeaders["X-Seq"])
+}
+
+func TestTemplateRender_timestamp(t *testing.T) {
+	// arrange
+	unit := NewTemplate()
+	// action
+	result, err := unit.Render(Request{URL: "http://localhost/{{timestamp}}"}, nil)
+	// verify
+	verify.Ok(t, err)
+	timestamp, err := strconv.ParseInt(result.URL[len("http://localhost/"):], 10, 64)
+	verify.Ok(t, err)
+	verify.Assert(t, timestamp > 1600000000, "Unexpected timestamp %d", timestamp)
+}
+
+func TestTemplateRender_invalid(t *testing.T) {
+	// arrange
+	unit := NewTemplate()
+	// action
+	_, errParse := unit.Render(Request{URL: "http://localhost/{{unknown}}"}, nil)
+	_, errMissing := unit.Render(Request{URL: "http://localhost/{{.id}}"}, nil)
+	_, errRange := unit.Render(Request{URL: "http://localhost/{{randInt 10 1}}"}, nil)
+	// verify
+	verify.Assert(t, errParse != nil, "Expected error for unknown function")
+	verify.Assert(t, errMissing != nil, "Expected error for missing variable")
+	verify.Assert(t, errRange != nil, "Expected error for invalid range")
+}
+
+func TestTemplateNext(t *testing.T) {
+	// arrange
+	template := NewTemplate()
+	requests := []Request{{URL: "http://localhost/a/{{seq}}"}, {URL: "http://localhost/b/{{seq}}"}}
+	unit := template.Next(Sequential(requests, 0), nil)
+	// action
+	urls := []string{next(t, unit), next(t, unit), next(t, unit)}
+	// verify
+	verify.Equals(t, []string{"http://localhost/a/1", "http://localhost/b/2", "http://localhost/a/3"}, urls)
+}
+
+func TestTemplateNext_invalid(t *testing.T) {
+	// arrange
+	template := NewTemplate()
+	rows := []map[string]string{{"host": "bad host"}}
+	requests := []Request{{URL: "http://{{.host}}/"}, {URL: "http://localhost/{{.missing}}"}}
+	unit := NewClient(Request{})
+	unit.NextRequest = template.Next(Sequential(requests, 0), Cycle(rows))
+	// action
+	unit.RunForAmount(2)
+	// verify
+	verify.Equals(t, 2, unit.Statistic.RequestCount)
+	verify.Equals(t, 2, unit.Statistic.NetworkFailedCount)
+}
+
+func TestHasPlaceholders(t *testing.T) {
+	verify.Assert(t, !HasPlaceholders(Request{URL: "http://localhost", PostBody: []byte("{}")}), "Expected no placeholders")
+	verify.Assert(t, HasPlaceholders(Request{URL: "http://localhost/{{seq}}"}), "Expected placeholder in url")
+	verify.Assert(t, HasPlaceholders(Request{URL: "http://localhost", PostBody: []byte("{{uuid}}")}), "Expected placeholder in body")
+	verify.Assert(t, HasPlaceholders(Request{URL: "http://localhost", AdditionalHeaders: map[string]string{"X-Id": "{{uuid}}"}}), "Expected placeholder in header")
+}
+
+func TestCycle(t *testing.T) {
+	// arrange
+	rows := []map[string]string{{"user": "max"}, {"user": "tom"}}
+	unit := Cycle(rows)
+	// action
+	users := []string{unit()["user"], unit()["user"], unit()["user"]}
+	// verify
+	verify.Equals(t, []string{"max", "tom", "max"}, users)
+}
@@ -0,0 +1,44 @@
+// SPDX-FileCopyrightText: 2021 Eric Neidhardt
+// SPDX-License-Identifier: MIT
+package client
+
+import (
+	"crypto/tls"
+	"fmt"
+	"strings"
+)
+
+// tlsVersions are the supported TLS versions by their number.
+var tlsVersions = map[string]uint16{
+	"1.0": tls.VersionTLS10,
+	"1.1": tls.VersionTLS11,
+	"1.2": tls.VersionTLS12,
+	"1.3": tls.VersionTLS13,
+}
+
+// ParseTLSVersion parses a TLS version given by its number, e.g. 1.2 or 1.3.
+func ParseTLSVersion(version string) (uint16, error) {
+	v, ok := tlsVersions[strings.TrimPrefix(strings.ToLower(version), "tls")]
+	if !ok {
+		return 0, fmt.Errorf("unsupported tls version %s, expected 1.0, 1.1, 1.2 or 1.3", version)
+	}
+	return v, nil
+}
+
+// ParseCipherSuites parses cipher suites given by their names, e.g. TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256.
+// Insecure cipher suites are supported as well, so that their cost can be compared.
+func ParseCipherSuites(names []string) ([]uint16, error) {
+	suites := make(map[string]uint16)
+	for _, s := range append(tls.CipherSuites(), tls.InsecureCipherSuites()...) {
+		suites[s.Name] = s.ID
+	}
+	ids := make([]uint16, 0, len(names))
+	for _, name := range names {
+		id, ok := suites[strings.ToUpper(strings.TrimSpace(name))]
+		if !ok {
+			return nil, fmt.Errorf("unsupported cipher suite %s", name)
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
@@ -0,0 +1,52 @@
+// SPDX-FileCopyrightText: 2021 Eric Neidhardt
+// SPDX-License-Identifier: MIT
+package client
+
+import (
+	"crypto/tls"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/EricNeid/go-bench/internal/verify"
+)
+
+func TestParseTLSVersion(t *testing.T) {
+	// action
+	v12, err1 := ParseTLSVersion("1.2")
+	v13, err2 := ParseTLSVersion("TLS1.3")
+	_, err3 := ParseTLSVersion("1.4")
+	// verify
+	verify.Ok(t, err1)
+	verify.Ok(t, err2)
+	verify.Equals(t, uint16(tls.VersionTLS12), v12)
+	verify.Equals(t, uint16(tls.VersionTLS13), v13)
+	verify.Assert(t, err3 != nil, "Unsupported version not detected")
+}
+
+func TestParseCipherSuites(t *testing.T) {
+	// action
+	result, err := ParseCipherSuites([]string{"TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256", "tls_rsa_with_aes_128_cbc_sha"})
+	_, errUnknown := ParseCipherSuites([]string{"TLS_NULL"})
+	// verify
+	verify.Ok(t, err)
+	verify.Equals(t, []uint16{tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256, tls.TLS_RSA_WITH_AES_128_CBC_SHA}, result)
+	verify.Assert(t, errUnknown != nil, "Unsupported cipher suite not detected")
+}
+
+func TestPerformRequest_tlsVersions(t *testing.T) {
+	// arrange
+	mockServer := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer mockServer.Close()
+	transport := mockServer.Client().Transport.(*http.Transport).Clone()
+	transport.TLSClientConfig.MaxVersion = tls.VersionTLS12
+	transport.TLSClientConfig.CipherSuites = []uint16{tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256}
+	unit := NewClient(Request{URL: mockServer.URL})
+	unit.HTTPClient.Transport = transport
+	// action
+	unit.RunForAmount(2)
+	// verify
+	verify.Equals(t, 2, unit.Statistic.SuccessCount)
+	verify.Equals(t, map[string]int{"TLS 1.2": 2}, unit.Statistic.TLSVersions)
+	verify.Equals(t, map[string]int{"TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256": 2}, unit.Statistic.CipherSuites)
+}
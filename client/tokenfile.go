@@ -0,0 +1,100 @@
+// SPDX-FileCopyrightText: 2021 Eric Neidhardt
+// SPDX-License-Identifier: MIT
+package client
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+)
+
+// defaultTokenCheck is the interval, in which a token file is checked for changes, if none is given.
+const defaultTokenCheck = time.Second
+
+// TokenFile provides a bearer token, which is read from a file and re-read when the file changes,
+// so that an external process can rotate the token while running. It is safe for concurrent use.
+type TokenFile struct {
+	Path string
+	// Interval, in which the file is checked for changes, one second if zero.
+	// If Reload is set, the file is re-read in this interval even if it seems unchanged.
+	Interval time.Duration
+	Reload   bool
+
+	mu      sync.Mutex
+	token   string
+	modTime time.Time
+	size    int64
+	checked time.Time
+}
+
+// Token returns the current token of the file. If re-reading the file fails or it is empty,
+// e.g. while it is rewritten, the previous token is kept.
+func (f *TokenFile) Token() (string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	interval := f.Interval
+	if interval <= 0 {
+		interval = defaultTokenCheck
+	}
+	now := time.Now()
+	if f.token != "" && now.Sub(f.checked) < interval {
+		return f.token, nil
+	}
+	f.checked = now
+
+	info, err := os.Stat(f.Path)
+	if err == nil && f.token != "" && !f.Reload && info.ModTime().Equal(f.modTime) && info.Size() == f.size {
+		return f.token, nil
+	}
+	var token []byte
+	if err == nil {
+		token, err = os.ReadFile(f.Path)
+	}
+	if err == nil {
+		if token = bytes.TrimSpace(token); len(token) == 0 {
+			err = errors.New("token file is empty")
+		}
+	}
+	if err != nil {
+		if f.token == "" {
+			return "", err
+		}
+		log.Printf("Could not reload token, keeping the previous one: %s", err)
+		return f.token, nil
+	}
+	if f.token != "" && string(token) != f.token {
+		log.Printf("Reloaded token of %s", f.Path)
+	}
+	f.token, f.modTime, f.size = string(token), info.ModTime(), info.Size()
+	return f.token, nil
+}
+
+// Transport returns a round tripper, which sets the token as bearer token of every request sent by base.
+// If base is nil, http.DefaultTransport is used.
+func (f *TokenFile) Transport(base http.RoundTripper) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &tokenFileTransport{file: f, base: base}
+}
+
+type tokenFileTransport struct {
+	file *TokenFile
+	base http.RoundTripper
+}
+
+func (t *tokenFileTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	token, err := t.file.Token()
+	if err != nil {
+		return nil, fmt.Errorf("could not read token: %w", err)
+	}
+	// a round tripper must not modify the given request
+	authorized := req.Clone(req.Context())
+	authorized.Header.Set("Authorization", "Bearer "+token)
+	return t.base.RoundTrip(authorized)
+}
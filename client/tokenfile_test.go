@@ -0,0 +1,68 @@
+// SPDX-FileCopyrightText: 2021 Eric Neidhardt
+// SPDX-License-Identifier: MIT
+package client
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/EricNeid/go-bench/internal/verify"
+)
+
+func TestTokenFile(t *testing.T) {
+	// arrange
+	path := filepath.Join(t.TempDir(), "token")
+	verify.Ok(t, os.WriteFile(path, []byte("token-1\n"), 0o600))
+	var receivedAuthorization []string
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		receivedAuthorization = append(receivedAuthorization, r.Header.Get("Authorization"))
+	}))
+	defer mockServer.Close()
+	tokenFile := &TokenFile{Path: path}
+	unit := NewClient(Request{URL: mockServer.URL})
+	unit.HTTPClient.Transport = tokenFile.Transport(nil)
+	// action
+	unit.PerformRequest()
+	verify.Ok(t, os.WriteFile(path, []byte("token-2"), 0o600))
+	verify.Ok(t, os.Chtimes(path, time.Now(), time.Now().Add(time.Minute)))
+	unit.PerformRequest()
+	tokenFile.checked = time.Time{}
+	unit.PerformRequest()
+	// verify
+	verify.Equals(t, []string{"Bearer token-1", "Bearer token-1", "Bearer token-2"}, receivedAuthorization)
+}
+
+func TestTokenFile_keepsPrevious(t *testing.T) {
+	// arrange
+	path := filepath.Join(t.TempDir(), "token")
+	verify.Ok(t, os.WriteFile(path, []byte("token-1"), 0o600))
+	unit := &TokenFile{Path: path, Reload: true}
+	// action
+	first, err1 := unit.Token()
+	verify.Ok(t, os.WriteFile(path, nil, 0o600))
+	unit.checked = time.Time{}
+	second, err2 := unit.Token()
+	verify.Ok(t, os.Remove(path))
+	unit.checked = time.Time{}
+	third, err3 := unit.Token()
+	// verify
+	verify.Ok(t, err1)
+	verify.Ok(t, err2)
+	verify.Ok(t, err3)
+	verify.Equals(t, "token-1", first)
+	verify.Equals(t, "token-1", second)
+	verify.Equals(t, "token-1", third)
+}
+
+func TestTokenFile_missing(t *testing.T) {
+	// arrange
+	unit := &TokenFile{Path: filepath.Join(t.TempDir(), "token")}
+	// action
+	_, err := unit.Token()
+	// verify
+	verify.Assert(t, err != nil, "Missing token file not detected")
+}
@@ -0,0 +1,217 @@
+// SPDX-FileCopyrightText: 2021 Eric Neidhardt
+// SPDX-License-Identifier: MIT
+package client
+
+import (
+	"crypto/tls"
+	"net"
+	"net/http/httptrace"
+	"sync"
+	"time"
+)
+
+// Phases of a request, see Statistic.Phases.
+const (
+	// PhaseDNS is the lookup of the host.
+	PhaseDNS = "dns"
+	// PhaseConnect is the establishment of the TCP connection.
+	PhaseConnect = "connect"
+	// PhaseTLS is a full TLS handshake.
+	PhaseTLS = "tls"
+	// PhaseTLSResumed is a TLS handshake, which resumed a previous session.
+	PhaseTLSResumed = "tls-resumed"
+	// PhaseHandshake is the opening handshake of a WebSocket or MQTT connection, see WebSocket and MQTT.
+	PhaseHandshake = "handshake"
+	// PhaseTTFB is the time from sending the request until the first byte of the response is received,
+	// including the other phases of new connections.
+	PhaseTTFB = "ttfb"
+	// PhaseTransfer is the time from the first byte of the response until its body is read.
+	PhaseTransfer = "transfer"
+	// PhaseRoundTrip is the time from sending a message until its reply is received, see WebSocket, UDP and DNSQuery.
+	PhaseRoundTrip = "round-trip"
+	// PhaseStreamSetup is the time from starting a gRPC or event stream until the headers of its response are received.
+	PhaseStreamSetup = "stream-setup"
+	// PhaseFirstMessage is the time from starting a gRPC or event stream until its first message is received.
+	PhaseFirstMessage = "first-message"
+	// PhaseDelivery is the time from publishing an MQTT message until a subscriber receives it, see MQTT.
+	PhaseDelivery = "delivery"
+)
+
+// Phases are the phases of a request in their order.
+var Phases = []string{PhaseDNS, PhaseConnect, PhaseTLS, PhaseTLSResumed, PhaseHandshake, PhaseTTFB, PhaseTransfer, PhaseRoundTrip, PhaseStreamSetup, PhaseFirstMessage, PhaseDelivery}
+
+// phaseTrace records the phases of a single request. The callbacks of a trace can be called concurrently,
+// e.g. when dialing several addresses.
+type phaseTrace struct {
+	mu           sync.Mutex
+	start        time.Time
+	dnsStart     time.Time
+	connectStart time.Time
+	tlsStart     time.Time
+	firstByte    time.Time
+	durations    map[string]time.Duration
+	tls          *tls.ConnectionState
+	gotConn      bool
+	reused       bool
+	family       string
+}
+
+// newPhaseTrace returns a trace of a request sent at start.
+func newPhaseTrace(start time.Time) (*phaseTrace, *httptrace.ClientTrace) {
+	p := &phaseTrace{start: start, durations: make(map[string]time.Duration)}
+	return p, &httptrace.ClientTrace{
+		DNSStart: func(httptrace.DNSStartInfo) { p.begin(&p.dnsStart) },
+		DNSDone:  func(httptrace.DNSDoneInfo) { p.end(PhaseDNS, p.dnsStart) },
+		ConnectStart: func(_, _ string) {
+			p.begin(&p.connectStart)
+		},
+		ConnectDone: func(_, _ string, err error) {
+			if err == nil {
+				p.end(PhaseConnect, p.connectStart)
+			}
+		},
+		TLSHandshakeStart: func() { p.begin(&p.tlsStart) },
+		TLSHandshakeDone: func(state tls.ConnectionState, err error) {
+			if err == nil {
+				p.mu.Lock()
+				p.tls = &state
+				p.mu.Unlock()
+			}
+			switch {
+			case err != nil:
+			case state.DidResume:
+				p.end(PhaseTLSResumed, p.tlsStart)
+			default:
+				p.end(PhaseTLS, p.tlsStart)
+			}
+		},
+		GotConn: func(info httptrace.GotConnInfo) {
+			p.mu.Lock()
+			defer p.mu.Unlock()
+			if !p.gotConn {
+				p.gotConn, p.reused = true, info.Reused
+				if info.Conn != nil {
+					p.family = ipFamily(info.Conn.RemoteAddr())
+				}
+			}
+		},
+		GotFirstResponseByte: func() {
+			p.mu.Lock()
+			defer p.mu.Unlock()
+			if p.firstByte.IsZero() {
+				p.firstByte = time.Now()
+			}
+		},
+	}
+}
+
+// phaseTraceKey is the context key of the phaseTrace of a request, for round trippers whose connections
+// are not dialed with the context of the request, see NewFastHTTPTransport.
+type phaseTraceKey struct{}
+
+// connected records the connection of the request, whose phases were traced by conn when it was dialed.
+// The connection was reused, if conn is nil.
+func (p *phaseTrace) connected(conn *phaseTrace, remote net.Addr) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.gotConn {
+		return
+	}
+	p.gotConn, p.reused, p.family = true, conn == nil, ipFamily(remote)
+	if conn == nil {
+		return
+	}
+	conn.mu.Lock()
+	defer conn.mu.Unlock()
+	for phase, d := range conn.durations {
+		p.durations[phase] = d
+	}
+	p.tls = conn.tls
+}
+
+// begin sets the start of a phase, unless it has already been started.
+func (p *phaseTrace) begin(start *time.Time) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if start.IsZero() {
+		*start = time.Now()
+	}
+}
+
+// end records the duration of a phase, unless it has already been recorded.
+func (p *phaseTrace) end(phase string, start time.Time) {
+	now := time.Now()
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if _, ok := p.durations[phase]; !ok {
+		p.durations[phase] = now.Sub(start)
+	}
+}
+
+// record adds the phases to the statistic, the body of the response received with protocol was read at end.
+// Phases of new connections are missing, if an existing connection was reused.
+func (p *phaseTrace) record(s *Statistic, protocol string, end time.Time) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if !p.firstByte.IsZero() {
+		p.durations[PhaseTTFB] = p.firstByte.Sub(p.start)
+		p.durations[PhaseTransfer] = end.Sub(p.firstByte)
+	}
+	if p.gotConn && p.reused {
+		s.ReusedConnections++
+	} else if p.gotConn {
+		s.NewConnections++
+		if s.Protocols == nil {
+			s.Protocols = make(map[string]int)
+		}
+		s.Protocols[protocol]++
+		if p.family != "" {
+			if s.IPFamilies == nil {
+				s.IPFamilies = make(map[string]int)
+			}
+			s.IPFamilies[p.family]++
+		}
+	}
+	if p.tls != nil {
+		if s.TLSVersions == nil {
+			s.TLSVersions = make(map[string]int)
+			s.CipherSuites = make(map[string]int)
+		}
+		s.TLSVersions[tls.VersionName(p.tls.Version)]++
+		s.CipherSuites[tls.CipherSuiteName(p.tls.CipherSuite)]++
+	}
+	if s.Phases == nil {
+		s.Phases = make(map[string]DurationHistogram)
+	}
+	for phase, d := range p.durations {
+		h := s.Phases[phase]
+		h.Add(d)
+		s.Phases[phase] = h
+	}
+}
+
+// ConnectionReuse returns the percentage of the requests, which were sent over a reused connection.
+func (s Statistic) ConnectionReuse() float64 {
+	connections := s.NewConnections + s.ReusedConnections
+	if connections == 0 {
+		return 0
+	}
+	return float64(s.ReusedConnections) * 100 / float64(connections)
+}
+
+// TLSResumption returns the percentage of the TLS handshakes, which resumed a previous session.
+// Resumed handshakes save the key exchange and the certificate verification of full handshakes.
+func (s Statistic) TLSResumption() float64 {
+	handshakes := s.Phases[PhaseTLS].Count + s.Phases[PhaseTLSResumed].Count
+	if handshakes == 0 {
+		return 0
+	}
+	return float64(s.Phases[PhaseTLSResumed].Count) * 100 / float64(handshakes)
+}
+
+// TTFB returns the distribution of the times to first byte, see PhaseTTFB.
+// For streamed and large responses it is the latency perceived by users, while the total latency
+// is dominated by the transfer.
+func (s Statistic) TTFB() DurationHistogram {
+	return s.Phases[PhaseTTFB]
+}
@@ -0,0 +1,79 @@
+// SPDX-FileCopyrightText: 2021 Eric Neidhardt
+// SPDX-License-Identifier: MIT
+package client
+
+import (
+	"crypto/tls"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/EricNeid/go-bench/internal/verify"
+)
+
+func TestPerformRequest_phases(t *testing.T) {
+	// arrange
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(20 * time.Millisecond)
+		w.Write([]byte("first"))
+		w.(http.Flusher).Flush()
+		time.Sleep(20 * time.Millisecond)
+		w.Write([]byte("second"))
+	}))
+	defer mockServer.Close()
+	unit := NewClient(Request{URL: strings.Replace(mockServer.URL, "127.0.0.1", "localhost", 1), KeepAlive: true})
+	// action
+	unit.RunForAmount(2)
+	// verify
+	verify.Equals(t, 2, unit.Statistic.SuccessCount)
+	phases := unit.Statistic.Phases
+	// the connection is reused by the second request
+	verify.Equals(t, 1, phases[PhaseDNS].Count)
+	verify.Equals(t, 1, phases[PhaseConnect].Count)
+	verify.Equals(t, 0, phases[PhaseTLS].Count)
+	verify.Equals(t, 2, phases[PhaseTTFB].Count)
+	verify.Equals(t, 2, phases[PhaseTransfer].Count)
+	verify.Assert(t, phases[PhaseTTFB].Min >= 20*time.Millisecond, "Unexpected ttfb %s", phases[PhaseTTFB].Min)
+	verify.Assert(t, phases[PhaseTransfer].Min >= 20*time.Millisecond, "Unexpected transfer %s", phases[PhaseTransfer].Min)
+	verify.Equals(t, phases[PhaseTTFB], unit.Statistic.TTFB())
+	verify.Equals(t, 1, unit.Statistic.NewConnections)
+	verify.Equals(t, 1, unit.Statistic.ReusedConnections)
+	verify.Equals(t, 50.0, unit.Statistic.ConnectionReuse())
+	verify.Assert(t, phases[PhaseTTFB].Max < unit.Statistic.Latency, "Ttfb %s exceeds latency %s", phases[PhaseTTFB].Max, unit.Statistic.Latency)
+}
+
+func TestPerformRequest_phasesOfTLS(t *testing.T) {
+	// arrange
+	mockServer := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer mockServer.Close()
+	unit := NewClient(Request{URL: mockServer.URL})
+	unit.HTTPClient.Transport = mockServer.Client().Transport
+	// action
+	unit.PerformRequest()
+	// verify
+	verify.Equals(t, 1, unit.Statistic.SuccessCount)
+	verify.Equals(t, 1, unit.Statistic.Phases[PhaseTLS].Count)
+	verify.Assert(t, unit.Statistic.Phases[PhaseTLS].Min > 0, "Missing duration of tls handshake")
+	verify.Equals(t, 0.0, unit.Statistic.TLSResumption())
+}
+
+func TestPerformRequest_phasesOfResumedTLS(t *testing.T) {
+	// arrange
+	mockServer := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer mockServer.Close()
+	transport := mockServer.Client().Transport.(*http.Transport).Clone()
+	transport.TLSClientConfig.ClientSessionCache = tls.NewLRUClientSessionCache(0)
+	unit := NewClient(Request{URL: mockServer.URL})
+	unit.HTTPClient.Transport = transport
+	// action
+	unit.RunForAmount(3)
+	// verify
+	verify.Equals(t, 3, unit.Statistic.SuccessCount)
+	verify.Equals(t, 1, unit.Statistic.Phases[PhaseTLS].Count)
+	verify.Equals(t, 2, unit.Statistic.Phases[PhaseTLSResumed].Count)
+	verify.Equals(t, 200.0/3, unit.Statistic.TLSResumption())
+	verify.Equals(t, 3, unit.Statistic.NewConnections)
+	verify.Equals(t, 0.0, unit.Statistic.ConnectionReuse())
+}
@@ -0,0 +1,138 @@
+// SPDX-FileCopyrightText: 2021 Eric Neidhardt
+// SPDX-License-Identifier: MIT
+package client
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/url"
+	"os"
+	"time"
+)
+
+// UDP benchmarks a server with UDP datagrams, e.g. a game server or a DNS-like backend. Each operation sends
+// Payload as a datagram over a socket of its own, which is reused by the following operations. If Reply is set,
+// the operation waits for the reply of the server, e.g. of an echo server, and counts the round trip as
+// PhaseRoundTrip. Datagrams without reply within Timeout are counted as Statistic.LostCount, the socket is
+// replaced afterwards, so that late replies are not taken for the replies of the following datagrams.
+type UDP struct {
+	// URL of the server with scheme udp, e.g. udp://localhost:9000.
+	URL string
+	// Dial, if set, opens the sockets instead of a net.Dialer.
+	Dial func(ctx context.Context, network, addr string) (net.Conn, error)
+	// Payload sent by each operation.
+	Payload []byte
+	// Reply is set, if the server replies to each datagram.
+	Reply bool
+	// Timeout for the reply, 0 for no timeout.
+	Timeout time.Duration
+	// RateLimit is the maximum number of datagrams per second, unlimited if <= 0.
+	RateLimit float64
+
+	Recorder
+
+	conn    net.Conn
+	buf     []byte
+	limiter rateLimiter
+}
+
+// Perform sends the datagram and receives the reply, if one is expected.
+func (u *UDP) Perform(ctx context.Context) (Result, bool) {
+	u.limiter.wait(ctx, u.RateLimit)
+	if ctx.Err() != nil {
+		return Result{}, false
+	}
+	runCtx := ctx
+	if u.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, u.Timeout)
+		defer cancel()
+	}
+	result := Result{Request: Request{URL: u.URL}, Start: time.Now()}
+	err := u.exchange(ctx, &result)
+	result.Latency = time.Since(result.Start)
+	switch {
+	case err == nil:
+		result.Class = ClassSuccess
+	case RunDone(runCtx):
+		u.Close()
+		result.Class, result.Err = ClassInterrupted, err
+	default:
+		u.Close()
+		if ctx.Err() != nil {
+			// the reply was interrupted by the deadline of the socket
+			err = ctx.Err()
+		}
+		result.Class, result.Err = ClassifyError(err), err
+		if result.BytesWritten > 0 && errors.Is(err, context.DeadlineExceeded) {
+			u.Update(func(s *Statistic) {
+				s.LostCount++
+			})
+		}
+	}
+	u.Record(result)
+	return result, true
+}
+
+// exchange sends the datagram and reads the reply into result.
+func (u *UDP) exchange(ctx context.Context, result *Result) error {
+	if u.conn == nil {
+		if err := u.open(ctx); err != nil {
+			return err
+		}
+	}
+	// interrupt the operation, when the context is done
+	stop := context.AfterFunc(ctx, func() {
+		u.conn.SetDeadline(time.Now())
+	})
+	defer stop()
+	n, err := u.conn.Write(u.Payload)
+	result.BytesWritten = int64(n)
+	if err != nil || !u.Reply {
+		return err
+	}
+	start := time.Now()
+	n, err = u.conn.Read(u.buf)
+	if errors.Is(err, os.ErrDeadlineExceeded) && ctx.Err() != nil {
+		err = ctx.Err()
+	}
+	if err != nil {
+		return err
+	}
+	result.BytesRead = int64(n)
+	result.Phases = map[string]time.Duration{PhaseRoundTrip: time.Since(start)}
+	return nil
+}
+
+// open opens the socket.
+func (u *UDP) open(ctx context.Context) error {
+	target, err := url.Parse(u.URL)
+	if err != nil {
+		return err
+	}
+	dial := u.Dial
+	if dial == nil {
+		dial = (&net.Dialer{}).DialContext
+	}
+	conn, err := dial(ctx, "udp", target.Host)
+	if err != nil {
+		return err
+	}
+	u.conn = conn
+	if u.buf == nil {
+		// large enough for any datagram
+		u.buf = make([]byte, 64*1024)
+	}
+	return nil
+}
+
+// Close closes the socket, if it is open. The next operation opens a new socket.
+func (u *UDP) Close() error {
+	if u.conn == nil {
+		return nil
+	}
+	err := u.conn.Close()
+	u.conn = nil
+	return err
+}
@@ -0,0 +1,75 @@
+// SPDX-FileCopyrightText: 2021 Eric Neidhardt
+// SPDX-License-Identifier: MIT
+package client
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/EricNeid/go-bench/internal/verify"
+)
+
+// udpServer replies to the datagrams, except for every drop-th one if drop is set, until it is closed.
+func udpServer(t *testing.T, drop int) net.PacketConn {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	verify.Ok(t, err)
+	go func() {
+		buf := make([]byte, 2048)
+		for i := 1; ; i++ {
+			n, addr, err := conn.ReadFrom(buf)
+			if err != nil {
+				return
+			}
+			if drop > 0 && i%drop == 0 {
+				continue
+			}
+			conn.WriteTo(buf[:n], addr)
+		}
+	}()
+	return conn
+}
+
+func TestUDP_echo(t *testing.T) {
+	// arrange
+	server := udpServer(t, 0)
+	defer server.Close()
+	unit := &UDP{URL: "udp://" + server.LocalAddr().String(), Payload: []byte("ping"), Reply: true, Timeout: time.Second}
+	// action
+	RunForAmount(context.Background(), 3, unit)
+	// verify
+	s := unit.Snapshot()
+	verify.Equals(t, 3, s.SuccessCount)
+	verify.Equals(t, int64(12), s.ReadThroughput)
+	verify.Equals(t, int64(12), s.WriteThroughput)
+	verify.Equals(t, 3, s.Phases[PhaseRoundTrip].Count)
+}
+
+func TestUDP_lost(t *testing.T) {
+	// arrange
+	server := udpServer(t, 2)
+	defer server.Close()
+	unit := &UDP{URL: "udp://" + server.LocalAddr().String(), Payload: []byte("ping"), Reply: true, Timeout: 50 * time.Millisecond}
+	// action
+	RunForAmount(context.Background(), 4, unit)
+	// verify
+	s := unit.Snapshot()
+	verify.Equals(t, 2, s.SuccessCount)
+	verify.Equals(t, 2, s.LostCount)
+	verify.Equals(t, map[string]int{FailureTimeout: 2}, s.NetworkFailures)
+}
+
+func TestUDP_withoutReply(t *testing.T) {
+	// arrange
+	server := udpServer(t, 0)
+	defer server.Close()
+	unit := &UDP{URL: "udp://" + server.LocalAddr().String(), Payload: make([]byte, 512)}
+	// action
+	RunForAmount(context.Background(), 10, unit)
+	// verify
+	s := unit.Snapshot()
+	verify.Equals(t, 10, s.SuccessCount)
+	verify.Equals(t, int64(10*512), s.WriteThroughput)
+	verify.Equals(t, int64(0), s.ReadThroughput)
+}
@@ -0,0 +1,94 @@
+// SPDX-FileCopyrightText: 2021 Eric Neidhardt
+// SPDX-License-Identifier: MIT
+package client
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"regexp"
+	"sync"
+)
+
+// Validation checks the responses with an expected status, see Client.Validations.
+type Validation struct {
+	// Kind of the validation, e.g. body, under which failures are counted in Statistic.ValidationFailures.
+	Kind  string
+	Check func(resp *http.Response, body []byte) error
+}
+
+// BodyMatches returns a validation of kind body, which fails if the body does not match the regular expression.
+func BodyMatches(re *regexp.Regexp) Validation {
+	return Validation{
+		Kind: "body",
+		Check: func(_ *http.Response, body []byte) error {
+			if !re.Match(body) {
+				return fmt.Errorf("body does not match %s", re)
+			}
+			return nil
+		},
+	}
+}
+
+// validate performs the validations and counts the failed ones. It returns the first failure.
+func (c *Client) validate(resp *http.Response, body []byte) error {
+	var failure error
+	for _, v := range c.Validations {
+		err := v.Check(resp, body)
+		if err == nil {
+			continue
+		}
+		if c.Statistic.ValidationFailures == nil {
+			c.Statistic.ValidationFailures = make(map[string]int)
+		}
+		c.Statistic.ValidationFailures[v.Kind]++
+		if failure == nil {
+			failure = err
+		}
+	}
+	return failure
+}
+
+// ChecksumMatches returns a validation of kind checksum, which fails if the SHA-256 of the body
+// is not the given hex encoded checksum.
+func ChecksumMatches(checksum string) (Validation, error) {
+	expected, err := hex.DecodeString(checksum)
+	if err != nil || len(expected) != sha256.Size {
+		return Validation{}, fmt.Errorf("invalid sha-256 checksum %s", checksum)
+	}
+	return Validation{
+		Kind: "checksum",
+		Check: func(_ *http.Response, body []byte) error {
+			if sum := sha256.Sum256(body); !bytes.Equal(sum[:], expected) {
+				return fmt.Errorf("checksum %x does not match %s", sum, checksum)
+			}
+			return nil
+		},
+	}, nil
+}
+
+// ConsistentBody returns a validation of kind checksum, which fails if the SHA-256 of the body differs from
+// the one of the first response received for the same URL. It may be shared by all clients.
+func ConsistentBody() Validation {
+	var mu sync.Mutex
+	first := make(map[string][sha256.Size]byte)
+	return Validation{
+		Kind: "checksum",
+		Check: func(resp *http.Response, body []byte) error {
+			sum := sha256.Sum256(body)
+			url := resp.Request.URL.String()
+			mu.Lock()
+			expected, ok := first[url]
+			if !ok {
+				first[url] = sum
+			}
+			mu.Unlock()
+			if ok && sum != expected {
+				return fmt.Errorf("checksum %x differs from the first response %x", sum, expected)
+			}
+			return nil
+		},
+	}
+}
@@ -0,0 +1,77 @@
+// SPDX-FileCopyrightText: 2021 Eric Neidhardt
+// SPDX-License-Identifier: MIT
+package client
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"testing"
+
+	"github.com/EricNeid/go-bench/internal/verify"
+)
+
+func TestPerformRequest_withValidations(t *testing.T) {
+	// arrange
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/error":
+			w.Write([]byte(`{"error":"database unavailable"}`))
+		case "/missing":
+			w.WriteHeader(http.StatusNotFound)
+		default:
+			w.Write([]byte(`{"status":"ok"}`))
+		}
+	}))
+	defer mockServer.Close()
+	var reasons []string
+	unit := NewClient(Request{})
+	unit.Validations = []Validation{BodyMatches(regexp.MustCompile(`"status":"ok"`))}
+	unit.NextRequest = Sequential([]Request{{URL: mockServer.URL}, {URL: mockServer.URL + "/error"}, {URL: mockServer.URL + "/missing"}}, 0)
+	unit.OnFailure = func(request Request, resp *http.Response, body []byte, reason error) {
+		reasons = append(reasons, reason.Error())
+	}
+	// action
+	unit.RunForAmount(3)
+	// verify
+	verify.Equals(t, 3, unit.Statistic.RequestCount)
+	verify.Equals(t, 1, unit.Statistic.SuccessCount)
+	verify.Equals(t, 1, unit.Statistic.ValidationFailedCount)
+	verify.Equals(t, 1, unit.Statistic.FailureCount)
+	verify.Equals(t, map[string]int{"body": 1}, unit.Statistic.ValidationFailures)
+	verify.Equals(t, []string{`body does not match "status":"ok"`, "unexpected status 404 Not Found"}, reasons)
+}
+
+func TestChecksumMatches(t *testing.T) {
+	// arrange
+	unit, err := ChecksumMatches("2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824")
+	verify.Ok(t, err)
+	// verify
+	verify.Ok(t, unit.Check(nil, []byte("hello")))
+	verify.Assert(t, unit.Check(nil, []byte("hell")) != nil, "Truncated body not detected")
+	_, err = ChecksumMatches("2cf24dba")
+	verify.Assert(t, err != nil, "Invalid checksum not detected")
+}
+
+func TestPerformRequest_withConsistentBody(t *testing.T) {
+	// arrange
+	count := 0
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		count++
+		if r.URL.Path == "/" && count == 3 {
+			w.Write([]byte("corrupt"))
+			return
+		}
+		w.Write([]byte(r.URL.Path))
+	}))
+	defer mockServer.Close()
+	unit := NewClient(Request{})
+	unit.Validations = []Validation{ConsistentBody()}
+	unit.NextRequest = Sequential([]Request{{URL: mockServer.URL}, {URL: mockServer.URL + "/other"}}, 0)
+	// action
+	unit.RunForAmount(4)
+	// verify
+	verify.Equals(t, 3, unit.Statistic.SuccessCount)
+	verify.Equals(t, 1, unit.Statistic.ValidationFailedCount)
+	verify.Equals(t, map[string]int{"checksum": 1}, unit.Statistic.ValidationFailures)
+}
@@ -0,0 +1,202 @@
+// SPDX-FileCopyrightText: 2021 Eric Neidhardt
+// SPDX-License-Identifier: MIT
+package client
+
+import (
+	"context"
+	"crypto/tls"
+	"net"
+	"net/http"
+	"net/url"
+	"time"
+
+	"golang.org/x/net/websocket"
+)
+
+// WebSocket benchmarks a WebSocket server over a single connection, which is opened by the first operation.
+// Each operation sends Message and counts the round trip until the reply of the server is received, e.g. of
+// an echo server, as PhaseRoundTrip. Connections are established again after a failure, their phases are
+// counted as PhaseConnect, PhaseTLS or PhaseTLSResumed and PhaseHandshake. Use a WebSocket per connection
+// to benchmark with concurrent connections, as Perform must not be called concurrently.
+type WebSocket struct {
+	// URL of the server with scheme ws or wss.
+	URL string
+	// Origin sent in the opening handshake, defaults to the URL with scheme http or https.
+	Origin string
+	// Header sent in addition in the opening handshake.
+	Header http.Header
+	// Message sent by each operation, as text or as binary message if Binary is set.
+	Message []byte
+	Binary  bool
+	// TLSConfig for wss, its ServerName defaults to the host of the URL.
+	TLSConfig *tls.Config
+	// Dial, if set, establishes the connections instead of a net.Dialer.
+	Dial func(ctx context.Context, network, addr string) (net.Conn, error)
+	// Timeout of each operation, including establishing the connection, 0 for no timeout.
+	Timeout time.Duration
+	// RateLimit is the maximum number of messages per second, unlimited if <= 0.
+	RateLimit float64
+
+	Recorder
+
+	ws      *websocket.Conn
+	conn    net.Conn
+	limiter rateLimiter
+}
+
+// Perform sends the message and receives the reply, after opening the connection if necessary.
+func (w *WebSocket) Perform(ctx context.Context) (Result, bool) {
+	w.limiter.wait(ctx, w.RateLimit)
+	if ctx.Err() != nil {
+		return Result{}, false
+	}
+	runCtx := ctx
+	if w.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, w.Timeout)
+		defer cancel()
+	}
+	result := Result{Request: Request{URL: w.URL}, Start: time.Now(), BytesWritten: int64(len(w.Message))}
+	err := w.roundTrip(ctx, &result)
+	result.Latency = time.Since(result.Start)
+	switch {
+	case err == nil:
+		result.Class = ClassSuccess
+		w.Update(func(s *Statistic) {
+			if _, ok := result.Phases[PhaseHandshake]; ok {
+				s.NewConnections++
+			} else {
+				s.ReusedConnections++
+			}
+		})
+	case RunDone(runCtx):
+		w.Close()
+		result.Class, result.Err = ClassInterrupted, err
+	default:
+		w.Close()
+		if ctx.Err() != nil {
+			// the operation was interrupted by the deadline of the connection
+			err = ctx.Err()
+		}
+		result.Class, result.Err = ClassifyError(err), err
+	}
+	w.Record(result)
+	return result, true
+}
+
+// roundTrip sends the message and reads the reply into result.
+func (w *WebSocket) roundTrip(ctx context.Context, result *Result) error {
+	result.Phases = make(map[string]time.Duration)
+	if w.ws == nil {
+		if err := w.connect(ctx, result.Phases); err != nil {
+			return err
+		}
+	}
+	start := time.Now()
+	// interrupt the operation, when the context is done
+	stop := context.AfterFunc(ctx, func() {
+		w.conn.SetDeadline(time.Now())
+	})
+	defer stop()
+	var err error
+	if w.Binary {
+		err = websocket.Message.Send(w.ws, w.Message)
+	} else {
+		err = websocket.Message.Send(w.ws, string(w.Message))
+	}
+	if err != nil {
+		return err
+	}
+	var reply []byte
+	if err := websocket.Message.Receive(w.ws, &reply); err != nil {
+		return err
+	}
+	result.Phases[PhaseRoundTrip] = time.Since(start)
+	result.BytesRead = int64(len(reply))
+	return nil
+}
+
+// connect opens the connection and adds the durations of its phases.
+func (w *WebSocket) connect(ctx context.Context, phases map[string]time.Duration) error {
+	config, err := w.config()
+	if err != nil {
+		return err
+	}
+	addr := config.Location.Host
+	if config.Location.Port() == "" {
+		addr = net.JoinHostPort(config.Location.Hostname(), map[string]string{"ws": "80", "wss": "443"}[config.Location.Scheme])
+	}
+	dial := w.Dial
+	if dial == nil {
+		dial = (&net.Dialer{}).DialContext
+	}
+	start := time.Now()
+	conn, err := dial(ctx, "tcp", addr)
+	if err != nil {
+		return err
+	}
+	phases[PhaseConnect] = time.Since(start)
+	if config.Location.Scheme == "wss" {
+		tlsConn := tls.Client(conn, config.TlsConfig)
+		start = time.Now()
+		if err := tlsConn.HandshakeContext(ctx); err != nil {
+			conn.Close()
+			return err
+		}
+		if tlsConn.ConnectionState().DidResume {
+			phases[PhaseTLSResumed] = time.Since(start)
+		} else {
+			phases[PhaseTLS] = time.Since(start)
+		}
+		conn = tlsConn
+	}
+	stop := context.AfterFunc(ctx, func() {
+		conn.SetDeadline(time.Now())
+	})
+	defer stop()
+	start = time.Now()
+	ws, err := websocket.NewClient(config, conn)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	phases[PhaseHandshake] = time.Since(start)
+	w.ws, w.conn = ws, conn
+	return nil
+}
+
+// config returns the configuration of the opening handshake.
+func (w *WebSocket) config() (*websocket.Config, error) {
+	origin := w.Origin
+	if origin == "" {
+		u, err := url.Parse(w.URL)
+		if err != nil {
+			return nil, err
+		}
+		u.Scheme = map[string]string{"ws": "http", "wss": "https"}[u.Scheme]
+		origin = u.String()
+	}
+	config, err := websocket.NewConfig(w.URL, origin)
+	if err != nil {
+		return nil, err
+	}
+	config.Header = w.Header.Clone()
+	config.TlsConfig = w.TLSConfig.Clone()
+	if config.TlsConfig == nil {
+		config.TlsConfig = &tls.Config{}
+	}
+	if config.TlsConfig.ServerName == "" {
+		config.TlsConfig.ServerName = config.Location.Hostname()
+	}
+	return config, nil
+}
+
+// Close closes the connection, if it is open. The next operation opens a new connection.
+func (w *WebSocket) Close() error {
+	if w.ws == nil {
+		return nil
+	}
+	err := w.ws.Close()
+	w.ws, w.conn = nil, nil
+	return err
+}
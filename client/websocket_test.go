@@ -0,0 +1,120 @@
+// SPDX-FileCopyrightText: 2021 Eric Neidhardt
+// SPDX-License-Identifier: MIT
+package client
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/EricNeid/go-bench/internal/verify"
+	"golang.org/x/net/websocket"
+)
+
+func echoServer(tls bool) *httptest.Server {
+	handler := websocket.Handler(func(ws *websocket.Conn) {
+		io.Copy(ws, ws)
+	})
+	if tls {
+		return httptest.NewTLSServer(handler)
+	}
+	return httptest.NewServer(handler)
+}
+
+func TestWebSocket_echo(t *testing.T) {
+	// arrange
+	server := echoServer(false)
+	defer server.Close()
+	unit := &WebSocket{URL: "ws" + strings.TrimPrefix(server.URL, "http"), Message: []byte("hello")}
+	defer unit.Close()
+	// action
+	RunForAmount(context.Background(), 3, unit)
+	// verify
+	s := unit.Snapshot()
+	verify.Equals(t, 3, s.RequestCount)
+	verify.Equals(t, 3, s.SuccessCount)
+	verify.Equals(t, int64(15), s.ReadThroughput)
+	verify.Equals(t, int64(15), s.WriteThroughput)
+	verify.Equals(t, 1, s.NewConnections)
+	verify.Equals(t, 2, s.ReusedConnections)
+	verify.Equals(t, 3, s.Phases[PhaseRoundTrip].Count)
+	verify.Equals(t, 1, s.Phases[PhaseConnect].Count)
+	verify.Equals(t, 1, s.Phases[PhaseHandshake].Count)
+	verify.Equals(t, 0, s.Phases[PhaseTLS].Count)
+}
+
+func TestWebSocket_wss(t *testing.T) {
+	// arrange
+	server := echoServer(true)
+	defer server.Close()
+	unit := &WebSocket{
+		URL:       "wss" + strings.TrimPrefix(server.URL, "https"),
+		Message:   []byte("hello"),
+		Binary:    true,
+		TLSConfig: server.Client().Transport.(*http.Transport).TLSClientConfig,
+	}
+	defer unit.Close()
+	// action
+	RunForAmount(context.Background(), 2, unit)
+	// verify
+	s := unit.Snapshot()
+	verify.Equals(t, 2, s.SuccessCount)
+	verify.Equals(t, 1, s.Phases[PhaseTLS].Count)
+}
+
+func TestWebSocket_reconnectAfterFailure(t *testing.T) {
+	// arrange
+	server := httptest.NewServer(websocket.Handler(func(ws *websocket.Conn) {
+		// reply once, then close the connection
+		var message string
+		if websocket.Message.Receive(ws, &message) == nil {
+			websocket.Message.Send(ws, message)
+		}
+	}))
+	defer server.Close()
+	unit := &WebSocket{URL: "ws" + strings.TrimPrefix(server.URL, "http"), Message: []byte("hello"), Timeout: time.Second}
+	defer unit.Close()
+	// action
+	RunForAmount(context.Background(), 3, unit)
+	// verify
+	s := unit.Snapshot()
+	verify.Equals(t, 3, s.RequestCount)
+	verify.Equals(t, 2, s.SuccessCount)
+	verify.Equals(t, 1, s.NetworkFailedCount)
+	verify.Equals(t, 2, s.NewConnections)
+}
+
+func TestWebSocket_interrupted(t *testing.T) {
+	// arrange
+	server := httptest.NewServer(websocket.Handler(func(ws *websocket.Conn) {
+		// never reply
+		io.Copy(io.Discard, ws)
+	}))
+	defer server.Close()
+	unit := &WebSocket{URL: "ws" + strings.TrimPrefix(server.URL, "http"), Message: []byte("hello")}
+	defer unit.Close()
+	// action
+	RunForDuration(context.Background(), 50*time.Millisecond, unit)
+	// verify
+	s := unit.Snapshot()
+	verify.Equals(t, 0, s.RequestCount)
+	verify.Equals(t, 1, s.InterruptedCount)
+}
+
+func TestWebSocket_refused(t *testing.T) {
+	// arrange
+	server := echoServer(false)
+	url := "ws" + strings.TrimPrefix(server.URL, "http")
+	server.Close()
+	unit := &WebSocket{URL: url, Message: []byte("hello")}
+	// action
+	result, ok := unit.Perform(context.Background())
+	// verify
+	verify.Assert(t, ok, "operation should be performed")
+	verify.Equals(t, FailureRefused, result.Class)
+	verify.Equals(t, map[string]int{FailureRefused: 1}, unit.Snapshot().NetworkFailures)
+}
@@ -0,0 +1,199 @@
+// SPDX-FileCopyrightText: 2021 Eric Neidhardt
+// SPDX-License-Identifier: MIT
+package main
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/EricNeid/go-bench/client"
+	"github.com/EricNeid/go-bench/config"
+)
+
+const defaultAgentPort = "7070"
+
+var (
+	agentFlags = flag.NewFlagSet("agent", flag.ExitOnError)
+	agentAddr  = agentFlags.String("addr", ":"+defaultAgentPort, "Address of the control API")
+	agentToken = agentFlags.String("token", "", "Token, which coordinators send as bearer token, defaults to $GOBENCH_AGENT_TOKEN, required unless listening on a loopback address")
+)
+
+func init() {
+	agentFlags.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage of %s agent:\n", os.Args[0])
+		fmt.Printf("  %s agent -addr :7070 -token secret\n", os.Args[0])
+		fmt.Printf("Runs the scenarios of a coordinator started by: %s run -workers host:7070 ...\n", os.Args[0])
+		fmt.Printf("Command line options:\n")
+		agentFlags.PrintDefaults()
+	}
+}
+
+// agentRun is the request of a coordinator to run a scenario.
+type agentRun struct {
+	// Scenario encoded by config.Scenario.Marshal.
+	Scenario []byte `json:"scenario"`
+	// Start of the clients, the same for all workers.
+	Start time.Time `json:"start"`
+}
+
+// agentResult is the response of a worker to an agentRun.
+type agentResult struct {
+	// Statistics of the workloads, see client.Runner.Statistics.
+	Statistics []client.Statistic `json:"statistics"`
+	Elapsed    time.Duration      `json:"elapsed"`
+}
+
+// agentStatus is the response of a worker to a status request.
+type agentStatus struct {
+	Version string `json:"version"`
+	Running bool   `json:"running"`
+}
+
+// agent runs one scenario at a time for a coordinator.
+type agent struct {
+	token string
+
+	mutex sync.Mutex
+	// stop cancels the current run, nil if none is running.
+	stop context.CancelFunc
+}
+
+// ServeHTTP serves the control API: POST /run runs a scenario and responds with its statistics,
+// POST /stop stops the current run and GET /status returns an agentStatus.
+func (a *agent) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !authorized(r, a.token) {
+		http.Error(w, "invalid token", http.StatusUnauthorized)
+		return
+	}
+	switch {
+	case r.URL.Path == "/run" && r.Method == http.MethodPost:
+		a.run(w, r)
+	case r.URL.Path == "/stop" && r.Method == http.MethodPost:
+		a.mutex.Lock()
+		if a.stop != nil {
+			a.stop()
+		}
+		a.mutex.Unlock()
+		w.WriteHeader(http.StatusNoContent)
+	case r.URL.Path == "/status" && r.Method == http.MethodGet:
+		a.mutex.Lock()
+		status := agentStatus{Version: version, Running: a.stop != nil}
+		a.mutex.Unlock()
+		writeJSON(w, status)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// run runs the scenario of the request until it is done, stopped or the coordinator closes the connection.
+func (a *agent) run(w http.ResponseWriter, r *http.Request) {
+	var request agentRun
+	if err := json.NewDecoder(io.LimitReader(r.Body, 64<<20)).Decode(&request); err != nil {
+		http.Error(w, fmt.Sprintf("invalid run: %s", err), http.StatusBadRequest)
+		return
+	}
+	scenario, err := config.Parse(request.Scenario)
+	if err == nil {
+		err = agentScenario(scenario)
+	}
+	if err != nil {
+		http.Error(w, fmt.Sprintf("invalid scenario: %s", err), http.StatusBadRequest)
+		return
+	}
+	ctx, cancel := context.WithCancel(r.Context())
+	defer cancel()
+	a.mutex.Lock()
+	if a.stop != nil {
+		a.mutex.Unlock()
+		http.Error(w, "a scenario is running already", http.StatusConflict)
+		return
+	}
+	a.stop = cancel
+	a.mutex.Unlock()
+	defer func() {
+		a.mutex.Lock()
+		a.stop = nil
+		a.mutex.Unlock()
+	}()
+
+	fmt.Printf("Running scenario of %s at %s\n", r.RemoteAddr, request.Start.Local().Format(time.TimeOnly))
+	var result agentResult
+	hooks := runHooks{start: request.Start, done: func(statistics []client.Statistic, elapsed time.Duration) {
+		result = agentResult{Statistics: statistics, Elapsed: elapsed}
+	}}
+	if runScenario(ctx, scenario, hooks) != 0 {
+		http.Error(w, "run failed, see the output of the agent", http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, result)
+}
+
+// agentScenario validates a scenario of a coordinator. Files are rejected, as they would be read on the host
+// of the agent, see runWorkers. Nothing is written on the agent, the coordinator writes and pushes the
+// merged results.
+func agentScenario(scenario *config.Scenario) error {
+	if files := scenario.Files(); len(files) > 0 {
+		return fmt.Errorf("files are not supported by agents, found %s", *files[0])
+	}
+	scenario.Output.File, scenario.Output.FailuresDir, scenario.Output.Prometheus = "", "", config.Prometheus{}
+	return scenario.Validate()
+}
+
+// loopbackAddr returns true, if the address of a listener is bound to a loopback interface only.
+func loopbackAddr(addr string) bool {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return false
+	}
+	ip := net.ParseIP(host)
+	return host == "localhost" || ip != nil && ip.IsLoopback()
+}
+
+// authorized returns true, if no token is required or the request has it as bearer token.
+func authorized(r *http.Request, token string) bool {
+	return token == "" || subtle.ConstantTimeCompare([]byte(r.Header.Get("Authorization")), []byte("Bearer "+token)) == 1
+}
+
+// writeJSON writes the value as JSON response.
+func writeJSON(w http.ResponseWriter, value any) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(value); err != nil {
+		fmt.Printf("Could not write response: %s\n", err)
+	}
+}
+
+// runAgent starts a worker agent, which runs the scenarios of coordinators, and returns the exit code.
+func runAgent(args []string) int {
+	_ = agentFlags.Parse(args)
+
+	token := *agentToken
+	if token == "" {
+		token = os.Getenv("GOBENCH_AGENT_TOKEN")
+	}
+	if token == "" && !loopbackAddr(*agentAddr) {
+		// anyone reaching the agent could send load to any target otherwise
+		fmt.Printf("A token is required to listen on %s, only loopback addresses are allowed without one\n", *agentAddr)
+		return 1
+	}
+	server := &http.Server{
+		Addr:              *agentAddr,
+		Handler:           &agent{token: token},
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	fmt.Printf("Agent listening on %s\n", *agentAddr)
+	if err := server.ListenAndServe(); err != nil {
+		fmt.Printf("Agent failed: %s\n", err)
+		return 1
+	}
+	return 0
+}
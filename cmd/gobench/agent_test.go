@@ -0,0 +1,59 @@
+// SPDX-FileCopyrightText: 2021 Eric Neidhardt
+// SPDX-License-Identifier: MIT
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/EricNeid/go-bench/config"
+	"github.com/EricNeid/go-bench/internal/verify"
+)
+
+func TestAgent_run_files(t *testing.T) {
+	// arrange
+	unit := httptest.NewServer(&agent{token: "secret"})
+	defer unit.Close()
+	body, err := json.Marshal(agentRun{
+		Scenario: []byte("targets:\n  - url: http://localhost\n    bodyFile: /etc/passwd\nconcurrency: 1\nrequests: 1\n"),
+		Start:    time.Now(),
+	})
+	verify.Ok(t, err)
+	// action
+	code, message := controlRequest(t, unit.URL, "secret", http.MethodPost, "/run", string(body))
+	// verify
+	verify.Equals(t, http.StatusBadRequest, code)
+	verify.Assert(t, strings.Contains(message, "/etc/passwd"), "file should be named: "+message)
+}
+
+func TestAgentScenario_output(t *testing.T) {
+	// arrange
+	scenario := &config.Scenario{
+		Targets:     []config.Target{{URL: "http://localhost"}},
+		Concurrency: 1,
+		Requests:    1,
+		Output: config.Output{
+			File:        "/tmp/result.json",
+			FailuresDir: "/etc/failures",
+			Prometheus:  config.Prometheus{Pushgateway: "http://pushgateway:9091"},
+		},
+	}
+	// action
+	err := agentScenario(scenario)
+	// verify
+	verify.Ok(t, err)
+	verify.Equals(t, config.Output{}, scenario.Output)
+}
+
+func TestLoopbackAddr(t *testing.T) {
+	verify.Assert(t, loopbackAddr("127.0.0.1:7070"), "IPv4 loopback not detected")
+	verify.Assert(t, loopbackAddr("[::1]:7070"), "IPv6 loopback not detected")
+	verify.Assert(t, loopbackAddr("localhost:7070"), "localhost not detected")
+	verify.Assert(t, !loopbackAddr(":7070"), "all interfaces detected as loopback")
+	verify.Assert(t, !loopbackAddr("0.0.0.0:7070"), "all interfaces detected as loopback")
+	verify.Assert(t, !loopbackAddr("10.0.0.5:7070"), "private address detected as loopback")
+}
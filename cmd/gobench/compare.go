@@ -0,0 +1,107 @@
+// SPDX-FileCopyrightText: 2021 Eric Neidhardt
+// SPDX-License-Identifier: MIT
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/EricNeid/go-bench/report"
+)
+
+// metric describes a single compared value of a result.
+type metric struct {
+	name           string
+	unit           string
+	value          func(r report.Result) float64
+	higherIsBetter bool
+}
+
+var comparedMetrics = []metric{
+	{"Successful requests rate:", "hits/sec", func(r report.Result) float64 { return float64(r.SuccessRate) }, true},
+	{"Read throughput:", "bytes/sec", func(r report.Result) float64 { return float64(r.ReadThroughput) }, true},
+	{"Write throughput:", "bytes/sec", func(r report.Result) float64 { return float64(r.WriteThroughput) }, true},
+	{"Average latency:", "ms", func(r report.Result) float64 { return r.AverageLatencyMs }, false},
+	{"TTFB p99:", "ms", func(r report.Result) float64 { return r.TTFBP99Ms }, false},
+}
+
+// Exit codes of compare, which CI gates can tell apart.
+const (
+	compareRegression = 1
+	compareError      = 2
+)
+
+var (
+	compareFlags = flag.NewFlagSet("compare", flag.ExitOnError)
+	tolerance    = compareFlags.Float64("tolerance", -1, "Allowed regression in percent, exit with 1 if exceeded, invalid result files exit with 2: gobench compare -tolerance 5 baseline.json current.json")
+)
+
+func init() {
+	compareFlags.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage of %s compare:\n", os.Args[0])
+		fmt.Printf("  %s compare [-tolerance percent] baseline.json current.json\n", os.Args[0])
+		fmt.Printf("Command line options:\n")
+		compareFlags.PrintDefaults()
+	}
+}
+
+// runCompare compares two result files and returns the exit code.
+// If a tolerance is given, the exit code is 1 if any metric regressed by more than the tolerance,
+// it is 2 if the files cannot be read. Metrics missing in the baseline, e.g. as it was written by an
+// older version, are not compared.
+func runCompare(args []string) int {
+	flags := compareFlags
+	_ = flags.Parse(args)
+
+	if flags.NArg() != 2 {
+		fmt.Println("Baseline and current result file are required")
+		flags.Usage()
+		return compareError
+	}
+
+	baseline, err := report.ReadSingle(flags.Arg(0))
+	if err != nil {
+		fmt.Printf("Could not read baseline %s: %s\n", flags.Arg(0), err)
+		return compareError
+	}
+	current, err := report.ReadSingle(flags.Arg(1))
+	if err != nil {
+		fmt.Printf("Could not read result %s: %s\n", flags.Arg(1), err)
+		return compareError
+	}
+
+	regressed := false
+	fmt.Printf("%-27s %15s %15s %15s %10s\n", "", "baseline", "current", "delta", "change")
+	for _, m := range comparedMetrics {
+		b := m.value(baseline)
+		c := m.value(current)
+		if b == 0 {
+			fmt.Printf("%-27s %15s %15.2f %15s %10s %s\n", m.name, "-", c, "", "", m.unit)
+			continue
+		}
+		change := percentChange(b, c)
+		regression := change
+		if m.higherIsBetter {
+			regression = -change
+		}
+
+		marker := ""
+		if *tolerance >= 0 && regression > *tolerance {
+			regressed = true
+			marker = " REGRESSION"
+		}
+		fmt.Printf("%-27s %15.2f %15.2f %+15.2f %+9.2f%% %s%s\n", m.name, b, c, c-b, change, m.unit, marker)
+	}
+
+	if regressed {
+		fmt.Printf("\nRegression exceeds tolerance of %.2f%%\n", *tolerance)
+		return compareRegression
+	}
+	return 0
+}
+
+// percentChange returns the change from baseline to current in percent, the baseline must not be 0.
+func percentChange(baseline, current float64) float64 {
+	return (current - baseline) / baseline * 100
+}
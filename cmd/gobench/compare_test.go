@@ -0,0 +1,41 @@
+// SPDX-FileCopyrightText: 2021 Eric Neidhardt
+// SPDX-License-Identifier: MIT
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/EricNeid/go-bench/internal/verify"
+	"github.com/EricNeid/go-bench/report"
+)
+
+// writeResult writes a result file with a single result and returns its path.
+func writeResult(t *testing.T, name string, result report.Result) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	verify.Ok(t, report.WriteFile(path, []report.Result{result}))
+	return path
+}
+
+func TestRunCompare(t *testing.T) {
+	// arrange, the baseline has no TTFB, e.g. as it was written by an older version
+	baseline := writeResult(t, "baseline.json", report.Result{SuccessRate: 1000, AverageLatencyMs: 10})
+	same := writeResult(t, "same.json", report.Result{SuccessRate: 990, AverageLatencyMs: 10.2, TTFBP99Ms: 50})
+	slower := writeResult(t, "slower.json", report.Result{SuccessRate: 800, AverageLatencyMs: 12})
+	invalid := filepath.Join(t.TempDir(), "invalid.json")
+	verify.Ok(t, os.WriteFile(invalid, []byte("{"), 0o644))
+	// action
+	passed := runCompare([]string{"-tolerance", "5", baseline, same})
+	regressed := runCompare([]string{"-tolerance", "5", baseline, slower})
+	unreadable := runCompare([]string{"-tolerance", "5", baseline, invalid})
+	missing := runCompare([]string{"-tolerance", "5", baseline, filepath.Join(t.TempDir(), "missing.json")})
+	incomplete := runCompare([]string{"-tolerance", "5", baseline})
+	// verify
+	verify.Equals(t, 0, passed)
+	verify.Equals(t, compareRegression, regressed)
+	verify.Equals(t, compareError, unreadable)
+	verify.Equals(t, compareError, missing)
+	verify.Equals(t, compareError, incomplete)
+}
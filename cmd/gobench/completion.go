@@ -0,0 +1,172 @@
+// SPDX-FileCopyrightText: 2021 Eric Neidhardt
+// SPDX-License-Identifier: MIT
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+)
+
+var completionFlags = flag.NewFlagSet("completion", flag.ExitOnError)
+
+func init() {
+	completionFlags.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage of %s completion:\n", os.Args[0])
+		fmt.Printf("  %s completion bash|zsh|fish\n", os.Args[0])
+		fmt.Printf("Example:\n")
+		fmt.Printf("  source <(%s completion bash)\n", os.Args[0])
+	}
+}
+
+// runCompletion prints the completion script for the given shell and returns the exit code.
+func runCompletion(args []string) int {
+	_ = completionFlags.Parse(args)
+	if completionFlags.NArg() != 1 {
+		fmt.Println("Shell is required")
+		completionFlags.Usage()
+		return 1
+	}
+
+	switch completionFlags.Arg(0) {
+	case "bash":
+		fmt.Print(bashCompletion())
+	case "zsh":
+		fmt.Print(zshCompletion())
+	case "fish":
+		fmt.Print(fishCompletion())
+	default:
+		fmt.Printf("Unsupported shell: %s\n", completionFlags.Arg(0))
+		completionFlags.Usage()
+		return 1
+	}
+	return 0
+}
+
+// completionFlag describes a flag for completion scripts.
+type completionFlag struct {
+	name        string
+	description string
+	isBool      bool
+}
+
+// flagsOf returns the flags of a command in lexical order.
+func flagsOf(c command) []completionFlag {
+	var flags []completionFlag
+	c.flags.VisitAll(func(f *flag.Flag) {
+		b, ok := f.Value.(interface{ IsBoolFlag() bool })
+		flags = append(flags, completionFlag{
+			name:        f.Name,
+			description: shortUsage(f.Usage),
+			isBool:      ok && b.IsBoolFlag(),
+		})
+	})
+	return flags
+}
+
+// shortUsage strips the example from a usage text.
+func shortUsage(usage string) string {
+	if i := strings.Index(usage, ": gobench"); i >= 0 {
+		usage = usage[:i]
+	}
+	return strings.TrimSpace(usage)
+}
+
+func bashCompletion() string {
+	var b strings.Builder
+	var names []string
+	for _, c := range commands {
+		names = append(names, c.name)
+	}
+
+	b.WriteString("# bash completion for gobench\n")
+	b.WriteString("_gobench() {\n")
+	b.WriteString("    local cur=\"${COMP_WORDS[COMP_CWORD]}\"\n")
+	b.WriteString("    if [ \"$COMP_CWORD\" -eq 1 ]; then\n")
+	fmt.Fprintf(&b, "        COMPREPLY=( $(compgen -W \"%s\" -- \"$cur\") )\n", strings.Join(names, " "))
+	b.WriteString("        return\n")
+	b.WriteString("    fi\n")
+	b.WriteString("    case \"$cur\" in\n")
+	b.WriteString("        -*) ;;\n")
+	b.WriteString("        *) return ;;\n")
+	b.WriteString("    esac\n")
+	b.WriteString("    case \"${COMP_WORDS[1]}\" in\n")
+	for _, c := range commands {
+		var flags []string
+		for _, f := range flagsOf(c) {
+			flags = append(flags, "-"+f.name)
+		}
+		fmt.Fprintf(&b, "        %s) COMPREPLY=( $(compgen -W \"%s\" -- \"$cur\") ) ;;\n", c.name, strings.Join(flags, " "))
+	}
+	b.WriteString("    esac\n")
+	b.WriteString("}\n")
+	b.WriteString("complete -o default -F _gobench gobench\n")
+	return b.String()
+}
+
+func zshCompletion() string {
+	quote := func(s string) string {
+		s = strings.ReplaceAll(s, "'", "'\\''")
+		s = strings.ReplaceAll(s, "[", "\\[")
+		s = strings.ReplaceAll(s, "]", "\\]")
+		return strings.ReplaceAll(s, ":", "\\:")
+	}
+
+	var b strings.Builder
+	b.WriteString("#compdef gobench\n")
+	b.WriteString("_gobench() {\n")
+	b.WriteString("    local -a commands\n")
+	b.WriteString("    commands=(\n")
+	for _, c := range commands {
+		fmt.Fprintf(&b, "        '%s:%s'\n", c.name, quote(c.description))
+	}
+	b.WriteString("    )\n")
+	b.WriteString("    if (( CURRENT == 2 )); then\n")
+	b.WriteString("        _describe 'command' commands\n")
+	b.WriteString("        return\n")
+	b.WriteString("    fi\n")
+	b.WriteString("    shift words\n")
+	b.WriteString("    (( CURRENT-- ))\n")
+	b.WriteString("    case \"$words[1]\" in\n")
+	for _, c := range commands {
+		fmt.Fprintf(&b, "        %s)\n", c.name)
+		b.WriteString("            _arguments \\\n")
+		for _, f := range flagsOf(c) {
+			if f.isBool {
+				fmt.Fprintf(&b, "                '-%s[%s]' \\\n", f.name, quote(f.description))
+			} else {
+				fmt.Fprintf(&b, "                '-%s[%s]:value:_default' \\\n", f.name, quote(f.description))
+			}
+		}
+		b.WriteString("                '*:file:_files'\n")
+		b.WriteString("            ;;\n")
+	}
+	b.WriteString("    esac\n")
+	b.WriteString("}\n")
+	b.WriteString("compdef _gobench gobench\n")
+	return b.String()
+}
+
+func fishCompletion() string {
+	quote := func(s string) string {
+		s = strings.ReplaceAll(s, "\\", "\\\\")
+		return "'" + strings.ReplaceAll(s, "'", "\\'") + "'"
+	}
+
+	var b strings.Builder
+	b.WriteString("# fish completion for gobench\n")
+	for _, c := range commands {
+		fmt.Fprintf(&b, "complete -c gobench -n __fish_use_subcommand -f -a %s -d %s\n", c.name, quote(c.description))
+	}
+	for _, c := range commands {
+		for _, f := range flagsOf(c) {
+			argument := " -r"
+			if f.isBool {
+				argument = ""
+			}
+			fmt.Fprintf(&b, "complete -c gobench -n '__fish_seen_subcommand_from %s' -o %s%s -d %s\n", c.name, f.name, argument, quote(f.description))
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,72 @@
+// SPDX-FileCopyrightText: 2021 Eric Neidhardt
+// SPDX-License-Identifier: MIT
+package main
+
+import (
+	"flag"
+	"strings"
+	"testing"
+
+	"github.com/EricNeid/go-bench/internal/verify"
+)
+
+func TestCompletion(t *testing.T) {
+	// expected lines of a command and one of its flags, by shell
+	for shell, test := range map[string]struct {
+		script  func() string
+		command func(c command) string
+		flag    func(c command, f *flag.Flag) string
+	}{
+		"bash": {
+			bashCompletion,
+			func(c command) string { return " " + c.name + " " },
+			func(c command, f *flag.Flag) string { return " -" + f.Name + " " },
+		},
+		"zsh": {
+			zshCompletion,
+			func(c command) string { return "'" + c.name + ":" },
+			func(c command, f *flag.Flag) string { return "'-" + f.Name + "[" },
+		},
+		"fish": {
+			fishCompletion,
+			func(c command) string { return " -a " + c.name + " " },
+			func(c command, f *flag.Flag) string { return "from " + c.name + "' -o " + f.Name + " " },
+		},
+	} {
+		t.Run(shell, func(t *testing.T) {
+			// action
+			result := test.script()
+			if shell == "bash" {
+				// commands and flags are the quoted words of compgen
+				result = strings.ReplaceAll(result, "\"", " ")
+			}
+			// verify
+			verify.Assert(t, len(commands) > 0, "Missing commands")
+			for _, c := range commands {
+				verify.Assert(t, strings.Contains(result, test.command(c)), "Missing command %s", c.name)
+				// bash and zsh list the flags in the section of the command
+				section := result
+				switch shell {
+				case "bash":
+					section = lineOf(result, "        "+c.name+") ")
+				case "zsh":
+					section = result[strings.Index(result, "        "+c.name+")\n"):]
+					section = section[:strings.Index(section, ";;")]
+				}
+				c.flags.VisitAll(func(f *flag.Flag) {
+					verify.Assert(t, strings.Contains(section, test.flag(c, f)), "Missing flag -%s of %s", f.Name, c.name)
+				})
+			}
+		})
+	}
+}
+
+// lineOf returns the line of s, which starts with prefix.
+func lineOf(s, prefix string) string {
+	for _, line := range strings.Split(s, "\n") {
+		if strings.HasPrefix(line, prefix) {
+			return line + "\n"
+		}
+	}
+	return ""
+}
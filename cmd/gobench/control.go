@@ -0,0 +1,308 @@
+// SPDX-FileCopyrightText: 2021 Eric Neidhardt
+// SPDX-License-Identifier: MIT
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/EricNeid/go-bench/client"
+	"github.com/EricNeid/go-bench/config"
+	"github.com/EricNeid/go-bench/report"
+)
+
+var (
+	controlFlags = flag.NewFlagSet("control", flag.ExitOnError)
+	controlAddr  = controlFlags.String("addr", "127.0.0.1:8081", "Address of the control API, e.g. :8081 to listen on all interfaces")
+	controlToken = controlFlags.String("token", "", "Token, which clients send as bearer token, defaults to $GOBENCH_CONTROL_TOKEN")
+)
+
+func init() {
+	controlFlags.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage of %s control:\n", os.Args[0])
+		fmt.Printf("  %s control -addr :8081 -token secret\n", os.Args[0])
+		fmt.Printf("Submitted scenarios must not reference files, e.g. bodyFile or dataFile, as they would be read on this host\n")
+		fmt.Printf("Endpoints:\n")
+		fmt.Printf("  POST   /scenarios              submit a scenario as YAML or JSON like a scenario file\n")
+		fmt.Printf("  GET    /scenarios              list the submitted scenarios\n")
+		fmt.Printf("  GET    /scenarios/{id}         state and results so far\n")
+		fmt.Printf("  POST   /scenarios/{id}/start   start a run\n")
+		fmt.Printf("  POST   /scenarios/{id}/stop    stop the run\n")
+		fmt.Printf("  GET    /scenarios/{id}/results results of the last run as result file\n")
+		fmt.Printf("  DELETE /scenarios/{id}         remove the scenario\n")
+		fmt.Printf("Command line options:\n")
+		controlFlags.PrintDefaults()
+	}
+}
+
+// States of the scenarios of the control API.
+const (
+	stateCreated = "created"
+	stateRunning = "running"
+	stateDone    = "done"
+	stateFailed  = "failed"
+)
+
+// controlScenario is a scenario submitted to the control API with its last run.
+type controlScenario struct {
+	id string
+	// data of the scenario, decoded for each run
+	data    []byte
+	state   string
+	err     string
+	started time.Time
+	stop    context.CancelFunc
+	// runner and workloads of the last run, while it is running
+	runner    *client.Runner
+	workloads []config.Workload
+	// results of the last run, when it is done
+	results []report.Result
+}
+
+// controlStatus is the response of the control API describing a scenario.
+type controlStatus struct {
+	ID    string `json:"id"`
+	State string `json:"state"`
+	Error string `json:"error,omitempty"`
+	// Start of the last run.
+	Started *time.Time `json:"started,omitempty"`
+	// Results so far of a running scenario or the ones of the last run.
+	Results []report.Result `json:"results,omitempty"`
+}
+
+// control serves the control API, it runs one scenario at a time.
+type control struct {
+	token string
+
+	mutex     sync.Mutex
+	scenarios map[string]*controlScenario
+	lastID    int
+	// running scenario, nil if none is running
+	running *controlScenario
+}
+
+// handler returns the handler of the endpoints, see controlFlags.Usage.
+func (c *control) handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /scenarios", c.create)
+	mux.HandleFunc("GET /scenarios", c.list)
+	mux.HandleFunc("GET /scenarios/{id}", c.withScenario(c.status))
+	mux.HandleFunc("DELETE /scenarios/{id}", c.withScenario(c.remove))
+	mux.HandleFunc("POST /scenarios/{id}/start", c.withScenario(c.start))
+	mux.HandleFunc("POST /scenarios/{id}/stop", c.withScenario(c.stopRun))
+	mux.HandleFunc("GET /scenarios/{id}/results", c.withScenario(c.resultFile))
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !authorized(r, c.token) {
+			http.Error(w, "invalid token", http.StatusUnauthorized)
+			return
+		}
+		mux.ServeHTTP(w, r)
+	})
+}
+
+// withScenario calls the handler with the scenario of the path, while holding the mutex.
+func (c *control) withScenario(handler func(w http.ResponseWriter, r *http.Request, s *controlScenario)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		c.mutex.Lock()
+		defer c.mutex.Unlock()
+		s, ok := c.scenarios[r.PathValue("id")]
+		if !ok {
+			http.Error(w, "unknown scenario", http.StatusNotFound)
+			return
+		}
+		handler(w, r, s)
+	}
+}
+
+// create validates and adds the scenario of the request body.
+func (c *control) create(w http.ResponseWriter, r *http.Request) {
+	data, err := io.ReadAll(io.LimitReader(r.Body, 64<<20))
+	if err != nil {
+		http.Error(w, fmt.Sprintf("could not read scenario: %s", err), http.StatusBadRequest)
+		return
+	}
+	if _, err := decodeControlScenario(data); err != nil {
+		http.Error(w, fmt.Sprintf("invalid scenario: %s", err), http.StatusBadRequest)
+		return
+	}
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.lastID++
+	s := &controlScenario{id: strconv.Itoa(c.lastID), data: data, state: stateCreated}
+	c.scenarios[s.id] = s
+	w.Header().Set("Location", "/scenarios/"+s.id)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	writeJSON(w, s.status())
+}
+
+// list responds with the states of all scenarios, without their results.
+func (c *control) list(w http.ResponseWriter, _ *http.Request) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	statuses := []controlStatus{}
+	for _, s := range c.scenarios {
+		status := s.status()
+		status.Results = nil
+		statuses = append(statuses, status)
+	}
+	sort.Slice(statuses, func(i, j int) bool {
+		a, _ := strconv.Atoi(statuses[i].ID)
+		b, _ := strconv.Atoi(statuses[j].ID)
+		return a < b
+	})
+	writeJSON(w, statuses)
+}
+
+func (c *control) status(w http.ResponseWriter, _ *http.Request, s *controlScenario) {
+	writeJSON(w, s.status())
+}
+
+func (c *control) remove(w http.ResponseWriter, _ *http.Request, s *controlScenario) {
+	if s.state == stateRunning {
+		http.Error(w, "scenario is running", http.StatusConflict)
+		return
+	}
+	delete(c.scenarios, s.id)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// start runs the scenario in the background, the results of a previous run are discarded.
+func (c *control) start(w http.ResponseWriter, _ *http.Request, s *controlScenario) {
+	if c.running != nil {
+		http.Error(w, fmt.Sprintf("scenario %s is running", c.running.id), http.StatusConflict)
+		return
+	}
+	scenario, err := decodeControlScenario(s.data)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("invalid scenario: %s", err), http.StatusBadRequest)
+		return
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	s.state, s.err, s.started, s.stop, s.results = stateRunning, "", time.Now(), cancel, nil
+	c.running = s
+	go c.run(ctx, s, scenario)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	writeJSON(w, s.status())
+}
+
+// run runs the scenario until it is done or stopped.
+func (c *control) run(ctx context.Context, s *controlScenario, scenario *config.Scenario) {
+	var results []report.Result
+	hooks := runHooks{
+		running: func(runner *client.Runner, workloads []config.Workload) {
+			c.mutex.Lock()
+			s.runner, s.workloads, s.started = runner, workloads, time.Now()
+			c.mutex.Unlock()
+		},
+		done: func(statistics []client.Statistic, elapsed time.Duration) {
+			c.mutex.Lock()
+			results = workloadResults(s.workloads, statistics, elapsed)
+			c.mutex.Unlock()
+		},
+	}
+	fmt.Printf("Running scenario %s\n", s.id)
+	code := runScenario(ctx, scenario, hooks)
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	s.stop()
+	s.runner, s.workloads, s.stop, s.results = nil, nil, nil, results
+	if code == 0 {
+		s.state = stateDone
+	} else {
+		s.state, s.err = stateFailed, "run failed, see the output of the control API"
+	}
+	c.running = nil
+}
+
+func (c *control) stopRun(w http.ResponseWriter, _ *http.Request, s *controlScenario) {
+	if s.state != stateRunning {
+		http.Error(w, "scenario is not running", http.StatusConflict)
+		return
+	}
+	s.stop()
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	writeJSON(w, s.status())
+}
+
+// resultFile responds with the results of the last run as written to result files, see report.EncodeFile.
+func (c *control) resultFile(w http.ResponseWriter, _ *http.Request, s *controlScenario) {
+	if s.state != stateDone {
+		http.Error(w, fmt.Sprintf("scenario is %s", s.state), http.StatusConflict)
+		return
+	}
+	data, err := report.EncodeFile(s.results)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("could not encode results: %s", err), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write(data)
+}
+
+// status returns the state of the scenario and its results so far. The mutex of the control must be held.
+func (s *controlScenario) status() controlStatus {
+	status := controlStatus{ID: s.id, State: s.state, Error: s.err, Results: s.results}
+	if !s.started.IsZero() {
+		status.Started = &s.started
+	}
+	if s.runner != nil {
+		status.Results = workloadResults(s.workloads, s.runner.Statistics(), time.Since(s.started))
+	}
+	return status
+}
+
+// decodeControlScenario decodes and validates a submitted scenario. Output files are not written by the
+// control API, the results are fetched from it instead. Scenarios referencing files are rejected, as anyone
+// reaching the control API could send the files of this host to their targets otherwise.
+func decodeControlScenario(data []byte) (*config.Scenario, error) {
+	scenario, err := config.Parse(data)
+	if err != nil {
+		return nil, err
+	}
+	if files := scenario.Files(); len(files) > 0 {
+		return nil, fmt.Errorf("files are not supported by the control API, found %s", *files[0])
+	}
+	scenario.Output.File, scenario.Output.FailuresDir = "", ""
+	if err := scenario.Validate(); err != nil {
+		return nil, err
+	}
+	return scenario, nil
+}
+
+// runControl starts the control API, which runs submitted scenarios, and returns the exit code.
+func runControl(args []string) int {
+	_ = controlFlags.Parse(args)
+
+	token := *controlToken
+	if token == "" {
+		token = os.Getenv("GOBENCH_CONTROL_TOKEN")
+	}
+	if token == "" {
+		fmt.Println("Warning: no token is given, anyone reaching the control API can run scenarios")
+	}
+	c := &control{token: token, scenarios: make(map[string]*controlScenario)}
+	server := &http.Server{
+		Addr:              *controlAddr,
+		Handler:           c.handler(),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	fmt.Printf("Control API listening on %s\n", *controlAddr)
+	if err := server.ListenAndServe(); err != nil {
+		fmt.Printf("Control API failed: %s\n", err)
+		return 1
+	}
+	return 0
+}
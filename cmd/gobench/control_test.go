@@ -0,0 +1,147 @@
+// SPDX-FileCopyrightText: 2021 Eric Neidhardt
+// SPDX-License-Identifier: MIT
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/EricNeid/go-bench/internal/verify"
+	"github.com/EricNeid/go-bench/report"
+)
+
+func TestControl_unauthorized(t *testing.T) {
+	// arrange
+	unit := httptest.NewServer((&control{token: "secret", scenarios: map[string]*controlScenario{}}).handler())
+	defer unit.Close()
+	// action
+	withoutToken, _ := controlRequest(t, unit.URL, "", http.MethodGet, "/scenarios", "")
+	invalidToken, _ := controlRequest(t, unit.URL, "invalid", http.MethodGet, "/scenarios", "")
+	// verify
+	verify.Equals(t, http.StatusUnauthorized, withoutToken)
+	verify.Equals(t, http.StatusUnauthorized, invalidToken)
+}
+
+func TestControl_create(t *testing.T) {
+	// arrange
+	unit := httptest.NewServer((&control{token: "secret", scenarios: map[string]*controlScenario{}}).handler())
+	defer unit.Close()
+	// action
+	created, body := controlRequest(t, unit.URL, "secret", http.MethodPost, "/scenarios",
+		"targets:\n  - url: http://localhost\nconcurrency: 1\nrequests: 1\n")
+	listed, list := controlRequest(t, unit.URL, "secret", http.MethodGet, "/scenarios", "")
+	// verify
+	verify.Equals(t, http.StatusCreated, created)
+	var status controlStatus
+	verify.Ok(t, json.Unmarshal([]byte(body), &status))
+	verify.Equals(t, controlStatus{ID: "1", State: stateCreated}, status)
+	verify.Equals(t, http.StatusOK, listed)
+	var statuses []controlStatus
+	verify.Ok(t, json.Unmarshal([]byte(list), &statuses))
+	verify.Equals(t, []controlStatus{status}, statuses)
+}
+
+func TestControl_create_invalid(t *testing.T) {
+	// arrange
+	unit := httptest.NewServer((&control{token: "secret", scenarios: map[string]*controlScenario{}}).handler())
+	defer unit.Close()
+	// action
+	invalid, _ := controlRequest(t, unit.URL, "secret", http.MethodPost, "/scenarios", "concurrency: 1\n")
+	bodyFile, message := controlRequest(t, unit.URL, "secret", http.MethodPost, "/scenarios",
+		"targets:\n  - url: http://localhost\n    bodyFile: /etc/passwd\n")
+	urlFile, _ := controlRequest(t, unit.URL, "secret", http.MethodPost, "/scenarios", "urlFile: \"-\"\n")
+	// verify
+	verify.Equals(t, http.StatusBadRequest, invalid)
+	verify.Equals(t, http.StatusBadRequest, bodyFile)
+	verify.Assert(t, strings.Contains(message, "/etc/passwd"), "file should be named: "+message)
+	verify.Equals(t, http.StatusBadRequest, urlFile)
+}
+
+func TestControl_startStop(t *testing.T) {
+	// arrange
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer target.Close()
+	unit := httptest.NewServer((&control{token: "secret", scenarios: map[string]*controlScenario{}}).handler())
+	defer unit.Close()
+	controlRequest(t, unit.URL, "secret", http.MethodPost, "/scenarios",
+		"targets:\n  - url: "+target.URL+"\nconcurrency: 1\nduration: 1m\n")
+	// action
+	started, _ := controlRequest(t, unit.URL, "secret", http.MethodPost, "/scenarios/1/start", "")
+	startedAgain, _ := controlRequest(t, unit.URL, "secret", http.MethodPost, "/scenarios/1/start", "")
+	resultsRunning, _ := controlRequest(t, unit.URL, "secret", http.MethodGet, "/scenarios/1/results", "")
+	stopped, _ := controlRequest(t, unit.URL, "secret", http.MethodPost, "/scenarios/1/stop", "")
+	status := waitControlDone(t, unit.URL, "1")
+	results, file := controlRequest(t, unit.URL, "secret", http.MethodGet, "/scenarios/1/results", "")
+	// verify
+	verify.Equals(t, http.StatusAccepted, started)
+	verify.Equals(t, http.StatusConflict, startedAgain)
+	verify.Equals(t, http.StatusConflict, resultsRunning)
+	verify.Equals(t, http.StatusAccepted, stopped)
+	verify.Equals(t, stateDone, status.State)
+	verify.Equals(t, http.StatusOK, results)
+	decoded, err := report.DecodeFile([]byte(file))
+	verify.Ok(t, err)
+	verify.Equals(t, 1, len(decoded))
+}
+
+func TestControl_results(t *testing.T) {
+	// arrange
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer target.Close()
+	unit := httptest.NewServer((&control{token: "secret", scenarios: map[string]*controlScenario{}}).handler())
+	defer unit.Close()
+	controlRequest(t, unit.URL, "secret", http.MethodPost, "/scenarios",
+		"targets:\n  - url: "+target.URL+"\nconcurrency: 1\nrequests: 5\n")
+	// action
+	resultsCreated, _ := controlRequest(t, unit.URL, "secret", http.MethodGet, "/scenarios/1/results", "")
+	controlRequest(t, unit.URL, "secret", http.MethodPost, "/scenarios/1/start", "")
+	status := waitControlDone(t, unit.URL, "1")
+	stopped, _ := controlRequest(t, unit.URL, "secret", http.MethodPost, "/scenarios/1/stop", "")
+	results, file := controlRequest(t, unit.URL, "secret", http.MethodGet, "/scenarios/1/results", "")
+	// verify
+	verify.Equals(t, http.StatusConflict, resultsCreated)
+	verify.Equals(t, stateDone, status.State)
+	verify.Equals(t, http.StatusConflict, stopped)
+	verify.Equals(t, http.StatusOK, results)
+	decoded, err := report.DecodeFile([]byte(file))
+	verify.Ok(t, err)
+	verify.Equals(t, 1, len(decoded))
+	verify.Equals(t, 5, decoded[0].Success)
+}
+
+// controlRequest sends a request to the control API and returns the status code and the body of the response.
+func controlRequest(t *testing.T, baseURL, token, method, path, body string) (int, string) {
+	t.Helper()
+	request, err := http.NewRequest(method, baseURL+path, strings.NewReader(body))
+	verify.Ok(t, err)
+	if token != "" {
+		request.Header.Set("Authorization", "Bearer "+token)
+	}
+	response, err := http.DefaultClient.Do(request)
+	verify.Ok(t, err)
+	defer response.Body.Close()
+	data, err := io.ReadAll(response.Body)
+	verify.Ok(t, err)
+	return response.StatusCode, string(data)
+}
+
+// waitControlDone polls the scenario until it is not running anymore and returns its status.
+func waitControlDone(t *testing.T, baseURL, id string) controlStatus {
+	t.Helper()
+	deadline := time.Now().Add(10 * time.Second)
+	for {
+		code, body := controlRequest(t, baseURL, "secret", http.MethodGet, "/scenarios/"+id, "")
+		verify.Equals(t, http.StatusOK, code)
+		var status controlStatus
+		verify.Ok(t, json.Unmarshal([]byte(body), &status))
+		if status.State != stateRunning || time.Now().After(deadline) {
+			return status
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
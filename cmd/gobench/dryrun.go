@@ -0,0 +1,247 @@
+// SPDX-FileCopyrightText: 2021 Eric Neidhardt
+// SPDX-License-Identifier: MIT
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httputil"
+	"sort"
+	"strings"
+
+	"github.com/EricNeid/go-bench/client"
+	"github.com/EricNeid/go-bench/config"
+	"github.com/EricNeid/go-bench/report"
+)
+
+// maxDryRunRequests is the maximum number of printed requests per workload.
+const maxDryRunRequests = 10
+
+// printDryRun prints the scenario and the requests as they would be sent.
+// The placeholders of the requests are rendered with the given variables, which may be nil.
+// Variables extracted by the setup steps are shown as <name>.
+func printDryRun(scenario *config.Scenario, setup []client.Step, workloads []config.Workload, vars func() map[string]string) error {
+	fmt.Printf("Clients:  %d\n", scenario.Concurrency)
+	if scenario.Requests > 0 {
+		fmt.Printf("Requests: %d per client\n", scenario.Requests)
+	} else {
+		fmt.Printf("Duration: %s\n", scenario.Duration)
+	}
+	if scenario.Rate > 0 {
+		fmt.Printf("Rate:     %.2f requests/sec per target\n", scenario.Rate)
+	}
+	if scenario.RetryAfter > 0 {
+		fmt.Printf("Backoff:  Retry-After of at most %s\n", scenario.RetryAfter)
+	}
+	fmt.Printf("Timeout:  %s\n", scenario.Timeout)
+	if scenario.Transport.ConnectTimeout > 0 {
+		fmt.Printf("Timeout:  %s to connect\n", scenario.Transport.ConnectTimeout)
+	}
+	if scenario.Transport.DNSMode != "" && scenario.Transport.DNSMode != config.DNSConnection {
+		fmt.Printf("DNS:      mode %s\n", scenario.Transport.DNSMode)
+	}
+	if scenario.Transport.DNSServer != "" {
+		fmt.Printf("DNS:      server %s\n", scenario.Transport.DNSServer)
+	}
+	for _, override := range scenario.Transport.Resolve {
+		fmt.Printf("Resolve:  %s\n", override)
+	}
+	if scenario.Transport.IPVersion != 0 {
+		fmt.Printf("IP:       version %d only\n", scenario.Transport.IPVersion)
+	}
+	if scenario.Transport.Interface != "" {
+		fmt.Printf("Sources:  addresses of %s\n", scenario.Transport.Interface)
+	}
+	if len(scenario.Transport.SourceAddresses) > 0 {
+		fmt.Printf("Sources:  %s\n", strings.Join(scenario.Transport.SourceAddresses, ", "))
+	}
+	if proxy, _ := scenario.Transport.ProxyURL(); proxy != nil {
+		fmt.Printf("Proxy:    %s\n", proxy.Redacted())
+	}
+	if scenario.Transport.ServerName != "" {
+		fmt.Printf("TLS:      server name %s\n", scenario.Transport.ServerName)
+	}
+	if scenario.Transport.Protocol != "" && scenario.Transport.Protocol != client.ProtocolAuto {
+		fmt.Printf("Protocol: %s\n", scenario.Transport.Protocol)
+	}
+	if scenario.Transport.Engine != "" && scenario.Transport.Engine != client.EngineNetHTTP {
+		fmt.Printf("Engine:   %s\n", scenario.Transport.Engine)
+	}
+	if scenario.Transport.ZeroRTT {
+		fmt.Println("Protocol: 0-RTT early data")
+	}
+	if scenario.Transport.TLSMinVersion != "" || scenario.Transport.TLSMaxVersion != "" {
+		fmt.Printf("TLS:      versions %s - %s\n", scenario.Transport.TLSMinVersion, scenario.Transport.TLSMaxVersion)
+	}
+	if len(scenario.Transport.CipherSuites) > 0 {
+		fmt.Printf("TLS:      cipher suites %s\n", strings.Join(scenario.Transport.CipherSuites, ", "))
+	}
+	if scenario.Transport.ClientCert != "" {
+		fmt.Printf("TLS:      client certificate %s\n", scenario.Transport.ClientCert)
+	}
+	if scenario.Transport.CACert != "" {
+		fmt.Printf("TLS:      CAs of %s\n", scenario.Transport.CACert)
+	}
+	if scenario.Transport.Insecure {
+		fmt.Println("TLS:      server certificates not verified")
+	}
+	if scenario.Transport.SessionTickets != "" {
+		fmt.Printf("TLS:      session tickets %s\n", scenario.Transport.SessionTickets)
+	}
+	if scenario.ExpectStatus != "" {
+		fmt.Printf("Expected: status %s\n", scenario.ExpectStatus)
+	}
+	if scenario.AssertBody != "" {
+		fmt.Printf("Expected: body matching %s\n", scenario.AssertBody)
+	}
+	if scenario.ResponseSchema != "" {
+		fmt.Printf("Expected: body valid against %s\n", scenario.ResponseSchema)
+	}
+	if scenario.AssertChecksum != "" {
+		fmt.Printf("Expected: body with sha-256 %s\n", scenario.AssertChecksum)
+	}
+	if scenario.OAuth2.TokenURL != "" {
+		fmt.Printf("OAuth2:   bearer token of %s for client %s\n", scenario.OAuth2.TokenURL, scenario.OAuth2.ClientID)
+	}
+	if scenario.JWT.KeyFile != "" {
+		fmt.Printf("JWT:      signed with %s per %s, claims %s\n", scenario.JWT.KeyFile, scenario.JWT.Mode, scenario.JWT.Claims)
+	}
+	if scenario.TokenFile != "" {
+		fmt.Printf("Token:    bearer token of %s\n", scenario.TokenFile)
+	}
+	if scenario.DigestAuth.User != "" {
+		fmt.Printf("Digest:   authenticated as %s\n", scenario.DigestAuth.User)
+	}
+	if scenario.AWSSigV4.Service != "" {
+		fmt.Printf("SigV4:    signed for service %s in %s\n", scenario.AWSSigV4.Service, scenario.AWSSigV4.Region)
+	}
+
+	template := client.NewTemplate()
+	if setup != nil {
+		fmt.Println()
+		fmt.Printf("Setup (%s):\n", scenario.SetupMode)
+		printSteps(setup)
+		extracted := &session{vars: make(map[string]string)}
+		for _, name := range extractedNames(setup) {
+			extracted.vars[name] = "<" + name + ">"
+		}
+		vars = extracted.with(vars)
+		fmt.Println()
+		fmt.Println("Requests:")
+	}
+
+	for i, workload := range workloads {
+		if len(workloads) > 1 {
+			fmt.Println()
+			fmt.Printf("Target %s:\n", report.Label(i))
+		}
+		if workload.Steps != nil {
+			printSteps(workload.Steps)
+			continue
+		}
+		if workload.WebSocket() || workload.TCP() || workload.UDP() || workload.DNS() || workload.MQTT() {
+			printPayload(workload.Requests[0])
+			continue
+		}
+		if workload.Stream != nil && len(workload.Requests) == 0 {
+			for j := 0; j < maxDryRunRequests; j++ {
+				request, ok := <-workload.Stream
+				if !ok {
+					break
+				}
+				if err := printRequest(template, request, vars); err != nil {
+					return err
+				}
+			}
+			continue
+		}
+		for j, request := range workload.Requests {
+			if j == maxDryRunRequests {
+				fmt.Println()
+				fmt.Printf("... %d more requests\n", len(workload.Requests)-j)
+				break
+			}
+			if err := printRequest(template, request, vars); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// printRequest prints the request with its placeholders rendered.
+func printRequest(template *client.Template, request client.Request, vars func() map[string]string) error {
+	var v map[string]string
+	if vars != nil {
+		v = vars()
+	}
+	request, err := template.Render(request, v)
+	if err != nil {
+		return err
+	}
+	req, err := request.NewHTTPRequest(context.Background())
+	if err != nil {
+		return err
+	}
+	// streamed bodies are too large to be printed
+	dump, err := httputil.DumpRequestOut(req, request.BodyFile == "")
+	if err != nil {
+		return err
+	}
+	fmt.Println()
+	fmt.Println(strings.TrimRight(string(dump), "\r\n"))
+	if request.BodyFile != "" {
+		fmt.Printf("<streamed from %s>\n", request.BodyFile)
+	}
+	return nil
+}
+
+// printPayload prints the target and the payload of a request of another protocol than HTTP, which is sent as is.
+func printPayload(request client.Request) {
+	fmt.Println()
+	fmt.Println(request.URL)
+	var keys []string
+	for k := range request.AdditionalHeaders {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		fmt.Printf("  %s: %s\n", k, request.AdditionalHeaders[k])
+	}
+	if request.PostBody != nil {
+		fmt.Printf("  %s\n", request.PostBody)
+	}
+}
+
+// printSteps prints the steps as configured, their placeholders depend on previous responses.
+func printSteps(steps []client.Step) {
+	for i, step := range steps {
+		fmt.Println()
+		method := step.Request.Method
+		if method == "" && step.Request.PostBody != nil {
+			method = http.MethodPost
+		} else if method == "" {
+			method = http.MethodGet
+		}
+		fmt.Printf("Step %d: %s %s\n", i+1, method, step.Request.URL)
+		var keys []string
+		for k := range step.Request.AdditionalHeaders {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			fmt.Printf("  %s: %s\n", k, step.Request.AdditionalHeaders[k])
+		}
+		if step.Request.PostBody != nil {
+			fmt.Printf("  %s\n", step.Request.PostBody)
+		}
+		for _, e := range step.Extract {
+			if e.JSONPath != "" {
+				fmt.Printf("  extract %s from %s\n", e.Name, e.JSONPath)
+			} else {
+				fmt.Printf("  extract %s from /%s/\n", e.Name, e.Regexp)
+			}
+		}
+	}
+}
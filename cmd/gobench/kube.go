@@ -0,0 +1,448 @@
+// SPDX-FileCopyrightText: 2021 Eric Neidhardt
+// SPDX-License-Identifier: MIT
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/exec"
+	"os/signal"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/EricNeid/go-bench/config"
+	"github.com/EricNeid/go-bench/report"
+)
+
+const (
+	// kubeFilesPath is the directory in the pods, to which the ConfigMap with the files of the scenario is mounted.
+	kubeFilesPath = "/etc/gobench/files"
+	// kubeSecretPath is the directory in the pods, to which the Secret with the scenario and its keys is mounted.
+	kubeSecretPath = "/etc/gobench/secret"
+	// kubeScenarioPath is the path of the scenario in the pods, it contains the expanded secrets of the scenario.
+	kubeScenarioPath = kubeSecretPath + "/scenario.yaml"
+	// kubeDataLimit is the maximum size of the data of a ConfigMap or Secret.
+	kubeDataLimit = 1 << 20
+	// kubeResultsDelimiter is the line written by the pods before and after their results, which finds them in
+	// the logs mixed with warnings and the output of the runtime, see -results-delimiter.
+	kubeResultsDelimiter = "--- gobench results ---"
+)
+
+var (
+	kubeFlags      = flag.NewFlagSet("kube", flag.ExitOnError)
+	kubeConfig     = kubeFlags.String("config", "", "Scenario file run by each worker")
+	kubeWorkers    = kubeFlags.Int("workers", 1, "Number of worker pods, each one runs the whole scenario")
+	kubeImage      = kubeFlags.String("image", "", "Image of the worker pods containing gobench, e.g. registry.example.com/gobench:"+version)
+	kubeNamespace  = kubeFlags.String("namespace", "", "Namespace of the Job, Secret and ConfigMap, the one of the kubectl context if not given")
+	kubeName       = kubeFlags.String("name", "gobench", "Name of the Job, Secret and ConfigMap, an existing Job of this name is replaced")
+	kubeKubectl    = kubeFlags.String("kubectl", "kubectl", "Path of kubectl, which applies the resources with its current context")
+	kubeStartDelay = durationFlag(30 * time.Second)
+	kubeTimeout    = durationFlag(time.Hour)
+	kubeRender     = kubeFlags.Bool("render", false, "Print the resources without applying them: gobench kube -config bench.yaml -workers 5 -image gobench -render | kubectl apply -f -")
+	kubeKeep       = kubeFlags.Bool("keep", false, "Keep the Job, Secret and ConfigMap after the run, e.g. to inspect the logs of the pods")
+	kubeOutput     = kubeFlags.String("o", "", "Write the merged results as JSON to file")
+	kubeFormat     = kubeFlags.String("format", "text", "Format of the printed results: text, json or csv")
+)
+
+func init() {
+	kubeFlags.Var(&kubeStartDelay, "start-delay", "Time from applying the Job until the workers start simultaneously, it should cover scheduling and pulling the image")
+	kubeFlags.Var(&kubeTimeout, "timeout", "Maximum time waiting for the Job to complete")
+	kubeFlags.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage of %s kube:\n", os.Args[0])
+		fmt.Printf("  %s kube -config bench.yaml -workers 5 -image registry.example.com/gobench:%s -namespace load\n", os.Args[0], version)
+		fmt.Printf("Command line options:\n")
+		kubeFlags.PrintDefaults()
+	}
+}
+
+// kubeResources returns the Secret with the encoded scenario and its keys, the ConfigMap with its other files,
+// see kubeFiles, and the Job with a pod per worker as yaml documents. The ConfigMap is omitted without files.
+func kubeResources(
+	name, namespace, image string, workers int, scenario []byte, files, secrets map[string][]byte, start time.Time,
+) ([]byte, error) {
+	metadata := func(resource string) map[string]any {
+		m := map[string]any{
+			"name":   resource,
+			"labels": map[string]string{"app.kubernetes.io/name": "gobench", "app.kubernetes.io/instance": name},
+		}
+		if namespace != "" {
+			m["namespace"] = namespace
+		}
+		return m
+	}
+	secretData := map[string]string{path.Base(kubeScenarioPath): base64.StdEncoding.EncodeToString(scenario)}
+	for key, content := range secrets {
+		secretData[key] = base64.StdEncoding.EncodeToString(content)
+	}
+	resources := []any{map[string]any{
+		"apiVersion": "v1",
+		"kind":       "Secret",
+		"type":       "Opaque",
+		"metadata":   metadata(name + "-scenario"),
+		"data":       secretData,
+	}}
+	volumeMounts := []any{map[string]any{"name": "scenario", "mountPath": kubeSecretPath, "readOnly": true}}
+	volumes := []any{map[string]any{
+		"name":   "scenario",
+		"secret": map[string]any{"secretName": name + "-scenario", "defaultMode": 0o400},
+	}}
+	if len(files) > 0 {
+		data := make(map[string]string)
+		binaryData := make(map[string]string)
+		for key, content := range files {
+			if utf8.Valid(content) {
+				data[key] = string(content)
+			} else {
+				binaryData[key] = base64.StdEncoding.EncodeToString(content)
+			}
+		}
+		configMap := map[string]any{
+			"apiVersion": "v1",
+			"kind":       "ConfigMap",
+			"metadata":   metadata(name + "-files"),
+		}
+		if len(data) > 0 {
+			configMap["data"] = data
+		}
+		if len(binaryData) > 0 {
+			configMap["binaryData"] = binaryData
+		}
+		resources = append(resources, configMap)
+		volumeMounts = append(volumeMounts, map[string]any{"name": "files", "mountPath": kubeFilesPath, "readOnly": true})
+		volumes = append(volumes, map[string]any{"name": "files", "configMap": map[string]any{"name": name + "-files"}})
+	}
+	podLabels := map[string]string{"app.kubernetes.io/name": "gobench", "app.kubernetes.io/instance": name}
+	job := map[string]any{
+		"apiVersion": "batch/v1",
+		"kind":       "Job",
+		"metadata":   metadata(name),
+		"spec": map[string]any{
+			"completions":    workers,
+			"parallelism":    workers,
+			"completionMode": "Indexed",
+			"backoffLimit":   0,
+			"template": map[string]any{
+				"metadata": map[string]any{"labels": podLabels},
+				"spec": map[string]any{
+					"restartPolicy": "Never",
+					// spread the workers over the nodes, so that they do not share their network
+					"affinity": map[string]any{"podAntiAffinity": map[string]any{
+						"preferredDuringSchedulingIgnoredDuringExecution": []any{map[string]any{
+							"weight": 100,
+							"podAffinityTerm": map[string]any{
+								"topologyKey":   "kubernetes.io/hostname",
+								"labelSelector": map[string]any{"matchLabels": podLabels},
+							},
+						}},
+					}},
+					"containers": []any{map[string]any{
+						"name":  "gobench",
+						"image": image,
+						"args": []string{
+							"run", "-config", kubeScenarioPath, "-start-at", start.UTC().Format(time.RFC3339),
+							"-results-delimiter", kubeResultsDelimiter,
+						},
+						"volumeMounts": volumeMounts,
+					}},
+					"volumes": volumes,
+				},
+			},
+		},
+	}
+	var documents bytes.Buffer
+	encoder := yaml.NewEncoder(&documents)
+	encoder.SetIndent(2)
+	for _, resource := range append(resources, job) {
+		if err := encoder.Encode(resource); err != nil {
+			return nil, err
+		}
+	}
+	if err := encoder.Close(); err != nil {
+		return nil, err
+	}
+	return documents.Bytes(), nil
+}
+
+// kubeFiles reads the files referenced by the scenario, which are shipped to the pods, and rewrites their paths
+// to the ones in the pods. Keys and credentials, see kubeSecretFiles, are returned as secrets shipped by the
+// Secret, the other files are shipped by the ConfigMap. Both are returned by their keys.
+// Requests from stdin and body files referenced by URL files are not supported.
+func kubeFiles(scenario *config.Scenario) (files, secrets map[string][]byte, err error) {
+	if scenario.URLFile == config.StdinURLFile {
+		return nil, nil, errors.New("requests cannot be read from stdin by the pods")
+	}
+	if scenario.URLFile != "" {
+		targets, err := config.LoadURLFile(scenario.URLFile)
+		if err != nil {
+			return nil, nil, err
+		}
+		for _, t := range targets {
+			if t.BodyFile != "" {
+				return nil, nil, fmt.Errorf("body files of URL files are not supported, found %s", t.BodyFile)
+			}
+		}
+	}
+	secret := make(map[string]bool)
+	for _, p := range kubeSecretFiles(scenario) {
+		secret[*p] = true
+	}
+	files, secrets = make(map[string][]byte), make(map[string][]byte)
+	paths := make(map[string]string)
+	filesSize, secretsSize := 0, 0
+	for _, p := range scenario.Files() {
+		mounted, ok := paths[*p]
+		if !ok {
+			data, err := os.ReadFile(*p)
+			if err != nil {
+				return nil, nil, err
+			}
+			key := fmt.Sprintf("file%d-%s", len(paths), kubeKey(filepath.Base(*p)))
+			if secret[*p] {
+				secrets[key], mounted = data, path.Join(kubeSecretPath, key)
+				secretsSize += len(data)
+			} else {
+				files[key], mounted = data, path.Join(kubeFilesPath, key)
+				filesSize += len(data)
+			}
+			paths[*p] = mounted
+		}
+		*p = mounted
+	}
+	if filesSize > kubeDataLimit {
+		return nil, nil, fmt.Errorf("files of %d bytes exceed the limit of ConfigMaps of %d bytes", filesSize, kubeDataLimit)
+	}
+	if secretsSize > kubeDataLimit {
+		return nil, nil, fmt.Errorf("keys of %d bytes exceed the limit of Secrets of %d bytes", secretsSize, kubeDataLimit)
+	}
+	return files, secrets, nil
+}
+
+// kubeSecretFiles returns the options of the scenario referencing keys and credentials, which are not stored in
+// ConfigMaps.
+func kubeSecretFiles(scenario *config.Scenario) []*string {
+	var secrets []*string
+	for _, p := range []*string{&scenario.Transport.ClientKey, &scenario.JWT.KeyFile, &scenario.TokenFile, &scenario.CookieFile} {
+		if *p != "" {
+			secrets = append(secrets, p)
+		}
+	}
+	return secrets
+}
+
+// kubeKey returns the name with the characters, which are not allowed in keys of ConfigMaps and Secrets,
+// replaced by _.
+func kubeKey(name string) string {
+	return strings.Map(func(r rune) rune {
+		if r == '-' || r == '.' || r == '_' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' {
+			return r
+		}
+		return '_'
+	}, name)
+}
+
+// delimitedResults returns the results written to the logs between the lines of kubeResultsDelimiter.
+func delimitedResults(logs []byte) ([]byte, error) {
+	delimiter := []byte(kubeResultsDelimiter + "\n")
+	_, after, found := bytes.Cut(logs, delimiter)
+	if found {
+		var results []byte
+		if results, _, found = bytes.Cut(after, delimiter); found {
+			return results, nil
+		}
+	}
+	return nil, errors.New("no results found")
+}
+
+// kubectl runs kubectl with the namespace and returns its output.
+func kubectl(stdin []byte, args ...string) ([]byte, error) {
+	if *kubeNamespace != "" {
+		args = append([]string{"--namespace", *kubeNamespace}, args...)
+	}
+	cmd := exec.Command(*kubeKubectl, args...) //nolint:gosec // kubectl is given by the user
+	if stdin != nil {
+		cmd.Stdin = bytes.NewReader(stdin)
+	}
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("kubectl %s: %w: %s", strings.Join(args, " "), err, strings.TrimSpace(stderr.String()))
+	}
+	return out, nil
+}
+
+// waitForJob polls the Job until all of its pods succeeded, one of them failed or the context is done.
+func waitForJob(ctx context.Context, name string, workers int) error {
+	for {
+		out, err := kubectl(nil, "get", "job", name, "-o", "jsonpath={.status.succeeded},{.status.failed}")
+		if err != nil {
+			return err
+		}
+		succeeded, failed, _ := strings.Cut(strings.TrimSpace(string(out)), ",")
+		if n, _ := strconv.Atoi(failed); n > 0 {
+			return fmt.Errorf("%d worker pods failed, see: kubectl logs -l job-name=%s", n, name)
+		}
+		if n, _ := strconv.Atoi(succeeded); n >= workers {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(2 * time.Second):
+		}
+	}
+}
+
+// gatherResults returns the results written by the pods of the Job to their logs.
+func gatherResults(name string) ([]string, [][]report.Result, error) {
+	out, err := kubectl(nil, "get", "pods", "-l", "job-name="+name, "--field-selector", "status.phase=Succeeded",
+		"-o", "jsonpath={.items[*].metadata.name}")
+	if err != nil {
+		return nil, nil, err
+	}
+	pods := strings.Fields(string(out))
+	if len(pods) == 0 {
+		return nil, nil, fmt.Errorf("no succeeded pods of Job %s found", name)
+	}
+	files := make([][]report.Result, 0, len(pods))
+	for _, pod := range pods {
+		logs, err := kubectl(nil, "logs", pod)
+		if err != nil {
+			return nil, nil, err
+		}
+		data, err := delimitedResults(logs)
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid logs of pod %s: %w", pod, err)
+		}
+		results, err := report.DecodeFile(data)
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid results of pod %s: %w", pod, err)
+		}
+		files = append(files, results)
+	}
+	return pods, files, nil
+}
+
+// runKube runs the scenario by a Kubernetes Job with a pod per worker, reports their merged results and
+// returns the exit code.
+func runKube(args []string) int {
+	flags := kubeFlags
+	_ = flags.Parse(args)
+
+	if *kubeConfig == "" || *kubeImage == "" {
+		fmt.Println("Scenario file and image are required")
+		flags.Usage()
+		return 1
+	}
+	if *kubeWorkers <= 0 {
+		fmt.Println("Number of workers must be larger than 0")
+		flags.Usage()
+		return 1
+	}
+	scenario, err := config.Load(*kubeConfig)
+	if err != nil {
+		fmt.Printf("Could not load scenario: %s\n", err)
+		return 1
+	}
+	if err := scenario.Validate(); err != nil {
+		fmt.Printf("Invalid scenario: %s\n", err)
+		return 1
+	}
+	// the pods write their results to their logs, from which they are gathered, the merged ones are pushed
+	pusher := prometheusReporter(scenario.Output.Prometheus)
+	scenario.Output = config.Output{File: report.StdoutFile, Quiet: true}
+	scenarioFiles, secrets, err := kubeFiles(scenario)
+	if err != nil {
+		fmt.Printf("Could not ship files of scenario: %s\n", err)
+		return 1
+	}
+	encoded, err := scenario.Marshal()
+	if err != nil {
+		fmt.Printf("Could not encode scenario: %s\n", err)
+		return 1
+	}
+	// the pods load the scenario by -config, which expands environment variable references a second time
+	encoded = []byte(config.Escape(string(encoded)))
+	start := time.Now().Add(time.Duration(kubeStartDelay))
+	resources, err := kubeResources(*kubeName, *kubeNamespace, *kubeImage, *kubeWorkers, encoded, scenarioFiles, secrets, start)
+	if err != nil {
+		fmt.Printf("Could not render resources: %s\n", err)
+		return 1
+	}
+	if *kubeRender {
+		fmt.Print(string(resources))
+		return 0
+	}
+
+	// the spec of a Job cannot be changed, a previous one is replaced
+	if _, err := kubectl(nil, "delete", "job", *kubeName, "--ignore-not-found", "--wait"); err != nil {
+		fmt.Printf("Could not delete previous Job: %s\n", err)
+		return 1
+	}
+	if _, err := kubectl(resources, "apply", "-f", "-"); err != nil {
+		fmt.Printf("Could not apply resources: %s\n", err)
+		return 1
+	}
+	if !*kubeKeep {
+		defer func() {
+			if _, err := kubectl(nil, "delete", "job,configmap,secret", "-l", "app.kubernetes.io/instance="+*kubeName); err != nil {
+				fmt.Printf("Could not delete resources: %s\n", err)
+			}
+		}()
+	}
+	fmt.Printf("Started Job %s with %d workers, waiting for completion...\n", *kubeName, *kubeWorkers)
+
+	// an interrupt deletes the Job, its results are lost
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(kubeTimeout))
+	defer cancel()
+	if err := waitForJob(ctx, *kubeName, *kubeWorkers); err != nil {
+		fmt.Printf("Job %s failed: %s\n", *kubeName, err)
+		return 1
+	}
+	pods, files, err := gatherResults(*kubeName)
+	if err != nil {
+		fmt.Printf("Could not gather results: %s\n", err)
+		return 1
+	}
+	merged, err := mergeFiles(pods, files)
+	if err != nil {
+		fmt.Printf("Could not merge results: %s\n", err)
+		return 1
+	}
+
+	reporter, err := report.New(*kubeFormat, os.Stdout)
+	if err != nil {
+		fmt.Printf("Could not print results: %s\n", err)
+		return 1
+	}
+	if err := reporter.Final(merged); err != nil {
+		fmt.Printf("Could not print results: %s\n", err)
+		return 1
+	}
+	if *kubeOutput != "" {
+		if err := report.WriteFile(*kubeOutput, merged); err != nil {
+			fmt.Printf("Could not write results to %s: %s\n", *kubeOutput, err)
+			return 1
+		}
+	}
+	if pusher != nil {
+		if err := pusher.Final(merged); err != nil {
+			fmt.Printf("Could not push results: %s\n", err)
+			return 1
+		}
+	}
+	return 0
+}
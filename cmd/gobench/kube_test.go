@@ -0,0 +1,86 @@
+// SPDX-FileCopyrightText: 2021 Eric Neidhardt
+// SPDX-License-Identifier: MIT
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/EricNeid/go-bench/config"
+	"github.com/EricNeid/go-bench/internal/verify"
+)
+
+func TestKubeResources(t *testing.T) {
+	// arrange
+	expected, err := os.ReadFile("testdata/kube.yaml")
+	verify.Ok(t, err)
+	scenario := []byte("targets:\n  - url: http://api\n    bodyFile: /etc/gobench/files/file0-body.json\n")
+	files := map[string][]byte{"file0-body.json": []byte("{\"key\":\"value\"}\n"), "file1-data.bin": {0xff, 0x00}}
+	secrets := map[string][]byte{"file2-key.pem": []byte("key")}
+	// action
+	result, err := kubeResources("bench", "load", "registry.example.com/gobench:0.3.0", 3, scenario, files, secrets,
+		time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC))
+	// verify
+	verify.Ok(t, err)
+	verify.Equals(t, string(expected), string(result))
+}
+
+func TestKubeFiles(t *testing.T) {
+	// arrange
+	dir := t.TempDir()
+	verify.Ok(t, os.WriteFile(filepath.Join(dir, "body data.json"), []byte("{}"), 0o644))
+	verify.Ok(t, os.WriteFile(filepath.Join(dir, "key.pem"), []byte("key"), 0o644))
+	verify.Ok(t, os.WriteFile(filepath.Join(dir, "bench.yaml"), []byte(`
+targets:
+  - url: http://localhost/a
+    bodyFile: body data.json
+  - url: http://localhost/b
+    bodyFile: body data.json
+transport:
+  clientKey: key.pem
+`), 0o644))
+	scenario, err := config.Load(filepath.Join(dir, "bench.yaml"))
+	verify.Ok(t, err)
+	// action
+	files, secrets, err := kubeFiles(scenario)
+	// verify
+	verify.Ok(t, err)
+	verify.Equals(t, map[string][]byte{"file1-body_data.json": []byte("{}")}, files)
+	verify.Equals(t, map[string][]byte{"file0-key.pem": []byte("key")}, secrets)
+	verify.Equals(t, "/etc/gobench/secret/file0-key.pem", scenario.Transport.ClientKey)
+	verify.Equals(t, "/etc/gobench/files/file1-body_data.json", scenario.Targets[0].BodyFile)
+	verify.Equals(t, "/etc/gobench/files/file1-body_data.json", scenario.Targets[1].BodyFile)
+}
+
+func TestKubeFiles_stdin(t *testing.T) {
+	// arrange
+	scenario := &config.Scenario{URLFile: config.StdinURLFile}
+	// action
+	_, _, err := kubeFiles(scenario)
+	// verify
+	verify.Assert(t, err != nil, "requests from stdin should be rejected")
+}
+
+func TestDelimitedResults(t *testing.T) {
+	// arrange
+	logs := []byte("Warning: insecure transport\n--- gobench results ---\n{\"requests\": 1}\n--- gobench results ---\n")
+	// action
+	result, err := delimitedResults(logs)
+	_, errMissing := delimitedResults([]byte("{\"requests\": 1}\n"))
+	// verify
+	verify.Ok(t, err)
+	verify.Equals(t, "{\"requests\": 1}\n", string(result))
+	verify.Assert(t, errMissing != nil, "logs without delimiters should be rejected")
+}
+
+func TestKubeResources_withoutFiles(t *testing.T) {
+	// action
+	result, err := kubeResources("bench", "", "gobench", 1, []byte("targets:\n  - url: http://api\n"), nil, nil, time.Now())
+	// verify
+	verify.Ok(t, err)
+	verify.Assert(t, !strings.Contains(string(result), "ConfigMap"), "ConfigMap without files rendered: %s", result)
+	verify.Assert(t, strings.Contains(string(result), "kind: Secret"), "Secret not rendered: %s", result)
+}
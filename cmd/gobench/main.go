@@ -6,152 +6,77 @@ import (
 	"flag"
 	"fmt"
 	"os"
-	"sync"
-	"time"
-
-	"github.com/EricNeid/go-bench/client"
+	"strings"
 )
 
 const version = "0.3.0"
 
-var (
-	clientCount = 100
-
-	requestCount        = -1
-	requestsDurationSec = -1
-
-	url = ""
-
-	postDataFilePath = ""
-	postBody         = ""
-	contentType      = ""
-
-	keepAlive = false
-
-	clientTimeoutMs int64 = 10 * 1000 // 10 seconds
+// command is a subcommand of gobench.
+type command struct {
+	name        string
+	description string
+	// flags of the command, used for help and shell completion
+	flags *flag.FlagSet
+	// run executes the command with the remaining arguments and returns the exit code.
+	run func(args []string) int
+}
 
-	authHeader        = ""
-	additionalHeaders = ""
-)
+var commands []command
 
 func init() {
-	flag.Usage = func() {
-		fmt.Fprintf(os.Stderr, "Usage of %s:\n", os.Args[0])
-		fmt.Printf("Version: %s\n", version)
-		fmt.Printf("Command line options:\n")
-		flag.PrintDefaults()
-	}
-	flag.IntVar(&clientCount, "c", clientCount, "Number of concurrent clients")
-	flag.IntVar(&requestCount, "r", requestCount, "Number of requests per client")
-	flag.IntVar(&requestsDurationSec, "t", requestsDurationSec, "Duration for performing requests (in seconds)")
-
-	flag.StringVar(&url, "u", url, "URL")
-
-	flag.StringVar(&postDataFilePath, "d", postDataFilePath, "HTTP POST data file path: gobench -u http://localhost -t 10 -d ./data.json")
-	flag.StringVar(&postBody, "b", postBody, "HTTP POST body: gobench -u http://localhost -t 10 -b '{\"name\":\"max\"}'")
-	flag.StringVar(&contentType, "content-type", contentType, "Content type of post body")
-
-	flag.BoolVar(&keepAlive, "k", keepAlive, "Do HTTP keep-alive ")
-	flag.Int64Var(&clientTimeoutMs, "timeout", clientTimeoutMs, "Timeout (in milliseconds)")
-
-	flag.StringVar(&authHeader, "auth", authHeader, "Authorization header: gobench -u http://localhost -t 10 -auth 'Basic QWxhZGRpbjpvcGVuIHNlc2FtZQ=='")
-	flag.StringVar(
-		&additionalHeaders, "headers", additionalHeaders, "additional header fields: gobench -u http://localhost -t 10 -headers key1=value1,key2=value2",
-	)
-
-	flag.Parse()
-
-	if url == "" {
-		println("Url is required")
-		flag.Usage()
-		os.Exit(1)
-	}
-
-	if requestCount == -1 && requestsDurationSec == -1 {
-		fmt.Println("Request count or request duration must be provided")
-		flag.Usage()
-		os.Exit(1)
+	commands = []command{
+		{"run", "Run a benchmark (default if no command is given)", runFlags, runBenchmark},
+		{"report", "Print a result file", reportFlags, runReport},
+		{"compare", "Compare two result files", compareFlags, runCompare},
+		{"merge", "Merge result files of runs at the same time", mergeFlags, runMerge},
+		{"serve", "Start a test server to benchmark against", serveFlags, runServe},
+		{"agent", "Start a worker agent, which runs the scenarios of run -workers", agentFlags, runAgent},
+		{"control", "Start a REST API to submit, run and stop scenarios and fetch their results", controlFlags, runControl},
+		{"kube", "Run a scenario by a Kubernetes Job with a pod per worker", kubeFlags, runKube},
+		{"completion", "Print shell completion script: bash, zsh or fish", completionFlags, runCompletion},
+		{"version", "Print the version", versionFlags, runVersion},
 	}
+}
 
-	if requestCount != -1 && requestsDurationSec != -1 {
-		fmt.Println("Only one should be provided: [requests|duration]")
-		flag.Usage()
-		os.Exit(1)
+func usage() {
+	fmt.Fprintf(os.Stderr, "Usage of %s:\n", os.Args[0])
+	fmt.Printf("Version: %s\n", version)
+	fmt.Printf("  %s <command> [options]\n", os.Args[0])
+	fmt.Printf("Commands:\n")
+	for _, c := range commands {
+		fmt.Printf("  %-10s %s\n", c.name, c.description)
 	}
+	fmt.Printf("Use %s <command> -h to show the options of a command.\n", os.Args[0])
+}
 
-	if clientCount <= 0 {
-		fmt.Println("Number of clients must be larger than 0")
-		flag.Usage()
-		os.Exit(1)
-	}
+func isHelp(arg string) bool {
+	return arg == "-h" || arg == "-help" || arg == "--help"
 }
 
 func main() {
-	request := client.NewRequest(url, postDataFilePath, postBody, contentType, keepAlive, authHeader, additionalHeaders)
-
-	var clients []*client.Client
-	for i := 0; i < clientCount; i++ {
-		clients = append(clients, client.NewClient(time.Duration(clientTimeoutMs)*time.Millisecond, *request))
+	args := os.Args[1:]
+	if len(args) == 0 || isHelp(args[0]) {
+		usage()
+		os.Exit(1)
 	}
-
-	fmt.Printf("Dispatching %d clients\n", len(clients))
-
-	var done sync.WaitGroup
-	done.Add(len(clients))
-	startTime := time.Now()
-
-	if requestCount != -1 {
-		for _, c := range clients {
-			go func(c *client.Client) {
-				c.RunForAmount(requestCount)
-				done.Done()
-			}(c)
-		}
-	} else if requestsDurationSec != -1 {
-		for _, c := range clients {
-			go func(c *client.Client) {
-				c.RunForDuration(time.Duration(requestsDurationSec) * time.Second)
-				done.Done()
-			}(c)
+	// options without command are passed to run, for compatibility with previous versions
+	if strings.HasPrefix(args[0], "-") {
+		os.Exit(runBenchmark(args))
+	}
+	for _, c := range commands {
+		if c.name == args[0] {
+			os.Exit(c.run(args[1:]))
 		}
 	}
-	fmt.Println("Waiting for results...")
-	done.Wait()
-
-	printResults(clients, startTime)
+	fmt.Printf("Unknown command: %s\n", args[0])
+	usage()
+	os.Exit(1)
 }
 
-func printResults(clients []*client.Client, startTime time.Time) {
-	var requests int64
-	var success int64
-	var failed int64
-	var networkFailed int64
-
-	var readThroughput int64
-	var writeThroughput int64
-
-	for _, c := range clients {
-		requests += int64(c.Statistic.RequestCount)
-		success += int64(c.Statistic.SuccessCount)
-		networkFailed += int64(c.Statistic.NetworkFailedCount)
-		failed += int64(c.Statistic.FailureCount)
-		readThroughput += c.Statistic.ReadThroughput
-		writeThroughput += c.Statistic.WriteThroughput
-	}
-
-	elapsed := int64(time.Since(startTime).Seconds())
-
-	if elapsed == 0 {
-		elapsed = 1
-	}
+var versionFlags = flag.NewFlagSet("version", flag.ExitOnError)
 
-	fmt.Println()
-	fmt.Printf("Requests:                       %10d hits\n", requests)
-	fmt.Printf("Successful requests:            %10d hits\n", success)
-	fmt.Printf("Network failed:                 %10d hits\n", networkFailed)
-	fmt.Printf("Bad requests failed (!2xx):     %10d hits\n", failed)
-	fmt.Printf("Successful requests rate:       %10d hits/sec\n", success/elapsed)
-	fmt.Printf("Read throughput:                %10d bytes/sec\n", readThroughput/elapsed)
-	fmt.Printf("Write throughput:               %10d bytes/sec\n", writeThroughput/elapsed)
-	fmt.Printf("Test time:                      %10d sec\n", elapsed)
+func runVersion(args []string) int {
+	_ = versionFlags.Parse(args)
+	fmt.Println(version)
+	return 0
 }
@@ -0,0 +1,92 @@
+// SPDX-FileCopyrightText: 2021 Eric Neidhardt
+// SPDX-License-Identifier: MIT
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/EricNeid/go-bench/report"
+)
+
+var (
+	mergeFlags  = flag.NewFlagSet("merge", flag.ExitOnError)
+	mergeFormat = mergeFlags.String("format", "text", "Output format: text, json or csv")
+	mergeOutput = mergeFlags.String("o", "", "Write the merged results as JSON to file")
+)
+
+func init() {
+	mergeFlags.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage of %s merge:\n", os.Args[0])
+		fmt.Printf("  %s merge [-format text|json|csv] [-o merged.json] a.json b.json ...\n", os.Args[0])
+		fmt.Printf("Command line options:\n")
+		mergeFlags.PrintDefaults()
+	}
+}
+
+// runMerge merges the result files of runs at the same time, e.g. of several load generators, prints the
+// merged results and returns the exit code.
+func runMerge(args []string) int {
+	flags := mergeFlags
+	_ = flags.Parse(args)
+
+	if flags.NArg() < 2 {
+		fmt.Println("At least two result files are required")
+		flags.Usage()
+		return 1
+	}
+
+	var files [][]report.Result
+	for _, path := range flags.Args() {
+		results, err := report.ReadFile(path)
+		if err != nil {
+			fmt.Printf("Could not read result %s: %s\n", path, err)
+			return 1
+		}
+		files = append(files, results)
+	}
+	merged, err := mergeFiles(flags.Args(), files)
+	if err != nil {
+		fmt.Printf("Could not merge results: %s\n", err)
+		return 1
+	}
+
+	reporter, err := report.New(*mergeFormat, os.Stdout)
+	if err != nil {
+		fmt.Printf("Could not print result: %s\n", err)
+		return 1
+	}
+	if err := reporter.Final(merged); err != nil {
+		fmt.Printf("Could not print result: %s\n", err)
+		return 1
+	}
+	if *mergeOutput != "" {
+		if err := report.WriteFile(*mergeOutput, merged); err != nil {
+			fmt.Printf("Could not write results to %s: %s\n", *mergeOutput, err)
+			return 1
+		}
+	}
+	return 0
+}
+
+// mergeFiles merges the results of the same targets of the given files, see report.Merge.
+func mergeFiles(names []string, files [][]report.Result) ([]report.Result, error) {
+	for i, results := range files {
+		if len(results) != len(files[0]) {
+			return nil, fmt.Errorf("%s has %d targets instead of %d", names[i], len(results), len(files[0]))
+		}
+	}
+	merged := make([]report.Result, len(files[0]))
+	for i := range merged {
+		targets := make([]report.Result, len(files))
+		for j, results := range files {
+			targets[j] = results[i]
+		}
+		var err error
+		if merged[i], err = report.Merge(targets...); err != nil {
+			return nil, err
+		}
+	}
+	return merged, nil
+}
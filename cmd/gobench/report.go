@@ -0,0 +1,53 @@
+// SPDX-FileCopyrightText: 2021 Eric Neidhardt
+// SPDX-License-Identifier: MIT
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/EricNeid/go-bench/report"
+)
+
+var (
+	reportFlags  = flag.NewFlagSet("report", flag.ExitOnError)
+	reportFormat = reportFlags.String("format", "text", "Output format: text, json or csv")
+)
+
+func init() {
+	reportFlags.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage of %s report:\n", os.Args[0])
+		fmt.Printf("  %s report [-format text|json|csv] result.json\n", os.Args[0])
+		fmt.Printf("Command line options:\n")
+		reportFlags.PrintDefaults()
+	}
+}
+
+// runReport prints the given result file and returns the exit code.
+func runReport(args []string) int {
+	flags := reportFlags
+	_ = flags.Parse(args)
+
+	if flags.NArg() != 1 {
+		fmt.Println("Result file is required")
+		flags.Usage()
+		return 1
+	}
+
+	results, err := report.ReadFile(flags.Arg(0))
+	if err != nil {
+		fmt.Printf("Could not read result %s: %s\n", flags.Arg(0), err)
+		return 1
+	}
+	reporter, err := report.New(*reportFormat, os.Stdout)
+	if err != nil {
+		fmt.Printf("Could not print result: %s\n", err)
+		return 1
+	}
+	if err := reporter.Final(results); err != nil {
+		fmt.Printf("Could not print result: %s\n", err)
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,48 @@
+// SPDX-FileCopyrightText: 2021 Eric Neidhardt
+// SPDX-License-Identifier: MIT
+package main
+
+import (
+	"io"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/EricNeid/go-bench/client"
+	"github.com/EricNeid/go-bench/config"
+	"github.com/EricNeid/go-bench/report"
+)
+
+// workloadResults returns the results of the workloads from their statistics, see client.Runner.Statistics.
+func workloadResults(workloads []config.Workload, statistics []client.Statistic, elapsed time.Duration) []report.Result {
+	var results []report.Result
+	for i, s := range statistics {
+		r := report.NewResult(s, elapsed)
+		r.Target = workloads[i].Name
+		results = append(results, r)
+	}
+	return results
+}
+
+// resultOutput returns the writer of the printed results, which are discarded, if the result file is written
+// to stdout instead, see report.StdoutFile.
+func resultOutput(output config.Output) io.Writer {
+	if output.File == report.StdoutFile {
+		return io.Discard
+	}
+	return os.Stdout
+}
+
+// prometheusReporter returns the reporter pushing the results to Prometheus, nil if it is not configured.
+func prometheusReporter(prometheus config.Prometheus) *report.Prometheus {
+	if prometheus.RemoteWrite == "" && prometheus.Pushgateway == "" {
+		return nil
+	}
+	return &report.Prometheus{
+		RemoteWrite: prometheus.RemoteWrite,
+		Pushgateway: prometheus.Pushgateway,
+		Job:         prometheus.Job,
+		Labels:      prometheus.Labels,
+		Client:      &http.Client{Timeout: 10 * time.Second},
+	}
+}
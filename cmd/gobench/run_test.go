@@ -0,0 +1,59 @@
+// SPDX-FileCopyrightText: 2021 Eric Neidhardt
+// SPDX-License-Identifier: MIT
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/EricNeid/go-bench/internal/verify"
+)
+
+// setOption sets an option of the run command, which is restored after the test.
+func setOption[T any](t *testing.T, option *T, value T) {
+	t.Helper()
+	previous := *option
+	*option = value
+	t.Cleanup(func() {
+		*option = previous
+	})
+}
+
+func TestLoadScenario_basicAuth(t *testing.T) {
+	// arrange
+	setOption(t, &url, "http://localhost")
+	setOption(t, &basicUser, "max")
+	setOption(t, &basicPassword, "secret")
+	// action
+	result, err := loadScenario()
+	// verify
+	verify.Ok(t, err)
+	verify.Equals(t, "Basic bWF4OnNlY3JldA==", result.Headers["Authorization"])
+}
+
+func TestLoadScenario_basicAuthWithAuthorization(t *testing.T) {
+	configFile := filepath.Join(t.TempDir(), "bench.yaml")
+	verify.Ok(t, os.WriteFile(configFile, []byte("headers:\n  authorization: Bearer token\n"), 0o600))
+	for name, option := range map[string]struct {
+		value *string
+		set   string
+	}{
+		"auth":    {&authHeader, "Bearer token"},
+		"headers": {&additionalHeaders, "Authorization=Bearer token"},
+		"config":  {&configFilePath, configFile},
+	} {
+		t.Run(name, func(t *testing.T) {
+			// arrange
+			setOption(t, &url, "http://localhost")
+			setOption(t, &basicUser, "max")
+			setOption(t, &basicPassword, "secret")
+			setOption(t, option.value, option.set)
+			// action
+			_, err := loadScenario()
+			// verify
+			verify.Assert(t, err != nil && strings.Contains(err.Error(), "only one should be provided"), "Authorization header combined with user and pass not detected: %v", err)
+		})
+	}
+}
@@ -0,0 +1,153 @@
+// SPDX-FileCopyrightText: 2021 Eric Neidhardt
+// SPDX-License-Identifier: MIT
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/EricNeid/go-bench/config"
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/h2c"
+)
+
+var (
+	serveFlags   = flag.NewFlagSet("serve", flag.ExitOnError)
+	serveAddr    = serveFlags.String("addr", ":8080", "Address to listen on")
+	serveStatus  = serveFlags.String("status", strconv.Itoa(http.StatusOK), "Status code of responses, or codes with weights, e.g. 200:90,503:10")
+	serveBody    = serveFlags.String("body", "ok", "Body of responses")
+	serveSize    = serveFlags.String("size", "", "Size of generated response bodies instead of -body, e.g. 4KB")
+	serveLatency durationFlag
+	serveJitter  durationFlag
+	serveH2C     = serveFlags.Bool("h2c", false, "Accept HTTP/2 without TLS besides HTTP/1.1")
+)
+
+func init() {
+	serveFlags.Var(&serveLatency, "latency", "Fixed delay of responses, e.g. 20ms")
+	serveFlags.Var(&serveJitter, "jitter", "Random delay of up to the given duration added to -latency, e.g. 5ms")
+	serveFlags.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage of %s serve:\n", os.Args[0])
+		fmt.Printf("  %s serve -addr :8080 -latency 20ms -jitter 5ms -size 4KB -status 200:99,503:1\n", os.Args[0])
+		fmt.Printf("Command line options:\n")
+		serveFlags.PrintDefaults()
+	}
+}
+
+// weightedStatus is a status code of the test server with its share of the responses.
+type weightedStatus struct {
+	code   int
+	weight int
+}
+
+// parseStatuses parses a status code like 200 or codes with weights like 200:90,503:10.
+// Weights must be larger than 0.
+func parseStatuses(value string) ([]weightedStatus, error) {
+	var statuses []weightedStatus
+	for _, part := range strings.Split(value, ",") {
+		code, weight, found := strings.Cut(strings.TrimSpace(part), ":")
+		status := weightedStatus{weight: 1}
+		var err error
+		if status.code, err = strconv.Atoi(code); err != nil || status.code < 100 || status.code > 999 {
+			return nil, fmt.Errorf("invalid status code %s", code)
+		}
+		if found {
+			if status.weight, err = strconv.Atoi(weight); err != nil || status.weight <= 0 {
+				return nil, fmt.Errorf("invalid weight %s of status code %s", weight, code)
+			}
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses, nil
+}
+
+// pickStatus returns a status code chosen by its weight.
+func pickStatus(statuses []weightedStatus) int {
+	total := 0
+	for _, s := range statuses {
+		total += s.weight
+	}
+	n := rand.Intn(total) //nolint:gosec // no security relevance
+	for _, s := range statuses {
+		if n < s.weight {
+			return s.code
+		}
+		n -= s.weight
+	}
+	return statuses[len(statuses)-1].code
+}
+
+// serveHandler returns the handler of the test server, which answers every request after the latency and
+// a random jitter with a status code picked by its weight and the body. Responses with status 1xx, 204 and
+// 304 have no body.
+func serveHandler(statuses []weightedStatus, body []byte, latency, jitter time.Duration) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		delay := latency
+		if jitter > 0 {
+			delay += time.Duration(rand.Int63n(int64(jitter))) //nolint:gosec // no security relevance
+		}
+		if delay > 0 {
+			select {
+			case <-time.After(delay):
+			case <-r.Context().Done():
+				return
+			}
+		}
+		code := pickStatus(statuses)
+		if code < http.StatusOK || code == http.StatusNoContent || code == http.StatusNotModified {
+			w.WriteHeader(code)
+			return
+		}
+		w.Header().Set("Content-Length", strconv.Itoa(len(body)))
+		w.WriteHeader(code)
+		_, _ = w.Write(body)
+	})
+}
+
+// runServe starts a test server, which answers every request with a configurable response.
+func runServe(args []string) int {
+	_ = serveFlags.Parse(args)
+
+	statuses, err := parseStatuses(*serveStatus)
+	if err != nil {
+		fmt.Printf("Invalid -status: %s\n", err)
+		return 1
+	}
+	body := []byte(*serveBody)
+	if *serveSize != "" {
+		size, err := config.ParseByteSize(*serveSize)
+		if err != nil {
+			fmt.Printf("Invalid -size: %s\n", err)
+			return 1
+		}
+		body = bytes.Repeat([]byte("x"), int(size))
+	}
+	latency, jitter := time.Duration(serveLatency), time.Duration(serveJitter)
+	if latency < 0 || jitter < 0 {
+		fmt.Printf("Invalid -latency or -jitter: durations must not be negative\n")
+		return 1
+	}
+
+	handler := serveHandler(statuses, body, latency, jitter)
+	if *serveH2C {
+		handler = h2c.NewHandler(handler, &http2.Server{})
+	}
+	server := &http.Server{
+		Addr:              *serveAddr,
+		Handler:           handler,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	fmt.Printf("Listening on %s\n", *serveAddr)
+	if err := server.ListenAndServe(); err != nil {
+		fmt.Printf("Server failed: %s\n", err)
+		return 1
+	}
+	return 0
+}
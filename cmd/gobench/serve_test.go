@@ -0,0 +1,81 @@
+// SPDX-FileCopyrightText: 2021 Eric Neidhardt
+// SPDX-License-Identifier: MIT
+package main
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/EricNeid/go-bench/internal/verify"
+)
+
+func TestParseStatuses(t *testing.T) {
+	// action
+	single, errSingle := parseStatuses("204")
+	weighted, errWeighted := parseStatuses("200:90, 503:10")
+	// verify
+	verify.Ok(t, errSingle)
+	verify.Equals(t, []weightedStatus{{code: 204, weight: 1}}, single)
+	verify.Ok(t, errWeighted)
+	verify.Equals(t, []weightedStatus{{code: 200, weight: 90}, {code: 503, weight: 10}}, weighted)
+}
+
+func TestParseStatuses_invalid(t *testing.T) {
+	for _, value := range []string{"", "ok", "99", "1000", "200:0", "200:-1", "200:x", "200:90,503:0"} {
+		_, err := parseStatuses(value)
+		verify.Assert(t, err != nil, "invalid status %q not rejected", value)
+	}
+}
+
+func TestPickStatus(t *testing.T) {
+	// arrange
+	statuses := []weightedStatus{{code: 200, weight: 90}, {code: 503, weight: 10}}
+	counts := make(map[int]int)
+	// action
+	for i := 0; i < 10000; i++ {
+		counts[pickStatus(statuses)]++
+	}
+	// verify
+	verify.Equals(t, 2, len(counts))
+	verify.Assert(t, counts[503] > 700 && counts[503] < 1300, "503 picked %d of 10000 times instead of about 1000", counts[503])
+}
+
+func TestServeHandler(t *testing.T) {
+	// arrange
+	unit := httptest.NewServer(serveHandler([]weightedStatus{{code: 201, weight: 1}}, []byte("ok"), 50*time.Millisecond, 0))
+	defer unit.Close()
+	// action
+	start := time.Now()
+	response, err := http.Get(unit.URL)
+	verify.Ok(t, err)
+	defer response.Body.Close()
+	body, err := io.ReadAll(response.Body)
+	elapsed := time.Since(start)
+	// verify
+	verify.Ok(t, err)
+	verify.Equals(t, http.StatusCreated, response.StatusCode)
+	verify.Equals(t, "ok", string(body))
+	verify.Equals(t, int64(2), response.ContentLength)
+	verify.Assert(t, elapsed >= 50*time.Millisecond, "response after %s instead of the latency of 50ms", elapsed)
+}
+
+func TestServeHandler_withoutBody(t *testing.T) {
+	for _, code := range []int{http.StatusNoContent, http.StatusNotModified} {
+		// arrange
+		unit := httptest.NewServer(serveHandler([]weightedStatus{{code: code, weight: 1}}, []byte("ok"), 0, 0))
+		// action
+		response, err := http.Get(unit.URL)
+		verify.Ok(t, err)
+		body, err := io.ReadAll(response.Body)
+		response.Body.Close()
+		unit.Close()
+		// verify
+		verify.Ok(t, err)
+		verify.Equals(t, code, response.StatusCode)
+		verify.Equals(t, "", string(body))
+		verify.Equals(t, "", response.Header.Get("Content-Length"))
+	}
+}
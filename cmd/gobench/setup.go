@@ -0,0 +1,115 @@
+// SPDX-FileCopyrightText: 2021 Eric Neidhardt
+// SPDX-License-Identifier: MIT
+package main
+
+import (
+	"context"
+	"net/http"
+	neturl "net/url"
+	"sync"
+	"time"
+
+	"github.com/EricNeid/go-bench/client"
+)
+
+// session holds the variables extracted by the setup steps.
+type session struct {
+	vars map[string]string
+}
+
+// with returns the variables of the session together with the ones of vars, which may be nil.
+func (s *session) with(vars func() map[string]string) func() map[string]string {
+	return func() map[string]string {
+		result := make(map[string]string, len(s.vars))
+		for k, v := range s.vars {
+			result[k] = v
+		}
+		if vars != nil {
+			for k, v := range vars() {
+				result[k] = v
+			}
+		}
+		return result
+	}
+}
+
+// clientSetup is the setup of a client, which is performed before running.
+type clientSetup struct {
+	client  *client.Client
+	session *session
+	vars    func() map[string]string
+}
+
+// performSetups performs the setup steps for all clients concurrently and returns the first error.
+func performSetups(setups []clientSetup, steps []client.Step, template *client.Template) error {
+	errs := make(chan error, len(setups))
+	var done sync.WaitGroup
+	done.Add(len(setups))
+	for _, s := range setups {
+		go func(s clientSetup) {
+			defer done.Done()
+			var vars map[string]string
+			if s.vars != nil {
+				vars = s.vars()
+			}
+			result, err := s.client.PerformSteps(context.Background(), template, steps, vars)
+			if err != nil {
+				errs <- err
+				return
+			}
+			s.session.vars = result
+		}(s)
+	}
+	done.Wait()
+	close(errs)
+	return <-errs
+}
+
+// performGlobalSetup performs the setup steps once and returns the session and the cookies set by the responses.
+func performGlobalSetup(
+	steps []client.Step,
+	template *client.Template,
+	timeout time.Duration,
+	transport http.RoundTripper,
+	seedCookies []*http.Cookie,
+	urls []string,
+) (*session, []*http.Cookie, error) {
+	c := client.NewClient(client.Request{}, client.WithTimeout(timeout))
+	c.HTTPClient.Transport = transport
+	jar, err := client.NewCookieJar(seedCookies, urls)
+	if err != nil {
+		return nil, nil, err
+	}
+	c.HTTPClient.Jar = jar
+	vars, err := c.PerformSteps(context.Background(), template, steps, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var cookies []*http.Cookie
+	known := make(map[string]bool)
+	for _, rawURL := range urls {
+		u, err := neturl.Parse(rawURL)
+		if err != nil {
+			return nil, nil, err
+		}
+		for _, cookie := range jar.Cookies(u) {
+			if !known[cookie.Name] {
+				known[cookie.Name] = true
+				cookies = append(cookies, cookie)
+			}
+		}
+	}
+	return &session{vars: vars}, cookies, nil
+}
+
+// extractedNames returns the names of the variables extracted by the steps.
+func extractedNames(steps []client.Step) []string {
+	var names []string
+	for _, step := range steps {
+		for _, e := range step.Extract {
+			names = append(names, e.Name)
+		}
+	}
+	return names
+}
@@ -0,0 +1,203 @@
+// SPDX-FileCopyrightText: 2021 Eric Neidhardt
+// SPDX-License-Identifier: MIT
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/EricNeid/go-bench/client"
+	"github.com/EricNeid/go-bench/config"
+	"github.com/EricNeid/go-bench/report"
+)
+
+// workersStartDelay is the time until the workers start, so that all of them received the scenario.
+const workersStartDelay = 2 * time.Second
+
+// workerURL returns the URL of the control API of a worker agent given by host[:port] or URL.
+func workerURL(addr string) string {
+	if strings.Contains(addr, "://") {
+		return strings.TrimSuffix(addr, "/")
+	}
+	if _, _, err := net.SplitHostPort(addr); err != nil {
+		addr = net.JoinHostPort(addr, defaultAgentPort)
+	}
+	return "http://" + addr
+}
+
+// runWorkers runs the scenario on the given worker agents, which start simultaneously, reports their
+// merged results and returns the exit code.
+func runWorkers(scenario *config.Scenario, addrs []string) int {
+	var urls []string
+	for _, addr := range addrs {
+		for _, a := range strings.Split(addr, ",") {
+			if a = strings.TrimSpace(a); a != "" {
+				urls = append(urls, workerURL(a))
+			}
+		}
+	}
+	token := workersToken
+	if token == "" {
+		token = os.Getenv("GOBENCH_AGENT_TOKEN")
+	}
+	// the paths are resolved on the coordinator, the workers would read other files or none
+	if files := scenario.Files(); len(files) > 0 {
+		fmt.Printf("Files are not supported by workers, found %s\n", *files[0])
+		return 1
+	}
+	if scenario.Output.FailuresDir != "" {
+		fmt.Println("Saving failures is not supported by workers")
+		return 1
+	}
+	workloads, err := scenario.Workloads()
+	if err != nil {
+		fmt.Printf("Could not create requests: %s\n", err)
+		return 1
+	}
+	encoded, err := scenario.Marshal()
+	if err != nil {
+		fmt.Printf("Could not encode scenario: %s\n", err)
+		return 1
+	}
+	body, err := json.Marshal(agentRun{Scenario: encoded, Start: time.Now().Add(workersStartDelay)})
+	if err != nil {
+		fmt.Printf("Could not encode scenario: %s\n", err)
+		return 1
+	}
+
+	reporter, err := report.New(scenario.Output.Format, resultOutput(scenario.Output))
+	if err != nil {
+		fmt.Printf("Could not print results: %s\n", err)
+		return 1
+	}
+	targets := make([]string, len(workloads))
+	for i, workload := range workloads {
+		targets[i] = workload.Name
+	}
+	if err := reporter.Start(targets); err != nil {
+		fmt.Printf("Could not print results: %s\n", err)
+		return 1
+	}
+	if !scenario.Output.Quiet {
+		fmt.Printf("Dispatching scenario to %d workers\n", len(urls))
+	}
+
+	// an interrupt or a failed worker stops all workers, the results until then are reported
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	results := make([]agentResult, len(urls))
+	errs := make([]error, len(urls))
+	var done sync.WaitGroup
+	for i, u := range urls {
+		done.Add(1)
+		go func() {
+			defer done.Done()
+			results[i], errs[i] = runWorker(u, token, body)
+			if errs[i] != nil {
+				stop()
+			}
+		}()
+	}
+	finished := make(chan struct{})
+	go func() {
+		done.Wait()
+		close(finished)
+	}()
+	select {
+	case <-finished:
+	case <-ctx.Done():
+		for _, u := range urls {
+			if err := stopWorker(u, token); err != nil {
+				fmt.Printf("Could not stop worker %s: %s\n", u, err)
+			}
+		}
+		<-finished
+	}
+
+	failed := false
+	statistics := make([]client.Statistic, len(workloads))
+	var elapsed time.Duration
+	for i, result := range results {
+		if errs[i] == nil && len(result.Statistics) != len(workloads) {
+			errs[i] = fmt.Errorf("%d workloads instead of %d", len(result.Statistics), len(workloads))
+		}
+		if errs[i] != nil {
+			fmt.Printf("Worker %s failed: %s\n", urls[i], errs[i])
+			failed = true
+			continue
+		}
+		for j, s := range result.Statistics {
+			statistics[j].Merge(s)
+		}
+		elapsed = max(elapsed, result.Elapsed)
+	}
+	if failed {
+		return 1
+	}
+
+	merged := workloadResults(workloads, statistics, elapsed)
+	if err := reporter.Final(merged); err != nil {
+		fmt.Printf("Could not print results: %s\n", err)
+		return 1
+	}
+	if scenario.Output.File != "" {
+		if err := report.WriteFile(scenario.Output.File, merged); err != nil {
+			fmt.Printf("Could not write results to %s: %s\n", scenario.Output.File, err)
+			return 1
+		}
+	}
+	if pusher := prometheusReporter(scenario.Output.Prometheus); pusher != nil {
+		if err := pusher.Final(merged); err != nil {
+			fmt.Printf("Could not push results: %s\n", err)
+			return 1
+		}
+	}
+	return 0
+}
+
+// runWorker sends the run to a worker and returns its result, when it is done.
+func runWorker(workerURL, token string, body []byte) (agentResult, error) {
+	var result agentResult
+	err := callWorker(workerURL+"/run", token, body, &result)
+	return result, err
+}
+
+// stopWorker stops the current run of a worker.
+func stopWorker(workerURL, token string) error {
+	return callWorker(workerURL+"/stop", token, nil, nil)
+}
+
+// callWorker posts the body to the control API of a worker and decodes its response into result, if set.
+func callWorker(rawURL, token string, body []byte, result any) error {
+	request, err := http.NewRequest(http.MethodPost, rawURL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	request.Header.Set("Content-Type", "application/json")
+	if token != "" {
+		request.Header.Set("Authorization", "Bearer "+token)
+	}
+	response, err := http.DefaultClient.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+	if response.StatusCode/100 != 2 {
+		message, _ := io.ReadAll(io.LimitReader(response.Body, 4096))
+		return fmt.Errorf("%s: %s", response.Status, strings.TrimSpace(string(message)))
+	}
+	if result == nil {
+		return nil
+	}
+	return json.NewDecoder(response.Body).Decode(result)
+}
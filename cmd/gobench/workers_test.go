@@ -0,0 +1,69 @@
+// SPDX-FileCopyrightText: 2021 Eric Neidhardt
+// SPDX-License-Identifier: MIT
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/EricNeid/go-bench/config"
+	"github.com/EricNeid/go-bench/internal/verify"
+	"github.com/EricNeid/go-bench/report"
+)
+
+func TestRunWorkers(t *testing.T) {
+	// arrange
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer target.Close()
+	worker1 := httptest.NewServer(&agent{})
+	defer worker1.Close()
+	worker2 := httptest.NewServer(&agent{})
+	defer worker2.Close()
+	resultFile := filepath.Join(t.TempDir(), "result.json")
+	scenario := &config.Scenario{
+		Targets:     []config.Target{{URL: target.URL}},
+		Concurrency: 1,
+		Requests:    5,
+		Output:      config.Output{File: resultFile, Quiet: true},
+	}
+	// action
+	code := runWorkers(scenario, []string{worker1.URL + "," + worker2.URL})
+	// verify
+	verify.Equals(t, 0, code)
+	data, err := os.ReadFile(resultFile)
+	verify.Ok(t, err)
+	results, err := report.DecodeFile(data)
+	verify.Ok(t, err)
+	verify.Equals(t, 1, len(results))
+	verify.Equals(t, 10, results[0].Success)
+}
+
+func TestRunWorkers_files(t *testing.T) {
+	// arrange
+	scenario := &config.Scenario{
+		Targets:     []config.Target{{URL: "http://localhost", BodyFile: "/etc/passwd"}},
+		Concurrency: 1,
+		Requests:    1,
+	}
+	// action
+	code := runWorkers(scenario, []string{"localhost:1"})
+	// verify
+	verify.Equals(t, 1, code)
+}
+
+func TestRunWorkers_failuresDir(t *testing.T) {
+	// arrange
+	scenario := &config.Scenario{
+		Targets:     []config.Target{{URL: "http://localhost"}},
+		Concurrency: 1,
+		Requests:    1,
+		Output:      config.Output{FailuresDir: t.TempDir()},
+	}
+	// action
+	code := runWorkers(scenario, []string{"localhost:1"})
+	// verify
+	verify.Equals(t, 1, code)
+}
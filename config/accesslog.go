@@ -0,0 +1,108 @@
+// SPDX-FileCopyrightText: 2021 Eric Neidhardt
+// SPDX-License-Identifier: MIT
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/EricNeid/go-bench/client"
+)
+
+// LogEntry is a request parsed from an access log.
+type LogEntry struct {
+	Time   time.Time
+	Target Target
+}
+
+// accessLogLine matches the common and combined log format of nginx and Apache:
+//
+//	host ident user [time] "request" status size ["referer" "user agent"]
+var accessLogLine = regexp.MustCompile(`^\S+ \S+ \S+ \[([^\]]+)\] "([^"]*)" \S+ \S+(?: "([^"]*)" "([^"]*)")?`)
+
+const accessLogTimeLayout = "02/Jan/2006:15:04:05 -0700"
+
+// LoadAccessLog reads the requests of an access log in common or combined log format.
+// Empty lines are ignored.
+func LoadAccessLog(filePath string) ([]LogEntry, error) {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, err
+	}
+	var entries []LogEntry
+	for i, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		entry, err := ParseAccessLogLine(line)
+		if err != nil {
+			return nil, fmt.Errorf("%s:%d: %w", filePath, i+1, err)
+		}
+		entries = append(entries, entry)
+	}
+	if len(entries) == 0 {
+		return nil, fmt.Errorf("%s: no requests found", filePath)
+	}
+	return entries, nil
+}
+
+// ParseAccessLogLine parses a single line in common or combined log format.
+// Referer and user agent of the combined format are used as request headers.
+func ParseAccessLogLine(line string) (LogEntry, error) {
+	var entry LogEntry
+	groups := accessLogLine.FindStringSubmatch(line)
+	if groups == nil {
+		return entry, errors.New("not in common or combined log format")
+	}
+
+	t, err := time.Parse(accessLogTimeLayout, groups[1])
+	if err != nil {
+		return entry, err
+	}
+	entry.Time = t
+
+	request := strings.Fields(groups[2])
+	if len(request) < 2 {
+		return entry, fmt.Errorf("invalid request %q", groups[2])
+	}
+	entry.Target.Method = request[0]
+	entry.Target.URL = request[1]
+
+	headers := make(map[string]string)
+	if groups[3] != "" && groups[3] != "-" {
+		headers["Referer"] = groups[3]
+	}
+	if groups[4] != "" && groups[4] != "-" {
+		headers["User-Agent"] = groups[4]
+	}
+	if len(headers) > 0 {
+		entry.Target.Headers = headers
+	}
+	return entry, nil
+}
+
+// replay returns a channel, which receives the requests at their offset relative to the first request,
+// divided by speed. The offsets start once the first request is received.
+func replay(requests []client.Request, offsets []time.Duration, speed float64) <-chan client.Request {
+	stream := make(chan client.Request)
+	go func() {
+		defer close(stream)
+		var startTime time.Time
+		for i, request := range requests {
+			if i > 0 {
+				due := startTime.Add(time.Duration(float64(offsets[i]) / speed))
+				time.Sleep(time.Until(due))
+			}
+			stream <- request
+			if i == 0 {
+				startTime = time.Now()
+			}
+		}
+	}()
+	return stream
+}
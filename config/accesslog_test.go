@@ -0,0 +1,59 @@
+// SPDX-FileCopyrightText: 2021 Eric Neidhardt
+// SPDX-License-Identifier: MIT
+package config
+
+import (
+	"testing"
+	"time"
+
+	"github.com/EricNeid/go-bench/internal/verify"
+)
+
+func TestParseAccessLogLine(t *testing.T) {
+	// common log format
+	result, err := ParseAccessLogLine(`127.0.0.1 - frank [10/Oct/2000:13:55:36 -0700] "GET /apache_pb.gif?x=1 HTTP/1.0" 200 2326`)
+	verify.Ok(t, err)
+	verify.Equals(t, "GET", result.Target.Method)
+	verify.Equals(t, "/apache_pb.gif?x=1", result.Target.URL)
+	verify.Equals(t, time.Date(2000, 10, 10, 20, 55, 36, 0, time.UTC), result.Time.UTC())
+	verify.Assert(t, result.Target.Headers == nil, "Unexpected headers")
+
+	// combined log format
+	result, err = ParseAccessLogLine(`10.0.0.1 - - [10/Oct/2000:13:55:37 +0000] "POST /api HTTP/1.1" 201 0 "-" "curl/7.68.0"`)
+	verify.Ok(t, err)
+	verify.Equals(t, "POST", result.Target.Method)
+	verify.Equals(t, map[string]string{"User-Agent": "curl/7.68.0"}, result.Target.Headers)
+
+	// invalid
+	_, err = ParseAccessLogLine(`not a log line`)
+	verify.Assert(t, err != nil, "Invalid line not detected")
+	_, err = ParseAccessLogLine(`10.0.0.1 - - [10/Oct/2000:13:55:37 +0000] "-" 400 0`)
+	verify.Assert(t, err != nil, "Invalid request not detected")
+}
+
+func TestWorkloads_withAccessLog(t *testing.T) {
+	// arrange
+	path := writeFile(t, t.TempDir(), "access.log", `
+127.0.0.1 - - [10/Oct/2000:13:55:36 +0000] "GET /a HTTP/1.1" 200 10
+127.0.0.1 - - [10/Oct/2000:13:55:37 +0000] "GET /b HTTP/1.1" 200 10
+`)
+	unit := Scenario{
+		Targets:       []Target{{URL: "http://localhost:8080"}},
+		AccessLogFile: path,
+		ReplaySpeed:   10,
+	}
+	// action
+	result, err := unit.Workloads()
+	// verify
+	verify.Ok(t, err)
+	verify.Equals(t, 1, len(result))
+	verify.Equals(t, 2, len(result[0].Requests))
+	first := <-result[0].Stream
+	startTime := time.Now()
+	second := <-result[0].Stream
+	_, open := <-result[0].Stream
+	verify.Equals(t, "http://localhost:8080/a", first.URL)
+	verify.Equals(t, "http://localhost:8080/b", second.URL)
+	verify.Assert(t, time.Since(startTime) >= 90*time.Millisecond, "Timing not replayed: %s", time.Since(startTime))
+	verify.Assert(t, !open, "Stream not closed")
+}
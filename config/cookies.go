@@ -0,0 +1,96 @@
+// SPDX-FileCopyrightText: 2021 Eric Neidhardt
+// SPDX-License-Identifier: MIT
+package config
+
+import (
+	"bufio"
+	"fmt"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// httpOnlyPrefix marks http only cookies in cookie files written by curl.
+const httpOnlyPrefix = "#HttpOnly_"
+
+// LoadCookieFile reads the cookies of a file in Netscape format, as written by curl -c or browser extensions:
+//
+//	domain	include subdomains	path	secure	expires	name	value
+//
+// Empty lines and comments are ignored. Cookies are always set for the domain including subdomains.
+func LoadCookieFile(filePath string) ([]*http.Cookie, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var cookies []*http.Cookie
+	scanner := bufio.NewScanner(file)
+	for lineNumber := 1; scanner.Scan(); lineNumber++ {
+		line := strings.TrimSpace(scanner.Text())
+		httpOnly := strings.HasPrefix(line, httpOnlyPrefix)
+		line = strings.TrimPrefix(line, httpOnlyPrefix)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.Split(line, "\t")
+		if len(fields) != 7 {
+			return nil, fmt.Errorf("%s:%d: expected 7 tab separated fields, got %d", filePath, lineNumber, len(fields))
+		}
+		cookie := &http.Cookie{
+			Domain:   strings.TrimPrefix(fields[0], "."),
+			Path:     fields[2],
+			Secure:   strings.EqualFold(fields[3], "TRUE"),
+			Name:     fields[5],
+			Value:    fields[6],
+			HttpOnly: httpOnly,
+		}
+		expires, err := strconv.ParseInt(fields[4], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%s:%d: invalid expiration %s", filePath, lineNumber, fields[4])
+		}
+		if expires > 0 {
+			cookie.Expires = time.Unix(expires, 0)
+		}
+		cookies = append(cookies, cookie)
+	}
+	return cookies, scanner.Err()
+}
+
+// ParseCookies parses cookies given like a Cookie header: name1=value1; name2=value2.
+// Malformed pairs are ignored.
+func ParseCookies(cookies string) map[string]string {
+	result := make(map[string]string)
+	for _, cookie := range strings.Split(cookies, ";") {
+		name, value, ok := strings.Cut(cookie, "=")
+		if name = strings.TrimSpace(name); ok && name != "" {
+			result[name] = strings.TrimSpace(value)
+		}
+	}
+	return result
+}
+
+// SeedCookies returns the cookies of the cookie file followed by the cookies of the scenario
+// in order of their names, which are initially set for each client.
+func (s *Scenario) SeedCookies() ([]*http.Cookie, error) {
+	var cookies []*http.Cookie
+	if s.CookieFile != "" {
+		var err error
+		if cookies, err = LoadCookieFile(s.CookieFile); err != nil {
+			return nil, err
+		}
+	}
+	var names []string
+	for name := range s.Cookies {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		cookies = append(cookies, &http.Cookie{Name: name, Value: s.Cookies[name]})
+	}
+	return cookies, nil
+}
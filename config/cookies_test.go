@@ -0,0 +1,57 @@
+// SPDX-FileCopyrightText: 2021 Eric Neidhardt
+// SPDX-License-Identifier: MIT
+package config
+
+import (
+	"testing"
+	"time"
+
+	"github.com/EricNeid/go-bench/internal/verify"
+)
+
+func TestLoadCookieFile(t *testing.T) {
+	// arrange
+	path := writeFile(t, t.TempDir(), "cookies.txt", "# Netscape HTTP Cookie File\n\n"+
+		".example.com\tTRUE\t/\tFALSE\t0\ttracking\tabc\n"+
+		"#HttpOnly_localhost\tFALSE\t/api\tTRUE\t1700000000\tsession\tdef\n")
+	// action
+	cookies, err := LoadCookieFile(path)
+	// verify
+	verify.Ok(t, err)
+	verify.Equals(t, 2, len(cookies))
+	verify.Equals(t, "example.com", cookies[0].Domain)
+	verify.Equals(t, "tracking", cookies[0].Name)
+	verify.Equals(t, "abc", cookies[0].Value)
+	verify.Assert(t, cookies[0].Expires.IsZero(), "Expected session cookie")
+	verify.Equals(t, "localhost", cookies[1].Domain)
+	verify.Equals(t, "/api", cookies[1].Path)
+	verify.Assert(t, cookies[1].Secure && cookies[1].HttpOnly, "Expected secure http only cookie")
+	verify.Equals(t, time.Unix(1700000000, 0), cookies[1].Expires)
+}
+
+func TestLoadCookieFile_invalid(t *testing.T) {
+	// arrange
+	path := writeFile(t, t.TempDir(), "cookies.txt", "example.com TRUE / FALSE 0 name value\n")
+	// action
+	_, err := LoadCookieFile(path)
+	// verify
+	verify.Assert(t, err != nil, "Invalid line not detected")
+}
+
+func TestParseCookies(t *testing.T) {
+	verify.Equals(t, map[string]string{"a": "1", "b": "x=y"}, ParseCookies("a=1; b=x=y; ;invalid"))
+}
+
+func TestSeedCookies(t *testing.T) {
+	// arrange
+	path := writeFile(t, t.TempDir(), "cookies.txt", "example.com\tTRUE\t/\tFALSE\t0\tfile\t1\n")
+	unit := Scenario{CookieFile: path, Cookies: map[string]string{"b": "2", "a": "3"}}
+	// action
+	cookies, err := unit.SeedCookies()
+	// verify
+	verify.Ok(t, err)
+	verify.Equals(t, 3, len(cookies))
+	verify.Equals(t, "file", cookies[0].Name)
+	verify.Equals(t, "a", cookies[1].Name)
+	verify.Equals(t, "b", cookies[2].Name)
+}
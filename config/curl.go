@@ -0,0 +1,156 @@
+// SPDX-FileCopyrightText: 2021 Eric Neidhardt
+// SPDX-License-Identifier: MIT
+package config
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+)
+
+// ignoredCurlOptions do not influence the request and take no value.
+var ignoredCurlOptions = map[string]bool{
+	"-s": true, "--silent": true,
+	"-S": true, "--show-error": true,
+	"-v": true, "--verbose": true,
+	"-i": true, "--include": true,
+	"-L": true, "--location": true,
+	"-f": true, "--fail": true,
+	"-k": true, "--insecure": true,
+	"--http1.1": true, "--http2": true,
+}
+
+// ParseCurl parses a curl command line into a target.
+// Supported are the options for method, URL, headers, data, form, user, user agent, cookie and referer.
+// Data files given with @file are read immediately.
+func ParseCurl(command string) (Target, error) {
+	t := Target{Headers: make(map[string]string)}
+	command = strings.ReplaceAll(command, "\\\r\n", " ")
+	command = strings.ReplaceAll(command, "\\\n", " ")
+	args, err := splitArgs(command)
+	if err != nil {
+		return t, err
+	}
+	if len(args) == 0 || args[0] != "curl" {
+		return t, errors.New("command must start with curl")
+	}
+
+	var data []string
+	get := false
+	for i := 1; i < len(args); i++ {
+		option := args[i]
+		if !strings.HasPrefix(option, "-") {
+			t.URL = option
+			continue
+		}
+		if ignoredCurlOptions[option] {
+			continue
+		}
+		switch option {
+		case "-G", "--get":
+			get = true
+			continue
+		case "-I", "--head":
+			t.Method = "HEAD"
+			continue
+		case "--compressed":
+			t.Headers["Accept-Encoding"] = "deflate, gzip"
+			continue
+		}
+
+		if i+1 >= len(args) {
+			return t, fmt.Errorf("missing value of curl option %s", option)
+		}
+		i++
+		value := args[i]
+		switch option {
+		case "-X", "--request":
+			t.Method = value
+		case "--url":
+			t.URL = value
+		case "-H", "--header":
+			key, v, ok := cutHeader(value)
+			if !ok {
+				return t, fmt.Errorf("invalid header %s", value)
+			}
+			if strings.EqualFold(key, "Content-Type") {
+				t.ContentType = v
+			} else {
+				t.Headers[key] = v
+			}
+		case "-d", "--data", "--data-ascii", "--data-binary", "--data-raw":
+			if strings.HasPrefix(value, "@") && option != "--data-raw" {
+				content, err := os.ReadFile(value[1:])
+				if err != nil {
+					return t, err
+				}
+				value = string(content)
+				if option != "--data-binary" {
+					value = strings.NewReplacer("\r", "", "\n", "").Replace(value)
+				}
+			}
+			data = append(data, value)
+		case "--data-urlencode":
+			data = append(data, urlEncodeCurlData(value))
+		case "-F", "--form":
+			part, err := ParsePart(value)
+			if err != nil {
+				return t, err
+			}
+			t.Multipart = append(t.Multipart, part)
+		case "-u", "--user":
+			t.Headers["Authorization"] = "Basic " + base64.StdEncoding.EncodeToString([]byte(value))
+		case "-A", "--user-agent":
+			t.Headers["User-Agent"] = value
+		case "-b", "--cookie":
+			t.Headers["Cookie"] = value
+		case "-e", "--referer":
+			t.Headers["Referer"] = value
+		default:
+			return t, fmt.Errorf("unsupported curl option %s", option)
+		}
+	}
+
+	if t.URL == "" {
+		return t, errors.New("curl command contains no url")
+	}
+	if len(data) > 0 {
+		if get {
+			separator := "?"
+			if strings.Contains(t.URL, "?") {
+				separator = "&"
+			}
+			t.URL += separator + strings.Join(data, "&")
+		} else {
+			t.Body = strings.Join(data, "&")
+			if t.Method == "" {
+				t.Method = "POST"
+			}
+			if t.ContentType == "" {
+				t.ContentType = "application/x-www-form-urlencoded"
+			}
+		}
+	}
+	if len(t.Multipart) > 0 && t.Method == "" {
+		t.Method = "POST"
+	}
+	if len(t.Headers) == 0 {
+		t.Headers = nil
+	}
+	return t, nil
+}
+
+// urlEncodeCurlData encodes data like curl --data-urlencode: content or name=content.
+func urlEncodeCurlData(value string) string {
+	if i := strings.Index(value, "="); i >= 0 {
+		name := value[:i]
+		if name == "" {
+			return url.QueryEscape(value[i+1:])
+		}
+		return name + "=" + url.QueryEscape(value[i+1:])
+	}
+	return url.QueryEscape(value)
+}
@@ -0,0 +1,65 @@
+// SPDX-FileCopyrightText: 2021 Eric Neidhardt
+// SPDX-License-Identifier: MIT
+package config
+
+import (
+	"testing"
+
+	"github.com/EricNeid/go-bench/internal/verify"
+)
+
+func TestParseCurl(t *testing.T) {
+	// action
+	result, err := ParseCurl(`curl -s -X PUT 'https://example.com/api/users/1' \
+  -H 'Content-Type: application/json' \
+  -H "Authorization: Bearer token" \
+  --data-raw '{"name":  "max"}'`)
+	// verify
+	verify.Ok(t, err)
+	verify.Equals(t, "PUT", result.Method)
+	verify.Equals(t, "https://example.com/api/users/1", result.URL)
+	verify.Equals(t, "application/json", result.ContentType)
+	verify.Equals(t, map[string]string{"Authorization": "Bearer token"}, result.Headers)
+	verify.Equals(t, `{"name":  "max"}`, result.Body)
+}
+
+func TestParseCurl_formData(t *testing.T) {
+	// action
+	result, err := ParseCurl(`curl https://example.com/login -d user=max --data-urlencode 'pass=a b' -u max:secret`)
+	// verify
+	verify.Ok(t, err)
+	verify.Equals(t, "POST", result.Method)
+	verify.Equals(t, "application/x-www-form-urlencoded", result.ContentType)
+	verify.Equals(t, "user=max&pass=a+b", result.Body)
+	verify.Equals(t, "Basic bWF4OnNlY3JldA==", result.Headers["Authorization"])
+}
+
+func TestParseCurl_multipart(t *testing.T) {
+	// action
+	result, err := ParseCurl(`curl https://example.com/upload -F name=max -F 'avatar=@avatar.png;type=image/png'`)
+	// verify
+	verify.Ok(t, err)
+	verify.Equals(t, "POST", result.Method)
+	verify.Equals(t, []Part{{Name: "name", Value: "max"}, {Name: "avatar", File: "avatar.png", ContentType: "image/png"}}, result.Multipart)
+}
+
+func TestParseCurl_get(t *testing.T) {
+	// action
+	result, err := ParseCurl(`curl -G https://example.com/search?lang=en -d q=test`)
+	// verify
+	verify.Ok(t, err)
+	verify.Equals(t, "", result.Method)
+	verify.Equals(t, "https://example.com/search?lang=en&q=test", result.URL)
+	verify.Equals(t, "", result.Body)
+}
+
+func TestParseCurl_invalid(t *testing.T) {
+	_, err := ParseCurl(`wget https://example.com`)
+	verify.Assert(t, err != nil, "Missing curl not detected")
+
+	_, err = ParseCurl(`curl -X POST`)
+	verify.Assert(t, err != nil, "Missing url not detected")
+
+	_, err = ParseCurl(`curl --proxy-magic https://example.com`)
+	verify.Assert(t, err != nil, "Unsupported option not detected")
+}
@@ -0,0 +1,38 @@
+// SPDX-FileCopyrightText: 2021 Eric Neidhardt
+// SPDX-License-Identifier: MIT
+package config
+
+import (
+	"testing"
+
+	"github.com/EricNeid/go-bench/internal/verify"
+)
+
+func TestLoadDataFile(t *testing.T) {
+	// arrange
+	path := writeFile(t, t.TempDir(), "users.csv", "username, token\nmax,abc\n\"tom, jr\",def\n")
+	// action
+	rows, err := LoadDataFile(path)
+	// verify
+	verify.Ok(t, err)
+	verify.Equals(t, []map[string]string{
+		{"username": "max", "token": "abc"},
+		{"username": "tom, jr", "token": "def"},
+	}, rows)
+}
+
+func TestLoadDataFile_invalid(t *testing.T) {
+	// arrange
+	dir := t.TempDir()
+	empty := writeFile(t, dir, "empty.csv", "")
+	headerOnly := writeFile(t, dir, "header.csv", "username,token\n")
+	missingColumn := writeFile(t, dir, "missing.csv", "username,token\nmax\n")
+	// action
+	_, errEmpty := LoadDataFile(empty)
+	_, errHeaderOnly := LoadDataFile(headerOnly)
+	_, errMissingColumn := LoadDataFile(missingColumn)
+	// verify
+	verify.Assert(t, errEmpty != nil, "Missing header not detected")
+	verify.Assert(t, errHeaderOnly != nil, "Missing rows not detected")
+	verify.Assert(t, errMissingColumn != nil, "Missing column not detected")
+}
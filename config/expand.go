@@ -0,0 +1,76 @@
+// SPDX-FileCopyrightText: 2021 Eric Neidhardt
+// SPDX-License-Identifier: MIT
+package config
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// expression matches ${NAME}, ${NAME:-default} and ${NAME:?message}, $${ escapes the expression.
+var expression = regexp.MustCompile(`\$\$\{|\$\{([A-Za-z_][A-Za-z0-9_]*)(?:(:-|:\?)([^}]*))?\}`)
+
+// Expand replaces environment variable references in value.
+//
+//	${NAME}          value of NAME, error if not set
+//	${NAME:-default} value of NAME, default if not set or empty
+//	${NAME:?message} value of NAME, error with message if not set or empty
+//	$${NAME}         literal ${NAME}
+func Expand(value string, lookup func(string) (string, bool)) (string, error) {
+	var err error
+	result := expression.ReplaceAllStringFunc(value, func(match string) string {
+		if match == "$${" {
+			return "${"
+		}
+		groups := expression.FindStringSubmatch(match)
+		name, operator, argument := groups[1], groups[2], groups[3]
+
+		v, ok := lookup(name)
+		switch operator {
+		case ":-":
+			if !ok || v == "" {
+				return argument
+			}
+		case ":?":
+			if (!ok || v == "") && err == nil {
+				err = fmt.Errorf("%s: %s", name, argument)
+			}
+		default:
+			if !ok && err == nil {
+				err = fmt.Errorf("environment variable %s is not set", name)
+			}
+		}
+		return v
+	})
+	return result, err
+}
+
+// Escape escapes the environment variable references in value, so that Expand returns value as is, e.g. for
+// scenarios encoded by Marshal, which are loaded by Load again.
+func Escape(value string) string {
+	return strings.ReplaceAll(value, "${", "$${")
+}
+
+// expandNode expands environment variable references in all scalar values of the given node.
+func expandNode(node *yaml.Node, lookup func(string) (string, bool)) error {
+	if node.Kind == yaml.ScalarNode && strings.Contains(node.Value, "${") {
+		value, err := Expand(node.Value, lookup)
+		if err != nil {
+			return fmt.Errorf("line %d: %w", node.Line, err)
+		}
+		node.Value = value
+		if node.Style == 0 {
+			// resolve the type of plain values again, e.g. to decode numbers
+			node.Tag = ""
+		}
+	}
+	for _, child := range node.Content {
+		if err := expandNode(child, lookup); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,90 @@
+// SPDX-FileCopyrightText: 2021 Eric Neidhardt
+// SPDX-License-Identifier: MIT
+package config
+
+import (
+	"testing"
+
+	"github.com/EricNeid/go-bench/internal/verify"
+)
+
+func lookup(values map[string]string) func(string) (string, bool) {
+	return func(name string) (string, bool) {
+		v, ok := values[name]
+		return v, ok
+	}
+}
+
+func TestExpand(t *testing.T) {
+	env := lookup(map[string]string{"HOST": "example.com", "EMPTY": ""})
+
+	result, err := Expand("https://${HOST}/api", env)
+	verify.Ok(t, err)
+	verify.Equals(t, "https://example.com/api", result)
+
+	result, err = Expand("${PORT:-8080} ${EMPTY:-default}", env)
+	verify.Ok(t, err)
+	verify.Equals(t, "8080 default", result)
+
+	result, err = Expand("$${HOST} $HOST", env)
+	verify.Ok(t, err)
+	verify.Equals(t, "${HOST} $HOST", result)
+
+	_, err = Expand("${TOKEN}", env)
+	verify.Assert(t, err != nil, "Missing variable not detected")
+
+	_, err = Expand("${EMPTY:?token required}", env)
+	verify.Equals(t, "EMPTY: token required", err.Error())
+}
+
+func TestEscape(t *testing.T) {
+	env := lookup(map[string]string{"HOST": "example.com"})
+	for _, value := range []string{"${HOST}", "$${HOST}", "$$${HOST} $HOST", "${PORT:-8080}"} {
+		result, err := Expand(Escape(value), env)
+		verify.Ok(t, err)
+		verify.Equals(t, value, result)
+	}
+}
+
+func TestLoad_expandsEnvironment(t *testing.T) {
+	// arrange
+	t.Setenv("GOBENCH_TEST_HOST", "example.com")
+	t.Setenv("GOBENCH_TEST_TOKEN", "secret: with colon")
+	path := writeFile(t, t.TempDir(), "bench.yaml", `
+targets:
+  - url: https://${GOBENCH_TEST_HOST}/api
+headers:
+  Authorization: Bearer ${GOBENCH_TEST_TOKEN}
+concurrency: ${GOBENCH_TEST_CONCURRENCY:-5}
+`)
+	// action
+	result, err := Load(path)
+	// verify
+	verify.Ok(t, err)
+	verify.Equals(t, "https://example.com/api", result.Targets[0].URL)
+	verify.Equals(t, "Bearer secret: with colon", result.Headers["Authorization"])
+	verify.Equals(t, 5, result.Concurrency)
+}
+
+func TestLoad_missingEnvironment(t *testing.T) {
+	// arrange
+	path := writeFile(t, t.TempDir(), "bench.yaml", "targets:\n  - url: https://${GOBENCH_TEST_UNDEFINED}/api\n")
+	// action
+	_, err := Load(path)
+	// verify
+	verify.Assert(t, err != nil, "Missing variable not detected")
+}
+
+func TestLoad_escapedMarshal(t *testing.T) {
+	// arrange
+	dir := t.TempDir()
+	scenario, err := Load(writeFile(t, dir, "bench.yaml", "targets:\n  - url: http://localhost/$${GOBENCH_TEST_UNDEFINED}\n"))
+	verify.Ok(t, err)
+	data, err := scenario.Marshal()
+	verify.Ok(t, err)
+	// action
+	result, err := Load(writeFile(t, dir, "marshaled.yaml", Escape(string(data))))
+	// verify
+	verify.Ok(t, err)
+	verify.Equals(t, "http://localhost/${GOBENCH_TEST_UNDEFINED}", result.Targets[0].URL)
+}
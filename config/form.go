@@ -0,0 +1,69 @@
+// SPDX-FileCopyrightText: 2021 Eric Neidhardt
+// SPDX-License-Identifier: MIT
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/EricNeid/go-bench/client"
+)
+
+// formContentType is the content type of url encoded form bodies.
+const formContentType = "application/x-www-form-urlencoded"
+
+// ParseForm parses form fields given as key=value.
+func ParseForm(fields []string) (map[string]string, error) {
+	form := make(map[string]string, len(fields))
+	for _, field := range fields {
+		key, value, ok := strings.Cut(field, "=")
+		if !ok || key == "" {
+			return nil, fmt.Errorf("invalid form field %s, expected key=value", field)
+		}
+		form[key] = value
+	}
+	return form, nil
+}
+
+// formRequest sets the url encoded form as body of the request and its content type, if none is given.
+func formRequest(request client.Request, form map[string]string) client.Request {
+	keys := make([]string, 0, len(form))
+	for key := range form {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	pairs := make([]string, len(keys))
+	for i, key := range keys {
+		pairs[i] = queryEscapeTemplate(key) + "=" + queryEscapeTemplate(form[key])
+	}
+	request.PostBody = []byte(strings.Join(pairs, "&"))
+	if request.ContentType == "" {
+		request.ContentType = formContentType
+	}
+	return request
+}
+
+// queryEscapeTemplate escapes the text of value for a query. Placeholders are kept,
+// but their rendered values are escaped as well, see client.Template.
+func queryEscapeTemplate(value string) string {
+	var b strings.Builder
+	for {
+		start := strings.Index(value, "{{")
+		if start < 0 {
+			break
+		}
+		end := strings.Index(value[start:], "}}")
+		if end < 0 {
+			break
+		}
+		end += start
+		action := strings.TrimSpace(value[start+2 : end])
+		b.WriteString(url.QueryEscape(value[:start]))
+		b.WriteString("{{" + action + " | urlquery}}")
+		value = value[end+2:]
+	}
+	b.WriteString(url.QueryEscape(value))
+	return b.String()
+}
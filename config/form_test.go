@@ -0,0 +1,54 @@
+// SPDX-FileCopyrightText: 2021 Eric Neidhardt
+// SPDX-License-Identifier: MIT
+package config
+
+import (
+	"testing"
+
+	"github.com/EricNeid/go-bench/client"
+	"github.com/EricNeid/go-bench/internal/verify"
+)
+
+func TestParseForm(t *testing.T) {
+	// action
+	result, err := ParseForm([]string{"user=max", "pass=a=b", "empty="})
+	_, invalid := ParseForm([]string{"user"})
+	// verify
+	verify.Ok(t, err)
+	verify.Equals(t, map[string]string{"user": "max", "pass": "a=b", "empty": ""}, result)
+	verify.Assert(t, invalid != nil, "Missing value not detected")
+}
+
+func TestWorkloads_withForm(t *testing.T) {
+	// arrange
+	unit := Scenario{
+		Targets: []Target{
+			{URL: "http://localhost/login"},
+			{URL: "http://localhost/search", ContentType: "application/x-www-form-urlencoded; charset=utf-8", Form: map[string]string{"q": "a b"}},
+		},
+		Form: map[string]string{"user": "max", "pass": "s&cret"},
+	}
+	// action
+	result, err := unit.Workloads()
+	// verify
+	verify.Ok(t, err)
+	verify.Equals(t, []byte("pass=s%26cret&user=max"), result[0].Requests[0].PostBody)
+	verify.Equals(t, "application/x-www-form-urlencoded", result[0].Requests[0].ContentType)
+	verify.Equals(t, []byte("q=a+b"), result[1].Requests[0].PostBody)
+	verify.Equals(t, "application/x-www-form-urlencoded; charset=utf-8", result[1].Requests[0].ContentType)
+}
+
+func TestWorkloads_withFormPlaceholders(t *testing.T) {
+	// arrange
+	unit := Scenario{
+		Targets: []Target{{URL: "http://localhost/login"}},
+		Form:    map[string]string{"user": "{{ .user }}@example"},
+	}
+	// action
+	result, err := unit.Workloads()
+	verify.Ok(t, err)
+	rendered, err := client.NewTemplate().Render(result[0].Requests[0], map[string]string{"user": "max&co"})
+	// verify
+	verify.Ok(t, err)
+	verify.Equals(t, []byte("user=max%26co%40example"), rendered.PostBody)
+}
@@ -0,0 +1,39 @@
+// SPDX-FileCopyrightText: 2021 Eric Neidhardt
+// SPDX-License-Identifier: MIT
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/EricNeid/go-bench/client"
+)
+
+// request sets the GraphQL request as body of the request, which is sent by POST as JSON if not configured otherwise.
+func (g GraphQL) request(request client.Request) (client.Request, error) {
+	query, err := os.ReadFile(g.Query)
+	if err != nil {
+		return request, err
+	}
+	request.PostBody = client.GraphQLBody(string(query), []byte(strings.TrimSpace(g.Variables)), g.OperationName)
+	request.Method = firstNonEmpty(request.Method, http.MethodPost)
+	request.ContentType = firstNonEmpty(request.ContentType, "application/json")
+	return request, nil
+}
+
+// validateVariables checks if the variables are a JSON object. Variables with placeholders are checked
+// when their requests are rendered.
+func (g GraphQL) validateVariables() error {
+	variables := strings.TrimSpace(g.Variables)
+	if variables == "" || strings.Contains(variables, "{{") {
+		return nil
+	}
+	var object map[string]json.RawMessage
+	if err := json.Unmarshal([]byte(variables), &object); err != nil || object == nil {
+		return errors.New("graphql variables must be a json object")
+	}
+	return nil
+}
@@ -0,0 +1,49 @@
+// SPDX-FileCopyrightText: 2021 Eric Neidhardt
+// SPDX-License-Identifier: MIT
+package config
+
+import (
+	"testing"
+
+	"github.com/EricNeid/go-bench/internal/verify"
+)
+
+func TestWorkloads_withGraphQL(t *testing.T) {
+	// arrange
+	query := writeFile(t, t.TempDir(), "user.graphql", "query User($id: ID!) {\n  user(id: $id) { name }\n}\n")
+	unit := Scenario{
+		Targets:     []Target{{URL: "http://localhost/graphql"}},
+		GraphQL:     GraphQL{Query: query, Variables: `{"id": "{{seq}}"}`, OperationName: "User"},
+		Concurrency: 1,
+		Requests:    1,
+	}
+	// action
+	result, err := unit.Workloads()
+	validations, validationsErr := unit.Validations()
+	// verify
+	verify.Ok(t, err)
+	verify.Ok(t, validationsErr)
+	verify.Ok(t, unit.Validate())
+	verify.Equals(t, "POST", result[0].Requests[0].Method)
+	verify.Equals(t, "application/json", result[0].Requests[0].ContentType)
+	verify.Equals(t, `{"query":"query User($id: ID!) {\n  user(id: $id) { name }\n}\n","variables":{"id": "{{seq}}"},"operationName":"User"}`,
+		string(result[0].Requests[0].PostBody))
+	verify.Equals(t, 1, len(validations))
+	verify.Equals(t, "graphql", validations[0].Kind)
+}
+
+func TestValidate_graphql(t *testing.T) {
+	// arrange
+	base := Scenario{Targets: []Target{{URL: "http://localhost/graphql"}}, Concurrency: 1, Requests: 1}
+	withBody := base
+	withBody.GraphQL = GraphQL{Query: "user.graphql"}
+	withBody.Body = "{}"
+	invalidVariables := base
+	invalidVariables.GraphQL = GraphQL{Query: "user.graphql", Variables: `["id"]`}
+	withoutQuery := base
+	withoutQuery.GraphQL = GraphQL{Variables: `{"id":1}`}
+	// verify
+	verify.Assert(t, withBody.Validate() != nil, "graphql query with body not rejected")
+	verify.Assert(t, invalidVariables.Validate() != nil, "invalid variables not rejected")
+	verify.Assert(t, withoutQuery.Validate() != nil, "variables without query not rejected")
+}
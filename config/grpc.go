@@ -0,0 +1,43 @@
+// SPDX-FileCopyrightText: 2021 Eric Neidhardt
+// SPDX-License-Identifier: MIT
+package config
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+
+	"github.com/EricNeid/go-bench/client"
+)
+
+// LoadMethod returns the configured method from the descriptor set or, if none is given, by server reflection
+// of the server at baseURL. The reflection request is sent with the round tripper, http.DefaultTransport if nil.
+func (g GRPC) LoadMethod(ctx context.Context, baseURL string, roundTripper http.RoundTripper) (*client.GRPCMethod, error) {
+	if g.Protoset == "" {
+		return client.ReflectGRPC(ctx, &http.Client{Transport: roundTripper}, baseURL, g.Method)
+	}
+	data, err := os.ReadFile(g.Protoset)
+	if err != nil {
+		return nil, err
+	}
+	registry, err := client.ParseDescriptorSet(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", g.Protoset, err)
+	}
+	return registry.Method(g.Method)
+}
+
+// StreamMessages returns the framed messages sent by each call of a streaming method, see client.GRPCStream.
+// Servers of server-streaming methods receive a single message.
+func (g GRPC) StreamMessages(method *client.GRPCMethod, message []byte) ([]byte, error) {
+	frame, err := method.Encode(message)
+	if err != nil {
+		return nil, err
+	}
+	if !method.ClientStreaming || g.Messages <= 1 {
+		return frame, nil
+	}
+	return bytes.Repeat(frame, g.Messages), nil
+}
@@ -0,0 +1,75 @@
+// SPDX-FileCopyrightText: 2021 Eric Neidhardt
+// SPDX-License-Identifier: MIT
+package config
+
+import (
+	"context"
+	"testing"
+
+	"github.com/EricNeid/go-bench/client"
+	"github.com/EricNeid/go-bench/internal/verify"
+)
+
+func TestWorkloads_withGRPC(t *testing.T) {
+	// arrange
+	unit := Scenario{
+		Targets:     []Target{{URL: "http://localhost:50051/", Body: `{"name":"{{.user}}"}`}},
+		GRPC:        GRPC{Method: "helloworld.Greeter.SayHello"},
+		Concurrency: 1,
+		Requests:    1,
+	}
+	// action
+	result, err := unit.Workloads()
+	validations, validationsErr := unit.Validations()
+	// verify
+	verify.Ok(t, err)
+	verify.Ok(t, validationsErr)
+	verify.Ok(t, unit.Validate())
+	verify.Equals(t, "http://localhost:50051/helloworld.Greeter/SayHello", result[0].Requests[0].URL)
+	verify.Equals(t, "POST", result[0].Requests[0].Method)
+	verify.Equals(t, true, result[0].Requests[0].KeepAlive)
+	verify.Equals(t, []byte(`{"name":"{{.user}}"}`), result[0].Requests[0].PostBody)
+	verify.Equals(t, 1, len(validations))
+	verify.Equals(t, "grpc-status", validations[0].Kind)
+}
+
+func TestValidate_grpcProtocol(t *testing.T) {
+	// arrange
+	unit := Scenario{
+		Targets:     []Target{{URL: "http://localhost:50051"}},
+		GRPC:        GRPC{Method: "helloworld.Greeter/SayHello"},
+		Transport:   Transport{Protocol: client.ProtocolHTTP1},
+		Concurrency: 1,
+		Requests:    1,
+	}
+	// action
+	err := unit.Validate()
+	// verify
+	verify.Assert(t, err != nil, "grpc over http1 not rejected")
+}
+
+func TestGRPC_LoadMethod(t *testing.T) {
+	// arrange
+	dir := t.TempDir()
+	invalid := writeFile(t, dir, "invalid.protoset", "\xff")
+	// action
+	_, missing := GRPC{Method: "a.B/C", Protoset: dir + "/missing.protoset"}.LoadMethod(context.Background(), "", nil)
+	_, invalidErr := GRPC{Method: "a.B/C", Protoset: invalid}.LoadMethod(context.Background(), "", nil)
+	// verify
+	verify.Assert(t, missing != nil, "missing descriptor set not detected")
+	verify.Assert(t, invalidErr != nil, "invalid descriptor set not detected")
+}
+
+func TestValidate_grpcMessages(t *testing.T) {
+	// arrange
+	unit := Scenario{
+		Targets:     []Target{{URL: "http://localhost:50051"}},
+		GRPC:        GRPC{Method: "helloworld.Greeter/SayHelloStream", Messages: -1},
+		Concurrency: 1,
+		Requests:    1,
+	}
+	// action
+	err := unit.Validate()
+	// verify
+	verify.Assert(t, err != nil, "negative number of messages not rejected")
+}
@@ -0,0 +1,116 @@
+// SPDX-FileCopyrightText: 2021 Eric Neidhardt
+// SPDX-License-Identifier: MIT
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+)
+
+// har is the subset of the HTTP Archive format needed to replay requests.
+type har struct {
+	Log struct {
+		Entries []struct {
+			Request struct {
+				Method  string `json:"method"`
+				URL     string `json:"url"`
+				Headers []struct {
+					Name  string `json:"name"`
+					Value string `json:"value"`
+				} `json:"headers"`
+				PostData *struct {
+					MimeType string `json:"mimeType"`
+					Text     string `json:"text"`
+					Params   []struct {
+						Name  string `json:"name"`
+						Value string `json:"value"`
+					} `json:"params"`
+				} `json:"postData"`
+			} `json:"request"`
+		} `json:"entries"`
+	} `json:"log"`
+}
+
+// ignoredHARHeaders are set by the client itself and not replayed.
+var ignoredHARHeaders = map[string]bool{
+	"Host":              true,
+	"Content-Length":    true,
+	"Connection":        true,
+	"Keep-Alive":        true,
+	"Transfer-Encoding": true,
+	"Upgrade":           true,
+}
+
+// LoadHARFile reads the requests recorded in a HAR file, as exported by browsers, as targets.
+// HTTP/2 pseudo headers and connection related headers are skipped.
+func LoadHARFile(filePath string) ([]Target, error) {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, err
+	}
+	var archive har
+	if err := json.Unmarshal(data, &archive); err != nil {
+		return nil, fmt.Errorf("%s: %w", filePath, err)
+	}
+
+	var targets []Target
+	for _, entry := range archive.Log.Entries {
+		r := entry.Request
+		t := Target{
+			URL:     r.URL,
+			Method:  r.Method,
+			Headers: make(map[string]string),
+		}
+		for _, h := range r.Headers {
+			name := http.CanonicalHeaderKey(h.Name)
+			if strings.HasPrefix(h.Name, ":") || ignoredHARHeaders[name] {
+				continue
+			}
+			if name == "Content-Type" {
+				t.ContentType = h.Value
+				continue
+			}
+			t.Headers[name] = h.Value
+		}
+		if r.PostData != nil {
+			if r.PostData.MimeType != "" {
+				t.ContentType = r.PostData.MimeType
+			}
+			t.Body = r.PostData.Text
+			if t.Body == "" && len(r.PostData.Params) > 0 {
+				form := url.Values{}
+				for _, p := range r.PostData.Params {
+					form.Add(p.Name, p.Value)
+				}
+				t.Body = form.Encode()
+			}
+		}
+		targets = append(targets, t)
+	}
+	if len(targets) == 0 {
+		return nil, fmt.Errorf("%s: no requests found", filePath)
+	}
+	return targets, nil
+}
+
+// rebaseURL replaces scheme and host of ref with the ones of base.
+func rebaseURL(base, ref string) (string, error) {
+	if base == "" {
+		return ref, nil
+	}
+	baseURL, err := url.Parse(base)
+	if err != nil {
+		return "", err
+	}
+	refURL, err := url.Parse(ref)
+	if err != nil {
+		return "", err
+	}
+	refURL.Scheme = baseURL.Scheme
+	refURL.Host = baseURL.Host
+	return refURL.String(), nil
+}
@@ -0,0 +1,82 @@
+// SPDX-FileCopyrightText: 2021 Eric Neidhardt
+// SPDX-License-Identifier: MIT
+package config
+
+import (
+	"testing"
+
+	"github.com/EricNeid/go-bench/internal/verify"
+)
+
+const testHAR = `{
+  "log": {
+    "version": "1.2",
+    "entries": [
+      {
+        "request": {
+          "method": "GET",
+          "url": "https://example.com/api/users?page=1",
+          "headers": [
+            {"name": ":authority", "value": "example.com"},
+            {"name": "accept", "value": "application/json"},
+            {"name": "cookie", "value": "session=abc"},
+            {"name": "content-length", "value": "0"}
+          ]
+        }
+      },
+      {
+        "request": {
+          "method": "POST",
+          "url": "https://example.com/api/users",
+          "headers": [{"name": "Content-Type", "value": "application/json"}],
+          "postData": {"mimeType": "application/json", "text": "{\"name\":\"max\"}"}
+        }
+      },
+      {
+        "request": {
+          "method": "POST",
+          "url": "https://example.com/login",
+          "headers": [],
+          "postData": {
+            "mimeType": "application/x-www-form-urlencoded",
+            "params": [{"name": "user", "value": "max"}, {"name": "pass", "value": "secret"}]
+          }
+        }
+      }
+    ]
+  }
+}`
+
+func TestLoadHARFile(t *testing.T) {
+	// arrange
+	path := writeFile(t, t.TempDir(), "session.har", testHAR)
+	// action
+	result, err := LoadHARFile(path)
+	// verify
+	verify.Ok(t, err)
+	verify.Equals(t, 3, len(result))
+	verify.Equals(t, "GET", result[0].Method)
+	verify.Equals(t, "https://example.com/api/users?page=1", result[0].URL)
+	verify.Equals(t, map[string]string{"Accept": "application/json", "Cookie": "session=abc"}, result[0].Headers)
+	verify.Equals(t, "POST", result[1].Method)
+	verify.Equals(t, "application/json", result[1].ContentType)
+	verify.Equals(t, "{\"name\":\"max\"}", result[1].Body)
+	verify.Equals(t, "pass=secret&user=max", result[2].Body)
+}
+
+func TestWorkloads_withHARFile(t *testing.T) {
+	// arrange
+	path := writeFile(t, t.TempDir(), "session.har", testHAR)
+	unit := Scenario{
+		Targets: []Target{{URL: "http://localhost:8080"}},
+		HARFile: path,
+	}
+	// action
+	result, err := unit.Workloads()
+	// verify
+	verify.Ok(t, err)
+	verify.Equals(t, 1, len(result))
+	verify.Equals(t, 3, len(result[0].Requests))
+	verify.Equals(t, "http://localhost:8080/api/users?page=1", result[0].Requests[0].URL)
+	verify.Equals(t, []byte("{\"name\":\"max\"}"), result[0].Requests[1].PostBody)
+}
@@ -0,0 +1,76 @@
+// SPDX-FileCopyrightText: 2021 Eric Neidhardt
+// SPDX-License-Identifier: MIT
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/EricNeid/go-bench/client"
+)
+
+// ParsePart parses a part of a multipart body given like curl -F: name=value for a form field,
+// name=@file for a file, optionally followed by ;type=content/type.
+func ParsePart(field string) (Part, error) {
+	name, value, ok := strings.Cut(field, "=")
+	if !ok || name == "" {
+		return Part{}, fmt.Errorf("invalid form part %s, expected name=value or name=@file", field)
+	}
+	if !strings.HasPrefix(value, "@") {
+		return Part{Name: name, Value: value}, nil
+	}
+	part := Part{Name: name, File: value[1:]}
+	if file, contentType, ok := strings.Cut(part.File, ";type="); ok {
+		part.File, part.ContentType = file, contentType
+	}
+	if part.File == "" {
+		return Part{}, fmt.Errorf("missing file of form part %s", name)
+	}
+	return part, nil
+}
+
+// validateParts checks the parts of the multipart body of the given kind of request.
+func validateParts(kind string, parts []Part) error {
+	for _, part := range parts {
+		if part.Name == "" {
+			return fmt.Errorf("name is required for multipart of %s", kind)
+		}
+		if part.Value != "" && part.File != "" {
+			return fmt.Errorf("only one should be provided for multipart %s of %s: [value|file]", part.Name, kind)
+		}
+	}
+	return nil
+}
+
+// multipartRequest sets the encoded parts as body of the request.
+// The files are read once, the body is shared by all requests.
+func multipartRequest(request client.Request, parts []Part) (client.Request, error) {
+	encoded := make([]client.Part, len(parts))
+	for i, part := range parts {
+		encoded[i] = client.Part{Name: part.Name, Value: part.Value, ContentType: part.ContentType}
+		if part.File != "" {
+			content, err := os.ReadFile(part.File)
+			if err != nil {
+				return request, err
+			}
+			encoded[i].FileName = filepath.Base(part.File)
+			encoded[i].Content = content
+		}
+	}
+	body, contentType, err := client.MultipartBody(encoded)
+	if err != nil {
+		return request, err
+	}
+	request.PostBody, request.ContentType = body, contentType
+	return request, nil
+}
+
+// appendPartFiles appends the files of the parts to paths, see Scenario.Files.
+func appendPartFiles(paths []*string, parts []Part) []*string {
+	for i := range parts {
+		paths = append(paths, &parts[i].File)
+	}
+	return paths
+}
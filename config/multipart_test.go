@@ -0,0 +1,55 @@
+// SPDX-FileCopyrightText: 2021 Eric Neidhardt
+// SPDX-License-Identifier: MIT
+package config
+
+import (
+	"bytes"
+	"io"
+	"mime"
+	"mime/multipart"
+	"testing"
+
+	"github.com/EricNeid/go-bench/internal/verify"
+)
+
+func TestParsePart(t *testing.T) {
+	// action
+	field, err1 := ParsePart("name=max")
+	file, err2 := ParsePart("avatar=@./avatar.png;type=image/png")
+	_, err3 := ParsePart("name")
+	_, err4 := ParsePart("avatar=@")
+	// verify
+	verify.Ok(t, err1)
+	verify.Ok(t, err2)
+	verify.Equals(t, Part{Name: "name", Value: "max"}, field)
+	verify.Equals(t, Part{Name: "avatar", File: "./avatar.png", ContentType: "image/png"}, file)
+	verify.Assert(t, err3 != nil, "Missing value not detected")
+	verify.Assert(t, err4 != nil, "Missing file not detected")
+}
+
+func TestWorkloads_withMultipart(t *testing.T) {
+	// arrange
+	path := writeFile(t, t.TempDir(), "avatar.png", "png")
+	unit := Scenario{
+		Targets:   []Target{{URL: "http://localhost/upload"}},
+		Multipart: []Part{{Name: "name", Value: "max"}, {Name: "avatar", File: path, ContentType: "image/png"}},
+	}
+	// action
+	result, err := unit.Workloads()
+	// verify
+	verify.Ok(t, err)
+	request := result[0].Requests[0]
+	mediaType, params, err := mime.ParseMediaType(request.ContentType)
+	verify.Ok(t, err)
+	verify.Equals(t, "multipart/form-data", mediaType)
+	reader := multipart.NewReader(bytes.NewReader(request.PostBody), params["boundary"])
+	part, err := reader.NextPart()
+	verify.Ok(t, err)
+	verify.Equals(t, "name", part.FormName())
+	part, err = reader.NextPart()
+	verify.Ok(t, err)
+	content, _ := io.ReadAll(part)
+	verify.Equals(t, "avatar.png", part.FileName())
+	verify.Equals(t, "image/png", part.Header.Get("Content-Type"))
+	verify.Equals(t, "png", string(content))
+}
@@ -0,0 +1,52 @@
+// SPDX-FileCopyrightText: 2021 Eric Neidhardt
+// SPDX-License-Identifier: MIT
+package config
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ByteSize is a number of bytes, which is given like 512, 64KB or 1.5MB in scenario files, see ParseByteSize.
+type ByteSize int64
+
+// UnmarshalYAML parses sizes given as plain numbers or with a unit.
+func (b *ByteSize) UnmarshalYAML(value *yaml.Node) error {
+	size, err := ParseByteSize(value.Value)
+	if err != nil {
+		return err
+	}
+	*b = size
+	return nil
+}
+
+// sizeUnits are the supported units of sizes, multiples of 1024.
+var sizeUnits = []struct {
+	suffix string
+	factor float64
+}{
+	{"kib", 1 << 10}, {"mib", 1 << 20}, {"gib", 1 << 30},
+	{"kb", 1 << 10}, {"mb", 1 << 20}, {"gb", 1 << 30},
+	{"k", 1 << 10}, {"m", 1 << 20}, {"g", 1 << 30},
+	{"b", 1},
+}
+
+// ParseByteSize parses a size given in bytes or with one of the units B, KB, MB or GB, which are multiples of 1024.
+func ParseByteSize(value string) (ByteSize, error) {
+	number := strings.ToLower(strings.TrimSpace(value))
+	factor := 1.0
+	for _, unit := range sizeUnits {
+		if strings.HasSuffix(number, unit.suffix) {
+			number, factor = strings.TrimSpace(strings.TrimSuffix(number, unit.suffix)), unit.factor
+			break
+		}
+	}
+	size, err := strconv.ParseFloat(number, 64)
+	if err != nil || size < 0 {
+		return 0, fmt.Errorf("invalid size %s, expected e.g. 512, 64KB or 1.5MB", value)
+	}
+	return ByteSize(size * factor), nil
+}
@@ -0,0 +1,38 @@
+// SPDX-FileCopyrightText: 2021 Eric Neidhardt
+// SPDX-License-Identifier: MIT
+package config
+
+import (
+	"testing"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/EricNeid/go-bench/internal/verify"
+)
+
+func TestParseByteSize(t *testing.T) {
+	for value, expected := range map[string]ByteSize{
+		"512":   512,
+		"512B":  512,
+		"64KB":  64 * 1024,
+		"64k":   64 * 1024,
+		"1.5MB": 1536 * 1024,
+		"1 GiB": 1 << 30,
+	} {
+		result, err := ParseByteSize(value)
+		verify.Ok(t, err)
+		verify.Equals(t, expected, result)
+	}
+	_, err := ParseByteSize("64XB")
+	verify.Assert(t, err != nil, "Invalid unit not detected")
+}
+
+func TestByteSize_UnmarshalYAML(t *testing.T) {
+	// arrange
+	var scenario Scenario
+	// action
+	err := yaml.Unmarshal([]byte("bodySize: 64KB"), &scenario)
+	// verify
+	verify.Ok(t, err)
+	verify.Equals(t, ByteSize(64*1024), scenario.BodySize)
+}
@@ -0,0 +1,113 @@
+// SPDX-FileCopyrightText: 2021 Eric Neidhardt
+// SPDX-License-Identifier: MIT
+package config
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/EricNeid/go-bench/client"
+)
+
+// RoundTripper creates the transport configured by t, which is shared by all clients, nil if http.DefaultTransport
+// can be used. It can be wrapped, e.g. for instrumentation, as long as the wrapper passes on the context of the
+// requests, which traces their phases, see client.WithTransport.
+func (t Transport) RoundTripper() http.RoundTripper {
+	// validated with the scenario
+	tlsConfig, _ := t.TLSConfig()
+	proxy, _ := t.ProxyURL()
+	if t.ConnectTimeout == 0 && (t.DNSMode == "" || t.DNSMode == DNSConnection) && t.DNSServer == "" && len(t.Resolve) == 0 && t.SRV == "" &&
+		len(t.SourceAddresses) == 0 && t.Interface == "" && t.IPVersion == 0 && proxy == nil && tlsConfig == nil && (t.Protocol == "" || t.Protocol == client.ProtocolAuto) &&
+		(t.Engine == "" || t.Engine == client.EngineNetHTTP) {
+		return nil
+	}
+	if t.Protocol == client.ProtocolHTTP3 {
+		return client.NewHTTP3Transport(tlsConfig, t.ZeroRTT)
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = tlsConfig
+	if proxy != nil && proxy.Scheme != "socks5" {
+		transport.Proxy = http.ProxyURL(proxy)
+	}
+	if t.DNSMode == DNSRequest {
+		transport.DisableKeepAlives = true
+	}
+	dial := t.DialContext()
+	if t.Engine == client.EngineFastHTTP {
+		if t.DNSMode == DNSRequest {
+			return &closingTransport{base: client.NewFastHTTPTransport(tlsConfig, dial)}
+		}
+		return client.NewFastHTTPTransport(tlsConfig, dial)
+	}
+	transport.DialContext = dial
+	roundTripper, _ := client.ConfigureProtocol(transport, t.Protocol)
+	return roundTripper
+}
+
+// DialContext creates the dialer configured by t, which establishes the connections of RoundTripper. It is used
+// by clients of other protocols, which dial their connections themselves, see client.WebSocket.
+func (t Transport) DialContext() func(ctx context.Context, network, addr string) (net.Conn, error) {
+	var resolver *net.Resolver
+	if t.DNSServer != "" {
+		resolver = client.NewResolver(t.DNSServer)
+	}
+	// same settings as the dialer of http.DefaultTransport
+	dialer := &net.Dialer{
+		Timeout:   30 * time.Second,
+		KeepAlive: 30 * time.Second,
+		Resolver:  resolver,
+	}
+	if t.ConnectTimeout > 0 {
+		dialer.Timeout = t.ConnectTimeout
+	}
+	dial := dialer.DialContext
+	if sources, _ := t.Sources(); sources != nil {
+		dial = sources.DialContext(dialer)
+	}
+	if t.IPVersion != 0 {
+		dial = client.IPVersion(t.IPVersion, dial)
+	}
+	// validated with the scenario
+	if proxy, _ := t.ProxyURL(); proxy != nil && proxy.Scheme == "socks5" {
+		password, _ := proxy.User.Password()
+		dial = client.SOCKS5(proxy.Host, proxy.User.Username(), password, dial)
+	}
+	if t.DNSMode == DNSCache {
+		dial = (&client.DNSCache{Resolver: resolver}).DialContext(dial)
+	}
+	if len(t.Resolve) > 0 {
+		overrides, _ := t.HostOverrides()
+		dial = overrides.DialContext(dial)
+	}
+	if srv := t.SRVTargets(); srv != nil {
+		dial = srv.DialContext(dial)
+	}
+	return dial
+}
+
+// SRVTargets returns the targets of the SRV record, nil if none is given. Each call returns new targets,
+// which are resolved on their first use.
+func (t Transport) SRVTargets() *client.SRVTargets {
+	if t.SRV == "" {
+		return nil
+	}
+	srv := &client.SRVTargets{Name: t.SRV, Refresh: t.SRVRefresh}
+	if t.DNSServer != "" {
+		srv.Resolver = client.NewResolver(t.DNSServer)
+	}
+	return srv
+}
+
+// closingTransport sends every request over a new connection, like http.Transport.DisableKeepAlives.
+type closingTransport struct {
+	base http.RoundTripper
+}
+
+func (t *closingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	// a round tripper must not modify the given request
+	req = req.Clone(req.Context())
+	req.Close = true
+	return t.base.RoundTrip(req)
+}
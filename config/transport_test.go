@@ -0,0 +1,70 @@
+// SPDX-FileCopyrightText: 2021 Eric Neidhardt
+// SPDX-License-Identifier: MIT
+package config
+
+import (
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/EricNeid/go-bench/client"
+	"github.com/EricNeid/go-bench/internal/verify"
+)
+
+func TestTransport_RoundTripper(t *testing.T) {
+	// action
+	defaults := Transport{}.RoundTripper()
+	configured := Transport{ConnectTimeout: time.Second}.RoundTripper()
+	fastHTTP := Transport{Engine: client.EngineFastHTTP}.RoundTripper()
+	// verify
+	verify.Assert(t, defaults == nil, "Default transport not used")
+	_, ok := configured.(*http.Transport)
+	verify.Assert(t, ok, "Unexpected transport %T", configured)
+	_, ok = fastHTTP.(*http.Transport)
+	verify.Assert(t, !ok && fastHTTP != nil, "Unexpected transport %T", fastHTTP)
+}
+
+func TestTransport_RoundTripper_dnsRequest(t *testing.T) {
+	for _, engine := range []string{client.EngineNetHTTP, client.EngineFastHTTP} {
+		t.Run(engine, func(t *testing.T) {
+			// arrange
+			mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+			defer mockServer.Close()
+			unit := client.NewClient(client.Request{URL: mockServer.URL, KeepAlive: true},
+				client.WithTransport(Transport{DNSMode: DNSRequest, Engine: engine}.RoundTripper()))
+			// action
+			unit.RunForAmount(3)
+			// verify
+			verify.Equals(t, 3, unit.Statistic.SuccessCount)
+			verify.Equals(t, 3, unit.Statistic.NewConnections)
+		})
+	}
+}
+
+func TestTransport_RoundTripper_serverName(t *testing.T) {
+	for _, engine := range []string{client.EngineNetHTTP, client.EngineFastHTTP} {
+		t.Run(engine, func(t *testing.T) {
+			// arrange
+			serverNames := make(chan string, 1)
+			mockServer := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				serverNames <- r.TLS.ServerName
+			}))
+			defer mockServer.Close()
+			// the certificate of the test server is valid for example.com, but not for other names
+			caCert := writeFile(t, t.TempDir(), "ca.pem", string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: mockServer.Certificate().Raw})))
+			unit := &http.Client{Transport: Transport{ServerName: "example.com", CACert: caCert, Engine: engine}.RoundTripper()}
+			mismatch := &http.Client{Transport: Transport{ServerName: "edge.test", CACert: caCert, Engine: engine}.RoundTripper()}
+			// action
+			resp, err := unit.Get(mockServer.URL)
+			_, errMismatch := mismatch.Get(mockServer.URL)
+			// verify
+			verify.Ok(t, err)
+			resp.Body.Close()
+			verify.Equals(t, http.StatusOK, resp.StatusCode)
+			verify.Equals(t, "example.com", <-serverNames)
+			verify.Assert(t, errMismatch != nil, "Certificate not verified against the server name")
+		})
+	}
+}
@@ -0,0 +1,213 @@
+// SPDX-FileCopyrightText: 2021 Eric Neidhardt
+// SPDX-License-Identifier: MIT
+package config
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// LoadURLFile reads targets from a file with one request per line.
+// Empty lines and lines starting with # are ignored. A line is either a plain URL
+// or a structured request, like a command line:
+//
+//	[METHOD] URL [-H key=value]... [-content-type type] [-b body | -b @file]
+//
+// Values containing spaces are quoted with ' or ". Relative body files are resolved
+// against the directory of the URL file.
+func LoadURLFile(filePath string) ([]Target, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var targets []Target
+	err = scanURLLines(file, filepath.Dir(filePath), func(lineNumber int, t Target, err error) error {
+		if err != nil {
+			return fmt.Errorf("%s:%d: %w", filePath, lineNumber, err)
+		}
+		targets = append(targets, t)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(targets) == 0 {
+		return nil, fmt.Errorf("%s: no requests found", filePath)
+	}
+	return targets, nil
+}
+
+// scanURLLines parses the lines read from r and calls fn for every request or invalid line.
+// Scanning stops if fn returns an error.
+func scanURLLines(r io.Reader, dir string, fn func(lineNumber int, t Target, err error) error) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for lineNumber := 1; scanner.Scan(); lineNumber++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		t, err := ParseURLLine(line)
+		t.BodyFile = resolvePath(dir, t.BodyFile)
+		if err := fn(lineNumber, t, err); err != nil {
+			return err
+		}
+	}
+	return scanner.Err()
+}
+
+// ParseURLLine parses a single line of an URL file, see LoadURLFile.
+func ParseURLLine(line string) (Target, error) {
+	var t Target
+	args, err := splitArgs(line)
+	if err != nil {
+		return t, err
+	}
+	if len(args) == 0 {
+		return t, errors.New("empty request")
+	}
+
+	if len(args) > 1 && isMethod(args[0]) && !strings.HasPrefix(args[1], "-") {
+		t.Method = args[0]
+		args = args[1:]
+	}
+	t.URL = args[0]
+
+	for i := 1; i < len(args); i++ {
+		option := args[i]
+		if i+1 >= len(args) {
+			return t, fmt.Errorf("missing value of option %s", option)
+		}
+		i++
+		value := args[i]
+
+		switch option {
+		case "-H":
+			key, v, ok := cutHeader(value)
+			if !ok {
+				return t, fmt.Errorf("invalid header %s", value)
+			}
+			if t.Headers == nil {
+				t.Headers = make(map[string]string)
+			}
+			t.Headers[key] = v
+		case "-content-type":
+			t.ContentType = value
+		case "-b":
+			if strings.HasPrefix(value, "@") {
+				t.BodyFile = value[1:]
+			} else {
+				t.Body = value
+			}
+		default:
+			return t, fmt.Errorf("unknown option %s", option)
+		}
+	}
+	return t, nil
+}
+
+// isMethod returns true if value looks like a HTTP method.
+func isMethod(value string) bool {
+	for _, r := range value {
+		if r < 'A' || r > 'Z' {
+			return false
+		}
+	}
+	return value != ""
+}
+
+// cutHeader splits a header given as key=value or key: value.
+func cutHeader(header string) (key, value string, ok bool) {
+	i := strings.IndexAny(header, "=:")
+	if i <= 0 {
+		return "", "", false
+	}
+	return strings.TrimSpace(header[:i]), strings.TrimSpace(header[i+1:]), true
+}
+
+// splitArgs splits a line into arguments separated by spaces, respecting quotes and backslash escapes.
+func splitArgs(line string) ([]string, error) {
+	var args []string
+	var current strings.Builder
+	inArg := false
+	var quote rune
+	escaped := false
+
+	for _, r := range line {
+		switch {
+		case escaped:
+			current.WriteRune(r)
+			escaped = false
+		case r == '\\' && quote != '\'':
+			escaped = true
+			inArg = true
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			} else {
+				current.WriteRune(r)
+			}
+		case r == '\'' || r == '"':
+			quote = r
+			inArg = true
+		case r == ' ' || r == '\t' || r == '\n' || r == '\r':
+			if inArg {
+				args = append(args, current.String())
+				current.Reset()
+				inArg = false
+			}
+		default:
+			current.WriteRune(r)
+			inArg = true
+		}
+	}
+	if quote != 0 {
+		return nil, errors.New("unterminated quote")
+	}
+	if escaped {
+		return nil, errors.New("unterminated escape")
+	}
+	if inArg {
+		args = append(args, current.String())
+	}
+	return args, nil
+}
+
+// resolveURL resolves a relative URL against the given base URL.
+// References with template placeholders are joined without escaping the placeholders.
+func resolveURL(base, ref string) (string, error) {
+	if base == "" {
+		return ref, nil
+	}
+	baseURL, err := url.Parse(base)
+	if err != nil {
+		return "", err
+	}
+	if strings.Contains(ref, "{{") {
+		switch {
+		case strings.Contains(ref, "://") || strings.HasPrefix(ref, "{{"):
+			return ref, nil
+		case strings.HasPrefix(ref, "/"):
+			return baseURL.Scheme + "://" + baseURL.Host + ref, nil
+		default:
+			dir := baseURL.Path[:strings.LastIndex(baseURL.Path, "/")+1]
+			if dir == "" {
+				dir = "/"
+			}
+			return baseURL.Scheme + "://" + baseURL.Host + dir + ref, nil
+		}
+	}
+	refURL, err := url.Parse(ref)
+	if err != nil {
+		return "", err
+	}
+	return baseURL.ResolveReference(refURL).String(), nil
+}
@@ -0,0 +1,50 @@
+// SPDX-FileCopyrightText: 2021 Eric Neidhardt
+// SPDX-License-Identifier: MIT
+package config
+
+import (
+	"testing"
+
+	"github.com/EricNeid/go-bench/internal/verify"
+)
+
+func TestParseURLLine(t *testing.T) {
+	// plain url
+	result, err := ParseURLLine("http://localhost/api")
+	verify.Ok(t, err)
+	verify.Equals(t, Target{URL: "http://localhost/api"}, result)
+
+	// structured request
+	result, err = ParseURLLine(`PUT /api/foo -H "X-Name: max mustermann" -H key=value -content-type text/plain -b 'hello world'`)
+	verify.Ok(t, err)
+	verify.Equals(t, "PUT", result.Method)
+	verify.Equals(t, "/api/foo", result.URL)
+	verify.Equals(t, "max mustermann", result.Headers["X-Name"])
+	verify.Equals(t, "value", result.Headers["key"])
+	verify.Equals(t, "text/plain", result.ContentType)
+	verify.Equals(t, "hello world", result.Body)
+
+	// body file
+	result, err = ParseURLLine(`POST /api/foo -b @body.json`)
+	verify.Ok(t, err)
+	verify.Equals(t, "body.json", result.BodyFile)
+
+	// escaped quotes
+	result, err = ParseURLLine(`POST /api/foo -b "{\"name\":\"max\"}"`)
+	verify.Ok(t, err)
+	verify.Equals(t, `{"name":"max"}`, result.Body)
+}
+
+func TestParseURLLine_invalid(t *testing.T) {
+	_, err := ParseURLLine(`POST /api/foo -b 'unterminated`)
+	verify.Assert(t, err != nil, "Unterminated quote not detected")
+
+	_, err = ParseURLLine(`POST /api/foo -b`)
+	verify.Assert(t, err != nil, "Missing value not detected")
+
+	_, err = ParseURLLine(`POST /api/foo -x value`)
+	verify.Assert(t, err != nil, "Unknown option not detected")
+
+	_, err = ParseURLLine(`POST /api/foo -H novalue`)
+	verify.Assert(t, err != nil, "Invalid header not detected")
+}
@@ -0,0 +1,52 @@
+// SPDX-FileCopyrightText: 2021 Eric Neidhardt
+// SPDX-License-Identifier: MIT
+package config
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+
+	"github.com/EricNeid/go-bench/client"
+)
+
+// Validations returns the checks of the scenario, which responses with an expected status have to pass.
+func (s *Scenario) Validations() ([]client.Validation, error) {
+	var validations []client.Validation
+	if s.AssertBody != "" {
+		re, err := regexp.Compile(s.AssertBody)
+		if err != nil {
+			return nil, fmt.Errorf("invalid body assertion: %w", err)
+		}
+		validations = append(validations, client.BodyMatches(re))
+	}
+	if s.ResponseSchema != "" {
+		data, err := os.ReadFile(s.ResponseSchema)
+		if err != nil {
+			return nil, err
+		}
+		schema, err := client.ParseSchema(data)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", s.ResponseSchema, err)
+		}
+		validations = append(validations, client.MatchesSchema(schema))
+	}
+	if s.GRPC.Method != "" {
+		validations = append(validations, client.GRPCStatus())
+	}
+	if s.GraphQL.Query != "" {
+		validations = append(validations, client.GraphQLErrors())
+	}
+	switch s.AssertChecksum {
+	case "":
+	case ChecksumFirst:
+		validations = append(validations, client.ConsistentBody())
+	default:
+		checksum, err := client.ChecksumMatches(s.AssertChecksum)
+		if err != nil {
+			return nil, err
+		}
+		validations = append(validations, checksum)
+	}
+	return validations, nil
+}
@@ -0,0 +1,72 @@
+// SPDX-FileCopyrightText: 2021 Eric Neidhardt
+// SPDX-License-Identifier: MIT
+package config
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/EricNeid/go-bench/internal/verify"
+)
+
+func TestValidations(t *testing.T) {
+	// arrange
+	unit := &Scenario{AssertBody: `"status":\s*"ok"`}
+	// action
+	validations, err := unit.Validations()
+	// verify
+	verify.Ok(t, err)
+	verify.Equals(t, 1, len(validations))
+	verify.Equals(t, "body", validations[0].Kind)
+	verify.Ok(t, validations[0].Check(nil, []byte(`{"status": "ok"}`)))
+	verify.Assert(t, validations[0].Check(nil, []byte(`{"error": "failed"}`)) != nil, "Mismatching body not detected")
+}
+
+func TestValidations_none(t *testing.T) {
+	// action
+	validations, err := (&Scenario{}).Validations()
+	// verify
+	verify.Ok(t, err)
+	verify.Equals(t, 0, len(validations))
+}
+
+func TestValidations_responseSchema(t *testing.T) {
+	// arrange
+	dir := t.TempDir()
+	writeFile(t, dir, "user.json", `{"type": "object", "required": ["id"]}`)
+	writeFile(t, dir, "bench.yaml", "targets:\n  - url: http://localhost\nresponseSchema: user.json\nassertBody: id\n")
+	unit, err := Load(filepath.Join(dir, "bench.yaml"))
+	verify.Ok(t, err)
+	// action
+	validations, err := unit.Validations()
+	// verify
+	verify.Ok(t, err)
+	verify.Equals(t, 2, len(validations))
+	verify.Equals(t, "schema", validations[1].Kind)
+	verify.Ok(t, validations[1].Check(nil, []byte(`{"id": 1}`)))
+	verify.Assert(t, validations[1].Check(nil, []byte(`{"name": "max"}`)) != nil, "Schema violation not detected")
+}
+
+func TestValidations_invalidSchema(t *testing.T) {
+	// arrange
+	dir := t.TempDir()
+	writeFile(t, dir, "user.json", `{"type": "object"`)
+	// action
+	_, err := (&Scenario{ResponseSchema: filepath.Join(dir, "user.json")}).Validations()
+	_, errMissing := (&Scenario{ResponseSchema: filepath.Join(dir, "missing.json")}).Validations()
+	// verify
+	verify.Assert(t, err != nil, "Invalid schema not detected")
+	verify.Assert(t, errMissing != nil, "Missing schema not detected")
+}
+
+func TestValidations_checksum(t *testing.T) {
+	// action
+	validations, err := (&Scenario{AssertChecksum: "2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824"}).Validations()
+	first, errFirst := (&Scenario{AssertChecksum: ChecksumFirst}).Validations()
+	// verify
+	verify.Ok(t, err)
+	verify.Ok(t, errFirst)
+	verify.Equals(t, "checksum", validations[0].Kind)
+	verify.Ok(t, validations[0].Check(nil, []byte("hello")))
+	verify.Equals(t, "checksum", first[0].Kind)
+}
@@ -2,4 +2,25 @@
 // SPDX-License-Identifier: MIT
 module github.com/EricNeid/go-bench
 
-go 1.18
+go 1.23.0
+
+require (
+	github.com/andybalholm/brotli v1.2.0
+	github.com/quic-go/quic-go v0.54.0
+	github.com/valyala/fasthttp v1.65.0
+	golang.org/x/net v0.43.0
+	gopkg.in/yaml.v3 v3.0.1
+)
+
+require (
+	github.com/klauspost/compress v1.18.0 // indirect
+	github.com/quic-go/qpack v0.5.1 // indirect
+	github.com/valyala/bytebufferpool v1.0.0 // indirect
+	go.uber.org/mock v0.5.0 // indirect
+	golang.org/x/crypto v0.41.0 // indirect
+	golang.org/x/mod v0.26.0 // indirect
+	golang.org/x/sync v0.16.0 // indirect
+	golang.org/x/sys v0.35.0 // indirect
+	golang.org/x/text v0.28.0 // indirect
+	golang.org/x/tools v0.35.0 // indirect
+)
@@ -0,0 +1,69 @@
+// SPDX-FileCopyrightText: 2021 Eric Neidhardt
+// SPDX-License-Identifier: MIT
+package report
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+)
+
+// EncodeCSV writes the results as CSV with a header line and one line per target.
+// The columns are named like the JSON fields.
+func EncodeCSV(w io.Writer, results []Result) error {
+	return (&csvReporter{w: w}).Final(results)
+}
+
+// csvReporter writes a line per target for the intermediate and the final results,
+// the header line is written before the first one.
+type csvReporter struct {
+	w      io.Writer
+	header bool
+}
+
+func (r *csvReporter) Start([]string) error {
+	return nil
+}
+
+func (r *csvReporter) Interval(results []Result) error {
+	return r.write(results)
+}
+
+func (r *csvReporter) Final(results []Result) error {
+	return r.write(results)
+}
+
+func (r *csvReporter) write(results []Result) error {
+	t := reflect.TypeOf(Result{})
+	var header []string
+	var fields []int
+	for i := 0; i < t.NumField(); i++ {
+		if t.Field(i).Tag.Get("csv") == "-" || !t.Field(i).IsExported() {
+			continue
+		}
+		header = append(header, strings.Split(t.Field(i).Tag.Get("json"), ",")[0])
+		fields = append(fields, i)
+	}
+
+	writer := csv.NewWriter(r.w)
+	if !r.header {
+		if err := writer.Write(header); err != nil {
+			return err
+		}
+		r.header = true
+	}
+	for _, result := range results {
+		v := reflect.ValueOf(result)
+		var line []string
+		for _, i := range fields {
+			line = append(line, fmt.Sprint(v.Field(i).Interface()))
+		}
+		if err := writer.Write(line); err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
@@ -0,0 +1,56 @@
+// SPDX-FileCopyrightText: 2021 Eric Neidhardt
+// SPDX-License-Identifier: MIT
+package report
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// EncodeJSON encodes the results as JSON. A single result is encoded as is,
+// results of several targets are encoded as object with the target labels as keys.
+func EncodeJSON(results []Result) ([]byte, error) {
+	return json.MarshalIndent(jsonValue(results), "", "  ")
+}
+
+// jsonValue returns the encoded value of the results, see EncodeJSON.
+func jsonValue[T any](results []T) any {
+	if len(results) == 1 {
+		return results[0]
+	}
+	labeled := make(map[string]T)
+	for i, r := range results {
+		labeled[strings.ToLower(Label(i))] = r
+	}
+	return labeled
+}
+
+// jsonReporter writes intermediate results as a JSON document per line and
+// the final results like EncodeJSON.
+type jsonReporter struct {
+	w io.Writer
+}
+
+func (r *jsonReporter) Start([]string) error {
+	return nil
+}
+
+func (r *jsonReporter) Interval(results []Result) error {
+	data, err := json.Marshal(jsonValue(results))
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintln(r.w, string(data))
+	return err
+}
+
+func (r *jsonReporter) Final(results []Result) error {
+	data, err := EncodeJSON(results)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintln(r.w, string(data))
+	return err
+}
@@ -0,0 +1,259 @@
+// SPDX-FileCopyrightText: 2021 Eric Neidhardt
+// SPDX-License-Identifier: MIT
+package report
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"maps"
+	"math"
+	"net/http"
+	"net/url"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Prometheus is a reporter pushing the intermediate and final results to Prometheus by remote-write and to a
+// Pushgateway, so that the results of benchmarks are kept alongside production metrics. Each result is pushed
+// as series like gobench_requests_total with the label target and the configured labels, e.g. run id, git sha
+// and environment. gobench_running is 1 for intermediate results and 0 for the final ones.
+type Prometheus struct {
+	// URL of the remote-write endpoint, e.g. http://prometheus:9090/api/v1/write, nothing is written if empty.
+	// Credentials of the URL are sent as basic authentication.
+	RemoteWrite string
+	// URL of the Pushgateway, e.g. http://pushgateway:9091, nothing is pushed if empty. Each push replaces
+	// the metrics of the group of the job and the labels.
+	Pushgateway string
+	// Value of the label job, gobench if empty.
+	Job string
+	// Labels of all series.
+	Labels map[string]string
+	// Client sending the results, http.DefaultClient if nil.
+	Client *http.Client
+}
+
+// promSeries is a sample of a series, its labels do not contain the name.
+type promSeries struct {
+	name   string
+	labels [][2]string
+	value  float64
+}
+
+func (p *Prometheus) Start([]string) error {
+	return nil
+}
+
+func (p *Prometheus) Interval(results []Result) error {
+	return p.push(promResults(results, true))
+}
+
+func (p *Prometheus) Final(results []Result) error {
+	return p.push(promResults(results, false))
+}
+
+// job returns the value of the label job.
+func (p *Prometheus) job() string {
+	if p.Job == "" {
+		return "gobench"
+	}
+	return p.Job
+}
+
+// push writes the series by remote-write and pushes them to the Pushgateway.
+func (p *Prometheus) push(series []promSeries) error {
+	if p.RemoteWrite != "" {
+		body := encodeSnappy(p.encodeWriteRequest(series, time.Now()))
+		header := http.Header{
+			"Content-Type":                      {"application/x-protobuf"},
+			"Content-Encoding":                  {"snappy"},
+			"X-Prometheus-Remote-Write-Version": {"0.1.0"},
+		}
+		if err := p.send(http.MethodPost, p.RemoteWrite, header, body); err != nil {
+			return fmt.Errorf("could not write results to prometheus: %w", err)
+		}
+	}
+	if p.Pushgateway != "" {
+		header := http.Header{"Content-Type": {"text/plain; version=0.0.4"}}
+		if err := p.send(http.MethodPut, p.groupURL(), header, encodeExposition(series)); err != nil {
+			return fmt.Errorf("could not push results to pushgateway: %w", err)
+		}
+	}
+	return nil
+}
+
+// send sends the body and returns an error, if the response is not successful.
+func (p *Prometheus) send(method, rawURL string, header http.Header, body []byte) error {
+	request, err := http.NewRequest(method, rawURL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	request.Header = header
+	httpClient := p.Client
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	response, err := httpClient.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+	if response.StatusCode/100 != 2 {
+		message, _ := io.ReadAll(io.LimitReader(response.Body, 4096))
+		return fmt.Errorf("%s: %s", response.Status, strings.TrimSpace(string(message)))
+	}
+	return nil
+}
+
+// groupURL returns the URL of the group of the job and the labels at the Pushgateway.
+func (p *Prometheus) groupURL() string {
+	path := "/metrics/" + promGroupLabel("job", p.job())
+	for _, name := range slices.Sorted(maps.Keys(p.Labels)) {
+		path += "/" + promGroupLabel(name, p.Labels[name])
+	}
+	return strings.TrimSuffix(p.Pushgateway, "/") + path
+}
+
+// promGroupLabel returns a label of the path of a group, values with slashes are base64 encoded.
+func promGroupLabel(name, value string) string {
+	if value == "" || strings.Contains(value, "/") {
+		return name + "@base64/" + base64.RawURLEncoding.EncodeToString([]byte(value))
+	}
+	return name + "/" + url.PathEscape(value)
+}
+
+// promResults returns the series of the results.
+func promResults(results []Result, running bool) []promSeries {
+	var series []promSeries
+	add := func(name string, r Result, value float64, labels ...[2]string) {
+		if r.Target != "" {
+			labels = append(labels, [2]string{"target", r.Target})
+		}
+		series = append(series, promSeries{name: "gobench_" + name, labels: labels, value: value})
+	}
+	for _, r := range results {
+		elapsed := r.elapsed.Seconds()
+		if r.elapsed == 0 {
+			elapsed = float64(r.TestTime)
+		}
+		add("running", r, promBool(running))
+		add("duration_seconds", r, elapsed)
+		add("requests_total", r, float64(r.Requests))
+		add("success_total", r, float64(r.Success))
+		add("failed_total", r, float64(r.Failed))
+		add("network_failed_total", r, float64(r.NetworkFailed))
+		add("validation_failed_total", r, float64(r.ValidationFailed))
+		add("throttled_total", r, float64(r.Throttled))
+		add("success_rate", r, float64(r.SuccessRate))
+		add("read_throughput_bytes", r, float64(r.ReadThroughput))
+		add("write_throughput_bytes", r, float64(r.WriteThroughput))
+		add("latency_mean_seconds", r, r.AverageLatencyMs/1000)
+		add("ttfb_seconds", r, r.TTFBP50Ms/1000, [2]string{"quantile", "0.5"})
+		add("ttfb_seconds", r, r.TTFBP90Ms/1000, [2]string{"quantile", "0.9"})
+		add("ttfb_seconds", r, r.TTFBP99Ms/1000, [2]string{"quantile", "0.99"})
+		for _, code := range slices.Sorted(maps.Keys(r.StatusCodes)) {
+			add("status_codes_total", r, float64(r.StatusCodes[code]), [2]string{"code", strconv.Itoa(code)})
+		}
+		for _, cause := range slices.Sorted(maps.Keys(r.NetworkFailures)) {
+			add("network_failures_total", r, float64(r.NetworkFailures[cause]), [2]string{"cause", cause})
+		}
+	}
+	// samples of a metric are grouped in the exposition format
+	sort.SliceStable(series, func(i, j int) bool {
+		return series[i].name < series[j].name
+	})
+	return series
+}
+
+// promBool returns 1 for true and 0 for false.
+func promBool(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// encodeExposition encodes the series in the text format of Prometheus. The job and the labels are added by
+// the Pushgateway from the group.
+func encodeExposition(series []promSeries) []byte {
+	var buf bytes.Buffer
+	for i, s := range series {
+		if i == 0 || series[i-1].name != s.name {
+			metricType := "gauge"
+			if strings.HasSuffix(s.name, "_total") {
+				metricType = "counter"
+			}
+			fmt.Fprintf(&buf, "# TYPE %s %s\n", s.name, metricType)
+		}
+		buf.WriteString(s.name)
+		for j, label := range s.labels {
+			if j == 0 {
+				buf.WriteByte('{')
+			} else {
+				buf.WriteByte(',')
+			}
+			value := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(label[1])
+			fmt.Fprintf(&buf, "%s=\"%s\"", label[0], value)
+		}
+		if len(s.labels) > 0 {
+			buf.WriteByte('}')
+		}
+		fmt.Fprintf(&buf, " %s\n", strconv.FormatFloat(s.value, 'g', -1, 64))
+	}
+	return buf.Bytes()
+}
+
+// encodeWriteRequest encodes the series as prometheus.WriteRequest of remote-write with the job, the labels
+// and a sample at the given time.
+func (p *Prometheus) encodeWriteRequest(series []promSeries, at time.Time) []byte {
+	var request []byte
+	for _, s := range series {
+		labels := append([][2]string{{"__name__", s.name}, {"job", p.job()}}, s.labels...)
+		for name, value := range p.Labels {
+			labels = append(labels, [2]string{name, value})
+		}
+		// remote-write requires the labels sorted by name
+		sort.Slice(labels, func(i, j int) bool {
+			return labels[i][0] < labels[j][0]
+		})
+		var timeSeries []byte
+		for _, label := range labels {
+			var encoded []byte
+			encoded = appendProtoBytes(encoded, 1, []byte(label[0]))
+			encoded = appendProtoBytes(encoded, 2, []byte(label[1]))
+			timeSeries = appendProtoBytes(timeSeries, 1, encoded)
+		}
+		// sample of a double value (fixed64) and a timestamp in milliseconds (varint)
+		sample := binary.LittleEndian.AppendUint64([]byte{1<<3 | 1}, math.Float64bits(s.value))
+		sample = binary.AppendUvarint(append(sample, 2<<3), uint64(at.UnixMilli()))
+		timeSeries = appendProtoBytes(timeSeries, 2, sample)
+		request = appendProtoBytes(request, 1, timeSeries)
+	}
+	return request
+}
+
+// appendProtoBytes appends a length-delimited protobuf field.
+func appendProtoBytes(buf []byte, number int, b []byte) []byte {
+	buf = binary.AppendUvarint(buf, uint64(number)<<3|2)
+	buf = binary.AppendUvarint(buf, uint64(len(b)))
+	return append(buf, b...)
+}
+
+// encodeSnappy encodes data in the block format of snappy, as required by remote-write. The data is not
+// compressed, but stored as literals, which every decoder accepts.
+func encodeSnappy(data []byte) []byte {
+	buf := binary.AppendUvarint(nil, uint64(len(data)))
+	for len(data) > 0 {
+		n := min(len(data), 1<<16)
+		// literal with a length of up to 2^16 given by 2 bytes
+		buf = append(buf, 61<<2, byte(n-1), byte((n-1)>>8))
+		buf = append(buf, data[:n]...)
+		data = data[n:]
+	}
+	return buf
+}
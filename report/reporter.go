@@ -0,0 +1,40 @@
+// SPDX-FileCopyrightText: 2021 Eric Neidhardt
+// SPDX-License-Identifier: MIT
+package report
+
+import (
+	"fmt"
+	"io"
+)
+
+// Formats of reporters, see New.
+const (
+	FormatText = "text"
+	FormatJSON = "json"
+	FormatCSV  = "csv"
+)
+
+// Reporter reports a benchmark when it starts, in intervals while it is running and when it finished.
+// The results are the ones of all targets, summed up since the start.
+type Reporter interface {
+	// Start is called before the first request with the URLs or names of the targets.
+	Start(targets []string) error
+	// Interval is called with the intermediate results while the benchmark is running.
+	Interval(results []Result) error
+	// Final is called with the results after the benchmark finished.
+	Final(results []Result) error
+}
+
+// New returns a reporter of the given format: text, json or csv. It writes to w.
+func New(format string, w io.Writer) (Reporter, error) {
+	switch format {
+	case "", FormatText:
+		return &textReporter{w: w}, nil
+	case FormatJSON:
+		return &jsonReporter{w: w}, nil
+	case FormatCSV:
+		return &csvReporter{w: w}, nil
+	default:
+		return nil, fmt.Errorf("unsupported format %s", format)
+	}
+}
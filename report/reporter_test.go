@@ -0,0 +1,201 @@
+// SPDX-FileCopyrightText: 2021 Eric Neidhardt
+// SPDX-License-Identifier: MIT
+package report
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/EricNeid/go-bench/client"
+	"github.com/EricNeid/go-bench/internal/verify"
+)
+
+func TestNew(t *testing.T) {
+	for _, format := range []string{"", FormatText, FormatJSON, FormatCSV} {
+		_, err := New(format, &bytes.Buffer{})
+		verify.Ok(t, err)
+	}
+	_, err := New("xml", &bytes.Buffer{})
+	verify.Assert(t, err != nil, "Invalid format not detected")
+}
+
+func TestReporter_json(t *testing.T) {
+	// arrange
+	var out bytes.Buffer
+	unit, err := New(FormatJSON, &out)
+	verify.Ok(t, err)
+	// action
+	verify.Ok(t, unit.Start([]string{"a"}))
+	verify.Ok(t, unit.Interval([]Result{{Requests: 1}}))
+	verify.Ok(t, unit.Final([]Result{{Requests: 2}}))
+	// verify
+	lines := strings.SplitN(out.String(), "\n", 2)
+	var interval, final Result
+	verify.Ok(t, json.Unmarshal([]byte(lines[0]), &interval))
+	verify.Ok(t, json.Unmarshal([]byte(lines[1]), &final))
+	verify.Equals(t, int64(1), interval.Requests)
+	verify.Equals(t, int64(2), final.Requests)
+}
+
+func TestReporter_csv(t *testing.T) {
+	// arrange
+	var out bytes.Buffer
+	unit, err := New(FormatCSV, &out)
+	verify.Ok(t, err)
+	// action
+	verify.Ok(t, unit.Start([]string{"a", "b"}))
+	verify.Ok(t, unit.Interval([]Result{{Target: "a"}, {Target: "b"}}))
+	verify.Ok(t, unit.Final([]Result{{Target: "a"}, {Target: "b"}}))
+	// verify
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	verify.Equals(t, 5, len(lines))
+	verify.Assert(t, strings.HasPrefix(lines[0], "target,requests,"), "Unexpected header %s", lines[0])
+	verify.Assert(t, strings.HasPrefix(lines[1], "a,"), "Unexpected line %s", lines[1])
+	verify.Assert(t, strings.HasPrefix(lines[4], "b,"), "Unexpected line %s", lines[4])
+}
+
+func TestReporter_text(t *testing.T) {
+	// arrange
+	var out bytes.Buffer
+	unit, err := New(FormatText, &out)
+	verify.Ok(t, err)
+	// action
+	verify.Ok(t, unit.Interval([]Result{{Requests: 3, TestTime: 1}, {Requests: 4, TestTime: 1}}))
+	// verify
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	verify.Equals(t, 2, len(lines))
+	verify.Assert(t, strings.HasPrefix(lines[0], "A: "), "Unexpected line %s", lines[0])
+	verify.Assert(t, strings.HasPrefix(lines[1], "B: "), "Unexpected line %s", lines[1])
+}
+
+func TestWriteFile(t *testing.T) {
+	// arrange
+	filePath := filepath.Join(t.TempDir(), "result.json")
+	results := []Result{{Target: "a", Requests: 1}, {Target: "b", Requests: 2}}
+	// action
+	verify.Ok(t, WriteFile(filePath, results))
+	read, err := ReadFile(filePath)
+	// verify
+	verify.Ok(t, err)
+	verify.Equals(t, results, read)
+	_, err = ReadSingle(filePath)
+	verify.Assert(t, err != nil, "Multiple results not detected")
+}
+
+func TestMerge(t *testing.T) {
+	// arrange
+	dir := t.TempDir()
+	var fast, slow client.Recorder
+	for i := 0; i < 90; i++ {
+		fast.Record(client.Result{Class: client.ClassSuccess, StatusCode: 200, Phases: map[string]time.Duration{client.PhaseTTFB: time.Millisecond}})
+	}
+	for i := 0; i < 10; i++ {
+		slow.Record(client.Result{Class: client.ClassStatus, StatusCode: 503, Phases: map[string]time.Duration{client.PhaseTTFB: time.Second}})
+	}
+	verify.Ok(t, WriteFile(filepath.Join(dir, "a.json"), []Result{NewResult(fast.Snapshot(), 10*time.Second)}))
+	verify.Ok(t, WriteFile(filepath.Join(dir, "b.json"), []Result{NewResult(slow.Snapshot(), 10*time.Second)}))
+	a, err := ReadSingle(filepath.Join(dir, "a.json"))
+	verify.Ok(t, err)
+	b, err := ReadSingle(filepath.Join(dir, "b.json"))
+	verify.Ok(t, err)
+	// action
+	result, err := Merge(a, b)
+	// verify
+	verify.Ok(t, err)
+	verify.Equals(t, int64(100), result.Requests)
+	verify.Equals(t, int64(90), result.Success)
+	verify.Equals(t, int64(9), result.SuccessRate)
+	verify.Equals(t, map[int]int64{200: 90, 503: 10}, result.StatusCodes)
+	verify.Assert(t, result.TTFBP50Ms < 2, "Unexpected p50 %f", result.TTFBP50Ms)
+	verify.Assert(t, result.TTFBP99Ms > 500, "Unexpected p99 %f", result.TTFBP99Ms)
+	verify.Equals(t, int64(10), result.TestTime)
+}
+
+func TestMerge_withoutStatistic(t *testing.T) {
+	// action
+	_, err := Merge(Result{Requests: 1}, Result{Requests: 2})
+	// verify
+	verify.Assert(t, err != nil, "Results without statistic merged")
+}
+
+func TestReporter_textSteps(t *testing.T) {
+	// arrange
+	var out bytes.Buffer
+	unit, err := New(FormatText, &out)
+	verify.Ok(t, err)
+	steps := map[string]StepResult{"10": {Requests: 1}, "2": {Requests: 2}, "1": {Requests: 3}}
+	// action
+	verify.Ok(t, unit.Final([]Result{{Steps: steps}}))
+	// verify
+	printed := out.String()
+	first, second, tenth := strings.Index(printed, "  1 "), strings.Index(printed, "  2 "), strings.Index(printed, "  10 ")
+	verify.Assert(t, first >= 0 && first < second && second < tenth, "Steps not sorted by number: %s", printed)
+}
+
+func TestPrometheus_pushgateway(t *testing.T) {
+	// arrange
+	var method, path, body string
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, _ := io.ReadAll(r.Body)
+		method, path, body = r.Method, r.URL.EscapedPath(), string(data)
+	}))
+	defer mockServer.Close()
+	unit := &Prometheus{Pushgateway: mockServer.URL, Labels: map[string]string{"sha": "a1b2", "branch": "feature/x"}}
+	// action
+	err := unit.Final([]Result{{Target: "api", Requests: 10, StatusCodes: map[int]int64{200: 10}}})
+	// verify
+	verify.Ok(t, err)
+	verify.Equals(t, http.MethodPut, method)
+	verify.Equals(t, "/metrics/job/gobench/branch@base64/ZmVhdHVyZS94/sha/a1b2", path)
+	verify.Assert(t, strings.Contains(body, "# TYPE gobench_requests_total counter\ngobench_requests_total{target=\"api\"} 10\n"), "Unexpected body %s", body)
+	verify.Assert(t, strings.Contains(body, `gobench_status_codes_total{code="200",target="api"} 10`), "Unexpected body %s", body)
+	verify.Assert(t, strings.Contains(body, `gobench_running{target="api"} 0`), "Unexpected body %s", body)
+}
+
+func TestPrometheus_remoteWrite(t *testing.T) {
+	// arrange
+	var header http.Header
+	var body []byte
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		header = r.Header
+		body, _ = io.ReadAll(r.Body)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer mockServer.Close()
+	unit := &Prometheus{RemoteWrite: mockServer.URL, Labels: map[string]string{"env": "staging"}}
+	// action
+	err := unit.Interval([]Result{{Target: "api", Requests: 10}})
+	// verify
+	verify.Ok(t, err)
+	verify.Equals(t, "snappy", header.Get("Content-Encoding"))
+	verify.Equals(t, "application/x-protobuf", header.Get("Content-Type"))
+	length, n := binary.Uvarint(body)
+	verify.Assert(t, n > 0, "Invalid snappy length")
+	// literal of the whole request, see encodeSnappy
+	request := body[n+3:]
+	verify.Equals(t, int(length), len(request))
+	verify.Assert(t, bytes.Contains(request, []byte("__name__\x12\x16gobench_requests_total")), "Missing series")
+	verify.Assert(t, bytes.Contains(request, []byte("env\x12\x07staging")), "Missing label")
+	verify.Assert(t, bytes.Contains(request, []byte("job\x12\x07gobench")), "Missing job")
+}
+
+func TestPrometheus_failed(t *testing.T) {
+	// arrange
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "out of order sample", http.StatusBadRequest)
+	}))
+	defer mockServer.Close()
+	unit := &Prometheus{RemoteWrite: mockServer.URL}
+	// action
+	err := unit.Final([]Result{{Requests: 1}})
+	// verify
+	verify.Assert(t, err != nil && strings.Contains(err.Error(), "out of order sample"), "Unexpected error %v", err)
+}
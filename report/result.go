@@ -0,0 +1,358 @@
+// SPDX-FileCopyrightText: 2021 Eric Neidhardt
+// SPDX-License-Identifier: MIT
+
+// Package report summarizes benchmarks and reports their results as text, JSON or CSV or pushes them to Prometheus.
+package report
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/EricNeid/go-bench/client"
+)
+
+// Result is the summary of a benchmark of a target, as it is printed and written to result files.
+type Result struct {
+	Target string `json:"target,omitempty"`
+
+	Requests         int64 `json:"requests"`
+	Success          int64 `json:"success"`
+	NetworkFailed    int64 `json:"networkFailed"`
+	Failed           int64 `json:"failed"`
+	LengthMismatch   int64 `json:"lengthMismatch"`
+	ValidationFailed int64 `json:"validationFailed"`
+	SchemaViolations int64 `json:"schemaViolations"`
+	ChecksumFailed   int64 `json:"checksumFailed"`
+	// Requests in flight at the end of the run, not counted as requests.
+	Interrupted int64 `json:"interrupted"`
+	// Responses throttling the clients with Retry-After and the overall time waited for them.
+	Throttled  int64   `json:"throttled"`
+	BackoffSec float64 `json:"backoffSec"`
+
+	// Number of network failures per cause, see client.FailureCauses. Not written to csv.
+	NetworkFailures map[string]int64 `json:"networkFailures,omitempty" csv:"-"`
+	// Number of responses per status code. Not written to csv.
+	StatusCodes map[int]int64 `json:"statusCodes,omitempty" csv:"-"`
+	// Number of DNS responses per response code, e.g. NXDOMAIN. Not written to csv.
+	ResponseCodes map[string]int64 `json:"responseCodes,omitempty" csv:"-"`
+
+	// Successful requests per second.
+	SuccessRate int64 `json:"successRate"`
+	// Messages received by streaming calls or events of event streams and messages per second,
+	// see client.GRPCStream and client.SSE.
+	Messages    int64 `json:"messages"`
+	MessageRate int64 `json:"messageRate"`
+	// Event streams ended before the end of the run.
+	Dropped int64 `json:"dropped"`
+	// Datagrams without reply, see client.UDP.
+	Lost int64 `json:"lost"`
+	// Bytes per second.
+	ReadThroughput int64 `json:"readThroughput"`
+	// Bytes per second as received, before decompression.
+	WireReadThroughput int64 `json:"wireReadThroughput"`
+	// Bytes per second.
+	WriteThroughput int64 `json:"writeThroughput"`
+
+	// Number of host lookups, see client.PhaseDNS for their durations.
+	DNSLookups int64 `json:"dnsLookups"`
+	// Requests sent over new and reused connections, reuse in percent of the requests.
+	NewConnections    int64   `json:"newConnections"`
+	ReusedConnections int64   `json:"reusedConnections"`
+	ConnectionReuse   float64 `json:"connectionReuse"`
+	// TLS handshakes, resumed sessions in percent of the handshakes.
+	TLSHandshakes int64   `json:"tlsHandshakes"`
+	TLSResumption float64 `json:"tlsResumption"`
+	// Number of new connections per negotiated protocol, e.g. HTTP/2.0. Not written to csv.
+	Protocols map[string]int64 `json:"protocols,omitempty" csv:"-"`
+	// Number of new connections per IP family, IPv4 or IPv6. Not written to csv.
+	IPFamilies map[string]int64 `json:"ipFamilies,omitempty" csv:"-"`
+	// Number of TLS handshakes per negotiated version and cipher suite. Not written to csv.
+	TLSVersions  map[string]int64 `json:"tlsVersions,omitempty" csv:"-"`
+	CipherSuites map[string]int64 `json:"cipherSuites,omitempty" csv:"-"`
+
+	// Body sizes of the responses in bytes.
+	ResponseSizeMin  int64 `json:"responseSizeMin"`
+	ResponseSizeMean int64 `json:"responseSizeMean"`
+	ResponseSizeMax  int64 `json:"responseSizeMax"`
+	// Number of responses per size bucket, see client.ResponseSizeBuckets. Not written to csv.
+	ResponseSizes []int `json:"responseSizes,omitempty" csv:"-"`
+
+	AverageLatencyMs float64 `json:"averageLatencyMs"`
+	// Percentiles of the time to first byte.
+	TTFBP50Ms float64 `json:"ttfbP50Ms"`
+	TTFBP90Ms float64 `json:"ttfbP90Ms"`
+	TTFBP99Ms float64 `json:"ttfbP99Ms"`
+	// Latencies per status class, e.g. 2xx. Not written to csv.
+	ClassLatencies map[string]LatencyResult `json:"classLatencies,omitempty" csv:"-"`
+	// Durations of the phases of requests, see client.Phases. Not written to csv.
+	Phases map[string]PercentileResult `json:"phases,omitempty" csv:"-"`
+	// Results per step of a chain, see client.Statistic.Steps. Not written to csv.
+	Steps map[string]StepResult `json:"steps,omitempty" csv:"-"`
+	// Test duration in seconds.
+	TestTime int64 `json:"testTime"`
+
+	// statistic the result was summarized from and the elapsed time of the run, see Merge.
+	statistic *client.Statistic
+	elapsed   time.Duration
+}
+
+// fileResult is a result as written to result files, with the statistic it was summarized from,
+// so that result files can be merged.
+type fileResult struct {
+	Result
+	Statistic  *client.Statistic `json:"statistic,omitempty"`
+	ElapsedSec float64           `json:"elapsedSec,omitempty"`
+}
+
+// LatencyResult summarizes the latencies of some responses.
+type LatencyResult struct {
+	Count  int64   `json:"count"`
+	MinMs  float64 `json:"minMs"`
+	MeanMs float64 `json:"meanMs"`
+	MaxMs  float64 `json:"maxMs"`
+}
+
+// PercentileResult summarizes the distribution of some durations.
+type PercentileResult struct {
+	Count int64   `json:"count"`
+	P50Ms float64 `json:"p50Ms"`
+	P90Ms float64 `json:"p90Ms"`
+	P99Ms float64 `json:"p99Ms"`
+	MaxMs float64 `json:"maxMs"`
+}
+
+// StepResult summarizes the requests of a step.
+type StepResult struct {
+	Requests int64 `json:"requests"`
+	Success  int64 `json:"success"`
+	// Latencies of the requests, which received a response.
+	Latency PercentileResult `json:"latency"`
+}
+
+// NewPercentileResult summarizes the durations of a histogram.
+func NewPercentileResult(h client.DurationHistogram) PercentileResult {
+	return PercentileResult{
+		Count: int64(h.Count),
+		P50Ms: milliseconds(h.Percentile(50)),
+		P90Ms: milliseconds(h.Percentile(90)),
+		P99Ms: milliseconds(h.Percentile(99)),
+		MaxMs: milliseconds(h.Max),
+	}
+}
+
+// NewResult summarizes the statistic of a target, which was benchmarked for the elapsed time,
+// see client.Statistic.Merge to sum up the statistics of several clients.
+func NewResult(s client.Statistic, elapsedTime time.Duration) Result {
+	r := Result{
+		Requests:          int64(s.RequestCount),
+		Success:           int64(s.SuccessCount),
+		NetworkFailed:     int64(s.NetworkFailedCount),
+		Failed:            int64(s.FailureCount),
+		LengthMismatch:    int64(s.LengthMismatchCount),
+		ValidationFailed:  int64(s.ValidationFailedCount),
+		SchemaViolations:  int64(s.ValidationFailures["schema"]),
+		ChecksumFailed:    int64(s.ValidationFailures["checksum"]),
+		Interrupted:       int64(s.InterruptedCount),
+		Messages:          int64(s.MessageCount),
+		Dropped:           int64(s.DroppedCount),
+		Lost:              int64(s.LostCount),
+		Throttled:         int64(s.ThrottledCount),
+		BackoffSec:        s.Backoff.Seconds(),
+		NetworkFailures:   toInt64(s.NetworkFailures),
+		StatusCodes:       toInt64(s.StatusCodes),
+		ResponseCodes:     toInt64(s.ResponseCodes),
+		NewConnections:    int64(s.NewConnections),
+		ReusedConnections: int64(s.ReusedConnections),
+		ConnectionReuse:   s.ConnectionReuse(),
+		DNSLookups:        int64(s.Phases[client.PhaseDNS].Count),
+		TLSHandshakes:     int64(s.Phases[client.PhaseTLS].Count + s.Phases[client.PhaseTLSResumed].Count),
+		TLSResumption:     s.TLSResumption(),
+		Protocols:         toInt64(s.Protocols),
+		IPFamilies:        toInt64(s.IPFamilies),
+		TLSVersions:       toInt64(s.TLSVersions),
+		CipherSuites:      toInt64(s.CipherSuites),
+		AverageLatencyMs:  milliseconds(s.AverageLatency()),
+	}
+
+	elapsed := int64(elapsedTime.Seconds())
+	if elapsed == 0 {
+		elapsed = 1
+	}
+
+	r.SuccessRate = r.Success / elapsed
+	r.MessageRate = r.Messages / elapsed
+	r.ReadThroughput = s.ReadThroughput / elapsed
+	r.WireReadThroughput = s.WireReadThroughput / elapsed
+	r.WriteThroughput = s.WriteThroughput / elapsed
+	sizes := s.ResponseSizes
+	r.ResponseSizeMin, r.ResponseSizeMean, r.ResponseSizeMax = sizes.Min, sizes.Mean(), sizes.Max
+	if sizes.Count > 0 {
+		r.ResponseSizes = sizes.Buckets[:]
+	}
+	for class, l := range s.ClassLatencies {
+		if r.ClassLatencies == nil {
+			r.ClassLatencies = make(map[string]LatencyResult)
+		}
+		r.ClassLatencies[fmt.Sprintf("%dxx", class)] = LatencyResult{
+			Count:  int64(l.Count),
+			MinMs:  milliseconds(l.Min),
+			MeanMs: milliseconds(l.Mean()),
+			MaxMs:  milliseconds(l.Max),
+		}
+	}
+	ttfb := s.TTFB()
+	r.TTFBP50Ms = milliseconds(ttfb.Percentile(50))
+	r.TTFBP90Ms = milliseconds(ttfb.Percentile(90))
+	r.TTFBP99Ms = milliseconds(ttfb.Percentile(99))
+	for phase, h := range s.Phases {
+		if r.Phases == nil {
+			r.Phases = make(map[string]PercentileResult)
+		}
+		r.Phases[phase] = NewPercentileResult(h)
+	}
+	for step, st := range s.Steps {
+		if r.Steps == nil {
+			r.Steps = make(map[string]StepResult)
+		}
+		r.Steps[step] = StepResult{
+			Requests: int64(st.RequestCount),
+			Success:  int64(st.SuccessCount),
+			Latency:  NewPercentileResult(st.Latencies),
+		}
+	}
+	r.TestTime = elapsed
+	r.statistic, r.elapsed = &s, elapsedTime
+
+	return r
+}
+
+// Merge combines the results of the same target from independent runs, e.g. of several load generators
+// running at the same time, as if they were measured by a single run. Counts and histograms are summed up,
+// rates refer to the longest run. Only results read from files of this version can be merged.
+func Merge(results ...Result) (Result, error) {
+	var merged client.Statistic
+	var elapsed time.Duration
+	for i, r := range results {
+		if r.statistic == nil {
+			return Result{}, fmt.Errorf("result %d contains no statistic, it was written by a previous version", i+1)
+		}
+		merged.Merge(*r.statistic)
+		elapsed = max(elapsed, r.elapsed)
+	}
+	if len(results) == 0 {
+		return Result{}, errors.New("no results to merge")
+	}
+	r := NewResult(merged, elapsed)
+	r.Target = results[0].Target
+	return r, nil
+}
+
+// milliseconds returns the duration in milliseconds with microsecond precision.
+func milliseconds(d time.Duration) float64 {
+	return float64(d.Microseconds()) / 1000
+}
+
+// EncodeFile encodes the results like EncodeJSON, with the statistics of the results, so that they can be merged.
+func EncodeFile(results []Result) ([]byte, error) {
+	files := make([]fileResult, len(results))
+	for i, r := range results {
+		files[i] = fileResult{Result: r, Statistic: r.statistic, ElapsedSec: r.elapsed.Seconds()}
+	}
+	return json.MarshalIndent(jsonValue(files), "", "  ")
+}
+
+// StdoutFile is the result file name, which writes the results to stdout.
+const StdoutFile = "-"
+
+// WriteFile writes the results to a JSON file, see EncodeFile.
+func WriteFile(filePath string, results []Result) error {
+	data, err := EncodeFile(results)
+	if err != nil {
+		return err
+	}
+	if filePath == StdoutFile {
+		_, err = fmt.Println(string(data))
+		return err
+	}
+	return os.WriteFile(filePath, data, 0o644)
+}
+
+// ReadFile reads results written by WriteFile.
+func ReadFile(filePath string) ([]Result, error) {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, err
+	}
+	return DecodeFile(data)
+}
+
+// DecodeFile decodes results encoded by EncodeFile.
+func DecodeFile(data []byte) ([]Result, error) {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+	if _, ok := fields["requests"]; ok {
+		r, err := decodeResult(data)
+		return []Result{r}, err
+	}
+
+	labels := make([]string, 0, len(fields))
+	for label := range fields {
+		labels = append(labels, label)
+	}
+	sort.Strings(labels)
+	var results []Result
+	for _, label := range labels {
+		r, err := decodeResult(fields[label])
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, r)
+	}
+	return results, nil
+}
+
+// decodeResult decodes a result of a result file with its statistic, if contained.
+func decodeResult(data []byte) (Result, error) {
+	var f fileResult
+	if err := json.Unmarshal(data, &f); err != nil {
+		return Result{}, err
+	}
+	r := f.Result
+	r.statistic, r.elapsed = f.Statistic, time.Duration(f.ElapsedSec*float64(time.Second))
+	return r, nil
+}
+
+// ReadSingle reads a file containing the result of a single target.
+func ReadSingle(filePath string) (Result, error) {
+	results, err := ReadFile(filePath)
+	if err != nil {
+		return Result{}, err
+	}
+	if len(results) != 1 {
+		return Result{}, fmt.Errorf("expected result of a single target, got %d", len(results))
+	}
+	return results[0], nil
+}
+
+// Label returns the label of the target with the given index: A, B, C, ...
+func Label(index int) string {
+	return string(rune('A' + index))
+}
+
+// toInt64 converts the counts of a statistic to the ones of a result, nil if there are none.
+func toInt64[K comparable](counts map[K]int) map[K]int64 {
+	var converted map[K]int64
+	for k, n := range counts {
+		if converted == nil {
+			converted = make(map[K]int64, len(counts))
+		}
+		converted[k] = int64(n)
+	}
+	return converted
+}
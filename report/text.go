@@ -0,0 +1,369 @@
+// SPDX-FileCopyrightText: 2021 Eric Neidhardt
+// SPDX-License-Identifier: MIT
+package report
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/EricNeid/go-bench/client"
+)
+
+// resultRows describes the printed lines of a result.
+var resultRows = []struct {
+	label string
+	unit  string
+	value func(r Result) string
+}{
+	{"Requests:", "hits", func(r Result) string { return fmt.Sprintf("%10d", r.Requests) }},
+	{"Successful requests:", "hits", func(r Result) string { return fmt.Sprintf("%10d", r.Success) }},
+	{"Network failed:", "hits", func(r Result) string { return fmt.Sprintf("%10d", r.NetworkFailed) }},
+	{"Bad requests failed (status):", "hits", func(r Result) string { return fmt.Sprintf("%10d", r.Failed) }},
+	{"Content-Length mismatches:", "hits", func(r Result) string { return fmt.Sprintf("%10d", r.LengthMismatch) }},
+	{"Validation failed:", "hits", func(r Result) string { return fmt.Sprintf("%10d", r.ValidationFailed) }},
+	{"Schema violations:", "hits", func(r Result) string { return fmt.Sprintf("%10d", r.SchemaViolations) }},
+	{"Checksum mismatches:", "hits", func(r Result) string { return fmt.Sprintf("%10d", r.ChecksumFailed) }},
+	{"Interrupted at end:", "hits", func(r Result) string { return fmt.Sprintf("%10d", r.Interrupted) }},
+	{"Throttled (Retry-After):", "hits", func(r Result) string { return fmt.Sprintf("%10d", r.Throttled) }},
+	{"Backoff time:", "sec", func(r Result) string { return fmt.Sprintf("%10.2f", r.BackoffSec) }},
+	{"Successful requests rate:", "hits/sec", func(r Result) string { return fmt.Sprintf("%10d", r.SuccessRate) }},
+	{"Messages received:", "hits", func(r Result) string { return fmt.Sprintf("%10d", r.Messages) }},
+	{"Messages rate:", "hits/sec", func(r Result) string { return fmt.Sprintf("%10d", r.MessageRate) }},
+	{"Dropped streams:", "hits", func(r Result) string { return fmt.Sprintf("%10d", r.Dropped) }},
+	{"Lost datagrams:", "hits", func(r Result) string { return fmt.Sprintf("%10d", r.Lost) }},
+	{"Read throughput:", "bytes/sec", func(r Result) string { return fmt.Sprintf("%10d", r.ReadThroughput) }},
+	{"Wire read throughput:", "bytes/sec", func(r Result) string { return fmt.Sprintf("%10d", r.WireReadThroughput) }},
+	{"Write throughput:", "bytes/sec", func(r Result) string { return fmt.Sprintf("%10d", r.WriteThroughput) }},
+	{"DNS lookups:", "hits", func(r Result) string { return fmt.Sprintf("%10d", r.DNSLookups) }},
+	{"New connections:", "hits", func(r Result) string { return fmt.Sprintf("%10d", r.NewConnections) }},
+	{"Connection reuse:", "%", func(r Result) string { return fmt.Sprintf("%10.2f", r.ConnectionReuse) }},
+	{"TLS handshakes:", "hits", func(r Result) string { return fmt.Sprintf("%10d", r.TLSHandshakes) }},
+	{"TLS resumption:", "%", func(r Result) string { return fmt.Sprintf("%10.2f", r.TLSResumption) }},
+	{"Response size min:", "bytes", func(r Result) string { return fmt.Sprintf("%10d", r.ResponseSizeMin) }},
+	{"Response size mean:", "bytes", func(r Result) string { return fmt.Sprintf("%10d", r.ResponseSizeMean) }},
+	{"Response size max:", "bytes", func(r Result) string { return fmt.Sprintf("%10d", r.ResponseSizeMax) }},
+	{"Average latency:", "ms", func(r Result) string { return fmt.Sprintf("%10.2f", r.AverageLatencyMs) }},
+	{"TTFB p50:", "ms", func(r Result) string { return fmt.Sprintf("%10.2f", r.TTFBP50Ms) }},
+	{"TTFB p90:", "ms", func(r Result) string { return fmt.Sprintf("%10.2f", r.TTFBP90Ms) }},
+	{"TTFB p99:", "ms", func(r Result) string { return fmt.Sprintf("%10.2f", r.TTFBP99Ms) }},
+	{"Test time:", "sec", func(r Result) string { return fmt.Sprintf("%10d", r.TestTime) }},
+}
+
+// printTable prints the given results side by side, one column per result.
+func printTable(w io.Writer, results ...Result) {
+	for _, row := range resultRows {
+		fmt.Fprintf(w, "%-32s", row.label)
+		for _, r := range results {
+			fmt.Fprint(w, row.value(r))
+		}
+		fmt.Fprintf(w, " %s\n", row.unit)
+	}
+}
+
+// printDistribution prints the counts of the given results side by side, one line per label.
+// Lines without any count are omitted, nothing is printed if all counts are missing.
+// The share of each count is printed, if there is a single result.
+func printDistribution(w io.Writer, title string, labels []string, counts ...[]int) {
+	var printed bool
+	for i, label := range labels {
+		var line strings.Builder
+		var found bool
+		for _, c := range counts {
+			n := 0
+			if i < len(c) {
+				n = c[i]
+			}
+			found = found || n > 0
+			fmt.Fprintf(&line, "%10d", n)
+		}
+		if !found {
+			continue
+		}
+		if !printed {
+			fmt.Fprintf(w, "\n%s\n", title)
+			printed = true
+		}
+		if len(counts) == 1 {
+			total := 0
+			for _, n := range counts[0] {
+				total += n
+			}
+			fmt.Fprintf(&line, " hits %5.1f%%", float64(counts[0][i])*100/float64(total))
+		} else {
+			line.WriteString(" hits")
+		}
+		fmt.Fprintf(w, "  %-30s%s\n", label, line.String())
+	}
+}
+
+// responseSizeLabels returns the labels of the buckets of client.ResponseSizeBuckets.
+func responseSizeLabels() []string {
+	var labels []string
+	for _, bound := range client.ResponseSizeBuckets {
+		labels = append(labels, "<= "+formatSize(bound))
+	}
+	return append(labels, "> "+formatSize(client.ResponseSizeBuckets[len(client.ResponseSizeBuckets)-1]))
+}
+
+// formatSize formats a number of bytes with the largest fitting unit of B, KiB and MiB.
+func formatSize(size int64) string {
+	switch {
+	case size >= 1<<20 && size%(1<<20) == 0:
+		return fmt.Sprintf("%d MiB", size>>20)
+	case size >= 1<<10 && size%(1<<10) == 0:
+		return fmt.Sprintf("%d KiB", size>>10)
+	default:
+		return fmt.Sprintf("%d B", size)
+	}
+}
+
+// printDistributions prints the distributions of the given results after their table.
+func printDistributions(w io.Writer, results ...Result) {
+	sizes := make([][]int, len(results))
+	for i, r := range results {
+		sizes[i] = r.ResponseSizes
+	}
+	printDistribution(w, "Response sizes:", responseSizeLabels(), sizes...)
+
+	failures := make([][]int, len(results))
+	for i, r := range results {
+		for _, cause := range client.FailureCauses {
+			failures[i] = append(failures[i], int(r.NetworkFailures[cause]))
+		}
+	}
+	printDistribution(w, "Network failures:", client.FailureCauses, failures...)
+
+	seen := make(map[int]bool)
+	var codes []int
+	for _, r := range results {
+		for code := range r.StatusCodes {
+			if !seen[code] {
+				seen[code] = true
+				codes = append(codes, code)
+			}
+		}
+	}
+	sort.Ints(codes)
+	labels := make([]string, len(codes))
+	for i, code := range codes {
+		labels[i] = strings.TrimSpace(fmt.Sprintf("%d %s", code, http.StatusText(code)))
+	}
+	counts := make([][]int, len(results))
+	for i, r := range results {
+		for _, code := range codes {
+			counts[i] = append(counts[i], int(r.StatusCodes[code]))
+		}
+	}
+	printDistribution(w, "Status codes:", labels, counts...)
+	printNamedDistribution(w, "DNS response codes:", results, func(r Result) map[string]int64 { return r.ResponseCodes })
+
+	printNamedDistribution(w, "Protocols of new connections:", results, func(r Result) map[string]int64 { return r.Protocols })
+	printNamedDistribution(w, "IP families of new connections:", results, func(r Result) map[string]int64 { return r.IPFamilies })
+	printNamedDistribution(w, "TLS versions:", results, func(r Result) map[string]int64 { return r.TLSVersions })
+	printNamedDistribution(w, "TLS cipher suites:", results, func(r Result) map[string]int64 { return r.CipherSuites })
+	printClassLatencies(w, results...)
+	printPhases(w, results...)
+	printSteps(w, results...)
+}
+
+// printNamedDistribution prints the counts of the results, which are sorted by their names.
+func printNamedDistribution(w io.Writer, title string, results []Result, counts func(r Result) map[string]int64) {
+	seen := make(map[string]bool)
+	var names []string
+	for _, r := range results {
+		for name := range counts(r) {
+			if !seen[name] {
+				seen[name] = true
+				names = append(names, name)
+			}
+		}
+	}
+	sort.Strings(names)
+	values := make([][]int, len(results))
+	for i, r := range results {
+		for _, name := range names {
+			values[i] = append(values[i], int(counts(r)[name]))
+		}
+	}
+	printDistribution(w, title, names, values...)
+}
+
+// printPhases prints the durations of the phases of requests. For a single result count and percentiles
+// are printed, otherwise the median of each result.
+func printPhases(w io.Writer, results ...Result) {
+	var phases []string
+	for _, phase := range client.Phases {
+		for _, r := range results {
+			if _, ok := r.Phases[phase]; ok {
+				phases = append(phases, phase)
+				break
+			}
+		}
+	}
+	if len(phases) == 0 {
+		return
+	}
+	if len(results) == 1 {
+		fmt.Fprintf(w, "\n%-32s%10s%10s%10s%10s%10s\n", "Phases:", "hits", "p50", "p90", "p99", "max")
+		for _, phase := range phases {
+			p := results[0].Phases[phase]
+			fmt.Fprintf(w, "  %-30s%10d%10.2f%10.2f%10.2f%10.2f ms\n", phase, p.Count, p.P50Ms, p.P90Ms, p.P99Ms, p.MaxMs)
+		}
+		return
+	}
+	fmt.Fprintf(w, "\nMedian per phase:\n")
+	for _, phase := range phases {
+		fmt.Fprintf(w, "  %-30s", phase)
+		for _, r := range results {
+			if p, ok := r.Phases[phase]; ok {
+				fmt.Fprintf(w, "%10.2f", p.P50Ms)
+			} else {
+				fmt.Fprintf(w, "%10s", "-")
+			}
+		}
+		fmt.Fprintln(w, " ms")
+	}
+}
+
+// printSteps prints the requests and latencies of the steps of a chain. For a single result counts and
+// percentiles are printed, otherwise the median of each result. Steps named by numbers are sorted by them.
+func printSteps(w io.Writer, results ...Result) {
+	var steps []string
+	seen := make(map[string]bool)
+	for _, r := range results {
+		for step := range r.Steps {
+			if !seen[step] {
+				seen[step] = true
+				steps = append(steps, step)
+			}
+		}
+	}
+	if len(steps) == 0 {
+		return
+	}
+	sort.Slice(steps, func(i, j int) bool {
+		a, errA := strconv.Atoi(steps[i])
+		b, errB := strconv.Atoi(steps[j])
+		if errA == nil && errB == nil {
+			return a < b
+		}
+		return steps[i] < steps[j]
+	})
+	if len(results) == 1 {
+		fmt.Fprintf(w, "\n%-32s%10s%10s%10s%10s%10s\n", "Steps:", "hits", "success", "p50", "p90", "p99")
+		for _, step := range steps {
+			st := results[0].Steps[step]
+			fmt.Fprintf(w, "  %-30s%10d%10d%10.2f%10.2f%10.2f ms\n",
+				step, st.Requests, st.Success, st.Latency.P50Ms, st.Latency.P90Ms, st.Latency.P99Ms)
+		}
+		return
+	}
+	fmt.Fprintf(w, "\nMedian latency per step:\n")
+	for _, step := range steps {
+		fmt.Fprintf(w, "  %-30s", step)
+		for _, r := range results {
+			if st, ok := r.Steps[step]; ok {
+				fmt.Fprintf(w, "%10.2f", st.Latency.P50Ms)
+			} else {
+				fmt.Fprintf(w, "%10s", "-")
+			}
+		}
+		fmt.Fprintln(w, " ms")
+	}
+}
+
+// printClassLatencies prints the latencies per status class. For a single result count, min, mean and max
+// are printed, otherwise the mean of each result.
+func printClassLatencies(w io.Writer, results ...Result) {
+	var classes []string
+	seen := make(map[string]bool)
+	for _, r := range results {
+		for class := range r.ClassLatencies {
+			if !seen[class] {
+				seen[class] = true
+				classes = append(classes, class)
+			}
+		}
+	}
+	if len(classes) == 0 {
+		return
+	}
+	sort.Strings(classes)
+	if len(results) == 1 {
+		fmt.Fprintf(w, "\n%-32s%10s%10s%10s%10s\n", "Latency per status:", "hits", "min", "mean", "max")
+		for _, class := range classes {
+			l := results[0].ClassLatencies[class]
+			fmt.Fprintf(w, "  %-30s%10d%10.2f%10.2f%10.2f ms\n", class, l.Count, l.MinMs, l.MeanMs, l.MaxMs)
+		}
+		return
+	}
+	fmt.Fprintf(w, "\nMean latency per status:\n")
+	for _, class := range classes {
+		fmt.Fprintf(w, "  %-30s", class)
+		for _, r := range results {
+			if l, ok := r.ClassLatencies[class]; ok {
+				fmt.Fprintf(w, "%10.2f", l.MeanMs)
+			} else {
+				fmt.Fprintf(w, "%10s", "-")
+			}
+		}
+		fmt.Fprintln(w, " ms")
+	}
+}
+
+// printResults prints the result of a single target.
+func printResults(w io.Writer, r Result) {
+	fmt.Fprintln(w)
+	printTable(w, r)
+	printDistributions(w, r)
+}
+
+// printComparison prints the results of several targets side by side, labeled A, B, C, ...
+func printComparison(w io.Writer, results []Result) {
+	fmt.Fprintln(w)
+	for i, r := range results {
+		fmt.Fprintf(w, "%s: %s\n", Label(i), r.Target)
+	}
+	fmt.Fprintln(w)
+	fmt.Fprintf(w, "%-32s", "")
+	for i := range results {
+		fmt.Fprintf(w, "%10s", Label(i))
+	}
+	fmt.Fprintln(w)
+	printTable(w, results...)
+	printDistributions(w, results...)
+}
+
+// textReporter prints a line per target for the intermediate results and
+// the final results as table followed by their distributions.
+type textReporter struct {
+	w io.Writer
+}
+
+func (r *textReporter) Start([]string) error {
+	return nil
+}
+
+func (r *textReporter) Interval(results []Result) error {
+	for i, result := range results {
+		if len(results) > 1 {
+			fmt.Fprintf(r.w, "%s: ", Label(i))
+		}
+		fmt.Fprintf(r.w, "%5ds %10d requests %10d successful %10d hits/sec %10.2f ms TTFB p99\n",
+			result.TestTime, result.Requests, result.Success, result.SuccessRate, result.TTFBP99Ms)
+	}
+	return nil
+}
+
+func (r *textReporter) Final(results []Result) error {
+	if len(results) == 1 {
+		printResults(r.w, results[0])
+	} else {
+		printComparison(r.w, results)
+	}
+	return nil
+}